@@ -71,6 +71,7 @@ After=network.target
 Type=simple
 User=root
 ExecStart=/usr/local/bin/ip_updater -config=/etc/ip_updater/config.conf
+ExecReload=/bin/kill -HUP \$MAINPID
 Restart=always
 RestartSec=10
 KillMode=process
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ip-updater/pkg/fileupdate"
+)
+
+// benchReaders is how many goroutines read the file while it is updated
+const benchReaders = 4
+
+// benchFormat is a sample target file for -bench-fileupdate
+type benchFormat struct {
+	format  string
+	keyPath string
+	content string
+}
+
+var benchFormats = []benchFormat{
+	{"json", "server/public_ip", `{"server": {"name": "bench", "public_ip": "192.0.2.1", "port": 443}}` + "\n"},
+	{"yaml", "server/public_ip", "server:\n  name: bench\n  public_ip: 192.0.2.1\n  port: 443\n"},
+	{"toml", "server/public_ip", "[server]\nname = \"bench\"\npublic_ip = \"192.0.2.1\"\nport = 443\n"},
+	{"ini", "server/public_ip", "[server]\nname = bench\npublic_ip = 192.0.2.1\nport = 443\n"},
+	{"plist", "server/public_ip", `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>server</key>
+	<dict>
+		<key>name</key>
+		<string>bench</string>
+		<key>public_ip</key>
+		<string>192.0.2.1</string>
+	</dict>
+</dict>
+</plist>
+`},
+	{"hosts", "bench.example.com", "127.0.0.1 localhost\n192.0.2.1 bench.example.com bench\n"},
+}
+
+// benchResult is the outcome of benchmarking one format
+type benchResult struct {
+	updates     int
+	elapsed     time.Duration
+	writeErrors int
+	reads       int64
+	badReads    int64
+	firstBad    string
+}
+
+// benchFileUpdate runs UpdateIP repeatedly against a temp file of every
+// supported format while readers parse the file concurrently. Since updates
+// replace the file with a rename, a reader must never see a partial file:
+// any read that fails to parse or returns an address that was never written
+// counts as corrupt, and the command exits non-zero.
+func benchFileUpdate(iterations int) {
+	if iterations <= 0 {
+		iterations = 200
+	}
+
+	dir, err := os.MkdirTemp("", "ip_updater_bench")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create temp dir: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Printf("file updater benchmark: %d updates per format, %d concurrent readers\n\n", iterations, benchReaders)
+	fmt.Printf("%-6s  %8s  %10s  %10s  %8s  %8s  %s\n", "format", "updates", "updates/s", "avg", "errors", "reads", "corrupt")
+
+	failed := false
+	for _, f := range benchFormats {
+		path := filepath.Join(dir, "bench."+f.format)
+		if err := os.WriteFile(path, []byte(f.content), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", path, err)
+			os.Exit(1)
+		}
+
+		result := benchFormatUpdates(f, path, iterations)
+		avg := time.Duration(0)
+		if result.updates > 0 {
+			avg = result.elapsed / time.Duration(result.updates)
+		}
+		fmt.Printf("%-6s  %8d  %10.0f  %10s  %8d  %8d  %d\n", f.format, result.updates,
+			float64(result.updates)/result.elapsed.Seconds(), avg.Round(time.Microsecond),
+			result.writeErrors, result.reads, result.badReads)
+		if result.firstBad != "" {
+			fmt.Printf("        first corrupt read: %s\n", result.firstBad)
+		}
+		if result.writeErrors > 0 || result.badReads > 0 {
+			failed = true
+		}
+	}
+
+	if failed {
+		fmt.Println("\nFAIL: updates failed or a reader observed a partial file")
+		os.Exit(1)
+	}
+	fmt.Println("\nOK: no reader observed a partial or corrupt file")
+}
+
+func benchFormatUpdates(f benchFormat, path string, iterations int) benchResult {
+	var result benchResult
+	var reads, badReads atomic.Int64
+	var firstBad atomic.Value
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < benchReaders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reader := fileupdate.New(path, f.format, f.keyPath, false)
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				value, err := reader.GetCurrentValue()
+				reads.Add(1)
+				if err == nil && !benchAddress(value) {
+					err = fmt.Errorf("unexpected value %q", value)
+				}
+				if err != nil {
+					badReads.Add(1)
+					firstBad.CompareAndSwap(nil, err.Error())
+				}
+			}
+		}()
+	}
+
+	writer := fileupdate.New(path, f.format, f.keyPath, false)
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		// Alternate between addresses so every update rewrites the file
+		if err := writer.UpdateIP(fmt.Sprintf("198.51.100.%d", i%250+1)); err != nil {
+			result.writeErrors++
+			continue
+		}
+		result.updates++
+	}
+	result.elapsed = time.Since(start)
+
+	close(stop)
+	wg.Wait()
+	result.reads = reads.Load()
+	result.badReads = badReads.Load()
+	if bad, ok := firstBad.Load().(string); ok {
+		result.firstBad = bad
+	}
+	return result
+}
+
+// benchAddress reports whether value is one of the addresses the benchmark
+// writes (or the initial one)
+func benchAddress(value string) bool {
+	ip := net.ParseIP(value).To4()
+	if ip == nil {
+		return false
+	}
+	return value == "192.0.2.1" || (ip[0] == 198 && ip[1] == 51 && ip[2] == 100)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileUpdateReadersNeverSeePartialFile(t *testing.T) {
+	for _, f := range benchFormats {
+		t.Run(f.format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bench."+f.format)
+			if err := os.WriteFile(path, []byte(f.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result := benchFormatUpdates(f, path, 100)
+			if result.writeErrors > 0 {
+				t.Fatalf("%d of 100 updates failed", result.writeErrors)
+			}
+			if result.badReads > 0 {
+				t.Fatalf("%d of %d reads saw a partial file, first: %s", result.badReads, result.reads, result.firstBad)
+			}
+			if result.reads == 0 {
+				t.Fatal("no reads ran during the updates")
+			}
+		})
+	}
+}
+
+func BenchmarkFileUpdate(b *testing.B) {
+	for _, f := range benchFormats {
+		b.Run(f.format, func(b *testing.B) {
+			path := filepath.Join(b.TempDir(), "bench."+f.format)
+			if err := os.WriteFile(path, []byte(f.content), 0644); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			if result := benchFormatUpdates(f, path, b.N); result.writeErrors > 0 || result.badReads > 0 {
+				b.Fatalf("%d failed updates, %d corrupt reads", result.writeErrors, result.badReads)
+			}
+		})
+	}
+}
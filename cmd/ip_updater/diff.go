@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+
+	"ip-updater/internal/config"
+	"ip-updater/internal/detector"
+	"ip-updater/internal/logger"
+	"ip-updater/internal/netutil"
+	"ip-updater/internal/statefile"
+	"ip-updater/internal/updater"
+	"ip-updater/pkg/fileupdate"
+)
+
+// diffFileUpdates prints, for every file updater, a unified diff between the
+// file as it is and as the updater would write it for the current public IP
+// (or ip when given). Nothing is written. The diffs go to stdout and log
+// messages to stderr, so the output can be saved or paged. It exits 1 when
+// an updater fails.
+func diffFileUpdates(configFile, ip string, log *logger.Logger) {
+	log.SetOutput(os.Stderr)
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		log.ErrorHighlightf("配置文件加载失败: %v", err)
+		os.Exit(1)
+	}
+	if len(cfg.FileUpdaters) == 0 {
+		log.WarnHighlight("未找到文件更新器配置")
+		os.Exit(1)
+	}
+
+	if ip == "" {
+		if err := netutil.SetLocalAddr(cfg.LocalAddr); err != nil {
+			log.ErrorHighlightf("local_addr无效: %v", err)
+			os.Exit(1)
+		}
+		d := detector.New(cfg.IPDetection)
+		d.SetLogger(log)
+		if ip, err = d.GetPublicIP(); err != nil {
+			log.ErrorHighlightf("获取公网IP失败: %v", err)
+			os.Exit(1)
+		}
+		log.Infof("当前公网IP: %s", ip)
+	} else if net.ParseIP(ip) == nil {
+		log.ErrorHighlightf("-diff-ip 不是合法的IP地址: %s", ip)
+		os.Exit(1)
+	}
+
+	u := updater.New(cfg, log)
+	// List updaters replace the entry they wrote last time
+	loadState := statefile.Load
+	if cfg.SignStateFile {
+		loadState = statefile.LoadSigned
+	}
+	if state, err := loadState(cfg.StateFile); err == nil {
+		u.SetListEntries(state.ListEntries)
+	}
+
+	failed := false
+	for _, preview := range u.PreviewFiles(ip) {
+		if preview.Err != nil {
+			log.ErrorHighlightf("文件更新器 %s 预览失败: %v", preview.Name, preview.Err)
+			failed = true
+			continue
+		}
+		if bytes.Equal(preview.Old, preview.New) {
+			log.Infof("✔️ %s: %s 无需修改", preview.Name, preview.FilePath)
+			continue
+		}
+		fmt.Print(fileupdate.UnifiedDiff(preview.FilePath, preview.Old, preview.New))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+
+	"ip-updater/internal/config"
+	"ip-updater/internal/logger"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Where an -explain value comes from
+const (
+	sourceFile     = "file"     // as written in the config file
+	sourceDefault  = "default"  // not in the file, the built-in default
+	sourceDerived  = "derived"  // computed from other settings
+	sourceResolved = "resolved" // in the file, changed while loading (decrypted, Punycode, ...)
+)
+
+// explainEntry is one setting in the -explain output
+type explainEntry struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+	Note   string      `json:"note,omitempty"`
+}
+
+// explainConfig prints every setting of the effective configuration, like
+// -dump-config, together with where its value comes from: the file, a
+// default, or derived from other settings
+func explainConfig(configFile string, asJSON bool, log *logger.Logger) {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		log.ErrorHighlightf("配置文件加载失败: %v", err)
+		os.Exit(1)
+	}
+	for _, warning := range cfg.Warnings {
+		fmt.Fprintf(os.Stderr, "配置警告: %s\n", warning)
+	}
+
+	// Load created the file if it was missing, so it can be read as written
+	var written map[string]interface{}
+	if _, err := toml.DecodeFile(configFile, &written); err != nil {
+		log.ErrorHighlightf("配置文件读取失败: %v", err)
+		os.Exit(1)
+	}
+
+	// Values are compared before redaction, so a credential is only
+	// "resolved" when it was decrypted
+	effective, err := configTree(cfg)
+	if err != nil {
+		log.ErrorHighlightf("配置导出失败: %v", err)
+		os.Exit(1)
+	}
+	redactConfig(cfg)
+	redacted, err := configTree(cfg)
+	if err != nil {
+		log.ErrorHighlightf("配置导出失败: %v", err)
+		os.Exit(1)
+	}
+
+	var entries []explainEntry
+	explainValue("", effective, redacted, written, true, &entries)
+	explainDerived(entries, written)
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if asJSON {
+		encoder.SetIndent("", "  ")
+		encoder.Encode(entries)
+		fmt.Print(out.String())
+		return
+	}
+
+	for _, entry := range entries {
+		out.Reset()
+		encoder.Encode(entry.Value)
+		line := fmt.Sprintf("%-8s  %s = %s", entry.Source, entry.Key, bytes.TrimSpace(out.Bytes()))
+		if entry.Note != "" {
+			line += "  (" + entry.Note + ")"
+		}
+		fmt.Println(line)
+	}
+}
+
+// configTree converts the config to generic TOML values, keyed like the file
+func configTree(cfg *config.Config) (map[string]interface{}, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(cfg); err != nil {
+		return nil, err
+	}
+	var tree map[string]interface{}
+	_, err := toml.Decode(buf.String(), &tree)
+	return tree, err
+}
+
+// explainValue flattens the effective config into entries, comparing each
+// value with the one written in the file (defined reports whether the file
+// has the key at all). display is the same value with credentials redacted.
+func explainValue(key string, value, display, written interface{}, defined bool, entries *[]explainEntry) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		displayTable, _ := display.(map[string]interface{})
+		writtenTable, _ := written.(map[string]interface{})
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			writtenValue, ok := writtenTable[name]
+			explainValue(joinKey(key, name), v[name], displayTable[name], writtenValue, defined && ok, entries)
+		}
+
+	case []map[string]interface{}:
+		displayTables, _ := display.([]map[string]interface{})
+		writtenTables, _ := written.([]map[string]interface{})
+		for i, table := range v {
+			var displayTable, writtenTable interface{}
+			if i < len(displayTables) {
+				displayTable = displayTables[i]
+			}
+			if i < len(writtenTables) {
+				writtenTable = writtenTables[i]
+			}
+			explainValue(fmt.Sprintf("%s[%d]", key, i), table, displayTable, writtenTable, defined && i < len(writtenTables), entries)
+		}
+
+	default:
+		source := sourceDefault
+		if defined {
+			source = sourceFile
+			if !reflect.DeepEqual(value, written) {
+				source = sourceResolved
+			}
+		}
+		*entries = append(*entries, explainEntry{Key: key, Value: display, Source: source})
+	}
+}
+
+// explainDerived marks the settings config.Load computes from others
+func explainDerived(entries []explainEntry, written map[string]interface{}) {
+	notes := map[string]string{
+		"ip_detection.cache_ttl": "half the shorter of dns_check_interval and file_check_interval",
+	}
+	if _, ok := written["check_interval"]; ok && written["config_version"] == nil {
+		notes["dns_check_interval"] = "legacy check_interval (config_version 0)"
+		notes["file_check_interval"] = "legacy check_interval (config_version 0)"
+	}
+
+	for i := range entries {
+		note, ok := notes[entries[i].Key]
+		if !ok || entries[i].Source == sourceFile {
+			continue
+		}
+		// cache_ttl = 0 in the file also means "derive it"
+		entries[i].Source = sourceDerived
+		entries[i].Note = note
+	}
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
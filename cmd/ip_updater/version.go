@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// versionInfo is the -version -json output
+type versionInfo struct {
+	Version    string `json:"version"`
+	GitCommit  string `json:"git_commit,omitempty"`
+	BuildTime  string `json:"build_time,omitempty"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	GoVersion  string `json:"go_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+}
+
+// buildVersionInfo combines the values set by the build script with the
+// build information embedded by the Go toolchain, which covers binaries
+// built with a plain `go build` or `go install`
+func buildVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.GitCommit == "" || info.GitCommit == "unknown" {
+				info.GitCommit = setting.Value
+			}
+		case "vcs.time":
+			info.CommitTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+func printVersionJSON() {
+	out, err := json.MarshalIndent(buildVersionInfo(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "version: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
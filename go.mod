@@ -4,9 +4,15 @@ go 1.21
 
 require (
 	github.com/BurntSushi/toml v1.3.2
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/net v0.19.0
 	gopkg.in/ini.v1 v1.67.0
 	gopkg.in/yaml.v3 v3.0.1
+	howett.net/plist v1.0.1
 )
 
-require golang.org/x/sys v0.15.0 // indirect
+require (
+	golang.org/x/sys v0.15.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
@@ -1,48 +1,278 @@
 package config
 
 import (
+	"fmt"
 	"ip-updater/internal/crypto"
 	"ip-updater/internal/detector"
+	"ip-updater/internal/healthcheck"
+	"ip-updater/internal/logger"
+	"ip-updater/internal/netutil"
+	"ip-updater/internal/notify"
+	"ip-updater/internal/privdrop"
+	"ip-updater/internal/reachability"
+	"ip-updater/internal/schedule"
+	"ip-updater/pkg/fileupdate"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"golang.org/x/net/idna"
 )
 
 type Config struct {
-	CheckInterval     int               `toml:"check_interval"`      // 兼容旧版本，现在作为默认间隔
-	DNSCheckInterval  int               `toml:"dns_check_interval"`  // DNS更新检查间隔
-	FileCheckInterval int               `toml:"file_check_interval"` // 文件更新检查间隔
-	IPDetection       detector.Config   `toml:"ip_detection"`
-	DNSUpdaters       []DNSUpdater      `toml:"dns_updater"`
-	FileUpdaters      []FileUpdater     `toml:"file_updater"`
-	Retry             RetryConfig       `toml:"retry"`
-	Logging           LoggingConfig     `toml:"logging"`
+	ConfigVersion     int             `toml:"config_version"`      // 配置文件格式版本
+	CheckInterval     int             `toml:"check_interval"`      // 兼容旧版本，现在作为默认间隔
+	DNSCheckInterval  int             `toml:"dns_check_interval"`  // DNS更新检查间隔
+	FileCheckInterval int             `toml:"file_check_interval"` // 文件更新检查间隔
+	DNSCheckCron      string          `toml:"dns_check_cron"`      // DNS检查的cron表达式，设置后代替dns_check_interval
+	FileCheckCron     string          `toml:"file_check_cron"`     // 文件检查的cron表达式，设置后代替file_check_interval
+	CombinedUpdates   bool            `toml:"combined_updates"`    // 按dns_check_interval检测一次IP，同时更新DNS和文件
+	UpdateOrder       string          `toml:"update_order"`        // 同一次检查中DNS和文件的更新顺序: dns_first / files_first
+	WatchConfig       bool            `toml:"watch_config"`        // 配置文件变化时自动重新加载
+	ShutdownTimeout   int             `toml:"shutdown_timeout"`    // 收到退出信号后等待保存状态、发送通知的最长秒数
+	LocalAddr         string          `toml:"local_addr"`          // 出站请求使用的本机源地址
+	StateFile         string          `toml:"state_file"`          // 保存已应用IP的状态文件
+	SignStateFile     bool            `toml:"sign_state_file"`     // 为状态文件写入HMAC签名，加载时校验
+	StartupUpdate     string          `toml:"startup_update"`      // 启动时更新策略: always / if_changed
+	ReassertInterval  int             `toml:"reassert_interval"`   // IP未变化时，距上次写入超过该秒数后重新确认DNS记录，0关闭
+	VerifyRecords     bool            `toml:"verify_records"`      // 每次DNS检查都读取服务商的现有记录与检测到的IP比较
+	IPMode            string          `toml:"ip_mode"`             // 检测和更新的地址族: ipv4 / ipv6 / dual (默认)
+	StrictKeys        bool            `toml:"strict_keys"`         // 配置中存在无法识别的键名时拒绝加载
+	MaxRecordTTL      int             `toml:"max_record_ttl"`      // 记录TTL超过该值时在加载配置时警告，-1关闭
+	RunAsUser         string          `toml:"run_as_user"`         // 以root启动时，完成初始化后切换到该用户运行 (仅Linux)
+	RunAsGroup        string          `toml:"run_as_group"`        // 切换到的用户组，默认为该用户的主组
+	IPDetection       detector.Config `toml:"ip_detection"`
+	DNSUpdaters       []DNSUpdater    `toml:"dns_updater"`
+	FileUpdaters      []FileUpdater   `toml:"file_updater"`
+	Retry             RetryConfig     `toml:"retry"`
+	Logging           LoggingConfig   `toml:"logging"`
+	Status            StatusConfig    `toml:"status"`
+	Schedule          ScheduleConfig  `toml:"schedule"`
+	APIQuota          APIQuotaConfig  `toml:"api_quota"`
+	Notify            notify.Config   `toml:"notify"`
+
+	// Reachability asks an external checker whether ports on the detected IP
+	// are open from the internet (optional)
+	Reachability *reachability.Config `toml:"reachability"`
+
+	// Warnings collects problems found while loading that don't prevent
+	// the config from being used, such as unknown keys
+	Warnings []string `toml:"-"`
 }
 
+// duplicate_records policies
+const (
+	// DuplicateWarn updates the record the provider returns first, as
+	// before, and logs a warning
+	DuplicateWarn = "warn"
+	// DuplicateError leaves the records alone and fails the update
+	DuplicateError = "error"
+	// DuplicateValue updates the record the updater wrote last, or the one
+	// already holding the IP, like match_value without a starting value
+	DuplicateValue = "value"
+	// DuplicateAll updates every record sharing the name and type
+	DuplicateAll = "all"
+)
+
+// compare_fields values
+const (
+	CompareValue = "value"
+	CompareTTL   = "ttl"
+)
+
+// update_order values; empty keeps the default of DNS first at startup and
+// both at once with combined_updates
+const (
+	UpdateOrderDNSFirst   = "dns_first"
+	UpdateOrderFilesFirst = "files_first"
+)
+
+// Startup update policies
+const (
+	StartupUpdateAlways    = "always"
+	StartupUpdateIfChanged = "if_changed"
+)
+
+// ip_mode values: IPModeIPv4 and IPModeIPv6 restrict detection and updates
+// to one address family, IPModeDual follows the record types
+const (
+	IPModeIPv4 = "ipv4"
+	IPModeIPv6 = "ipv6"
+	IPModeDual = "dual"
+)
+
 type DNSUpdater struct {
-	Name         string            `toml:"name"`
-	Provider     string            `toml:"provider"`
-	AccessKey    string            `toml:"access_key"`
-	SecretKey    string            `toml:"secret_key"`
-	Token        string            `toml:"token"`
-	Domain       string            `toml:"domain"`
-	Records      []DNSRecord       `toml:"record"`
-	ExtraConfig  map[string]string `toml:"extra_config"`
+	Name        string              `toml:"name"`
+	Provider    string              `toml:"provider"`
+	AccessKey   string              `toml:"access_key"`
+	SecretKey   string              `toml:"secret_key"`
+	Token       string              `toml:"token"`
+	Domain      string              `toml:"domain"`
+	Records     []DNSRecord         `toml:"record"`
+	ExtraConfig map[string]string   `toml:"extra_config"`
+	DependsOn   []string            `toml:"depends_on"`   // 依赖的更新器名称，依赖成功后才执行
+	Fallback    *FallbackProvider   `toml:"fallback"`     // 主服务商重试后仍失败时使用的备用服务商
+	Replicas    []FallbackProvider  `toml:"replica"`      // 同时保持更新的其他服务商（如主备NS），字段与fallback相同
+	HealthCheck *healthcheck.Config `toml:"health_check"` // 发布前检查新IP上的服务是否可达
+	Notify      *bool               `toml:"notify"`       // false: 不发送该更新器的变更/失败通知
+	Cron        string              `toml:"cron"`         // 该更新器按cron表达式单独检查，不随dns_check_interval检查
+
+	// ParallelUpdates is how many changed records are updated at the same
+	// time on providers without batch updates; 0 uses the default (4)
+	ParallelUpdates int `toml:"parallel_updates"`
+
+	// DuplicateRecords is what to do when several records share the name
+	// and type of a configured record (round-robin or duplicate entries):
+	// warn (default), error, value or all, see the Duplicate constants
+	DuplicateRecords string `toml:"duplicate_records"`
+
+	// CompareFields lists the fields of an existing record that are
+	// compared with the config; any difference triggers an update. The
+	// value is always compared; "ttl" adds the TTL, see the Compare constants
+	CompareFields []string `toml:"compare_fields"`
+
+	// Static failover: once detection has been failing for fallback_after
+	// seconds, the A records publish fallback_ip until detection recovers
+	FallbackIP    string `toml:"fallback_ip"`
+	FallbackAfter int    `toml:"fallback_after"` // 默认1800秒
+
+	// OriginalDomain keeps the domain as written in the config file when it
+	// was converted to punycode, so logs can show the readable form.
+	OriginalDomain string `toml:"-"`
+}
+
+// FallbackProvider is a second provider, with its own credentials, that
+// receives the same records when the primary provider fails
+type FallbackProvider struct {
+	Provider  string `toml:"provider"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	Token     string `toml:"token"`
+	Domain    string `toml:"domain"` // 默认与主服务商相同
+
+	OriginalDomain string `toml:"-"`
+}
+
+// FallbackUpdater returns the updater to use when the primary provider has
+// failed: the same records sent to the fallback provider
+func (u DNSUpdater) FallbackUpdater() (DNSUpdater, bool) {
+	if u.Fallback == nil {
+		return DNSUpdater{}, false
+	}
+
+	fallback := u
+	fallback.Provider = u.Fallback.Provider
+	fallback.AccessKey = u.Fallback.AccessKey
+	fallback.SecretKey = u.Fallback.SecretKey
+	fallback.Token = u.Fallback.Token
+	fallback.Fallback = nil
+	if u.Fallback.Domain != "" {
+		fallback.Domain = u.Fallback.Domain
+		fallback.OriginalDomain = u.Fallback.OriginalDomain
+	}
+	return fallback, true
+}
+
+// ReplicaUpdaters returns one updater per replica provider, each applying
+// the same records with its own credentials
+func (u DNSUpdater) ReplicaUpdaters() []DNSUpdater {
+	var replicas []DNSUpdater
+	for _, r := range u.Replicas {
+		replica := u
+		replica.Provider = r.Provider
+		replica.AccessKey = r.AccessKey
+		replica.SecretKey = r.SecretKey
+		replica.Token = r.Token
+		replica.Fallback = nil
+		replica.Replicas = nil
+		if r.Domain != "" {
+			replica.Domain = r.Domain
+			replica.OriginalDomain = r.OriginalDomain
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas
+}
+
+// ComparesTTL reports whether a TTL differing from the configured one
+// triggers an update (compare_fields)
+func (u DNSUpdater) ComparesTTL() bool {
+	return slices.Contains(u.CompareFields, CompareTTL)
+}
+
+// NotifyEnabled reports whether the updater's results are included in
+// notifications (notify defaults to true)
+func (u DNSUpdater) NotifyEnabled() bool {
+	return u.Notify == nil || *u.Notify
+}
+
+// NotifyEnabled reports whether the updater's results are included in
+// notifications (notify defaults to true)
+func (u FileUpdater) NotifyEnabled() bool {
+	return u.Notify == nil || *u.Notify
+}
+
+// DisplayDomain returns the domain as the user wrote it, for logging
+func (u DNSUpdater) DisplayDomain() string {
+	if u.OriginalDomain != "" {
+		return u.OriginalDomain
+	}
+	return u.Domain
 }
 
 type DNSRecord struct {
 	Name string `toml:"name"`
 	Type string `toml:"type"`
 	TTL  int    `toml:"ttl"`
+	// Remark is written to the record's remark on providers that have one
+	// (aliyun, tencent); unset leaves the remark untouched
+	Remark string `toml:"remark"`
+	// Target is the hostname a CNAME or ALIAS/ANAME record points at. These
+	// records hold it instead of the detected IP and are kept in place
+	// alongside the address records.
+	Target string `toml:"target"`
+	// CreateOnly creates the record when it is missing but never changes an
+	// existing one, e.g. a value that is also maintained by hand
+	CreateOnly bool `toml:"create_only"`
+	// MatchValue picks the record this updater manages among several with
+	// the same name and type (round-robin): the one currently holding this
+	// value. Once updated, the record is followed by its ID in the state
+	// file.
+	MatchValue string `toml:"match_value"`
 }
 
 type FileUpdater struct {
-	Name     string `toml:"name"`
-	FilePath string `toml:"file_path"`
-	Format   string `toml:"format"`
-	KeyPath  string `toml:"key_path"`
-	Backup   bool   `toml:"backup"`
+	Name        string   `toml:"name"`
+	FilePath    string   `toml:"file_path"`
+	Format      string   `toml:"format"`
+	KeyPath     string   `toml:"key_path"`
+	Backup      bool     `toml:"backup"`
+	DependsOn   []string `toml:"depends_on"`   // 依赖的更新器名称，依赖成功后才执行
+	VerifyWrite bool     `toml:"verify_write"` // 写入后读回校验
+
+	// KeySeparator separates the nested keys of key_path, "/" by default;
+	// e.g. "." for dot notation (server.public_ip)
+	KeySeparator string `toml:"key_separator"`
+
+	BackupDir       string `toml:"backup_dir"`       // 备份文件目录，默认与原文件同目录
+	BackupTimestamp bool   `toml:"backup_timestamp"` // 备份文件名附加时间戳 (仅 backup_dir)
+	BackupKeep      int    `toml:"backup_keep"`      // 保留的带时间戳备份数，0 全部保留
+
+	// List mode: the key holds a delimited list (e.g. an allowlist) and only
+	// the entry written by this updater is replaced
+	List          bool   `toml:"list"`
+	ListDelimiter string `toml:"list_delimiter"` // 默认 ","
+
+	// Size guard for the target file, which is read whole on every update
+	MaxFileSize       int    `toml:"max_file_size"`        // MB，默认10，-1 不限制
+	MaxFileSizeAction string `toml:"max_file_size_action"` // warn (默认) 或 error
+
+	HealthCheck *healthcheck.Config `toml:"health_check"` // 更新前检查新IP上的服务是否可达
+	Notify      *bool               `toml:"notify"`       // false: 不发送该更新器的变更/失败通知
+	Cron        string              `toml:"cron"`         // 该更新器按cron表达式单独检查，不随file_check_interval检查
 }
 
 type RetryConfig struct {
@@ -53,20 +283,86 @@ type RetryConfig struct {
 type LoggingConfig struct {
 	Level    string `toml:"level"`
 	FilePath string `toml:"file_path"`
-	MaxSize  int    `toml:"max_size"`
-	MaxAge   int    `toml:"max_age"`
+	MaxSize  int    `toml:"max_size"` // 单个日志文件达到此大小(MB)时轮转，0 不轮转
+	MaxAge   int    `toml:"max_age"`  // 轮转后的日志保留天数，0 不删除
+	Compress bool   `toml:"compress"` // 轮转后的日志使用gzip压缩
+	Output   string `toml:"output"`   // 输出位置: file (默认，标准输出和file_path) / syslog / journald / file,syslog
+
+	// MaskIPInLogs redacts the host part of public addresses in log output
+	MaskIPInLogs bool `toml:"mask_ip_in_logs"`
+
+	// syslog目标，syslog_network为空时发送到本机syslog
+	SyslogNetwork  string `toml:"syslog_network"`  // udp / tcp / unix
+	SyslogAddress  string `toml:"syslog_address"`  // "host:port" 或socket路径
+	SyslogFacility string `toml:"syslog_facility"` // 默认daemon
+	SyslogTag      string `toml:"syslog_tag"`      // 默认ip_updater
 }
 
+// Syslog returns the syslog settings for logger.Configure
+func (c LoggingConfig) Syslog() logger.SyslogConfig {
+	return logger.SyslogConfig{
+		Network:  c.SyslogNetwork,
+		Address:  c.SyslogAddress,
+		Facility: c.SyslogFacility,
+		Tag:      c.SyslogTag,
+	}
+}
+
+type APIQuotaConfig struct {
+	HourlyBudget int `toml:"hourly_budget"` // 每个服务商每小时API调用预算，0为不检查
+	WarnPercent  int `toml:"warn_percent"`  // 达到预算的百分比时发出警告
+}
+
+type StatusConfig struct {
+	ListenAddr        string `toml:"listen_addr"`         // 为空时不启动状态服务，未指定主机时仅监听127.0.0.1
+	EventBufferSize   int    `toml:"event_buffer_size"`   // 保留的最近事件数量
+	HistorySize       int    `toml:"history_size"`        // 保留的最近IP变化数量
+	AuthToken         string `toml:"auth_token"`          // Bearer Token认证
+	BasicAuthUser     string `toml:"basic_auth_user"`     // Basic认证用户名
+	BasicAuthPassword string `toml:"basic_auth_password"` // Basic认证密码
+	TextfileDir       string `toml:"textfile_dir"`        // 每次检查后写入node_exporter textfile collector指标的目录
+}
+
+// ScheduleConfig restricts when DNS changes may be applied. Changes detected
+// outside the allowed time are deferred until the window opens.
+type ScheduleConfig struct {
+	ActiveHours   []string `toml:"active_hours"`   // 允许更新的时段，如 "08:00-22:00"
+	FreezeWindows []string `toml:"freeze_windows"` // 禁止更新的时段，如 "01:00-05:00"
+}
+
+// CreateDefaultIfMissing controls whether Load writes a default config file
+// when configPath does not exist. When false, a missing file is an error.
+var CreateDefaultIfMissing = true
+
 func Load(configPath string) (*Config, error) {
 	// Create default config if file doesn't exist
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		if !CreateDefaultIfMissing {
+			return nil, fmt.Errorf("configuration file not found: %s", configPath)
+		}
+		// A dangling symlink (e.g. a ConfigMap not mounted yet) is never
+		// replaced, nor is its target created, with the default content
+		if info, err := os.Lstat(configPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			target, _ := os.Readlink(configPath)
+			return nil, fmt.Errorf("configuration file %s is a symlink to missing %s", configPath, target)
+		}
 		if err := createDefaultConfig(configPath); err != nil {
 			return nil, err
 		}
 	}
 
 	var config Config
-	if _, err := toml.DecodeFile(configPath, &config); err != nil {
+	meta, err := toml.DecodeFile(configPath, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upgrade older layouts before defaults fill in the missing fields
+	if err := migrate(&config, meta); err != nil {
+		return nil, err
+	}
+
+	if err := checkUnknownKeys(&config, meta); err != nil {
 		return nil, err
 	}
 
@@ -100,10 +396,63 @@ func Load(configPath string) (*Config, error) {
 		}
 	}
 
+	if len(config.IPDetection.IPv6Endpoints) == 0 {
+		config.IPDetection.IPv6Endpoints = []string{
+			"https://api6.ipify.org",
+			"https://ipv6.icanhazip.com",
+			"https://v6.ident.me",
+		}
+	}
+
 	if config.IPDetection.Timeout == 0 {
 		config.IPDetection.Timeout = 30
 	}
 
+	// Checks that fire within half the shorter interval share one detection
+	if config.IPDetection.CacheTTL == 0 {
+		config.IPDetection.CacheTTL = min(config.DNSCheckInterval, config.FileCheckInterval) / 2
+	} else if config.IPDetection.CacheTTL < -1 {
+		return nil, fmt.Errorf("invalid ip_detection.cache_ttl: %d", config.IPDetection.CacheTTL)
+	}
+	switch config.IPDetection.CacheFingerprint {
+	case "", netutil.FingerprintInterface:
+	case netutil.FingerprintGateway:
+		if !netutil.GatewayFingerprintSupported {
+			return nil, fmt.Errorf("ip_detection.cache_fingerprint = %q is only supported on Linux, use %q",
+				netutil.FingerprintGateway, netutil.FingerprintInterface)
+		}
+	default:
+		return nil, fmt.Errorf("invalid ip_detection.cache_fingerprint: %s (expected %s or %s)",
+			config.IPDetection.CacheFingerprint, netutil.FingerprintGateway, netutil.FingerprintInterface)
+	}
+	for endpoint, path := range config.IPDetection.JSONPaths {
+		if strings.TrimSpace(path) == "" || strings.Contains(path, "..") {
+			return nil, fmt.Errorf("invalid ip_detection.json_paths[%q]: %q", endpoint, path)
+		}
+	}
+	if config.IPDetection.MaxTimeout < 0 {
+		return nil, fmt.Errorf("invalid ip_detection.max_timeout: %d", config.IPDetection.MaxTimeout)
+	}
+	if config.IPDetection.MaxTimeout > 0 && config.IPDetection.MaxTimeout <= config.IPDetection.Timeout {
+		return nil, fmt.Errorf("ip_detection.max_timeout (%d) must be greater than timeout (%d)",
+			config.IPDetection.MaxTimeout, config.IPDetection.Timeout)
+	}
+	if config.IPDetection.Samples < 0 {
+		return nil, fmt.Errorf("invalid ip_detection.samples: %d", config.IPDetection.Samples)
+	}
+	if config.IPDetection.SampleInterval < 0 {
+		return nil, fmt.Errorf("invalid ip_detection.sample_interval: %d", config.IPDetection.SampleInterval)
+	}
+	if config.IPDetection.MaxRedirects < -1 {
+		return nil, fmt.Errorf("invalid ip_detection.max_redirects: %d", config.IPDetection.MaxRedirects)
+	}
+	if config.IPDetection.FailureAlertAfter < 0 {
+		return nil, fmt.Errorf("invalid ip_detection.failure_alert_after: %d", config.IPDetection.FailureAlertAfter)
+	}
+	if len(config.IPDetection.EscalationEndpoints) > 0 && config.IPDetection.FailureAlertAfter == 0 {
+		config.warnf("ip_detection.escalation_endpoints is ignored without failure_alert_after")
+	}
+
 	if config.Retry.Interval == 0 {
 		config.Retry.Interval = 60
 	}
@@ -120,6 +469,202 @@ func Load(configPath string) (*Config, error) {
 		config.Logging.FilePath = "/var/log/ip_updater/ip_updater.log"
 	}
 
+	if config.Logging.Output == "" {
+		config.Logging.Output = logger.OutputFile
+	}
+	if err := logger.ValidateOutput(config.Logging.Output); err != nil {
+		return nil, err
+	}
+	if err := config.Logging.Syslog().Validate(); err != nil {
+		return nil, err
+	}
+
+	if config.StateFile == "" {
+		config.StateFile = "/var/lib/ip_updater/state.json"
+	}
+
+	switch config.StartupUpdate {
+	case "":
+		config.StartupUpdate = StartupUpdateIfChanged
+	case StartupUpdateAlways, StartupUpdateIfChanged:
+	default:
+		return nil, fmt.Errorf("invalid startup_update: %s (expected %s or %s)",
+			config.StartupUpdate, StartupUpdateAlways, StartupUpdateIfChanged)
+	}
+
+	if config.CombinedUpdates && config.FileCheckCron != "" {
+		config.warnf("file_check_cron is ignored with combined_updates: DNS and file updaters are checked together on dns_check_interval or dns_check_cron")
+	}
+
+	if config.ShutdownTimeout < 0 {
+		return nil, fmt.Errorf("invalid shutdown_timeout: %d", config.ShutdownTimeout)
+	}
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = DefaultShutdownTimeout
+	}
+
+	if config.ReassertInterval < 0 {
+		return nil, fmt.Errorf("invalid reassert_interval: %d (expected seconds, 0 to disable)", config.ReassertInterval)
+	}
+
+	if config.Status.EventBufferSize <= 0 {
+		config.Status.EventBufferSize = 50
+	}
+	if config.Status.HistorySize <= 0 {
+		config.Status.HistorySize = 20
+	}
+
+	switch config.IPDetection.Strategy {
+	case "", detector.StrategyOrdered, detector.StrategySticky:
+	default:
+		return nil, fmt.Errorf("invalid ip_detection.strategy: %s (expected %s or %s)",
+			config.IPDetection.Strategy, detector.StrategyOrdered, detector.StrategySticky)
+	}
+
+	if config.Status.BasicAuthUser != "" && config.Status.BasicAuthPassword == "" {
+		return nil, fmt.Errorf("status.basic_auth_password is required when basic_auth_user is set")
+	}
+
+	if config.Status.TextfileDir != "" {
+		if info, err := os.Stat(config.Status.TextfileDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("invalid status.textfile_dir: %s is not a directory", config.Status.TextfileDir)
+		}
+	}
+
+	if config.IPDetection.Transform != nil {
+		if err := config.IPDetection.Transform.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.IPDetection.Liveness != nil {
+		if err := config.IPDetection.Liveness.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.IPDetection.Interface != "" {
+		if err := netutil.ValidateInterface(config.IPDetection.Interface); err != nil {
+			return nil, fmt.Errorf("invalid ip_detection.interface: %w", err)
+		}
+	}
+
+	if config.LocalAddr != "" && config.LocalAddr != netutil.LocalAddrAuto {
+		if err := netutil.ValidateLocalAddr(config.LocalAddr); err != nil {
+			return nil, fmt.Errorf("invalid local_addr: %w", err)
+		}
+	}
+
+	if config.RunAsGroup != "" && config.RunAsUser == "" {
+		return nil, fmt.Errorf("run_as_group requires run_as_user")
+	}
+	if config.RunAsUser != "" {
+		if !privdrop.Supported {
+			return nil, fmt.Errorf("run_as_user is only supported on Linux")
+		}
+		if _, err := privdrop.Lookup(config.RunAsUser, config.RunAsGroup); err != nil {
+			return nil, fmt.Errorf("invalid run_as_user: %w", err)
+		}
+	}
+
+	if config.APIQuota.HourlyBudget < 0 {
+		return nil, fmt.Errorf("invalid api_quota.hourly_budget: %d", config.APIQuota.HourlyBudget)
+	}
+
+	if config.APIQuota.WarnPercent <= 0 || config.APIQuota.WarnPercent > 100 {
+		config.APIQuota.WarnPercent = 80
+	}
+
+	if _, err := schedule.NewGate(config.Schedule.ActiveHours, config.Schedule.FreezeWindows); err != nil {
+		return nil, fmt.Errorf("invalid schedule: %w", err)
+	}
+
+	if err := validateCron(&config); err != nil {
+		return nil, err
+	}
+
+	if err := validateRecords(&config); err != nil {
+		return nil, err
+	}
+
+	if err := applyIPMode(&config); err != nil {
+		return nil, err
+	}
+
+	for i, updater := range config.DNSUpdaters {
+		if updater.Fallback != nil && updater.Fallback.Provider == "" {
+			return nil, fmt.Errorf("DNS updater %s: fallback.provider is required", updater.Name)
+		}
+		for _, replica := range updater.Replicas {
+			if replica.Provider == "" {
+				return nil, fmt.Errorf("DNS updater %s: replica.provider is required", updater.Name)
+			}
+		}
+		if updater.ParallelUpdates < 0 {
+			return nil, fmt.Errorf("DNS updater %s: invalid parallel_updates: %d", updater.Name, updater.ParallelUpdates)
+		}
+		switch updater.DuplicateRecords {
+		case "":
+			config.DNSUpdaters[i].DuplicateRecords = DuplicateWarn
+		case DuplicateWarn, DuplicateError, DuplicateValue, DuplicateAll:
+		default:
+			return nil, fmt.Errorf("DNS updater %s: invalid duplicate_records: %s (expected %s, %s, %s or %s)",
+				updater.Name, updater.DuplicateRecords, DuplicateWarn, DuplicateError, DuplicateValue, DuplicateAll)
+		}
+		for _, field := range updater.CompareFields {
+			if field != CompareValue && field != CompareTTL {
+				return nil, fmt.Errorf("DNS updater %s: invalid compare_fields entry: %s (expected %s or %s)", updater.Name, field, CompareValue, CompareTTL)
+			}
+		}
+		if err := validateFallbackIP(&config, &config.DNSUpdaters[i]); err != nil {
+			return nil, fmt.Errorf("DNS updater %s: %w", updater.Name, err)
+		}
+		if updater.HealthCheck != nil {
+			if err := updater.HealthCheck.Validate(); err != nil {
+				return nil, fmt.Errorf("DNS updater %s: %w", updater.Name, err)
+			}
+		}
+	}
+
+	for _, updater := range config.FileUpdaters {
+		if updater.HealthCheck != nil {
+			if err := updater.HealthCheck.Validate(); err != nil {
+				return nil, fmt.Errorf("file updater %s: %w", updater.Name, err)
+			}
+		}
+	}
+
+	if err := validateExtraConfig(&config); err != nil {
+		return nil, err
+	}
+
+	if err := validateFileUpdaters(&config); err != nil {
+		return nil, err
+	}
+
+	if config.Reachability != nil {
+		if err := config.Reachability.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.Notify.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid notify config: %w", err)
+	}
+
+	if err := orderByDependencies(&config); err != nil {
+		return nil, err
+	}
+
+	if err := validateUpdateOrder(&config); err != nil {
+		return nil, err
+	}
+
+	// Convert internationalized domain names to punycode for provider APIs
+	if err := normalizeDomains(&config); err != nil {
+		return nil, err
+	}
+
 	// Decrypt sensitive data
 	if err := decryptSensitiveData(&config); err != nil {
 		return nil, err
@@ -136,6 +681,11 @@ func createDefaultConfig(configPath string) error {
 
 	defaultConfig := `# IP-Updater Configuration File
 
+# 配置文件格式版本
+config_version = 1
+# 配置中存在无法识别（如拼写错误）的键名时拒绝加载，默认只输出警告
+strict_keys = false
+
 # Default check interval in seconds (兼容旧版本，建议使用下面的分离配置)
 check_interval = 600
 
@@ -145,9 +695,97 @@ dns_check_interval = 3600
 # 文件更新检查间隔 (seconds, default: 600 = 10 minutes)
 file_check_interval = 600
 
+# 按cron表达式检查，设置后代替对应的间隔 (分 时 日 月 周，本地时间)
+# 例如工作日6点和18点: "0 6,18 * * 1-5"；也可用 @hourly、@daily 等
+# dns_check_cron = "0 6,18 * * 1-5"
+# file_check_cron = "@hourly"
+
+# 合并检查: 按dns_check_interval (或dns_check_cron) 只检测一次IP，IP变化时同时更新DNS和文件，
+# 不再单独使用file_check_interval; 需要两者分别设置间隔时保持false
+combined_updates = false
+
+# IP变化时DNS和文件的更新顺序: "dns_first" (先更新DNS，再更新文件) 或 "files_first" (先更新文件，再更新DNS)；
+# 不设置时启动时先DNS后文件，合并检查时两者同时进行。前一步失败不会阻止后一步，需要依赖时使用depends_on
+# update_order = "dns_first"
+
+# 配置文件变化时自动重新加载 (也可发送 SIGHUP 手动重新加载)
+watch_config = false
+
+# 收到SIGINT/SIGTERM后最多等待的秒数: 保存状态文件、发送暂缓和汇总中的通知、关闭状态服务，
+# 超时后强制退出 (包括收到信号时仍在进行的检查)
+shutdown_timeout = 5
+
+# 状态文件，记录上次成功应用的IP，重启后用于判断是否需要更新
+state_file = "/var/lib/ip_updater/state.json"
+# 在状态文件旁写入HMAC签名 (state.json.hmac，使用本机密钥)，加载时校验不通过则按首次运行处理
+sign_state_file = false
+# 启动时更新策略: "if_changed" 仅在IP与状态文件记录不同时更新; "always" 每次启动都更新
+# (使用 -force 参数可临时强制启动时更新)
+startup_update = "if_changed"
+# IP未变化时，距某个DNS更新器上次成功写入超过该秒数后重新执行一次更新，修复在程序之外被改动的记录
+# (只写入与当前IP不一致的记录，0为关闭)
+reassert_interval = 0
+# 每次DNS检查时读取服务商的现有记录，与检测到的IP不一致就更新，而不只比较内存中上次应用的IP
+# (能发现记录被手动修改，但每次检查都会多一次查询记录的API调用)
+verify_records = false
+
+# 检测和更新的地址族: "dual" (默认) 按记录类型同时处理IPv4和IPv6; "ipv4" 只检测IPv4，跳过AAAA记录;
+# "ipv6" 只检测IPv6，跳过A记录，文件更新器写入IPv6地址
+ip_mode = "dual"
+
+# 出站请求（IP检测和DNS服务商API）使用的本机源地址，多出口主机可用于指定线路
+# 设为 "auto" 时跟随当前默认路由，双WAN切换后自动改用新线路
+# local_addr = "192.168.1.10"
+
+# 记录的ttl超过该值时在加载配置时给出警告：IP变化后，解析器可能在整个TTL内仍返回旧IP
+# (默认3600秒，-1关闭)
+# max_record_ttl = 3600
+
+# 以root启动时，打开日志文件和状态端口后切换到该用户运行 (仅Linux)
+# 该用户需要能写入被更新的文件、备份、状态文件和日志目录
+# run_as_user = "ip_updater"
+# run_as_group = "ip_updater"
+
 [ip_detection]
 # Timeout for IP detection requests in seconds
 timeout = 30
+# Adaptive timeout for congested links: each failed detection doubles the
+# request timeout up to max_timeout seconds, each success halves it back.
+# 0 = fixed timeout
+# max_timeout = 120
+# Endpoint strategy: "ordered" tries endpoints in list order every time,
+# "sticky" keeps using the last working endpoint after the first one fails
+strategy = "ordered"
+# Seconds before the sticky strategy re-probes the first endpoint
+sticky_cooldown = 600
+# IPv6 detection is only used when AAAA records are configured. When it fails,
+# AAAA records are skipped and IPv6 is re-checked after this many seconds
+ipv6_recheck_interval = 3600
+# Send detection requests from this network interface (e.g. the second WAN
+# uplink). Its address is looked up on every request, so DHCP/PPPoE changes are
+# followed; while it is down, requests use the default route
+# interface = "ppp0"
+# Seconds a detected IPv4 address is reused, so DNS and file checks that run
+# together detect once. 0 = half the shorter check interval, -1 = disabled.
+# The cache is dropped when the default route changes or the config reloads
+# cache_ttl = 0
+# For hosts that move between networks (laptops): also drop the cache as soon
+# as the network changes. "gateway" = default gateway address and MAC (Linux),
+# "interface" = interface and address of the default route
+# cache_fingerprint = "gateway"
+# A changed IPv4 address must be detected this many times in a row,
+# sample_interval seconds apart, before it is used (0/1 = accept at once)
+# samples = 2
+# sample_interval = 3
+# Redirects followed per detection request (0 = 3, -1 = treat as failure).
+# A redirecting endpoint is logged once; configure its final URL instead
+# max_redirects = 0
+# After this many consecutive failed detections, log an alert and send a
+# detection_failed notification (detection_recovered once it works again).
+# 0 = disabled. escalation_endpoints are then tried after the regular ones
+# failure_alert_after = 5
+# escalation_endpoints = ["https://checkip.amazonaws.com", "https://ifconfig.co/ip"]
+# ipv6_endpoints = ["https://api6.ipify.org", "https://ipv6.icanhazip.com"]
 
 # API endpoints for getting public IP (tried first) - 中国大陆可访问服务
 api_endpoints = [
@@ -163,6 +801,29 @@ web_endpoints = [
     "https://ip4.seeip.org"
 ]
 
+# Field holding the address in JSON responses, by endpoint URL or host.
+# Common services (ipify, ipinfo.io, ip-api.com, httpbin.org, ...) are built in
+# [ip_detection.json_paths]
+# "https://api.example.com/whoami" = "data.ip"
+
+# Behind a 1:1 NAT or VIP, publish a different address than the detected one:
+# a static table and/or a command reading the detected address on stdin and
+# printing the address to publish. Addresses not in the map go to the command
+# [ip_detection.transform]
+# map = { "203.0.113.5" = "198.51.100.20" }
+# command = ["/usr/local/bin/vip-for"]
+# timeout = 10
+
+# Check that the host is online before detecting; while it fails (offline,
+# still booting) checks are skipped quietly instead of logging detection
+# failures. Any tcp target connecting counts as online; with a command too,
+# it must exit 0 as well. The result is reused for cache_ttl seconds
+# [ip_detection.liveness]
+# tcp = ["1.1.1.1:443", "223.5.5.5:53"]
+# command = ["ping", "-c1", "-W2", "192.168.1.1"]
+# timeout = 5
+# cache_ttl = 30
+
 [retry]
 # Retry interval in seconds when update fails
 interval = 60
@@ -178,6 +839,72 @@ file_path = "/var/log/ip_updater/ip_updater.log"
 max_size = 100
 # Max age of log files in days
 max_age = 30
+# Gzip rotated log files
+compress = false
+# Output: file (stdout and file_path), syslog (syslog daemon only),
+# journald (stdout with priority prefixes, for systemd services) or
+# "file,syslog" (stdout and file_path, plus every entry sent to syslog)
+output = "file"
+# Syslog daemon for the syslog outputs: the local one unless syslog_network
+# (udp, tcp or unix) and syslog_address ("host:port" or a socket path) are set
+# syslog_network = "udp"
+# syslog_address = "192.0.2.10:514"
+# Syslog facility (daemon, user, local0 ... local7, ...) and tag
+syslog_facility = "daemon"
+syslog_tag = "ip_updater"
+# Redact public IPs in log output (203.0.113.x, 2001:db8:1::x), e.g. before
+# sharing logs; updates still use the full addresses
+mask_ip_in_logs = false
+
+[status]
+# Status HTTP endpoint (GET /status), disabled when empty.
+# Without a host (":8080") only 127.0.0.1 is bound; use "0.0.0.0:8080" to expose it
+# listen_addr = "127.0.0.1:8080"
+# Number of recent events kept in memory
+event_buffer_size = 50
+# Number of recent IP changes kept in memory (history in /status)
+history_size = 20
+# Optional authentication: bearer token and/or basic auth
+# auth_token = "your_token"
+# basic_auth_user = "admin"
+# basic_auth_password = "your_password"
+# Write Prometheus metrics (ip_updater.prom) to node_exporter's textfile
+# collector directory after every check
+# textfile_dir = "/var/lib/node_exporter/textfile_collector"
+
+[schedule]
+# DNS更新时间窗口，时段外检测到的变化会推迟到窗口打开后再应用 (HH:MM-HH:MM, 本地时间)
+# active_hours = ["08:00-23:00"]
+# freeze_windows = ["01:00-05:00"]
+
+[api_quota]
+# 每个服务商每小时的API调用预算，接近预算时输出警告 (0 = 不检查)
+hourly_budget = 0
+# 达到预算的百分比时警告
+warn_percent = 80
+
+# IP变化后的Webhook通知 (可配置多个)
+# body_template 为 Go text/template，可用字段: .Type .OldIP .NewIP .OldIPv6 .NewIPv6 .Success .Error .Results .Hostname .Timestamp
+# [notify]
+# notify_on_start = true                  # 启动检测完成后发送一次 start 通知
+# quiet_hours = ["23:00-07:00"]           # 静默时段：暂缓通知，结束时汇总为一条 summary 通知（失败告警仍立即发送）
+# 留空时发送默认JSON；json 函数可把值安全地嵌入JSON
+# [[notify.webhook]]
+# name = "slack"
+# url = "https://hooks.slack.com/services/XXX/YYY/ZZZ"
+# content_type = "application/json"
+# body_template = '''{"text": {{json (printf "%s: %s -> %s" .Hostname .OldIP .NewIP)}}}'''
+# digest = "0 8 * * *"                    # 汇总模式：按cron计划发送一条 digest 通知，不逐条发送
+
+# 外部可达性检查：检测到IP后请外部端口检测服务探测这些端口，
+# 从外网无法访问时记录日志并发送 unreachable 通知（恢复后发送 reachable）
+# url 中的 {ip} 和 {port} 会被替换；expect 为端口开放时响应体匹配的正则，留空时2xx即视为开放
+# [reachability]
+# url = "https://portcheck.example.com/check?ip={ip}&port={port}"
+# ports = [443]
+# expect = '"open":\s*true'
+# interval = 3600                         # IP未变化时的检查间隔(秒)
+# timeout = 10
 
 # Example DNS updater configurations (uncomment and configure as needed)
 
@@ -191,6 +918,12 @@ max_age = 30
 # name = "www"
 # type = "A"
 # ttl = 600
+# remark = "managed by ip_updater"        # 记录备注（阿里云/腾讯云），不设置则不修改
+# create_only = false                    # true: 记录不存在时创建，已存在时从不修改
+# [[dns_updater.record]]
+# name = "home"
+# type = "A+AAAA"                         # A和AAAA记录作为一组同时更新
+# ttl = 600
 
 # [[dns_updater]]
 # name = "tencent-example"
@@ -198,6 +931,10 @@ max_age = 30
 # access_key = "your_secret_id"            # Will be encrypted
 # secret_key = "your_secret_key"           # Will be encrypted
 # domain = "example.com"
+# fallback_ip = "198.51.100.10"           # 可选: 公网IP检测持续失败时改为发布此备用IP，检测恢复后切回
+# fallback_after = 1800                   # 检测连续失败多久(秒)后切换到fallback_ip
+# duplicate_records = "warn"               # 同名同类型有多条记录时: warn (更新第一条并警告) / error / value / all
+# compare_fields = ["value", "ttl"]        # 现有记录的TTL与配置不同时也更新 (默认只比较记录值)
 # [[dns_updater.record]]
 # name = "@"
 # type = "A"
@@ -223,6 +960,11 @@ max_age = 30
 # name = "api"
 # type = "A"
 # ttl = 600
+# match_value = "203.0.113.5"            # 可选: 同名多条A记录（轮询）时只更新值为此IP的一条
+# [dns_updater.health_check]             # 可选: 新IP上的服务可达后才发布
+# type = "tcp"                             # tcp / http / https
+# port = 443
+# timeout = 5
 
 # [[dns_updater]]
 # name = "godaddy-example"
@@ -242,7 +984,15 @@ max_age = 30
 # file_path = "/etc/myapp/config.json"
 # format = "json"
 # key_path = "server/public_ip"           # JSON path: server.public_ip
+# key_separator = "."                     # 使用点号分隔时写作 key_path = "server.public_ip"
 # backup = true
+# verify_write = false                    # 写入后读回校验，适用于overlay/网络文件系统
+# backup_dir = "/var/backups/ip_updater"  # 备份写入单独目录，默认写在原文件旁 (<file>.backup)
+# backup_timestamp = false                # 备份文件名附加时间戳，保留每次更新前的版本
+# backup_keep = 10                        # 只保留最近的N个带时间戳备份，0 全部保留
+# max_file_size = 10                      # 目标文件超过此大小(MB)时告警，-1 不限制
+# max_file_size_action = "warn"           # warn: 仅告警；error: 拒绝更新
+# notify = true                           # false: 照常更新，但不发送该更新器的通知
 
 # [[file_updater]]
 # name = "yaml-config-example"
@@ -264,9 +1014,32 @@ max_age = 30
 # format = "ini"
 # key_path = "network/ip"                 # INI path: [network] ip
 # backup = true
+
+# [[file_updater]]
+# name = "plist-config-example"
+# file_path = "/Library/Preferences/com.example.myapp.plist"
+# format = "plist"
+# key_path = "Server/Address"             # plist path: Server dict -> Address
+# backup = true
+
+# [[file_updater]]
+# name = "hosts-example"
+# file_path = "/etc/hosts"
+# format = "hosts"
+# key_path = "home.example.com"           # hostname; only its IPv4 line is rewritten
+# backup = true
 `
 
-	return os.WriteFile(configPath, []byte(defaultConfig), 0644)
+	// O_EXCL: never write through a symlink or over a file created meanwhile
+	file, err := os.OpenFile(configPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(defaultConfig); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
 
 func decryptSensitiveData(config *Config) error {
@@ -293,7 +1066,423 @@ func decryptSensitiveData(config *Config) error {
 				updater.Token = decrypted
 			}
 		}
+
+		providers := make([]*FallbackProvider, 0, len(updater.Replicas)+1)
+		if updater.Fallback != nil {
+			providers = append(providers, updater.Fallback)
+		}
+		for j := range updater.Replicas {
+			providers = append(providers, &updater.Replicas[j])
+		}
+		for _, provider := range providers {
+			for _, value := range []*string{&provider.AccessKey, &provider.SecretKey, &provider.Token} {
+				if *value == "" {
+					continue
+				}
+				if decrypted, err := crypto.Decrypt(*value); err == nil {
+					*value = decrypted
+				}
+			}
+		}
+	}
+
+	if config.Status.AuthToken != "" {
+		decrypted, err := crypto.Decrypt(config.Status.AuthToken)
+		if err == nil {
+			config.Status.AuthToken = decrypted
+		}
+	}
+
+	if config.Status.BasicAuthPassword != "" {
+		decrypted, err := crypto.Decrypt(config.Status.BasicAuthPassword)
+		if err == nil {
+			config.Status.BasicAuthPassword = decrypted
+		}
+	}
+
+	return nil
+}
+
+func normalizeDomains(config *Config) error {
+	for i := range config.DNSUpdaters {
+		updater := &config.DNSUpdaters[i]
+		if updater.Domain == "" {
+			continue
+		}
+
+		ascii, err := idna.Lookup.ToASCII(updater.Domain)
+		if err != nil {
+			return fmt.Errorf("invalid domain %q for DNS updater %s: %w", updater.Domain, updater.Name, err)
+		}
+
+		if ascii != updater.Domain {
+			updater.OriginalDomain = updater.Domain
+			updater.Domain = ascii
+		}
+
+		if fallback := updater.Fallback; fallback != nil && fallback.Domain != "" {
+			ascii, err := idna.Lookup.ToASCII(fallback.Domain)
+			if err != nil {
+				return fmt.Errorf("invalid fallback domain %q for DNS updater %s: %w", fallback.Domain, updater.Name, err)
+			}
+			if ascii != fallback.Domain {
+				fallback.OriginalDomain = fallback.Domain
+				fallback.Domain = ascii
+			}
+		}
+
+		for j := range updater.Replicas {
+			replica := &updater.Replicas[j]
+			if replica.Domain == "" {
+				continue
+			}
+			ascii, err := idna.Lookup.ToASCII(replica.Domain)
+			if err != nil {
+				return fmt.Errorf("invalid replica domain %q for DNS updater %s: %w", replica.Domain, updater.Name, err)
+			}
+			if ascii != replica.Domain {
+				replica.OriginalDomain = replica.Domain
+				replica.Domain = ascii
+			}
+		}
+	}
+
+	return nil
+}
+
+// RecordTypeDualStack is a record entry that keeps the A and the AAAA record
+// of one name in step: both are updated in the same pass, as one result
+const RecordTypeDualStack = "A+AAAA"
+
+// supportedRecordTypes lists the record types that can be configured.
+// ALIAS/ANAME are apex-only and need a provider with an apex alias, see
+// providerAliasTypes.
+var supportedRecordTypes = map[string]bool{
+	"A":                 true,
+	"AAAA":              true,
+	RecordTypeDualStack: true,
+	"ALIAS":             true,
+	"ANAME":             true,
+	"CNAME":             true,
+}
+
+// providerAliasTypes maps the providers with an apex alias record to its
+// type, which ALIAS and ANAME records are written as. Keep it in sync with
+// the providers implementing dns.AliasProvider.
+var providerAliasTypes = map[string]string{
+	"gandi":   "ALIAS",
+	"namecom": "ANAME",
+}
+
+// ProviderAliasType returns the apex alias record type of a provider, or ""
+// when it has none
+func ProviderAliasType(provider string) string {
+	return providerAliasTypes[strings.ToLower(provider)]
+}
+
+// HasIPv6Records reports whether any DNS record needs the public IPv6
+// address (AAAA or A+AAAA) next to the IPv4 one. It is false with ip_mode =
+// "ipv4", and with "ipv6", where the IPv6 address is the only one detected.
+func (c *Config) HasIPv6Records() bool {
+	if c.IPMode == IPModeIPv4 || c.IPMode == IPModeIPv6 {
+		return false
+	}
+	for _, updater := range c.DNSUpdaters {
+		for _, record := range updater.Records {
+			if record.Type == "AAAA" || record.Type == RecordTypeDualStack {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func validateRecords(config *Config) error {
+	if config.MaxRecordTTL == 0 {
+		config.MaxRecordTTL = DefaultMaxRecordTTL
+	}
+
+	for i := range config.DNSUpdaters {
+		updater := &config.DNSUpdaters[i]
+
+		for j := range updater.Records {
+			record := &updater.Records[j]
+			record.Type = strings.ToUpper(strings.TrimSpace(record.Type))
+
+			if !supportedRecordTypes[record.Type] {
+				return fmt.Errorf("DNS updater %s: unsupported record type %q for %s (supported: A, AAAA, A+AAAA, ALIAS, ANAME, CNAME)", updater.Name, record.Type, record.Name)
+			}
+
+			if err := validateAlias(updater, record); err != nil {
+				return err
+			}
+
+			if err := validateTarget(updater, record); err != nil {
+				return err
+			}
+
+			if err := validateMatchValue(updater, record); err != nil {
+				return err
+			}
+
+			// A long TTL keeps resolvers on the old IP long after a change
+			if config.MaxRecordTTL > 0 && record.TTL > config.MaxRecordTTL {
+				config.warnf("DNS updater %s: record %s has ttl = %d, resolvers may keep the old IP that long after it changes; consider %d or lower (max_record_ttl)",
+					updater.Name, record.Name, record.TTL, config.MaxRecordTTL)
+			}
+		}
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// applyIPMode checks ip_mode and warns about the records it leaves out. With
+// "ipv6" the detector looks up the IPv6 address in place of the IPv4 one.
+func applyIPMode(config *Config) error {
+	switch config.IPMode {
+	case "":
+		config.IPMode = IPModeDual
+	case IPModeIPv4, IPModeIPv6, IPModeDual:
+	default:
+		return fmt.Errorf("invalid ip_mode: %s (expected %s, %s or %s)", config.IPMode, IPModeIPv4, IPModeIPv6, IPModeDual)
+	}
+	config.IPDetection.IPv6Only = config.IPMode == IPModeIPv6
+
+	if config.IPMode == IPModeDual {
+		return nil
+	}
+	skipped, kept := "AAAA", "A"
+	if config.IPMode == IPModeIPv6 {
+		skipped, kept = "A", "AAAA"
+	}
+	for _, updater := range config.DNSUpdaters {
+		for _, record := range updater.Records {
+			switch record.Type {
+			case skipped:
+				config.warnf("DNS updater %s: ip_mode = %s, %s record %s is skipped", updater.Name, config.IPMode, skipped, record.Name)
+			case RecordTypeDualStack:
+				config.warnf("DNS updater %s: ip_mode = %s, only the %s record of %s is updated", updater.Name, config.IPMode, kept, record.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCron checks the global and per-updater cron expressions
+func validateCron(config *Config) error {
+	if config.DNSCheckCron != "" {
+		if _, err := schedule.ParseCron(config.DNSCheckCron); err != nil {
+			return fmt.Errorf("dns_check_cron: %w", err)
+		}
+	}
+	if config.FileCheckCron != "" {
+		if _, err := schedule.ParseCron(config.FileCheckCron); err != nil {
+			return fmt.Errorf("file_check_cron: %w", err)
+		}
+	}
+	for _, updater := range config.DNSUpdaters {
+		if updater.Cron != "" {
+			if _, err := schedule.ParseCron(updater.Cron); err != nil {
+				return fmt.Errorf("DNS updater %s: %w", updater.Name, err)
+			}
+		}
+	}
+	for _, updater := range config.FileUpdaters {
+		if updater.Cron != "" {
+			if _, err := schedule.ParseCron(updater.Cron); err != nil {
+				return fmt.Errorf("file updater %s: %w", updater.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// DefaultShutdownTimeout is how long, in seconds, the service may take to
+// save its state and send pending notifications after a shutdown signal
+const DefaultShutdownTimeout = 5
+
+// DefaultFallbackAfter is how long detection has to fail, in seconds, before
+// a DNS updater publishes its fallback_ip
+const DefaultFallbackAfter = 1800
+
+// validateFallbackIP checks the static failover address, which only A
+// records (and the A half of A+AAAA) receive; with ip_mode = "ipv6" it is
+// an IPv6 address for the AAAA records
+func validateFallbackIP(config *Config, updater *DNSUpdater) error {
+	if updater.FallbackAfter < 0 {
+		return fmt.Errorf("invalid fallback_after: %d", updater.FallbackAfter)
+	}
+	if updater.FallbackIP == "" {
+		if updater.FallbackAfter > 0 {
+			config.warnf("DNS updater %s: fallback_after is ignored without fallback_ip", updater.Name)
+		}
+		return nil
+	}
+
+	ip := net.ParseIP(updater.FallbackIP)
+	recordTypes := []string{"A", RecordTypeDualStack}
+	if config.IPMode == IPModeIPv6 {
+		if ip == nil || ip.To4() != nil {
+			return fmt.Errorf("fallback_ip must be an IPv6 address with ip_mode = ipv6: %s", updater.FallbackIP)
+		}
+		recordTypes[0] = "AAAA"
+	} else if ip == nil || ip.To4() == nil {
+		return fmt.Errorf("fallback_ip must be an IPv4 address: %s", updater.FallbackIP)
+	}
+	if err := netutil.CheckUsableAddress(updater.FallbackIP); err != nil {
+		return fmt.Errorf("invalid fallback_ip: %w", err)
+	}
+	if updater.FallbackAfter == 0 {
+		updater.FallbackAfter = DefaultFallbackAfter
+	}
+
+	for _, record := range updater.Records {
+		if slices.Contains(recordTypes, record.Type) {
+			return nil
+		}
+	}
+	config.warnf("DNS updater %s: fallback_ip is ignored, the updater has no %s records", updater.Name, recordTypes[0])
+	return nil
+}
+
+// validateAlias checks an ALIAS/ANAME record: it is only allowed at the
+// apex, and every provider of the updater (fallback and replicas included)
+// must have an apex alias record; it is never turned into an A record.
+func validateAlias(updater *DNSUpdater, record *DNSRecord) error {
+	if record.Type != "ALIAS" && record.Type != "ANAME" {
+		return nil
+	}
+	if record.Name != "@" && record.Name != "" {
+		return fmt.Errorf("DNS updater %s: %s records are only allowed at the zone apex (name = \"@\"), got %q", updater.Name, record.Type, record.Name)
+	}
+
+	providers := []string{updater.Provider}
+	if updater.Fallback != nil {
+		providers = append(providers, updater.Fallback.Provider)
+	}
+	for _, replica := range updater.Replicas {
+		providers = append(providers, replica.Provider)
+	}
+	for _, provider := range providers {
+		if ProviderAliasType(provider) == "" {
+			supported := make([]string, 0, len(providerAliasTypes))
+			for name := range providerAliasTypes {
+				supported = append(supported, name)
+			}
+			slices.Sort(supported)
+			return fmt.Errorf("DNS updater %s: provider %s doesn't support %s records (supported by: %s); use an A record at the apex instead",
+				updater.Name, provider, record.Type, strings.Join(supported, ", "))
+		}
+	}
+	return nil
+}
+
+// validateTarget checks a record's target: CNAME and ALIAS/ANAME records
+// need one, other types can't have one. The target is stored in punycode
+// without the trailing dot. A CNAME can't share its name with other
+// records, so it's not allowed at the apex or next to another record of the
+// same name.
+func validateTarget(updater *DNSUpdater, record *DNSRecord) error {
+	record.Target = strings.TrimSuffix(strings.TrimSpace(record.Target), ".")
+
+	if record.Type != "CNAME" && record.Type != "ALIAS" && record.Type != "ANAME" {
+		if record.Target != "" {
+			return fmt.Errorf("DNS updater %s: record %s has a target, which is only used with type = \"CNAME\", \"ALIAS\" or \"ANAME\"", updater.Name, record.Name)
+		}
+		return nil
+	}
+
+	if record.Target == "" {
+		return fmt.Errorf("DNS updater %s: %s record %s requires a target", updater.Name, record.Type, record.Name)
+	}
+	ascii, err := idna.Lookup.ToASCII(record.Target)
+	if err != nil || net.ParseIP(record.Target) != nil {
+		return fmt.Errorf("DNS updater %s: %s record %s: target must be a hostname, got %q", updater.Name, record.Type, record.Name, record.Target)
+	}
+	record.Target = ascii
+	if record.Type != "CNAME" {
+		return nil
+	}
+	if record.Name == "@" || record.Name == "" {
+		return fmt.Errorf("DNS updater %s: CNAME records are not allowed at the zone apex, use ALIAS/ANAME instead", updater.Name)
+	}
+	for _, other := range updater.Records {
+		if other.Type != "CNAME" && strings.EqualFold(other.Name, record.Name) {
+			return fmt.Errorf("DNS updater %s: CNAME record %s can't coexist with the %s record of the same name", updater.Name, record.Name, other.Type)
+		}
+	}
+	return nil
+}
+
+// validateMatchValue checks that match_value is an address of the record's
+// family
+func validateMatchValue(updater *DNSUpdater, record *DNSRecord) error {
+	if record.MatchValue == "" {
+		return nil
+	}
+	if record.Type != "A" && record.Type != "AAAA" {
+		return fmt.Errorf("DNS updater %s: record %s: match_value is only supported for A and AAAA records", updater.Name, record.Name)
+	}
+	ip := net.ParseIP(record.MatchValue)
+	if ip == nil || (record.Type == "A") != (ip.To4() != nil) {
+		return fmt.Errorf("DNS updater %s: %s record %s: match_value %q is not an address of its family", updater.Name, record.Type, record.Name, record.MatchValue)
+	}
+	record.MatchValue = ip.String()
+	return nil
+}
+
+// DefaultMaxRecordTTL is the TTL above which a dynamically updated record
+// gets a warning when max_record_ttl isn't set
+const DefaultMaxRecordTTL = 3600
+
+// max_file_size_action values
+const (
+	FileSizeActionWarn  = "warn"
+	FileSizeActionError = "error"
+)
+
+// validateFileUpdaters catches template errors at load time instead of on
+// the first IP change
+func validateFileUpdaters(config *Config) error {
+	for i := range config.FileUpdaters {
+		updater := &config.FileUpdaters[i]
+		isTemplate := strings.ToLower(updater.Format) == "template"
+
+		if updater.List {
+			if isTemplate || strings.ToLower(updater.Format) == "hosts" {
+				return fmt.Errorf("file updater %s: list mode is not supported with the %s format", updater.Name, strings.ToLower(updater.Format))
+			}
+			if updater.ListDelimiter == "" {
+				updater.ListDelimiter = ","
+			}
+		}
+
+		if updater.KeySeparator == "" {
+			updater.KeySeparator = fileupdate.DefaultKeySeparator
+		} else if strings.TrimSpace(updater.KeySeparator) != updater.KeySeparator || strings.Contains(updater.KeySeparator, "\\") {
+			return fmt.Errorf("file updater %s: invalid key_separator %q", updater.Name, updater.KeySeparator)
+		}
+
+		switch strings.ToLower(updater.MaxFileSizeAction) {
+		case "", FileSizeActionWarn, FileSizeActionError:
+			updater.MaxFileSizeAction = strings.ToLower(updater.MaxFileSizeAction)
+		default:
+			return fmt.Errorf("file updater %s: invalid max_file_size_action %q (supported: warn, error)", updater.Name, updater.MaxFileSizeAction)
+		}
+		if updater.BackupKeep < 0 {
+			return fmt.Errorf("file updater %s: invalid backup_keep: %d", updater.Name, updater.BackupKeep)
+		}
+		if updater.MaxFileSize < -1 {
+			return fmt.Errorf("file updater %s: invalid max_file_size: %d", updater.Name, updater.MaxFileSize)
+		}
+
+		if !isTemplate {
+			continue
+		}
+		if _, err := fileupdate.ParseTemplate(updater.KeyPath); err != nil {
+			return fmt.Errorf("file updater %s: %w", updater.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadConfig loads content as a current (config_version = 1) config file
+func loadConfig(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	return loadRawConfig(t, "config_version = 1\n"+content)
+}
+
+// loadRawConfig writes content to a config file in a temporary directory and
+// loads it
+func loadRawConfig(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+func TestAliasRecordOnProviderWithAlias(t *testing.T) {
+	config, err := loadConfig(t, `
+[[dns_updater]]
+name = "apex"
+provider = "gandi"
+token = "token"
+domain = "example.com"
+[[dns_updater.record]]
+name = "@"
+type = "aname"
+target = "home.example.com."
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := config.DNSUpdaters[0].Records[0]
+	if record.Type != "ANAME" || record.Target != "home.example.com" {
+		t.Fatalf("record = %+v, want type ANAME and target home.example.com", record)
+	}
+}
+
+func TestAliasRecordRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name: "provider without alias",
+			content: `
+[[dns_updater]]
+name = "apex"
+provider = "aliyun"
+domain = "example.com"
+[[dns_updater.record]]
+name = "@"
+type = "ALIAS"
+target = "home.example.com"
+`,
+			want: "provider aliyun doesn't support ALIAS records",
+		},
+		{
+			name: "fallback without alias",
+			content: `
+[[dns_updater]]
+name = "apex"
+provider = "namecom"
+domain = "example.com"
+[dns_updater.fallback]
+provider = "cloudflare"
+[[dns_updater.record]]
+name = "@"
+type = "ANAME"
+target = "home.example.com"
+`,
+			want: "provider cloudflare doesn't support ANAME records",
+		},
+		{
+			name: "not at the apex",
+			content: `
+[[dns_updater]]
+name = "apex"
+provider = "gandi"
+domain = "example.com"
+[[dns_updater.record]]
+name = "www"
+type = "ALIAS"
+target = "home.example.com"
+`,
+			want: "only allowed at the zone apex",
+		},
+		{
+			name: "without target",
+			content: `
+[[dns_updater]]
+name = "apex"
+provider = "gandi"
+domain = "example.com"
+[[dns_updater.record]]
+name = "@"
+type = "ALIAS"
+`,
+			want: "ALIAS record @ requires a target",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(t, tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInternationalizedDomainsBecomePunycode(t *testing.T) {
+	config, err := loadConfig(t, `
+[[dns_updater]]
+name = "idn"
+provider = "null"
+domain = "例え.jp"
+[dns_updater.fallback]
+provider = "null"
+domain = "bücher.example"
+[[dns_updater.record]]
+name = "@"
+type = "A"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updater := config.DNSUpdaters[0]
+	if updater.Domain != "xn--r8jz45g.jp" || updater.OriginalDomain != "例え.jp" {
+		t.Fatalf("domain = %q (original %q), want xn--r8jz45g.jp (original 例え.jp)", updater.Domain, updater.OriginalDomain)
+	}
+	if updater.DisplayDomain() != "例え.jp" {
+		t.Fatalf("DisplayDomain = %q, want 例え.jp", updater.DisplayDomain())
+	}
+	if fallback := updater.Fallback; fallback.Domain != "xn--bcher-kva.example" || fallback.OriginalDomain != "bücher.example" {
+		t.Fatalf("fallback domain = %q (original %q), want xn--bcher-kva.example", fallback.Domain, fallback.OriginalDomain)
+	}
+}
+
+func TestASCIIDomainHasNoOriginal(t *testing.T) {
+	config, err := loadConfig(t, `
+[[dns_updater]]
+name = "ascii"
+provider = "null"
+domain = "example.com"
+[[dns_updater.record]]
+name = "@"
+type = "A"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updater := config.DNSUpdaters[0]; updater.Domain != "example.com" || updater.OriginalDomain != "" {
+		t.Fatalf("domain = %q (original %q), want example.com unchanged", updater.Domain, updater.OriginalDomain)
+	}
+}
+
+func TestKeySeparator(t *testing.T) {
+	fileUpdater := func(separator string) string {
+		return `
+[[file_updater]]
+name = "app"
+file_path = "/tmp/app.json"
+format = "json"
+key_path = "server.public_ip"
+` + separator
+	}
+
+	config, err := loadConfig(t, fileUpdater(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sep := config.FileUpdaters[0].KeySeparator; sep != "/" {
+		t.Fatalf("key_separator = %q, want the default /", sep)
+	}
+
+	config, err = loadConfig(t, fileUpdater(`key_separator = "."`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sep := config.FileUpdaters[0].KeySeparator; sep != "." {
+		t.Fatalf("key_separator = %q, want .", sep)
+	}
+
+	for _, invalid := range []string{`" . "`, `"\\"`} {
+		if _, err := loadConfig(t, fileUpdater("key_separator = "+invalid)); err == nil || !strings.Contains(err.Error(), "invalid key_separator") {
+			t.Errorf("key_separator = %s: err = %v, want invalid key_separator", invalid, err)
+		}
+	}
+}
+
+func TestCompareFields(t *testing.T) {
+	updater := func(fields string) string {
+		return `
+[[dns_updater]]
+name = "home"
+provider = "null"
+domain = "example.com"
+compare_fields = ` + fields + `
+[[dns_updater.record]]
+name = "www"
+type = "A"
+`
+	}
+
+	config, err := loadConfig(t, updater(`["value", "ttl"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.DNSUpdaters[0].ComparesTTL() {
+		t.Fatal("compare_fields with ttl doesn't compare TTLs")
+	}
+
+	if _, err := loadConfig(t, updater(`["value", "proxied"]`)); err == nil || !strings.Contains(err.Error(), "invalid compare_fields entry: proxied") {
+		t.Fatalf("Load error = %v, want the invalid entry named", err)
+	}
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Updater kinds referenced by depends_on
+const (
+	kindDNS  = "dns_updater"
+	kindFile = "file_updater"
+)
+
+type dependencyNode struct {
+	kind      string
+	dependsOn []string
+}
+
+// validateUpdateOrder rejects an update_order that contradicts depends_on
+// between DNS and file updaters
+func validateUpdateOrder(config *Config) error {
+	switch config.UpdateOrder {
+	case "":
+	case UpdateOrderDNSFirst:
+		if config.DNSUpdatersDependOnFiles() {
+			return fmt.Errorf("update_order = %q contradicts a DNS updater depending on a file updater", UpdateOrderDNSFirst)
+		}
+	case UpdateOrderFilesFirst:
+		if config.FileUpdatersDependOnDNS() {
+			return fmt.Errorf("update_order = %q contradicts a file updater depending on a DNS updater", UpdateOrderFilesFirst)
+		}
+	default:
+		return fmt.Errorf("invalid update_order: %s (expected %s or %s)", config.UpdateOrder, UpdateOrderDNSFirst, UpdateOrderFilesFirst)
+	}
+	return nil
+}
+
+// orderByDependencies validates depends_on and reorders the DNS and file
+// updaters so that every updater comes after the ones it depends on within
+// its own list. Updaters without dependencies keep their config file order.
+// Dependencies between a DNS and a file updater are enforced at run time,
+// since the two lists are applied separately.
+func orderByDependencies(config *Config) error {
+	nodes := make(map[string]dependencyNode)
+	duplicates := make(map[string]bool)
+
+	addNode := func(name, kind string, dependsOn []string) {
+		if _, exists := nodes[name]; exists {
+			duplicates[name] = true
+		}
+		nodes[name] = dependencyNode{kind: kind, dependsOn: dependsOn}
+	}
+	for _, updater := range config.DNSUpdaters {
+		addNode(updater.Name, kindDNS, updater.DependsOn)
+	}
+	for _, updater := range config.FileUpdaters {
+		addNode(updater.Name, kindFile, updater.DependsOn)
+	}
+
+	for name, node := range nodes {
+		for _, dep := range node.dependsOn {
+			if dep == name {
+				return fmt.Errorf("%s %s depends on itself", node.kind, name)
+			}
+			if _, exists := nodes[dep]; !exists {
+				return fmt.Errorf("%s %s depends on unknown updater %q", node.kind, name, dep)
+			}
+			if duplicates[dep] {
+				return fmt.Errorf("%s %s depends on %q, but that name is used by more than one updater", node.kind, name, dep)
+			}
+		}
+	}
+
+	if cycle := findDependencyCycle(nodes); cycle != nil {
+		return fmt.Errorf("dependency cycle between updaters: %s", strings.Join(cycle, " -> "))
+	}
+
+	dnsOrder := topologicalOrder(len(config.DNSUpdaters), func(i int) (string, []string) {
+		return config.DNSUpdaters[i].Name, config.DNSUpdaters[i].DependsOn
+	})
+	dnsUpdaters := make([]DNSUpdater, 0, len(config.DNSUpdaters))
+	for _, i := range dnsOrder {
+		dnsUpdaters = append(dnsUpdaters, config.DNSUpdaters[i])
+	}
+	config.DNSUpdaters = dnsUpdaters
+
+	fileOrder := topologicalOrder(len(config.FileUpdaters), func(i int) (string, []string) {
+		return config.FileUpdaters[i].Name, config.FileUpdaters[i].DependsOn
+	})
+	fileUpdaters := make([]FileUpdater, 0, len(config.FileUpdaters))
+	for _, i := range fileOrder {
+		fileUpdaters = append(fileUpdaters, config.FileUpdaters[i])
+	}
+	config.FileUpdaters = fileUpdaters
+
+	return nil
+}
+
+// findDependencyCycle returns the names along a cycle, or nil if there is none
+func findDependencyCycle(nodes map[string]dependencyNode) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, dep := range nodes[name].dependsOn {
+			switch state[dep] {
+			case visiting:
+				for i, n := range path {
+					if n == dep {
+						return append(append([]string{}, path[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+
+	for name := range nodes {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// topologicalOrder returns the indices of a list so that each entry follows
+// the entries of the same list it depends on. Among the entries that are
+// ready, the earliest in the original order goes first. The graph has been
+// checked for cycles already.
+func topologicalOrder(count int, entry func(i int) (string, []string)) []int {
+	inList := make(map[string]bool, count)
+	for i := 0; i < count; i++ {
+		name, _ := entry(i)
+		inList[name] = true
+	}
+
+	placed := make(map[string]bool, count)
+	used := make([]bool, count)
+	order := make([]int, 0, count)
+
+	for len(order) < count {
+		for i := 0; i < count; i++ {
+			if used[i] {
+				continue
+			}
+
+			name, dependsOn := entry(i)
+			ready := true
+			for _, dep := range dependsOn {
+				if inList[dep] && !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+
+			used[i] = true
+			placed[name] = true
+			order = append(order, i)
+			break
+		}
+	}
+
+	return order
+}
+
+// FileUpdatersDependOnDNS reports whether any file updater waits for a DNS
+// updater, in which case a successful DNS update should be followed by a
+// file update pass
+func (c *Config) FileUpdatersDependOnDNS() bool {
+	dnsNames := make(map[string]bool, len(c.DNSUpdaters))
+	for _, updater := range c.DNSUpdaters {
+		dnsNames[updater.Name] = true
+	}
+	for _, updater := range c.FileUpdaters {
+		for _, dep := range updater.DependsOn {
+			if dnsNames[dep] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DNSUpdatersDependOnFiles is the reverse of FileUpdatersDependOnDNS
+func (c *Config) DNSUpdatersDependOnFiles() bool {
+	fileNames := make(map[string]bool, len(c.FileUpdaters))
+	for _, updater := range c.FileUpdaters {
+		fileNames[updater.Name] = true
+	}
+	for _, updater := range c.DNSUpdaters {
+		for _, dep := range updater.DependsOn {
+			if fileNames[dep] {
+				return true
+			}
+		}
+	}
+	return false
+}
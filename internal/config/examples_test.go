@@ -0,0 +1,45 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// The shipped example configs and the generated default config must load
+// without errors or warnings, so they keep up with new settings
+func TestExampleConfigsLoad(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "examples", "*.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no example configs found")
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			config, err := Load(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(config.Warnings) != 0 {
+				t.Errorf("warnings = %q", config.Warnings)
+			}
+		})
+	}
+}
+
+func TestDefaultConfigLoads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "config.toml")
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ConfigVersion != CurrentConfigVersion {
+		t.Errorf("config_version = %d, want %d", config.ConfigVersion, CurrentConfigVersion)
+	}
+	if len(config.Warnings) != 0 {
+		t.Errorf("warnings = %q", config.Warnings)
+	}
+}
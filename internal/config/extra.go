@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// commonExtraKeys are the extra_config keys every provider reads into
+// dns.ProviderSettings
+var commonExtraKeys = []string{"endpoint", "timeout", "user_agent", "proxy"}
+
+// providerExtraKeys lists the provider-specific extra_config keys. Keep it in
+// sync with the providers in pkg/dns; a provider missing here isn't checked.
+var providerExtraKeys = map[string][]string{
+	"aliyun":     {"signature_version"},
+	"tencent":    {"region"},
+	"huawei":     {"region"},
+	"cloudflare": nil,
+	"godaddy":    {"update_endpoint"},
+	"linode":     nil,
+	"vultr":      nil,
+	"desec":      nil,
+	"gandi":      nil,
+	"namecom":    nil,
+	"dynu":       nil,
+	"bunny":      nil,
+	"null":       {"latency", "fail_rate", "fail_first"},
+	"mock":       {"latency", "fail_rate", "fail_first"},
+}
+
+// ProviderExtraKeys returns the extra_config keys the provider recognizes,
+// and false for a provider without a declaration
+func ProviderExtraKeys(provider string) ([]string, bool) {
+	specific, ok := providerExtraKeys[strings.ToLower(provider)]
+	if !ok {
+		return nil, false
+	}
+	return append(append([]string{}, commonExtraKeys...), specific...), true
+}
+
+// validateExtraConfig reports extra_config keys that none of the updater's
+// providers (primary, fallback and replicas share the map) reads, since
+// they'd otherwise be silently ignored. Like unknown config keys they are
+// warnings, or an error with strict_keys = true.
+func validateExtraConfig(config *Config) error {
+	var messages []string
+	for _, updater := range config.DNSUpdaters {
+		providers := []string{updater.Provider}
+		if updater.Fallback != nil {
+			providers = append(providers, updater.Fallback.Provider)
+		}
+		for _, replica := range updater.Replicas {
+			providers = append(providers, replica.Provider)
+		}
+
+		known := make(map[string]bool)
+		checked := true
+		for _, provider := range providers {
+			keys, ok := ProviderExtraKeys(provider)
+			if !ok {
+				checked = false
+				break
+			}
+			for _, key := range keys {
+				known[key] = true
+			}
+		}
+		if !checked {
+			continue
+		}
+
+		var unknown []string
+		for key := range updater.ExtraConfig {
+			if !known[key] {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+
+		for _, key := range unknown {
+			message := fmt.Sprintf("DNS updater %s: unknown extra_config key %q for provider %s", updater.Name, key, strings.Join(providers, "/"))
+			if suggestion := suggestExtraKey(key, known); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			messages = append(messages, message)
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+	if config.StrictKeys {
+		return fmt.Errorf("%s", strings.Join(messages, "; "))
+	}
+	for _, message := range messages {
+		config.warnf("%s is ignored", message)
+	}
+	return nil
+}
+
+// suggestExtraKey returns the known key closest to a misspelled one, or ""
+// when nothing is close enough
+func suggestExtraKey(key string, known map[string]bool) string {
+	best := ""
+	bestDistance := 3 // at most two edits away
+	for candidate := range known {
+		distance := editDistance(key, candidate)
+		if distance < bestDistance || (distance == bestDistance && candidate < best) {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
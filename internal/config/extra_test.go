@@ -0,0 +1,84 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtraConfigKnownKeys(t *testing.T) {
+	config, err := loadConfig(t, `
+[[dns_updater]]
+name = "tencent"
+provider = "tencent"
+access_key = "id"
+secret_key = "key"
+domain = "example.com"
+extra_config = { region = "ap-guangzhou", timeout = "10", endpoint = "https://dnspod.example" }
+[[dns_updater.record]]
+name = "@"
+type = "A"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Warnings) != 0 {
+		t.Fatalf("warnings = %q, want none", config.Warnings)
+	}
+}
+
+const bogusExtraConfig = `
+[[dns_updater]]
+name = "aliyun"
+provider = "aliyun"
+access_key = "id"
+secret_key = "key"
+domain = "example.com"
+extra_config = { signatur_version = "v3", zone_id = "abc" }
+[[dns_updater.record]]
+name = "@"
+type = "A"
+`
+
+func TestExtraConfigUnknownKeys(t *testing.T) {
+	config, err := loadConfig(t, bogusExtraConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`DNS updater aliyun: unknown extra_config key "signatur_version" for provider aliyun (did you mean "signature_version"?) is ignored`,
+		`DNS updater aliyun: unknown extra_config key "zone_id" for provider aliyun is ignored`,
+	}
+	if !reflect.DeepEqual(config.Warnings, want) {
+		t.Fatalf("warnings = %q, want %q", config.Warnings, want)
+	}
+
+	_, err = loadConfig(t, "strict_keys = true\n"+bogusExtraConfig)
+	if err == nil || !strings.Contains(err.Error(), "signatur_version") {
+		t.Fatalf("strict_keys: err = %v, want the unknown extra_config key", err)
+	}
+}
+
+// The fallback shares the extra_config map, so its keys are accepted too
+func TestExtraConfigFallbackKeys(t *testing.T) {
+	config, err := loadConfig(t, `
+[[dns_updater]]
+name = "with-fallback"
+provider = "null"
+domain = "example.com"
+extra_config = { fail_first = "1", update_endpoint = "auto" }
+[dns_updater.fallback]
+provider = "godaddy"
+access_key = "key"
+secret_key = "secret"
+[[dns_updater.record]]
+name = "@"
+type = "A"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Warnings) != 0 {
+		t.Fatalf("warnings = %q, want none", config.Warnings)
+	}
+}
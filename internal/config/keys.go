@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// checkUnknownKeys reports keys in the file that don't map to any setting,
+// which are otherwise silently ignored by the decoder. They are warnings by
+// default and an error with strict_keys = true.
+func checkUnknownKeys(config *Config, meta toml.MetaData) error {
+	keys := unknownKeys(meta)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		message := fmt.Sprintf("unknown config key %q", key.String())
+		if suggestion := suggestKey(key); suggestion != "" {
+			message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+		messages = append(messages, message)
+	}
+
+	if config.StrictKeys {
+		return fmt.Errorf("%s", strings.Join(messages, "; "))
+	}
+
+	for _, message := range messages {
+		config.warnf("%s is ignored", message)
+	}
+	return nil
+}
+
+// unknownKeys returns the keys the decoder did not use, skipping keys below
+// an unknown table so a misspelled table is reported once
+func unknownKeys(meta toml.MetaData) []toml.Key {
+	undecoded := meta.Undecoded()
+	seen := make(map[string]bool, len(undecoded))
+	for _, key := range undecoded {
+		seen[key.String()] = true
+	}
+
+	var keys []toml.Key
+	for _, key := range undecoded {
+		reported := false
+		for n := len(key) - 1; n > 0; n-- {
+			if seen[key[:n].String()] {
+				reported = true
+				break
+			}
+		}
+		if !reported {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// suggestKey returns the known key closest to a misspelled one at the same
+// level, or "" when nothing is close enough
+func suggestKey(key toml.Key) string {
+	candidates := keysAt(reflect.TypeOf(Config{}), key[:len(key)-1])
+	name := key[len(key)-1]
+
+	best := ""
+	bestDistance := 3 // at most two edits away
+	for _, candidate := range candidates {
+		if distance := editDistance(name, candidate); distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+
+	return append(append(toml.Key{}, key[:len(key)-1]...), best).String()
+}
+
+// keysAt lists the TOML keys accepted in the table at path
+func keysAt(t reflect.Type, path []string) []string {
+	for _, name := range path {
+		t = elemType(t)
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+
+		found := false
+		for i := 0; i < t.NumField(); i++ {
+			if tomlName(t.Field(i)) == name {
+				t = t.Field(i).Type
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	t = elemType(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := tomlName(t.Field(i)); name != "" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+func tomlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+	if name == "-" || !field.IsExported() {
+		return ""
+	}
+	return name
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const misspelledConfig = `
+dns_chek_interval = 300
+
+[[dns_updater]]
+name = "typo"
+provider = "null"
+domain = "example.com"
+acess_key = "key"
+[[dns_updater.recrd]]
+name = "@"
+type = "A"
+[[dns_updater.record]]
+name = "@"
+type = "A"
+`
+
+func TestUnknownKeysWarn(t *testing.T) {
+	config, err := loadConfig(t, misspelledConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`unknown config key "dns_chek_interval" (did you mean "dns_check_interval"?) is ignored`,
+		`unknown config key "dns_updater.acess_key" (did you mean "dns_updater.access_key"?) is ignored`,
+		`unknown config key "dns_updater.recrd" (did you mean "dns_updater.record"?) is ignored`,
+	}
+	if !reflect.DeepEqual(config.Warnings, want) {
+		t.Fatalf("warnings = %q, want %q", config.Warnings, want)
+	}
+}
+
+func TestUnknownKeysStrict(t *testing.T) {
+	_, err := loadConfig(t, "strict_keys = true\n"+misspelledConfig)
+	if err == nil {
+		t.Fatal("unknown keys were accepted with strict_keys")
+	}
+	for _, key := range []string{"dns_chek_interval", "dns_updater.acess_key", "dns_updater.recrd"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q does not name %s", err, key)
+		}
+	}
+	// Keys below the unknown table are not reported separately
+	if strings.Contains(err.Error(), "recrd.name") {
+		t.Errorf("error %q reports keys inside the unknown table", err)
+	}
+}
+
+func TestUnknownKeyWithoutSuggestion(t *testing.T) {
+	config, err := loadConfig(t, "completely_unrelated = 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`unknown config key "completely_unrelated" is ignored`}
+	if !reflect.DeepEqual(config.Warnings, want) {
+		t.Fatalf("warnings = %q, want %q", config.Warnings, want)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"record", "record", 0},
+		{"recrd", "record", 1},
+		{"acess_key", "access_key", 1},
+		{"abc", "acb", 2},
+		{"", "abc", 3},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+)
+
+// CurrentConfigVersion is the config_version written to new config files.
+// Version 0 is any file written before config_version existed.
+const CurrentConfigVersion = 1
+
+// migrate upgrades older config layouts in place and collects warnings about
+// them
+func migrate(config *Config, meta toml.MetaData) error {
+	if config.ConfigVersion > CurrentConfigVersion {
+		return fmt.Errorf("config_version %d is newer than this version of ip_updater supports (%d)",
+			config.ConfigVersion, CurrentConfigVersion)
+	}
+	if config.ConfigVersion < 0 {
+		return fmt.Errorf("invalid config_version: %d", config.ConfigVersion)
+	}
+
+	if config.ConfigVersion == 0 {
+		migrateV0(config, meta)
+		config.warnf("config_version is not set, the file was treated as a legacy (version 0) config; add config_version = %d after reviewing the warnings", CurrentConfigVersion)
+	}
+
+	return nil
+}
+
+// migrateV0 handles configs from before the DNS and file intervals were
+// split: check_interval used to drive both checks
+func migrateV0(config *Config, meta toml.MetaData) {
+	if !meta.IsDefined("check_interval") || config.CheckInterval <= 0 {
+		return
+	}
+
+	if !meta.IsDefined("dns_check_interval") {
+		config.DNSCheckInterval = config.CheckInterval
+		config.warnf("dns_check_interval is not set, using the legacy check_interval (%d); check_interval is deprecated", config.CheckInterval)
+	}
+	if !meta.IsDefined("file_check_interval") {
+		config.FileCheckInterval = config.CheckInterval
+		config.warnf("file_check_interval is not set, using the legacy check_interval (%d); check_interval is deprecated", config.CheckInterval)
+	}
+}
+
+func (c *Config) warnf(format string, args ...interface{}) {
+	c.Warnings = append(c.Warnings, fmt.Sprintf(format, args...))
+}
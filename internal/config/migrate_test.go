@@ -0,0 +1,82 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const legacyConfig = `
+check_interval = 120
+
+[[dns_updater]]
+name = "legacy"
+provider = "null"
+domain = "example.com"
+[[dns_updater.record]]
+name = "@"
+type = "A"
+`
+
+// hasWarning reports whether one of the warnings contains substr
+func hasWarning(warnings []string, substr string) bool {
+	for _, warning := range warnings {
+		if strings.Contains(warning, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLegacyCheckIntervalMigrated(t *testing.T) {
+	config, err := loadRawConfig(t, legacyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DNSCheckInterval != 120 || config.FileCheckInterval != 120 {
+		t.Fatalf("intervals = %d/%d, want both from check_interval (120)", config.DNSCheckInterval, config.FileCheckInterval)
+	}
+	for _, want := range []string{"config_version is not set", "dns_check_interval is not set", "file_check_interval is not set"} {
+		if !hasWarning(config.Warnings, want) {
+			t.Errorf("warnings = %q, want one containing %q", config.Warnings, want)
+		}
+	}
+}
+
+func TestLegacyCheckIntervalKeepsExplicitIntervals(t *testing.T) {
+	config, err := loadRawConfig(t, "dns_check_interval = 30\n"+legacyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DNSCheckInterval != 30 || config.FileCheckInterval != 120 {
+		t.Fatalf("intervals = %d/%d, want 30/120", config.DNSCheckInterval, config.FileCheckInterval)
+	}
+	if hasWarning(config.Warnings, "dns_check_interval is not set") {
+		t.Fatalf("warnings = %q, dns_check_interval is set", config.Warnings)
+	}
+}
+
+func TestCurrentConfigHasNoMigrationWarnings(t *testing.T) {
+	config, err := loadConfig(t, `
+[[dns_updater]]
+name = "current"
+provider = "null"
+domain = "example.com"
+[[dns_updater.record]]
+name = "@"
+type = "A"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Warnings) != 0 {
+		t.Fatalf("warnings = %q, want none", config.Warnings)
+	}
+}
+
+func TestConfigVersionOutOfRange(t *testing.T) {
+	for _, version := range []string{"2", "-1"} {
+		if _, err := loadRawConfig(t, "config_version = "+version+"\n"); err == nil {
+			t.Errorf("config_version = %s was accepted", version)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher reports changes to the config file on disk. The parent directory is
+// watched so that both in-place edits and atomic replaces (write to a temp
+// file, then rename) are noticed. Bursts of events are debounced into a single
+// notification on Changes.
+//
+// When the config path is a symlink, the directory of its target is watched
+// as well, and the link is resolved again on every event in the watched
+// directories: swapping the link (as Kubernetes does for ConfigMap volumes,
+// through a ..data symlink) counts as a change.
+type Watcher struct {
+	Changes chan struct{}
+	Errors  chan error
+
+	watcher  *fsnotify.Watcher
+	path     string // absolute config path, possibly a symlink
+	realPath string // path with symlinks resolved, "" while it doesn't resolve
+	debounce time.Duration
+	done     chan struct{}
+	once     sync.Once
+}
+
+func NewWatcher(configPath string, debounce time.Duration) (*Watcher, error) {
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fsWatcher.Add(filepath.Dir(absPath)); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+
+	w := &Watcher{
+		Changes:  make(chan struct{}, 1),
+		Errors:   make(chan error, 1),
+		watcher:  fsWatcher,
+		path:     absPath,
+		debounce: debounce,
+		done:     make(chan struct{}),
+	}
+	w.resolve()
+
+	go w.run()
+	return w, nil
+}
+
+// resolve follows the config path's symlinks and watches the directory of the
+// target when it moved. It reports whether the target changed.
+func (w *Watcher) resolve() bool {
+	realPath, err := filepath.EvalSymlinks(w.path)
+	if err != nil {
+		// Dangling for now, e.g. in the middle of a link swap
+		realPath = ""
+	}
+	if realPath == w.realPath {
+		return false
+	}
+
+	oldDir := filepath.Dir(w.realPath)
+	w.realPath = realPath
+	if realPath == "" {
+		return true
+	}
+
+	linkDir := filepath.Dir(w.path)
+	newDir := filepath.Dir(realPath)
+	if newDir != oldDir && newDir != linkDir {
+		if err := w.watcher.Add(newDir); err != nil {
+			w.report(err)
+		}
+	}
+	// The old target directory is usually gone already (ConfigMap updates
+	// remove it), which also removes its watch
+	if oldDir != "." && oldDir != newDir && oldDir != linkDir {
+		w.watcher.Remove(oldDir)
+	}
+	return true
+}
+
+func (w *Watcher) report(err error) {
+	select {
+	case w.Errors <- err:
+	default:
+	}
+}
+
+func (w *Watcher) run() {
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.resolve() {
+				timer.Reset(w.debounce)
+				continue
+			}
+			name := filepath.Clean(event.Name)
+			if name != w.path && name != w.realPath {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			timer.Reset(w.debounce)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.report(err)
+
+		case <-timer.C:
+			select {
+			case w.Changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
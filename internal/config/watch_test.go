@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestWatcher(t *testing.T, path string) *Watcher {
+	t.Helper()
+	w, err := NewWatcher(path, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+func waitForChange(t *testing.T, w *Watcher, what string) {
+	t.Helper()
+	select {
+	case <-w.Changes:
+	case err := <-w.Errors:
+		t.Fatalf("%s: watcher error: %v", what, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s: no change reported", what)
+	}
+}
+
+// TestWatcherFollowsSymlinkSwap mounts the config like a Kubernetes
+// ConfigMap volume: config.toml -> ..data/config.toml, ..data -> a
+// timestamped directory, swapped atomically on every update
+func TestWatcherFollowsSymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "..2026_01", "config.toml"), "check_interval = 60\n")
+	symlink(t, "..2026_01", filepath.Join(dir, "..data"))
+	symlink(t, filepath.Join("..data", "config.toml"), filepath.Join(dir, "config.toml"))
+
+	w := newTestWatcher(t, filepath.Join(dir, "config.toml"))
+
+	previous := "..2026_01"
+	for _, version := range []string{"..2026_02", "..2026_03"} {
+		writeFile(t, filepath.Join(dir, version, "config.toml"), "check_interval = 120\n")
+		symlink(t, version, filepath.Join(dir, "..data_tmp"))
+		if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.RemoveAll(filepath.Join(dir, previous)); err != nil {
+			t.Fatal(err)
+		}
+		waitForChange(t, w, "swap to "+version)
+		previous = version
+	}
+}
+
+func TestWatcherDetectsInPlaceEditOfTarget(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "real", "config.toml")
+	writeFile(t, target, "check_interval = 60\n")
+	link := filepath.Join(t.TempDir(), "config.toml")
+	symlink(t, target, link)
+
+	w := newTestWatcher(t, link)
+
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString("check_interval = 120\n"); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	waitForChange(t, w, "in-place edit of the target")
+}
+
+func TestDanglingSymlinkIsNotReplaced(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "mounted", "config.toml")
+	link := filepath.Join(dir, "config.toml")
+	symlink(t, target, link)
+
+	_, err := Load(link)
+	if err == nil || !strings.Contains(err.Error(), "is a symlink to missing") {
+		t.Fatalf("Load error = %v, want the dangling symlink refused", err)
+	}
+
+	// Even when called directly, the default config isn't written through
+	// the link
+	if err := createDefaultConfig(link); err == nil {
+		t.Fatal("createDefaultConfig wrote through a dangling symlink")
+	}
+
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("link target was created: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("link was replaced: %v", err)
+	}
+}
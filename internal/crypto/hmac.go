@@ -0,0 +1,26 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Sign returns the hex encoded HMAC-SHA256 of data, keyed by the same
+// machine key that encrypts the credentials
+func Sign(data []byte) string {
+	mac := hmac.New(sha256.New, []byte(systemKey))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the HMAC Sign returns for data
+func Verify(data []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(systemKey))
+	mac.Write(data)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -1,22 +1,160 @@
 package detector
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"ip-updater/internal/netutil"
+)
+
+// Endpoint selection strategies
+const (
+	// StrategyOrdered tries the endpoints in list order on every detection
+	StrategyOrdered = "ordered"
+	// StrategySticky keeps using the last working endpoint after the primary
+	// fails, and only re-probes the primary once the cooldown has passed
+	StrategySticky = "sticky"
 )
 
+const defaultStickyCooldown = 600
+
+const defaultIPv6RecheckInterval = 3600
+
+const defaultSampleInterval = 3
+
+// defaultMaxRedirects is how many redirects a detection request follows
+// when max_redirects isn't set
+const defaultMaxRedirects = 3
+
+// maxResponseSize caps how much of a detection response is read; an IP
+// address is a few dozen bytes, anything longer is a page, not an answer
+const maxResponseSize = 4 << 10
+
 type Config struct {
-	APIEndpoints []string `toml:"api_endpoints"`
-	WebEndpoints []string `toml:"web_endpoints"`
-	Timeout      int      `toml:"timeout"` // seconds
+	APIEndpoints   []string `toml:"api_endpoints"`
+	WebEndpoints   []string `toml:"web_endpoints"`
+	Timeout        int      `toml:"timeout"`         // seconds
+	Strategy       string   `toml:"strategy"`        // ordered (default) or sticky
+	StickyCooldown int      `toml:"sticky_cooldown"` // seconds before re-probing the primary
+
+	// MaxTimeout enables the adaptive timeout: every failed detection doubles
+	// the per-request timeout up to this many seconds, every successful one
+	// halves it back towards timeout. 0 keeps the timeout fixed.
+	MaxTimeout int `toml:"max_timeout"`
+
+	// CacheTTL is how long a detected IPv4 address is reused, so DNS and
+	// file checks that fire together detect once. Seconds, -1 disables;
+	// config.Load sets 0 to half the shorter check interval.
+	CacheTTL int `toml:"cache_ttl"`
+
+	// CacheFingerprint drops the cached address as soon as the network
+	// fingerprint changes, instead of waiting for the TTL: "gateway" (default
+	// gateway address and MAC, Linux only) or "interface" (interface and
+	// address of the default route). Empty disables it.
+	CacheFingerprint string `toml:"cache_fingerprint"`
+
+	// JSONPaths gives the field holding the address in JSON responses, keyed
+	// by endpoint URL or host, e.g. "data.ip". Hosts of common services are
+	// built in (see jsonshape.go).
+	JSONPaths map[string]string `toml:"json_paths"`
+
+	// MaxRedirects caps the redirects followed per detection request.
+	// 0 uses the default (3), -1 treats any redirect as a failure.
+	MaxRedirects int `toml:"max_redirects"`
+
+	// FailureAlertAfter is the number of consecutive failed detections after
+	// which the service alerts (0 disables). EscalationEndpoints are then
+	// tried after the regular ones until detection succeeds again.
+	FailureAlertAfter   int      `toml:"failure_alert_after"`
+	EscalationEndpoints []string `toml:"escalation_endpoints"`
+
+	// Samples > 1 requires a changed IPv4 address to be detected that many
+	// times in a row, SampleInterval seconds apart, before it is returned,
+	// so one bad response can't trigger an update
+	Samples        int `toml:"samples"`
+	SampleInterval int `toml:"sample_interval"`
+
+	// IPv6 detection, only used when AAAA records are configured
+	IPv6Endpoints       []string `toml:"ipv6_endpoints"`
+	IPv6RecheckInterval int      `toml:"ipv6_recheck_interval"` // seconds before re-checking unavailable IPv6
+
+	// Interface sends detection requests from the current address of this
+	// network interface, looked up on every request, instead of local_addr
+	Interface string `toml:"interface"`
+
+	// Transform maps the detected addresses before they are used (optional)
+	Transform *TransformConfig `toml:"transform"`
+
+	// Liveness is checked before every detection; while it fails detection
+	// is skipped with ErrOffline (optional)
+	Liveness *LivenessConfig `toml:"liveness"`
+
+	// IPv6Only makes GetPublicIP detect the IPv6 address instead of the IPv4
+	// one (ip_mode = "ipv6"); set by config.Load
+	IPv6Only bool `toml:"-"`
+}
+
+type Logger interface {
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
 }
 
 type Detector struct {
 	config Config
 	client *http.Client
+	logger Logger
+
+	// Per-request timeout in nanoseconds, widened while detection fails
+	baseTimeout time.Duration
+	timeout     atomic.Int64
+
+	mu          sync.Mutex
+	sticky      string
+	stickySince time.Time
+
+	cacheMu   sync.Mutex
+	cachedIP  string
+	cachedAt  time.Time
+	escalated bool // guarded by cacheMu, like every detection
+	// fromEscalation is set when the cached IP came from an escalation
+	// endpoint, i.e. the regular endpoints are still failing
+	fromEscalation bool
+	// acceptedIP is the last address that passed the samples check
+	acceptedIP string
+	// fingerprint is the network fingerprint cachedIP was detected on
+	fingerprint string
+
+	// Endpoints already reported as redirecting or answering with the wrong
+	// address family, so the hint is logged once
+	redirectMu     sync.Mutex
+	redirectWarned map[string]bool
+
+	// IPv6 availability is cached so IPv4-only networks aren't probed on
+	// every check
+	ipv6Client    *http.Client
+	ipv6Mu        sync.Mutex
+	ipv6CheckedAt time.Time
+	ipv6Available bool
+
+	// Set with the interface option, nil otherwise
+	ifaceDialer   *netutil.InterfaceDialer
+	ifaceDialerV6 *netutil.InterfaceDialer
+
+	// Last mapping logged per detected address, so a transform is logged
+	// when it changes rather than on every detection
+	transformMu sync.Mutex
+	transformed map[string]string
+
+	// Last result of the liveness check
+	liveness liveness
 }
 
 func New(config Config) *Detector {
@@ -25,76 +163,520 @@ func New(config Config) *Detector {
 		timeout = time.Duration(config.Timeout) * time.Second
 	}
 
-	return &Detector{
-		config: config,
+	// Requests are bounded by the current timeout in fetch; the client
+	// timeout is only the hard cap
+	limit := max(timeout, time.Duration(config.MaxTimeout)*time.Second)
+
+	d := &Detector{
+		config:      config,
+		baseTimeout: timeout,
 		client: &http.Client{
-			Timeout: timeout,
+			Timeout:       limit,
+			Transport:     netutil.Transport(),
+			CheckRedirect: checkRedirect(config.MaxRedirects),
+		},
+		ipv6Client: &http.Client{
+			Timeout:       limit,
+			Transport:     ipv6Transport(),
+			CheckRedirect: checkRedirect(config.MaxRedirects),
 		},
+		redirectWarned: make(map[string]bool),
+		transformed:    make(map[string]string),
+	}
+	if config.Interface != "" {
+		d.ifaceDialer = netutil.NewInterfaceDialer(config.Interface)
+		d.ifaceDialerV6 = netutil.NewInterfaceDialer(config.Interface)
+		d.client.Transport = d.ifaceDialer.Transport("")
+		d.ipv6Client.Transport = d.ifaceDialerV6.Transport("tcp6")
+	}
+	d.timeout.Store(int64(timeout))
+	return d
+}
+
+// checkRedirect stops following redirects past the limit and hands the
+// redirect response back, so fetch can report where the endpoint points
+func checkRedirect(maxRedirects int) func(*http.Request, []*http.Request) error {
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return http.ErrUseLastResponse
+		}
+		return nil
 	}
 }
 
+func (d *Detector) SetLogger(logger Logger) {
+	d.logger = logger
+	if d.ifaceDialer != nil {
+		d.ifaceDialer.SetLogger(logger)
+		d.ifaceDialerV6.SetLogger(logger)
+	}
+}
+
+// GetPublicIP returns the public IPv4 address, reusing a detection made
+// within the cache TTL
 func (d *Detector) GetPublicIP() (string, error) {
-	// Try API endpoints first
-	for _, endpoint := range d.config.APIEndpoints {
-		if ip, err := d.getIPFromEndpoint(endpoint); err == nil {
-			return strings.TrimSpace(ip), nil
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+
+	ttl := time.Duration(d.config.CacheTTL) * time.Second
+	fingerprint := d.networkFingerprint()
+	if ttl > 0 && d.cachedIP != "" && time.Since(d.cachedAt) < ttl {
+		if fingerprint == d.fingerprint {
+			return d.cachedIP, nil
+		}
+		if d.logger != nil {
+			d.logger.Infof("🔀 网络已变化 (%s -> %s)，重新检测公网IP", d.fingerprint, fingerprint)
 		}
 	}
 
-	// Fall back to web endpoints
-	for _, endpoint := range d.config.WebEndpoints {
-		if ip, err := d.getIPFromEndpoint(endpoint); err == nil {
+	if err := d.online(); err != nil {
+		return "", err
+	}
+
+	ip, err := d.detectPublicIP()
+	d.adaptTimeout(err == nil)
+	if err == nil {
+		ip, err = d.confirmChange(ip)
+	}
+	if err == nil {
+		ip, err = d.transform(ip)
+	}
+	if err != nil {
+		d.cachedIP = ""
+		return "", err
+	}
+
+	d.cachedIP = ip
+	d.cachedAt = time.Now()
+	d.fingerprint = fingerprint
+	return ip, nil
+}
+
+// networkFingerprint returns the cache_fingerprint of the current network.
+// When it can't be determined (e.g. no default route) an empty string is
+// used, which differs from any fingerprint seen before.
+func (d *Detector) networkFingerprint() string {
+	if d.config.CacheFingerprint == "" {
+		return ""
+	}
+	fingerprint, err := netutil.NetworkFingerprint(d.config.CacheFingerprint)
+	if err != nil {
+		return ""
+	}
+	return fingerprint
+}
+
+// confirmChange samples a changed address again until samples detections
+// agree. When one doesn't, the change is not accepted yet and the previous
+// address is returned; the next check starts over.
+func (d *Detector) confirmChange(ip string) (string, error) {
+	if d.config.Samples <= 1 || ip == d.acceptedIP {
+		d.acceptedIP = ip
+		return ip, nil
+	}
+
+	interval := time.Duration(defaultSampleInterval) * time.Second
+	if d.config.SampleInterval > 0 {
+		interval = time.Duration(d.config.SampleInterval) * time.Second
+	}
+
+	for n := 2; n <= d.config.Samples; n++ {
+		time.Sleep(interval)
+
+		sample, err := d.detectPublicIP()
+		if err == nil && sample == ip {
+			continue
+		}
+		if err == nil {
+			err = fmt.Errorf("got %s", sample)
+		}
+		if d.logger != nil {
+			d.logger.Warnf("⚠️ 新IP %s 未通过稳定性校验 (第%d/%d次采样: %v)", ip, n, d.config.Samples, err)
+		}
+		if d.acceptedIP == "" {
+			return "", fmt.Errorf("detected IP %s is not stable: sample %d/%d: %v", ip, n, d.config.Samples, err)
+		}
+		return d.acceptedIP, nil
+	}
+
+	d.acceptedIP = ip
+	return ip, nil
+}
+
+// adaptTimeout widens the request timeout after a failed detection, so a
+// congested link gets more time, and narrows it again after a success
+func (d *Detector) adaptTimeout(ok bool) {
+	maxTimeout := time.Duration(d.config.MaxTimeout) * time.Second
+	if maxTimeout <= d.baseTimeout {
+		return
+	}
+
+	current := time.Duration(d.timeout.Load())
+	next := min(current*2, maxTimeout)
+	if ok {
+		next = max(current/2, d.baseTimeout)
+	}
+	if next == current {
+		return
+	}
+	d.timeout.Store(int64(next))
+
+	if d.logger == nil {
+		return
+	}
+	if !ok {
+		d.logger.Warnf("⚠️ IP检测失败，请求超时放宽到 %s", next)
+	} else if next == d.baseTimeout {
+		d.logger.Infof("✅ IP检测请求超时已恢复为 %s", next)
+	}
+}
+
+// InvalidateCache makes the next GetPublicIP detect again, e.g. after the
+// default route changed
+func (d *Detector) InvalidateCache() {
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+	d.cachedIP = ""
+
+	d.liveness.mu.Lock()
+	d.liveness.stale = true
+	d.liveness.mu.Unlock()
+}
+
+// SetEscalated adds the escalation endpoints to IPv4 detection, or removes
+// them again
+func (d *Detector) SetEscalated(escalated bool) {
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+	d.escalated = escalated
+}
+
+// FromEscalation reports whether the last detected IPv4 address came from an
+// escalation endpoint
+func (d *Detector) FromEscalation() bool {
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+	return d.fromEscalation
+}
+
+func (d *Detector) detectPublicIP() (string, error) {
+	d.fromEscalation = false
+	if d.config.IPv6Only {
+		return d.detectIPv6()
+	}
+	if d.config.Strategy == StrategySticky {
+		if ip, err := d.getPublicIPSticky(); err == nil || !d.escalated {
+			return ip, err
+		}
+	} else {
+		// Try API endpoints first
+		for _, endpoint := range d.config.APIEndpoints {
+			if ip, err := d.getIPFromEndpoint(endpoint); err == nil {
+				return strings.TrimSpace(ip), nil
+			}
+		}
+
+		// Fall back to web endpoints
+		for _, endpoint := range d.config.WebEndpoints {
+			if ip, err := d.getIPFromEndpoint(endpoint); err == nil {
+				return strings.TrimSpace(ip), nil
+			}
+		}
+	}
+
+	// Escalation endpoints only while detection keeps failing
+	if d.escalated {
+		for _, endpoint := range d.config.EscalationEndpoints {
+			if ip, err := d.getIPFromEndpoint(endpoint); err == nil {
+				d.fromEscalation = true
+				return strings.TrimSpace(ip), nil
+			}
+		}
+	}
+
+	return "", errors.New("failed to get public IP from all endpoints")
+}
+
+// getPublicIPSticky prefers the endpoint that answered last time when it
+// isn't the primary, so a failing primary doesn't cause flapping between
+// endpoints. The primary is tried again once the cooldown has passed.
+func (d *Detector) getPublicIPSticky() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	endpoints := append(append([]string{}, d.config.APIEndpoints...), d.config.WebEndpoints...)
+	if len(endpoints) == 0 {
+		return "", errors.New("failed to get public IP from all endpoints")
+	}
+	primary := endpoints[0]
+
+	failed := ""
+	if d.sticky != "" && time.Since(d.stickySince) < d.stickyCooldown() {
+		if ip, err := d.getIPFromEndpoint(d.sticky); err == nil {
 			return strings.TrimSpace(ip), nil
 		}
+		failed = d.sticky
+		d.sticky = ""
 	}
 
+	for _, endpoint := range endpoints {
+		if endpoint == failed {
+			continue
+		}
+		ip, err := d.getIPFromEndpoint(endpoint)
+		if err != nil {
+			continue
+		}
+
+		if endpoint == primary {
+			if d.sticky != "" && d.logger != nil {
+				d.logger.Infof("✅ 主IP检测端点已恢复: %s", primary)
+			}
+			d.sticky = ""
+		} else if endpoint != d.sticky {
+			if d.logger != nil {
+				d.logger.Warnf("⚠️ 主IP检测端点不可用，切换到: %s", endpoint)
+			}
+			d.sticky = endpoint
+			d.stickySince = time.Now()
+		} else {
+			// Still failing over after the cooldown, start a new period
+			d.stickySince = time.Now()
+		}
+		return strings.TrimSpace(ip), nil
+	}
+
+	d.sticky = ""
 	return "", errors.New("failed to get public IP from all endpoints")
 }
 
-func (d *Detector) getIPFromEndpoint(endpoint string) (string, error) {
-	resp, err := d.client.Get(endpoint)
+func (d *Detector) stickyCooldown() time.Duration {
+	if d.config.StickyCooldown > 0 {
+		return time.Duration(d.config.StickyCooldown) * time.Second
+	}
+	return defaultStickyCooldown * time.Second
+}
+
+// GetPublicIPv6 returns the public IPv6 address and whether IPv6 is usable.
+// After a failed detection IPv6 is reported unavailable without probing
+// until the recheck interval has passed; availability changes are logged
+// once instead of on every check.
+func (d *Detector) GetPublicIPv6() (string, bool) {
+	d.ipv6Mu.Lock()
+	defer d.ipv6Mu.Unlock()
+
+	checked := !d.ipv6CheckedAt.IsZero()
+	if checked && !d.ipv6Available && time.Since(d.ipv6CheckedAt) < d.ipv6RecheckInterval() {
+		return "", false
+	}
+	// Offline is not IPv6 being unavailable; it is checked again next time
+	if d.online() != nil {
+		return "", false
+	}
+
+	ip, err := d.detectIPv6()
+	if err == nil {
+		ip, err = d.transform(ip)
+	}
+	d.ipv6CheckedAt = time.Now()
 	if err != nil {
-		return "", err
+		if (!checked || d.ipv6Available) && d.logger != nil {
+			d.logger.Infof("ℹ️ IPv6不可用，将跳过AAAA记录，%s后重新检测: %v", d.ipv6RecheckInterval(), err)
+		}
+		d.ipv6Available = false
+		return "", false
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", errors.New("non-200 status code")
+	if checked && !d.ipv6Available && d.logger != nil {
+		d.logger.Infof("✅ IPv6已恢复可用: %s", ip)
+	}
+	d.ipv6Available = true
+	return ip, true
+}
+
+// GetPublicIPs detects the IPv4 address and, when withIPv6 is set, the IPv6
+// address at the same time. ipv6 is empty when IPv6 is unavailable; err
+// only reflects the IPv4 detection.
+func (d *Detector) GetPublicIPs(withIPv6 bool) (ipv4, ipv6 string, err error) {
+	if !withIPv6 {
+		ipv4, err = d.GetPublicIP()
+		return ipv4, "", err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ipv6, _ = d.GetPublicIPv6()
+	}()
+
+	ipv4, err = d.GetPublicIP()
+	<-done
+	return ipv4, ipv6, err
+}
+
+func (d *Detector) detectIPv6() (string, error) {
+	for _, endpoint := range d.config.IPv6Endpoints {
+		body, err := d.fetch(d.ipv6Client, endpoint)
+		if err == nil {
+			body, err = d.responseAddress(endpoint, body)
+		}
+		if err != nil {
+			continue
+		}
+
+		ip, err := parseIPv6(body)
+		if err != nil {
+			d.warnWrongFamily(endpoint, err)
+			continue
+		}
+		return ip, nil
+	}
+
+	return "", errors.New("failed to get public IPv6 from all endpoints")
+}
+
+func (d *Detector) ipv6RecheckInterval() time.Duration {
+	if d.config.IPv6RecheckInterval > 0 {
+		return time.Duration(d.config.IPv6RecheckInterval) * time.Second
+	}
+	return defaultIPv6RecheckInterval * time.Second
+}
+
+// ipv6Transport only dials IPv6, so a dual-stack endpoint can't answer
+// with the IPv4 address
+func ipv6Transport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "tcp6", addr)
+	}
+	return transport
+}
+
+func (d *Detector) getIPFromEndpoint(endpoint string) (string, error) {
+	body, err := d.fetch(d.client, endpoint)
+	if err == nil {
+		body, err = d.responseAddress(endpoint, body)
+	}
 	if err != nil {
 		return "", err
 	}
 
-	// Extract IP from response
-	ip := strings.TrimSpace(string(body))
+	ip, err := parseIPv4(body)
+	if err != nil {
+		d.warnWrongFamily(endpoint, err)
+		return "", err
+	}
+	return ip, nil
+}
 
-	// Basic IP validation
-	if !isValidIP(ip) {
+// errWrongFamily is returned for an address of the other family, e.g. an
+// IPv4 endpoint answering over IPv6
+var errWrongFamily = errors.New("wrong address family")
+
+// parseIPv4 returns the IPv4 address in a detection response. IPv4-mapped
+// IPv6 addresses (::ffff:203.0.113.5) are converted to their IPv4 form.
+func parseIPv4(body string) (string, error) {
+	ip := net.ParseIP(body)
+	if ip == nil {
 		return "", errors.New("invalid IP format")
 	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.String(), nil
+	}
+	return "", fmt.Errorf("%w: got IPv6 address %s, expected IPv4", errWrongFamily, ip)
+}
 
-	return ip, nil
+// parseIPv6 returns the IPv6 address in a detection response. IPv4 and
+// IPv4-mapped addresses are rejected: they can't go into an AAAA record.
+func parseIPv6(body string) (string, error) {
+	ip := net.ParseIP(body)
+	if ip == nil {
+		return "", errors.New("invalid IP format")
+	}
+	if ip.To4() != nil {
+		return "", fmt.Errorf("%w: got IPv4 address %s, expected IPv6", errWrongFamily, ip.To4())
+	}
+	return ip.To16().String(), nil
 }
 
-func isValidIP(ip string) bool {
-	parts := strings.Split(ip, ".")
-	if len(parts) != 4 {
-		return false
+// warnWrongFamily logs once per endpoint that it answered with the other
+// address family, which usually means the endpoint is misconfigured
+func (d *Detector) warnWrongFamily(endpoint string, err error) {
+	if !errors.Is(err, errWrongFamily) {
+		return
 	}
 
-	for _, part := range parts {
-		if len(part) == 0 || len(part) > 3 {
-			return false
-		}
+	d.redirectMu.Lock()
+	defer d.redirectMu.Unlock()
 
-		for _, char := range part {
-			if char < '0' || char > '9' {
-				return false
-			}
+	key := "family " + endpoint
+	if d.redirectWarned[key] {
+		return
+	}
+	d.redirectWarned[key] = true
+	if d.logger != nil {
+		d.logger.Warnf("⚠️ IP检测端点 %s 返回的地址类型不符，已跳过: %v", endpoint, err)
+	}
+}
+
+// fetch returns the trimmed body of a detection response. Redirects are
+// followed up to max_redirects; an endpoint that redirects is logged once so
+// the URL can be fixed, since the target is often an HTML page rather than
+// an IP.
+func (d *Detector) fetch(client *http.Client, endpoint string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(d.timeout.Load()))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if final := resp.Request.URL.String(); final != endpoint {
+		d.warnRedirect(endpoint, final)
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		location := resp.Header.Get("Location")
+		if u, err := resp.Location(); err == nil {
+			location = u.String()
 		}
+		d.warnRedirect(endpoint, location)
+		return "", fmt.Errorf("redirected to %s, not followed (max_redirects)", location)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("non-200 status code")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", err
 	}
 
-	return true
-}
\ No newline at end of file
+	return strings.TrimSpace(string(body)), nil
+}
+
+func (d *Detector) warnRedirect(endpoint, target string) {
+	d.redirectMu.Lock()
+	defer d.redirectMu.Unlock()
+
+	if d.redirectWarned[endpoint] {
+		return
+	}
+	d.redirectWarned[endpoint] = true
+	if d.logger != nil {
+		d.logger.Warnf("⚠️ IP检测端点 %s 重定向到 %s，建议直接配置最终地址", endpoint, target)
+	}
+}
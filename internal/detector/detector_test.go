@@ -0,0 +1,116 @@
+package detector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingLogger keeps the warnings logged by the detector
+type recordingLogger struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {}
+
+func (l *recordingLogger) Warnf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+// newRedirectServer serves an IP at /ip and a chain of redirects
+// /hop/N -> /hop/N-1 -> ... -> /ip
+func newRedirectServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ip", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "203.0.113.7")
+	})
+	mux.HandleFunc("/hop/", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(r.URL.Path, "/hop/%d", &n)
+		if n <= 1 {
+			http.Redirect(w, r, "/ip", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/hop/%d", n-1), http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/html", http.StatusFound)
+	})
+	mux.HandleFunc("/html", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>moved</body></html>")
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestDetector(maxRedirects int) (*Detector, *recordingLogger) {
+	d := New(Config{MaxRedirects: maxRedirects, CacheTTL: -1})
+	log := &recordingLogger{}
+	d.SetLogger(log)
+	return d, log
+}
+
+func TestRedirectFollowedAndLoggedOnce(t *testing.T) {
+	server := newRedirectServer(t)
+	d, log := newTestDetector(0)
+
+	for i := 0; i < 2; i++ {
+		ip, err := d.getIPFromEndpoint(server.URL + "/hop/2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ip != "203.0.113.7" {
+			t.Fatalf("ip = %s, want 203.0.113.7", ip)
+		}
+	}
+	if len(log.warnings) != 1 || !strings.Contains(log.warnings[0], server.URL+"/ip") {
+		t.Fatalf("warnings = %v, want one naming the final URL", log.warnings)
+	}
+}
+
+func TestRedirectLimit(t *testing.T) {
+	server := newRedirectServer(t)
+	d, _ := newTestDetector(0)
+
+	// The default follows three redirects, the fourth is refused
+	if _, err := d.getIPFromEndpoint(server.URL + "/hop/3"); err != nil {
+		t.Fatalf("three redirects: %v", err)
+	}
+	_, err := d.getIPFromEndpoint(server.URL + "/hop/4")
+	if err == nil || !strings.Contains(err.Error(), "not followed") {
+		t.Fatalf("four redirects: err = %v, want not followed", err)
+	}
+}
+
+func TestRedirectsDisabled(t *testing.T) {
+	server := newRedirectServer(t)
+	d, log := newTestDetector(-1)
+
+	_, err := d.getIPFromEndpoint(server.URL + "/hop/1")
+	if err == nil || !strings.Contains(err.Error(), "redirected to "+server.URL+"/ip") {
+		t.Fatalf("err = %v, want the redirect target", err)
+	}
+	if len(log.warnings) != 1 {
+		t.Fatalf("warnings = %v, want one", log.warnings)
+	}
+}
+
+func TestRedirectToPageIsRejected(t *testing.T) {
+	server := newRedirectServer(t)
+	d, log := newTestDetector(0)
+
+	if _, err := d.getIPFromEndpoint(server.URL + "/page"); err == nil {
+		t.Fatal("an HTML page was accepted as an IP")
+	}
+	if len(log.warnings) != 1 || !strings.Contains(log.warnings[0], server.URL+"/html") {
+		t.Fatalf("warnings = %v, want one naming the page", log.warnings)
+	}
+}
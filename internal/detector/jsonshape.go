@@ -0,0 +1,124 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// knownJSONShapes maps the hosts of common detection services that answer
+// with JSON to the field holding the address, so their URLs can be listed
+// without json_paths. Services answering with the bare address need none.
+var knownJSONShapes = map[string]string{
+	"api.ipify.org":      "ip", // ?format=json
+	"api4.ipify.org":     "ip",
+	"api6.ipify.org":     "ip",
+	"api64.ipify.org":    "ip",
+	"ipinfo.io":          "ip", // /json
+	"ifconfig.co":        "ip", // /json
+	"ipapi.co":           "ip", // /json
+	"ipwho.is":           "ip",
+	"jsonip.com":         "ip",
+	"api.myip.com":       "ip",
+	"api.seeip.org":      "ip", // /jsonip
+	"ip.cn":              "ip", // /api/index?ip&type=0
+	"ip-api.com":         "query",
+	"httpbin.org":        "origin",
+	"ident.me":           "address", // /.json
+	"v4.ident.me":        "address",
+	"v6.ident.me":        "address",
+	"wtfismyip.com":      "YourFuckingIPAddress", // /json
+	"ipv4.wtfismyip.com": "YourFuckingIPAddress",
+	"ipv6.wtfismyip.com": "YourFuckingIPAddress",
+}
+
+// jsonPath returns the path of the address in JSON responses of the
+// endpoint: json_paths by URL, then by host, then the built-in shapes
+func (d *Detector) jsonPath(endpoint string) string {
+	if path, ok := d.config.JSONPaths[endpoint]; ok {
+		return path
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if path, ok := d.config.JSONPaths[host]; ok {
+		return path
+	}
+	return knownJSONShapes[host]
+}
+
+// responseAddress returns the address in a detection response: the body
+// itself, or for a JSON object the value at the endpoint's JSON path. A JSON
+// response it can't read is logged once per endpoint.
+func (d *Detector) responseAddress(endpoint, body string) (string, error) {
+	if !strings.HasPrefix(body, "{") {
+		return body, nil
+	}
+
+	path := d.jsonPath(endpoint)
+	if path == "" {
+		err := fmt.Errorf("JSON response without a known address field")
+		d.warnJSON(endpoint, err)
+		return "", err
+	}
+	value, err := extractJSONPath(body, path)
+	if err != nil {
+		d.warnJSON(endpoint, err)
+		return "", err
+	}
+	// httpbin lists every address the request passed through
+	first, _, _ := strings.Cut(value, ",")
+	return strings.TrimSpace(first), nil
+}
+
+// extractJSONPath returns the string at a dot-separated path such as
+// "data.ip" or "addresses.0"; numeric segments index arrays
+func extractJSONPath(body, path string) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(body), &value); err != nil {
+		return "", fmt.Errorf("invalid JSON response: %w", err)
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			next, ok := node[segment]
+			if !ok {
+				return "", fmt.Errorf("JSON response has no %q", path)
+			}
+			value = next
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", fmt.Errorf("JSON response has no %q", path)
+			}
+			value = node[index]
+		default:
+			return "", fmt.Errorf("JSON response has no %q", path)
+		}
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("JSON value at %q is not a string", path)
+	}
+	return text, nil
+}
+
+func (d *Detector) warnJSON(endpoint string, err error) {
+	d.redirectMu.Lock()
+	defer d.redirectMu.Unlock()
+
+	key := "json " + endpoint
+	if d.redirectWarned[key] {
+		return
+	}
+	d.redirectWarned[key] = true
+	if d.logger != nil {
+		d.logger.Warnf("⚠️ IP检测端点 %s 返回的JSON无法解析出IP，已跳过: %v（可在 ip_detection.json_paths 中指定字段）", endpoint, err)
+	}
+}
@@ -0,0 +1,164 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLivenessTimeout  = 5
+	defaultLivenessCacheTTL = 30
+)
+
+// ErrOffline is returned by GetPublicIP when the liveness check fails: the
+// host is offline (or still booting), so no detection was attempted
+var ErrOffline = errors.New("liveness check failed, host is offline")
+
+// LivenessConfig is the [ip_detection.liveness] section: a check run before
+// detection, so an offline host skips the check quietly instead of logging
+// every endpoint as failing. With both tcp and command set, both must pass.
+type LivenessConfig struct {
+	// TCP lists "host:port" targets; connecting to any of them counts as online
+	TCP []string `toml:"tcp"`
+	// Command exits 0 when the host is online, e.g. ["ping", "-c1", "-W2", "192.0.2.1"]
+	Command  []string `toml:"command"`
+	Timeout  int      `toml:"timeout"`   // seconds per check, default 5
+	CacheTTL int      `toml:"cache_ttl"` // seconds a result is reused, default 30, -1 disables
+}
+
+// Validate checks the targets and the command without running them
+func (l *LivenessConfig) Validate() error {
+	for _, target := range l.TCP {
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			return fmt.Errorf("invalid ip_detection.liveness.tcp target %q: %w", target, err)
+		}
+	}
+	if len(l.Command) > 0 {
+		if _, err := exec.LookPath(l.Command[0]); err != nil {
+			return fmt.Errorf("invalid ip_detection.liveness.command: %w", err)
+		}
+	}
+	if l.Timeout < 0 {
+		return fmt.Errorf("invalid ip_detection.liveness.timeout: %d", l.Timeout)
+	}
+	if l.CacheTTL < -1 {
+		return fmt.Errorf("invalid ip_detection.liveness.cache_ttl: %d", l.CacheTTL)
+	}
+	if len(l.TCP) == 0 && len(l.Command) == 0 {
+		return fmt.Errorf("ip_detection.liveness needs tcp or command")
+	}
+	return nil
+}
+
+// liveness caches the last liveness result
+type liveness struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	online    bool
+	err       error
+	stale     bool // set by InvalidateCache, the result isn't reused
+}
+
+// online runs the liveness check, or reuses the last result within
+// cache_ttl. Going offline and coming back are logged once each.
+func (d *Detector) online() error {
+	l := d.config.Liveness
+	if l == nil {
+		return nil
+	}
+
+	d.liveness.mu.Lock()
+	defer d.liveness.mu.Unlock()
+
+	ttl := time.Duration(defaultLivenessCacheTTL) * time.Second
+	if l.CacheTTL != 0 {
+		ttl = time.Duration(l.CacheTTL) * time.Second
+	}
+	checked := !d.liveness.checkedAt.IsZero()
+	if checked && !d.liveness.stale && ttl > 0 && time.Since(d.liveness.checkedAt) < ttl {
+		return d.liveness.err
+	}
+
+	dial := (&net.Dialer{}).DialContext
+	if d.ifaceDialer != nil {
+		dial = d.ifaceDialer.DialContext
+	}
+	err := l.check(dial)
+	d.liveness.checkedAt = time.Now()
+	d.liveness.stale = false
+	switch {
+	case err != nil && (!checked || d.liveness.online) && d.logger != nil:
+		d.logger.Warnf("🔌 存活检查失败，暂停公网IP检测直到网络恢复: %v", err)
+	case err == nil && checked && !d.liveness.online && d.logger != nil:
+		d.logger.Infof("🔌 存活检查已恢复，继续检测公网IP")
+	}
+	d.liveness.online = err == nil
+	d.liveness.err = nil
+	if err != nil {
+		d.liveness.err = fmt.Errorf("%w: %v", ErrOffline, err)
+	}
+	return d.liveness.err
+}
+
+// dialFunc opens the TCP connections of the liveness check
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// check runs the configured checks. TCP connections go through the
+// interface option's dialer when it is set.
+func (l *LivenessConfig) check(dial dialFunc) error {
+	timeout := defaultLivenessTimeout * time.Second
+	if l.Timeout > 0 {
+		timeout = time.Duration(l.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if len(l.TCP) > 0 {
+		if err := l.dialAny(ctx, dial); err != nil {
+			return err
+		}
+	}
+	if len(l.Command) > 0 {
+		cmd := exec.CommandContext(ctx, l.Command[0], l.Command[1:]...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				return fmt.Errorf("liveness command failed: %w: %s", err, msg)
+			}
+			return fmt.Errorf("liveness command failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// dialAny connects to the TCP targets at the same time and returns as soon
+// as one of them accepts
+func (l *LivenessConfig) dialAny(ctx context.Context, dial dialFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan error, len(l.TCP))
+	for _, target := range l.TCP {
+		go func(target string) {
+			conn, err := dial(ctx, "tcp", target)
+			if err == nil {
+				conn.Close()
+			}
+			results <- err
+		}(target)
+	}
+
+	var errs []error
+	for range l.TCP {
+		err := <-results
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no liveness target reachable: %w", errors.Join(errs...))
+}
@@ -0,0 +1,87 @@
+package detector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sequenceServer answers detection requests with the given addresses in
+// order, repeating the last one
+type sequenceServer struct {
+	mu      sync.Mutex
+	answers []string
+}
+
+func newSequenceServer(t *testing.T) (*sequenceServer, string) {
+	s := &sequenceServer{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		answer := s.answers[0]
+		if len(s.answers) > 1 {
+			s.answers = s.answers[1:]
+		}
+		fmt.Fprintln(w, answer)
+	}))
+	t.Cleanup(server.Close)
+	return s, server.URL
+}
+
+func (s *sequenceServer) answer(answers ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.answers = answers
+}
+
+func newSamplingDetector(endpoint string) (*Detector, *recordingLogger) {
+	d := New(Config{APIEndpoints: []string{endpoint}, CacheTTL: -1, Samples: 2, SampleInterval: 1})
+	log := &recordingLogger{}
+	d.SetLogger(log)
+	return d, log
+}
+
+func TestSamplesRejectTransientChange(t *testing.T) {
+	s, endpoint := newSequenceServer(t)
+	d, log := newSamplingDetector(endpoint)
+
+	s.answer("192.0.2.1", "192.0.2.1")
+	if ip, err := d.GetPublicIP(); err != nil || ip != "192.0.2.1" {
+		t.Fatalf("first detection = %q, %v; want 192.0.2.1", ip, err)
+	}
+
+	// A single wrong answer keeps the accepted address
+	s.answer("198.51.100.9", "192.0.2.1")
+	if ip, err := d.GetPublicIP(); err != nil || ip != "192.0.2.1" {
+		t.Fatalf("transient change = %q, %v; want 192.0.2.1 kept", ip, err)
+	}
+	if len(log.warnings) != 1 || !strings.Contains(log.warnings[0], "198.51.100.9") {
+		t.Fatalf("warnings = %q, want one about the unstable address", log.warnings)
+	}
+
+	// A change seen in every sample is accepted
+	s.answer("198.51.100.9")
+	if ip, err := d.GetPublicIP(); err != nil || ip != "198.51.100.9" {
+		t.Fatalf("stable change = %q, %v; want 198.51.100.9", ip, err)
+	}
+
+	// The accepted address is returned without sampling again
+	s.answer("198.51.100.9", "192.0.2.1")
+	if ip, err := d.GetPublicIP(); err != nil || ip != "198.51.100.9" {
+		t.Fatalf("unchanged = %q, %v; want 198.51.100.9", ip, err)
+	}
+}
+
+func TestSamplesUnstableFirstDetection(t *testing.T) {
+	s, endpoint := newSequenceServer(t)
+	d, _ := newSamplingDetector(endpoint)
+
+	s.answer("192.0.2.1", "198.51.100.9")
+	_, err := d.GetPublicIP()
+	if err == nil || !strings.Contains(err.Error(), "not stable") {
+		t.Fatalf("err = %v, want an unstable first detection to fail", err)
+	}
+}
@@ -0,0 +1,113 @@
+package detector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const defaultTransformTimeout = 10
+
+// TransformConfig is the [ip_detection.transform] section: it maps the
+// detected address to the one to publish, for 1:1 NAT and VIP setups where
+// the address seen from outside isn't the one the records should hold
+type TransformConfig struct {
+	// Map is a static substitution table, detected address -> published
+	// address; addresses not in it are passed to Command, or kept
+	Map map[string]string `toml:"map"`
+	// Command is run with the detected address on stdin and prints the
+	// address to publish on stdout, e.g. ["/usr/local/bin/vip-for", "--wan"]
+	Command []string `toml:"command"`
+	Timeout int      `toml:"timeout"` // seconds, default 10
+}
+
+// Validate checks the table and the command without running it
+func (t *TransformConfig) Validate() error {
+	for from, to := range t.Map {
+		fromIP, toIP := net.ParseIP(from), net.ParseIP(to)
+		if fromIP == nil || toIP == nil {
+			return fmt.Errorf("invalid ip_detection.transform.map entry: %q = %q", from, to)
+		}
+		if (fromIP.To4() == nil) != (toIP.To4() == nil) {
+			return fmt.Errorf("ip_detection.transform.map entry %q = %q changes the address family", from, to)
+		}
+	}
+	if len(t.Command) > 0 {
+		if _, err := exec.LookPath(t.Command[0]); err != nil {
+			return fmt.Errorf("invalid ip_detection.transform.command: %w", err)
+		}
+	}
+	if t.Timeout < 0 {
+		return fmt.Errorf("invalid ip_detection.transform.timeout: %d", t.Timeout)
+	}
+	if len(t.Map) == 0 && len(t.Command) == 0 {
+		return fmt.Errorf("ip_detection.transform needs map or command")
+	}
+	return nil
+}
+
+// transform maps a detected address through the transform, if configured.
+// The result must be an address of the same family; a failing command fails
+// the detection rather than publishing the untransformed address.
+func (d *Detector) transform(ip string) (string, error) {
+	t := d.config.Transform
+	if t == nil {
+		return ip, nil
+	}
+
+	mapped, ok := t.Map[ip]
+	if !ok && len(t.Command) > 0 {
+		var err error
+		if mapped, err = t.run(ip); err != nil {
+			return "", fmt.Errorf("ip transform command failed for %s: %w", ip, err)
+		}
+		ok = true
+	}
+	if !ok {
+		return ip, nil
+	}
+
+	parsed := net.ParseIP(mapped)
+	if parsed == nil || (parsed.To4() == nil) != (net.ParseIP(ip).To4() == nil) {
+		return "", fmt.Errorf("ip transform of %s returned %q, not an address of the same family", ip, mapped)
+	}
+	mapped = parsed.String()
+
+	d.transformMu.Lock()
+	logged := d.transformed[ip] == mapped
+	d.transformed[ip] = mapped
+	d.transformMu.Unlock()
+	if !logged && d.logger != nil && mapped != ip {
+		d.logger.Infof("🔁 检测到的IP %s 转换为 %s", ip, mapped)
+	}
+	return mapped, nil
+}
+
+// run passes ip to the command on stdin and returns the first line it prints
+func (t *TransformConfig) run(ip string) (string, error) {
+	timeout := defaultTransformTimeout * time.Second
+	if t.Timeout > 0 {
+		timeout = time.Duration(t.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, t.Command[0], t.Command[1:]...)
+	cmd.Stdin = strings.NewReader(ip + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line), nil
+}
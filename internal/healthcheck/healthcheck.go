@@ -0,0 +1,180 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ip-updater/internal/netutil"
+)
+
+// Check types
+const (
+	TypeTCP   = "tcp"
+	TypeHTTP  = "http"
+	TypeHTTPS = "https"
+)
+
+const defaultTimeout = 5
+
+// Config describes the service that has to be reachable on the detected IP
+// before an updater publishes it
+type Config struct {
+	Type               string `toml:"type"`                 // tcp (default), http or https
+	Port               int    `toml:"port"`                 // 必填
+	Path               string `toml:"path"`                 // http(s) only, default "/"
+	Host               string `toml:"host"`                 // http(s) Host header and TLS server name; required for https unless insecure_skip_verify
+	ExpectStatus       int    `toml:"expect_status"`        // http(s) only, default: any status below 400
+	InsecureSkipVerify bool   `toml:"insecure_skip_verify"` // https only
+	Timeout            int    `toml:"timeout"`              // seconds
+}
+
+// Validate checks the settings without connecting anywhere
+func (c *Config) Validate() error {
+	switch c.checkType() {
+	case TypeTCP, TypeHTTP, TypeHTTPS:
+	default:
+		return fmt.Errorf("unsupported health_check type: %s", c.Type)
+	}
+
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("invalid health_check port: %d", c.Port)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("invalid health_check timeout: %d", c.Timeout)
+	}
+	if c.ExpectStatus != 0 && (c.ExpectStatus < 100 || c.ExpectStatus > 599) {
+		return fmt.Errorf("invalid health_check expect_status: %d", c.ExpectStatus)
+	}
+	return nil
+}
+
+func (c *Config) checkType() string {
+	if c.Type == "" {
+		return TypeTCP
+	}
+	return strings.ToLower(c.Type)
+}
+
+// Describe returns a short description for log messages, e.g. "tcp 203.0.113.5:443"
+func (c *Config) Describe(ip string) string {
+	target := net.JoinHostPort(ip, strconv.Itoa(c.Port))
+	if c.checkType() == TypeTCP {
+		return "tcp " + target
+	}
+	return fmt.Sprintf("%s://%s%s", c.checkType(), target, c.path())
+}
+
+func (c *Config) path() string {
+	if c.Path == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(c.Path, "/") {
+		return "/" + c.Path
+	}
+	return c.Path
+}
+
+// Check connects to the configured port on ip and returns an error if the
+// service does not answer as expected within the timeout. Connections are
+// made like every other outbound request: from the current address of iface
+// when not empty, otherwise from local_addr. An https check verifies the
+// certificate against Host, since a certificate rarely names the bare IP.
+func (c *Config) Check(ip, iface string) error {
+	timeout := time.Duration(defaultTimeout) * time.Second
+	if c.Timeout > 0 {
+		timeout = time.Duration(c.Timeout) * time.Second
+	}
+
+	// Dial in the family of ip, so an interface binding picks a source
+	// address that can reach it
+	network := "tcp6"
+	if parsed := net.ParseIP(ip); parsed == nil || parsed.To4() != nil {
+		network = "tcp4"
+	}
+
+	if c.checkType() == TypeTCP {
+		dial := netutil.DialContext
+		if iface != "" {
+			dial = netutil.NewInterfaceDialer(iface).DialContext
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		conn, err := dial(ctx, network, net.JoinHostPort(ip, strconv.Itoa(c.Port)))
+		if err != nil {
+			return err
+		}
+		conn.Close()
+		return nil
+	}
+
+	var transport *http.Transport
+	if iface != "" {
+		transport = netutil.NewInterfaceDialer(iface).Transport(network)
+	} else {
+		transport = netutil.CloneTransport()
+	}
+	return c.checkHTTP(ip, transport, timeout)
+}
+
+// serverName returns the name the certificate is verified against: Host
+// without a port
+func (c *Config) serverName() string {
+	if host, _, err := net.SplitHostPort(c.Host); err == nil {
+		return host
+	}
+	return c.Host
+}
+
+func (c *Config) checkHTTP(ip string, transport *http.Transport, timeout time.Duration) error {
+	// The service on ip is checked directly, never through a proxy
+	transport.Proxy = nil
+	transport.DisableKeepAlives = true
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.Host != "" {
+		transport.TLSClientConfig.ServerName = c.serverName()
+	}
+
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// A redirect usually points at the public hostname, which still
+		// resolves to the old IP; the first response is what counts
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	url := fmt.Sprintf("%s://%s%s", c.checkType(), net.JoinHostPort(ip, strconv.Itoa(c.Port)), c.path())
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if c.Host != "" {
+		req.Host = c.Host
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if c.ExpectStatus != 0 {
+		if resp.StatusCode != c.ExpectStatus {
+			return fmt.Errorf("unexpected status %d (expected %d)", resp.StatusCode, c.ExpectStatus)
+		}
+		return nil
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
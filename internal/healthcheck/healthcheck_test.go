@@ -0,0 +1,237 @@
+package healthcheck
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"ip-updater/internal/netutil"
+)
+
+// serverPort returns the port of a listening address
+func serverPort(t *testing.T, addr string) int {
+	t.Helper()
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+func listen(t *testing.T, network, address string) net.Listener {
+	t.Helper()
+	l, err := net.Listen(network, address)
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return l
+}
+
+func loopbackName(t *testing.T) string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 && iface.Flags&net.FlagUp != 0 {
+			return iface.Name
+		}
+	}
+	t.Skip("no loopback interface")
+	return ""
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		config Config
+		err    string
+	}{
+		{Config{Port: 443}, ""},
+		{Config{Type: "HTTPS", Port: 443, ExpectStatus: 204}, ""},
+		{Config{Type: "udp", Port: 53}, "unsupported health_check type: udp"},
+		{Config{Port: 0}, "invalid health_check port: 0"},
+		{Config{Port: 70000}, "invalid health_check port: 70000"},
+		{Config{Port: 80, Timeout: -1}, "invalid health_check timeout: -1"},
+		{Config{Type: "http", Port: 80, ExpectStatus: 99}, "invalid health_check expect_status: 99"},
+	}
+	for _, tt := range tests {
+		err := tt.config.Validate()
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("Validate(%+v) = %v, want %q", tt.config, err, tt.err)
+		}
+	}
+}
+
+func TestCheckTCP(t *testing.T) {
+	l := listen(t, "tcp4", "127.0.0.1:0")
+	port := serverPort(t, l.Addr().String())
+
+	check := Config{Port: port, Timeout: 1}
+	if err := check.Check("127.0.0.1", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Close()
+	if err := check.Check("127.0.0.1", ""); err == nil {
+		t.Fatal("check of a closed port passed")
+	}
+}
+
+func TestCheckHTTP(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Host+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusNoContent)
+		case "/moved":
+			http.Redirect(w, r, "https://www.example.com/", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(server.Close)
+	port := serverPort(t, server.Listener.Addr().String())
+
+	tests := []struct {
+		config Config
+		err    string
+	}{
+		{Config{Type: "http", Port: port, Path: "healthz", Host: "www.example.com"}, ""},
+		{Config{Type: "http", Port: port, Path: "/healthz", ExpectStatus: 204}, ""},
+		{Config{Type: "http", Port: port, Path: "/healthz", ExpectStatus: 200}, "unexpected status 204 (expected 200)"},
+		{Config{Type: "http", Port: port, Path: "/down"}, "unexpected status 503"},
+		// The redirect isn't followed
+		{Config{Type: "http", Port: port, Path: "/moved", ExpectStatus: 302}, ""},
+	}
+	for _, tt := range tests {
+		err := tt.config.Check("127.0.0.1", "")
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("Check(%+v) = %v, want %q", tt.config, err, tt.err)
+		}
+	}
+
+	if requests[0] != "www.example.com/healthz" {
+		t.Fatalf("first request = %s, want the configured host and path", requests[0])
+	}
+	if len(requests) != len(tests) {
+		t.Fatalf("requests = %q, want one per check", requests)
+	}
+}
+
+func TestCheckHTTPSServerName(t *testing.T) {
+	var mu sync.Mutex
+	var serverNames []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			mu.Lock()
+			serverNames = append(serverNames, hello.ServerName)
+			mu.Unlock()
+			return nil, nil
+		},
+	}
+	// The rejected certificate is logged by the server otherwise
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	port := serverPort(t, server.Listener.Addr().String())
+
+	check := Config{Type: "https", Port: port, Host: "www.example.com:8443", InsecureSkipVerify: true}
+	if err := check.Check("127.0.0.1", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without insecure_skip_verify the certificate is verified, and the
+	// test server's isn't trusted
+	check.InsecureSkipVerify = false
+	if err := check.Check("127.0.0.1", ""); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("err = %v, want a certificate error", err)
+	}
+
+	if len(serverNames) != 2 || serverNames[0] != "www.example.com" || serverNames[1] != "www.example.com" {
+		t.Fatalf("server names = %q, want the host without its port", serverNames)
+	}
+}
+
+func TestCheckUsesLocalAddr(t *testing.T) {
+	l := listen(t, "tcp6", "[::1]:0")
+	check := Config{Port: serverPort(t, l.Addr().String()), Timeout: 1}
+	if err := check.Check("::1", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bound to an IPv4 source, the IPv6 target is unreachable
+	if err := netutil.SetLocalAddr("127.0.0.1"); err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { netutil.SetLocalAddr("") })
+	if err := check.Check("::1", ""); err == nil {
+		t.Fatal("check passed, want it dialed from local_addr")
+	}
+}
+
+// hostAddr returns an IPv4 address of this host that isn't a loopback one
+func hostAddr(t *testing.T) string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil && !ipNet.IP.IsLoopback() {
+			return ipNet.IP.String()
+		}
+	}
+	t.Skip("no non-loopback IPv4 address")
+	return ""
+}
+
+func TestCheckUsesInterface(t *testing.T) {
+	target := hostAddr(t)
+	l, err := net.Listen("tcp4", net.JoinHostPort(target, "0"))
+	if err != nil {
+		t.Skip(err)
+	}
+	// Only requests dialed from the loopback interface's address pass
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if host, _, _ := net.SplitHostPort(r.RemoteAddr); host != "127.0.0.1" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	})}
+	go server.Serve(l)
+	t.Cleanup(func() { server.Close() })
+
+	check := Config{Type: "http", Port: serverPort(t, l.Addr().String()), Timeout: 1}
+	if err := check.Check(target, loopbackName(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := check.Check(target, ""); err == nil || err.Error() != "unexpected status 403" {
+		t.Fatalf("unbound check = %v, want it made from %s", err, target)
+	}
+
+	// A missing interface falls back to the default route
+	if err := check.Check(target, "ipupdater-test0"); err == nil || err.Error() != "unexpected status 403" {
+		t.Fatalf("check = %v, want it made unbound", err)
+	}
+}
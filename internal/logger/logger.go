@@ -29,6 +29,12 @@ const (
 type Logger struct {
 	*logrus.Logger
 	isColorEnabled bool
+	file           *rotatingFile
+
+	// plain drops the emoji from highlighted messages (syslog and journald)
+	plain bool
+	hook  closingHook // syslog connection
+	mask  bool        // mask_ip_in_logs
 }
 
 func New() *Logger {
@@ -54,7 +60,13 @@ func New() *Logger {
 	}
 }
 
-func (l *Logger) Configure(level, filePath string, maxSize, maxAge int) error {
+// Configure sets the level and the output. With OutputFile (the default)
+// logs go to stdout and the log file: the file is rotated once it reaches
+// maxSize MB (0: never), rotated files are removed after maxAge days (0:
+// kept) and gzipped when compress is set. OutputSyslog and OutputJournald
+// ignore the file settings. "file,syslog" keeps the file output and sends
+// every entry to the syslog daemon selected by syslogConfig as well.
+func (l *Logger) Configure(level, output, filePath string, maxSize, maxAge int, compress bool, syslogConfig SyslogConfig) error {
 	// Set log level
 	switch level {
 	case "debug":
@@ -69,15 +81,62 @@ func (l *Logger) Configure(level, filePath string, maxSize, maxAge int) error {
 		l.SetLevel(logrus.InfoLevel)
 	}
 
+	output, withSyslog, err := parseOutput(output)
+	if err != nil {
+		return err
+	}
+
+	switch output {
+	case OutputSyslog:
+		hook, err := newSyslogHook(syslogConfig)
+		if err != nil {
+			return err
+		}
+
+		// The hook sends every entry with its severity, stdout stays quiet
+		// so a systemd service doesn't log everything twice
+		l.isColorEnabled = false
+		l.plain = true
+		l.SetFormatter(&logrus.TextFormatter{
+			DisableColors:    true,
+			DisableTimestamp: true,
+		})
+		l.SetOutput(io.Discard)
+		l.replaceHook(hook)
+		l.replaceFile(nil)
+		return nil
+
+	case OutputJournald:
+		l.isColorEnabled = false
+		l.plain = true
+		l.SetFormatter(&journaldFormatter{logrus.TextFormatter{
+			DisableColors:    true,
+			DisableTimestamp: true,
+		}})
+		l.SetOutput(os.Stdout)
+		l.replaceHook(nil)
+		l.replaceFile(nil)
+		return nil
+	}
+
+	var hook closingHook
+	if withSyslog {
+		if hook, err = newSyslogHook(syslogConfig); err != nil {
+			return err
+		}
+	}
+
 	// Create log file if specified
 	if filePath != "" {
 		// Create directory if it doesn't exist
 		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			closeHook(hook)
 			return err
 		}
 
-		file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		file, err := openRotatingFile(filePath, maxSize, maxAge, compress)
 		if err != nil {
+			closeHook(hook)
 			return err
 		}
 
@@ -89,6 +148,18 @@ func (l *Logger) Configure(level, filePath string, maxSize, maxAge int) error {
 			DisableColors:   true,
 		})
 		l.SetOutput(io.MultiWriter(os.Stdout, file))
+		l.replaceFile(file)
+	} else if withSyslog {
+		// The hook formats its own entries, but colored highlights would
+		// still reach syslog through the message
+		l.isColorEnabled = false
+		l.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: "2006-01-02 15:04:05",
+			DisableColors:   true,
+		})
+		l.SetOutput(os.Stdout)
+		l.replaceFile(nil)
 	} else {
 		// For stdout only, keep colors enabled
 		l.isColorEnabled = true
@@ -97,17 +168,97 @@ func (l *Logger) Configure(level, filePath string, maxSize, maxAge int) error {
 			TimestampFormat: "2006-01-02 15:04:05",
 			ForceColors:     true,
 		})
+		l.SetOutput(os.Stdout)
+		l.replaceFile(nil)
 	}
+	l.plain = false
+	l.replaceHook(hook)
 
 	return nil
 }
 
+// closeHook closes a hook that wasn't installed because Configure failed
+func closeHook(hook closingHook) {
+	if hook != nil {
+		hook.Close()
+	}
+}
+
+// Sync flushes the log file to disk, e.g. before the process exits. Other
+// outputs are written synchronously and need no flushing.
+func (l *Logger) Sync() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Sync()
+}
+
+// SyslogEnabled reports whether entries are sent to syslog, alone or next to
+// the file output
+func (l *Logger) SyslogEnabled() bool {
+	return l.hook != nil
+}
+
+// replaceFile closes the log file opened by a previous Configure call
+// (e.g. on config reload) after output has switched to the new one
+func (l *Logger) replaceFile(file *rotatingFile) {
+	if l.file != nil && l.file != file {
+		l.file.Close()
+	}
+	l.file = file
+}
+
+// replaceHook installs the syslog hook (nil removes it) and closes the
+// previous one
+func (l *Logger) replaceHook(hook closingHook) {
+	if l.hook == hook {
+		return
+	}
+	old := l.hook
+	l.hook = hook
+	l.installHooks()
+	if old != nil {
+		old.Close()
+	}
+}
+
+// SetMaskIPs redacts public addresses in every log entry (see MaskIPs)
+func (l *Logger) SetMaskIPs(mask bool) {
+	if l.mask == mask {
+		return
+	}
+	l.mask = mask
+	l.installHooks()
+}
+
+// installHooks sets the logrus hooks. The mask hook comes first so the
+// syslog hook sends the masked entry.
+func (l *Logger) installHooks() {
+	hooks := make(logrus.LevelHooks)
+	if l.mask {
+		hooks.Add(maskHook{})
+	}
+	if l.hook != nil {
+		hooks.Add(l.hook)
+	}
+	l.ReplaceHooks(hooks)
+}
+
+// prefix is the uncolored label of a highlighted message, with its emoji
+// unless logging to syslog or the journal
+func (l *Logger) prefix(emoji, label string) string {
+	if l.plain {
+		return label + ": "
+	}
+	return emoji + " " + label + ": "
+}
+
 // Success logs with prominent green styling
 func (l *Logger) Success(msg string) {
 	if l.isColorEnabled {
 		l.WithField("status", "success").Infof("%s%s🎉 SUCCESS%s %s", BgGreen, ColorBold, ColorReset, msg)
 	} else {
-		l.WithField("status", "success").Infof("✅ SUCCESS: %s", msg)
+		l.WithField("status", "success").Infof(l.prefix("✅", "SUCCESS")+"%s", msg)
 	}
 }
 
@@ -116,7 +267,7 @@ func (l *Logger) Successf(format string, args ...interface{}) {
 	if l.isColorEnabled {
 		l.WithField("status", "success").Infof("%s%s🎉 SUCCESS%s "+format, append([]interface{}{BgGreen, ColorBold, ColorReset}, args...)...)
 	} else {
-		l.WithField("status", "success").Infof("✅ SUCCESS: "+format, args...)
+		l.WithField("status", "success").Infof(l.prefix("✅", "SUCCESS")+format, args...)
 	}
 }
 
@@ -125,7 +276,7 @@ func (l *Logger) ErrorHighlight(msg string) {
 	if l.isColorEnabled {
 		l.WithField("status", "error").Errorf("%s%s❌ ERROR%s %s", BgRed, ColorBold, ColorReset, msg)
 	} else {
-		l.WithField("status", "error").Errorf("❌ ERROR: %s", msg)
+		l.WithField("status", "error").Errorf(l.prefix("❌", "ERROR")+"%s", msg)
 	}
 }
 
@@ -134,7 +285,7 @@ func (l *Logger) ErrorHighlightf(format string, args ...interface{}) {
 	if l.isColorEnabled {
 		l.WithField("status", "error").Errorf("%s%s❌ ERROR%s "+format, append([]interface{}{BgRed, ColorBold, ColorReset}, args...)...)
 	} else {
-		l.WithField("status", "error").Errorf("❌ ERROR: "+format, args...)
+		l.WithField("status", "error").Errorf(l.prefix("❌", "ERROR")+format, args...)
 	}
 }
 
@@ -143,7 +294,7 @@ func (l *Logger) WarnHighlight(msg string) {
 	if l.isColorEnabled {
 		l.WithField("status", "warning").Warnf("%s%s⚠️ WARNING%s %s", BgYellow, ColorBold, ColorReset, msg)
 	} else {
-		l.WithField("status", "warning").Warnf("⚠️ WARNING: %s", msg)
+		l.WithField("status", "warning").Warnf(l.prefix("⚠️", "WARNING")+"%s", msg)
 	}
 }
 
@@ -152,6 +303,6 @@ func (l *Logger) WarnHighlightf(format string, args ...interface{}) {
 	if l.isColorEnabled {
 		l.WithField("status", "warning").Warnf("%s%s⚠️ WARNING%s "+format, append([]interface{}{BgYellow, ColorBold, ColorReset}, args...)...)
 	} else {
-		l.WithField("status", "warning").Warnf("⚠️ WARNING: "+format, args...)
+		l.WithField("status", "warning").Warnf(l.prefix("⚠️", "WARNING")+format, args...)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Candidates for masking; each match is parsed before it is masked, so
+// times ("21:00:19") and version numbers that aren't addresses are kept
+var (
+	ipv4Pattern = regexp.MustCompile(`\b\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}\b`)
+	ipv6Pattern = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+)
+
+// maskHook redacts the host part of public addresses in the message and
+// string fields of every entry (logging.mask_ip_in_logs). Only the output is
+// changed; the program keeps working with the full addresses.
+type maskHook struct{}
+
+func (maskHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (maskHook) Fire(entry *logrus.Entry) error {
+	entry.Message = MaskIPs(entry.Message)
+	for key, value := range entry.Data {
+		switch value := value.(type) {
+		case string:
+			entry.Data[key] = MaskIPs(value)
+		case error:
+			entry.Data[key] = MaskIPs(value.Error())
+		}
+	}
+	return nil
+}
+
+// MaskIPs replaces public addresses in s by their network part: IPv4 keeps
+// the first three octets ("203.0.113.x"), IPv6 the first three groups
+// ("2001:db8:1::x"). Private, loopback and link-local addresses are kept,
+// they don't identify the user and help when troubleshooting.
+func MaskIPs(s string) string {
+	if !strings.ContainsAny(s, ".:") {
+		return s
+	}
+	s = ipv4Pattern.ReplaceAllStringFunc(s, func(match string) string {
+		ip := net.ParseIP(match)
+		if ip == nil || !maskable(ip) {
+			return match
+		}
+		ip = ip.To4()
+		return fmt.Sprintf("%d.%d.%d.x", ip[0], ip[1], ip[2])
+	})
+	return ipv6Pattern.ReplaceAllStringFunc(s, func(match string) string {
+		ip := net.ParseIP(match)
+		if ip == nil || ip.To4() != nil || !maskable(ip) {
+			return match
+		}
+		prefix := ip.Mask(net.CIDRMask(48, 128)).String()
+		return strings.TrimSuffix(prefix, "::") + "::x"
+	})
+}
+
+func maskable(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsUnspecified()
+}
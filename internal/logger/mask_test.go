@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMaskIPs(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"IP changed: 198.51.100.1 -> 203.0.113.77", "IP changed: 198.51.100.x -> 203.0.113.x"},
+		{"AAAA = 2001:db8:1:2::abcd", "AAAA = 2001:db8:1::x"},
+		{"lan 192.168.1.20, lo 127.0.0.1, ll fe80::1, any 0.0.0.0", "lan 192.168.1.20, lo 127.0.0.1, ll fe80::1, any 0.0.0.0"},
+		{"started at 21:00:19, version 1.2.3.4567", "started at 21:00:19, version 1.2.3.4567"},
+		{"no addresses here", "no addresses here"},
+	}
+	for _, tt := range tests {
+		if got := MaskIPs(tt.in); got != tt.want {
+			t.Errorf("MaskIPs(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSetMaskIPs(t *testing.T) {
+	var buf bytes.Buffer
+	l := New()
+	l.SetOutput(&buf)
+
+	l.SetMaskIPs(true)
+	l.WithField("ip", "203.0.113.77").Infof("detected %s", "198.51.100.1")
+	masked := buf.String()
+	if strings.Contains(masked, "203.0.113.77") || strings.Contains(masked, "198.51.100.1") ||
+		!strings.Contains(masked, "203.0.113.x") || !strings.Contains(masked, "198.51.100.x") {
+		t.Fatalf("masked output = %q", masked)
+	}
+
+	buf.Reset()
+	l.SetMaskIPs(false)
+	l.Infof("detected %s", "198.51.100.1")
+	if !strings.Contains(buf.String(), "198.51.100.1") {
+		t.Fatalf("output = %q, want the address unmasked again", buf.String())
+	}
+}
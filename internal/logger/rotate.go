@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp added to rotated file names:
+// ip_updater.log becomes ip_updater-2024-01-02T15-04-05.000.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile is the log file writer: it starts a new file once the
+// current one reaches maxSize, removes rotated files older than maxAge and
+// optionally gzips them.
+type rotatingFile struct {
+	path     string
+	maxSize  int64         // bytes, 0 never rotates
+	maxAge   time.Duration // 0 keeps rotated files
+	compress bool
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	wg   sync.WaitGroup // background compression and cleanup
+}
+
+func openRotatingFile(path string, maxSizeMB, maxAgeDays int, compress bool) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:     path,
+		maxSize:  int64(maxSizeMB) << 20,
+		maxAge:   time.Duration(maxAgeDays) * 24 * time.Hour,
+		compress: compress,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate renames the current file with a timestamp and starts a new one
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(r.path)
+	backup := strings.TrimSuffix(r.path, ext) + "-" + time.Now().Format(backupTimeFormat) + ext
+	renameErr := os.Rename(r.path, backup)
+
+	// Keep logging even when the rename failed
+	if err := r.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if r.compress {
+			if err := compressFile(backup); err != nil {
+				fmt.Fprintf(os.Stderr, "log compression failed: %v\n", err)
+			}
+		}
+		r.removeExpired()
+	}()
+	return nil
+}
+
+// compressFile gzips path to path.gz and removes the original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
+
+// removeExpired deletes rotated files (compressed or not) older than maxAge
+func (r *rotatingFile) removeExpired() {
+	if r.maxAge <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(r.path)
+	prefix := filepath.Base(strings.TrimSuffix(r.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(r.path))
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-r.maxAge)
+	var expired []string
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, ".gz"), ext)
+		rotatedAt, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		if rotatedAt.Before(cutoff) {
+			expired = append(expired, filepath.Join(filepath.Dir(r.path), name))
+		}
+	}
+
+	for _, path := range expired {
+		os.Remove(path)
+	}
+}
+
+// Sync flushes the file to disk
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
+
+// Close closes the file after pending compression and cleanup finished
+func (r *rotatingFile) Close() error {
+	r.wg.Wait()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openTestFile opens a rotating log file in a temporary directory that
+// rotates after maxSize bytes
+func openTestFile(t *testing.T, maxSize int64, maxAgeDays int, compress bool) (*rotatingFile, string) {
+	t.Helper()
+	dir := t.TempDir()
+	r, err := openRotatingFile(filepath.Join(dir, "ip_updater.log"), 0, maxAgeDays, compress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.maxSize = maxSize
+	t.Cleanup(func() { r.Close() })
+	return r, dir
+}
+
+func rotatedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "ip_updater-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestRotateAtMaxSize(t *testing.T) {
+	r, dir := openTestFile(t, 16, 0, false)
+
+	r.Write([]byte("first line 0123\n"))
+	if files := rotatedFiles(t, dir); len(files) != 0 {
+		t.Fatalf("rotated before reaching the limit: %v", files)
+	}
+	r.Write([]byte("second line\n"))
+	r.Close()
+
+	files := rotatedFiles(t, dir)
+	if len(files) != 1 || !strings.HasSuffix(files[0], ".log") {
+		t.Fatalf("rotated files = %v, want one ip_updater-<time>.log", files)
+	}
+	if data, _ := os.ReadFile(files[0]); string(data) != "first line 0123\n" {
+		t.Fatalf("rotated file = %q, want the first line", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "ip_updater.log")); string(data) != "second line\n" {
+		t.Fatalf("current file = %q, want the second line", data)
+	}
+}
+
+func TestRotateCompresses(t *testing.T) {
+	r, dir := openTestFile(t, 16, 0, true)
+
+	r.Write([]byte("first line 0123\n"))
+	r.Write([]byte("second line\n"))
+	r.Close()
+
+	files := rotatedFiles(t, dir)
+	if len(files) != 1 || !strings.HasSuffix(files[0], ".log.gz") {
+		t.Fatalf("rotated files = %v, want only the gzipped one", files)
+	}
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != "first line 0123\n" {
+		t.Fatalf("decompressed = %q, want the first line", data)
+	}
+}
+
+func TestRemoveExpired(t *testing.T) {
+	r, dir := openTestFile(t, 0, 7, false)
+
+	stamp := func(age time.Duration) string {
+		return time.Now().Add(-age).Format(backupTimeFormat)
+	}
+	old := filepath.Join(dir, "ip_updater-"+stamp(8*24*time.Hour)+".log")
+	oldGz := filepath.Join(dir, "ip_updater-"+stamp(30*24*time.Hour)+".log.gz")
+	recent := filepath.Join(dir, "ip_updater-"+stamp(24*time.Hour)+".log")
+	unrelated := filepath.Join(dir, "ip_updater-notes.log")
+	for _, path := range []string{old, oldGz, recent, unrelated} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r.removeExpired()
+
+	for path, kept := range map[string]bool{old: false, oldGz: false, recent: true, unrelated: true} {
+		if _, err := os.Stat(path); (err == nil) != kept {
+			t.Errorf("%s: kept = %v, want %v", filepath.Base(path), err == nil, kept)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Log outputs (logging.output)
+const (
+	OutputFile     = "file"     // stdout, plus file_path when set
+	OutputSyslog   = "syslog"   // the local syslog daemon, with syslog severities
+	OutputJournald = "journald" // stdout with sd-daemon priority prefixes, for systemd services
+)
+
+// outputSeparator joins outputs in logging.output: "file,syslog" logs to
+// stdout and file_path and sends every entry to syslog as well
+const outputSeparator = ","
+
+// syslogTag identifies the program in syslog messages unless syslog_tag is
+// set
+const syslogTag = "ip_updater"
+
+// syslogFacilities are the facility names accepted in syslog_facility, with
+// their RFC 5424 codes shifted into place like log/syslog's LOG_* constants
+var syslogFacilities = map[string]int{
+	"kern":     0 << 3,
+	"user":     1 << 3,
+	"mail":     2 << 3,
+	"daemon":   3 << 3,
+	"auth":     4 << 3,
+	"syslog":   5 << 3,
+	"lpr":      6 << 3,
+	"news":     7 << 3,
+	"uucp":     8 << 3,
+	"cron":     9 << 3,
+	"authpriv": 10 << 3,
+	"ftp":      11 << 3,
+	"local0":   16 << 3,
+	"local1":   17 << 3,
+	"local2":   18 << 3,
+	"local3":   19 << 3,
+	"local4":   20 << 3,
+	"local5":   21 << 3,
+	"local6":   22 << 3,
+	"local7":   23 << 3,
+}
+
+// SyslogConfig selects the syslog daemon and how messages are labelled. The
+// zero value sends to the local daemon with the daemon facility and the
+// ip_updater tag.
+type SyslogConfig struct {
+	Network  string // udp, tcp or unix; empty for the local daemon
+	Address  string // "host:port" or a socket path, required with Network
+	Facility string // daemon when empty
+	Tag      string // ip_updater when empty
+}
+
+// Validate checks the settings without connecting
+func (c SyslogConfig) Validate() error {
+	switch c.Network {
+	case "":
+		if c.Address != "" {
+			return fmt.Errorf("logging.syslog_address needs logging.syslog_network")
+		}
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6", "unix", "unixgram":
+		if c.Address == "" {
+			return fmt.Errorf("logging.syslog_network %s needs logging.syslog_address", c.Network)
+		}
+	default:
+		return fmt.Errorf("invalid logging.syslog_network: %s (expected udp, tcp or unix)", c.Network)
+	}
+	if c.Facility != "" {
+		if _, ok := syslogFacilities[c.Facility]; !ok {
+			return fmt.Errorf("invalid logging.syslog_facility: %s", c.Facility)
+		}
+	}
+	return nil
+}
+
+// facility returns the facility code, daemon by default
+func (c SyslogConfig) facility() int {
+	if c.Facility == "" {
+		return syslogFacilities["daemon"]
+	}
+	return syslogFacilities[c.Facility]
+}
+
+// tag returns the tag, ip_updater by default
+func (c SyslogConfig) tag() string {
+	if c.Tag == "" {
+		return syslogTag
+	}
+	return c.Tag
+}
+
+// String describes the destination, facility and tag for log messages
+func (c SyslogConfig) String() string {
+	destination := "local"
+	if c.Network != "" {
+		destination = c.Network + " " + c.Address
+	}
+	facility := c.Facility
+	if facility == "" {
+		facility = "daemon"
+	}
+	return fmt.Sprintf("%s, facility %s, tag %s", destination, facility, c.tag())
+}
+
+// ValidateOutput checks logging.output: a single output, or file and syslog
+// joined by a comma
+func ValidateOutput(output string) error {
+	_, _, err := parseOutput(output)
+	return err
+}
+
+// parseOutput splits logging.output into the base output (OutputFile,
+// OutputSyslog or OutputJournald) and whether entries are also sent to
+// syslog, which only combines with OutputFile
+func parseOutput(output string) (base string, withSyslog bool, err error) {
+	switch output {
+	case "", OutputFile:
+		return OutputFile, false, nil
+	case OutputSyslog, OutputJournald:
+		return output, false, nil
+	}
+
+	parts := strings.Split(output, outputSeparator)
+	seen := make(map[string]bool)
+	for _, part := range parts {
+		seen[strings.TrimSpace(part)] = true
+	}
+	if len(parts) == 2 && len(seen) == 2 && seen[OutputFile] && seen[OutputSyslog] {
+		return OutputFile, true, nil
+	}
+	return "", false, fmt.Errorf("invalid logging.output: %s (expected %s, %s, %s or %s%s%s)",
+		output, OutputFile, OutputSyslog, OutputJournald, OutputFile, outputSeparator, OutputSyslog)
+}
+
+// closingHook is a hook holding a connection that is closed when the
+// logger is reconfigured
+type closingHook interface {
+	logrus.Hook
+	Close() error
+}
+
+// journaldFormatter prefixes every line with its sd-daemon priority
+// ("<3>" for errors, ...), which systemd reads from a service's stdout to set
+// the journal priority. The journal adds its own timestamp.
+type journaldFormatter struct {
+	logrus.TextFormatter
+}
+
+func (f *journaldFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	line, err := f.TextFormatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(fmt.Sprintf("<%d>", journaldPriority(entry.Level))), line...), nil
+}
+
+// journaldPriority maps a logrus level to the syslog severity used by the
+// syslog hook
+func journaldPriority(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return 2 // crit
+	case logrus.ErrorLevel:
+		return 3 // err
+	case logrus.WarnLevel:
+		return 4 // warning
+	case logrus.InfoLevel:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// newSyslogHook is not implemented where log/syslog isn't available
+func newSyslogHook(config SyslogConfig) (closingHook, error) {
+	return nil, fmt.Errorf("syslog output is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateOutput(t *testing.T) {
+	for _, output := range []string{"", "file", "syslog", "journald", "file,syslog", "syslog, file"} {
+		if err := ValidateOutput(output); err != nil {
+			t.Errorf("ValidateOutput(%q) = %v", output, err)
+		}
+	}
+	for _, output := range []string{"stdout", "file,journald", "file,file", "file,syslog,journald"} {
+		if err := ValidateOutput(output); err == nil || !strings.Contains(err.Error(), "invalid logging.output") {
+			t.Errorf("ValidateOutput(%q) = %v, want an error", output, err)
+		}
+	}
+}
+
+func TestSyslogConfigValidate(t *testing.T) {
+	tests := []struct {
+		config SyslogConfig
+		want   string
+	}{
+		{SyslogConfig{}, ""},
+		{SyslogConfig{Network: "udp", Address: "logs:514", Facility: "local3", Tag: "home"}, ""},
+		{SyslogConfig{Address: "logs:514"}, "needs logging.syslog_network"},
+		{SyslogConfig{Network: "tcp"}, "needs logging.syslog_address"},
+		{SyslogConfig{Network: "http", Address: "logs:514"}, "invalid logging.syslog_network"},
+		{SyslogConfig{Facility: "local8"}, "invalid logging.syslog_facility"},
+	}
+	for _, tt := range tests {
+		err := tt.config.Validate()
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("Validate(%+v) = %v, want %q", tt.config, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"log/syslog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syslogHook sends every entry to syslog with its severity. Entries are
+// formatted by the hook, without colors or timestamps (syslog adds its own),
+// so it can run next to the colored stdout and the file output.
+type syslogHook struct {
+	writer    *syslog.Writer
+	formatter logrus.Formatter
+}
+
+// newSyslogHook connects to the syslog daemon: the local one, or
+// config.Address over config.Network
+func newSyslogHook(config SyslogConfig) (closingHook, error) {
+	priority := syslog.Priority(config.facility()) | syslog.LOG_INFO
+	writer, err := syslog.Dial(config.Network, config.Address, priority, config.tag())
+	if err != nil {
+		return nil, err
+	}
+	return &syslogHook{
+		writer: writer,
+		formatter: &logrus.TextFormatter{
+			DisableColors:    true,
+			DisableTimestamp: true,
+		},
+	}, nil
+}
+
+func (h *syslogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *syslogHook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	message := string(line)
+	switch entry.Level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return h.writer.Crit(message)
+	case logrus.ErrorLevel:
+		return h.writer.Err(message)
+	case logrus.WarnLevel:
+		return h.writer.Warning(message)
+	case logrus.InfoLevel:
+		return h.writer.Info(message)
+	default:
+		return h.writer.Debug(message)
+	}
+}
+
+func (h *syslogHook) Close() error {
+	return h.writer.Close()
+}
@@ -0,0 +1,87 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenSyslog returns a UDP socket standing in for a syslog daemon
+func listenSyslog(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readSyslog(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestFileAndSyslogOutput(t *testing.T) {
+	conn := listenSyslog(t)
+	path := filepath.Join(t.TempDir(), "ip_updater.log")
+
+	l := New()
+	syslogConfig := SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), Facility: "local3", Tag: "iptest"}
+	if err := l.Configure("info", "file,syslog", path, 0, 0, false, syslogConfig); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.replaceHook(nil); l.replaceFile(nil) })
+	if !l.SyslogEnabled() {
+		t.Fatal("syslog not enabled with file,syslog")
+	}
+
+	l.WarnHighlightf("address changed")
+
+	// local3 (19) * 8 + warning (4)
+	message := readSyslog(t, conn)
+	if !strings.HasPrefix(message, "<156>") || !strings.Contains(message, "iptest[") || !strings.Contains(message, "address changed") {
+		t.Fatalf("syslog message = %q", message)
+	}
+	if strings.Contains(message, "\x1b[") {
+		t.Fatalf("syslog message has ANSI colors: %q", message)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "address changed") {
+		t.Fatalf("log file = %q, want the entry there too", data)
+	}
+}
+
+func TestReconfigureDropsSyslog(t *testing.T) {
+	conn := listenSyslog(t)
+
+	l := New()
+	if err := l.Configure("info", "syslog", "", 0, 0, false, SyslogConfig{Network: "udp", Address: conn.LocalAddr().String()}); err != nil {
+		t.Fatal(err)
+	}
+	l.Errorf("failed")
+	if message := readSyslog(t, conn); !strings.HasPrefix(message, "<27>") || !strings.Contains(message, "ip_updater[") {
+		t.Fatalf("syslog message = %q, want daemon.err with the default tag", message)
+	}
+
+	if err := l.Configure("info", "file", "", 0, 0, false, SyslogConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if l.SyslogEnabled() {
+		t.Fatal("syslog still enabled after switching to file output")
+	}
+}
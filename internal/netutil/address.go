@@ -0,0 +1,26 @@
+package netutil
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ErrUnusableAddress is returned for an address that must never be
+// written to DNS records or files
+var ErrUnusableAddress = errors.New("refusing to apply an unusable address")
+
+// CheckUsableAddress rejects an empty or unspecified (0.0.0.0, ::) address.
+// Detection already validates what it returns; this is the last check
+// before anything is written, so a bug there can't take services offline.
+func CheckUsableAddress(ip string) error {
+	trimmed := strings.TrimSpace(ip)
+	if trimmed == "" {
+		return fmt.Errorf("%w: empty address", ErrUnusableAddress)
+	}
+	if parsed := net.ParseIP(trimmed); parsed != nil && parsed.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrUnusableAddress, trimmed)
+	}
+	return nil
+}
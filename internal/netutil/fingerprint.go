@@ -0,0 +1,31 @@
+package netutil
+
+import "fmt"
+
+// Network fingerprint kinds, see NetworkFingerprint
+const (
+	// FingerprintGateway identifies the network by the default gateway's
+	// address and MAC (Linux only)
+	FingerprintGateway = "gateway"
+	// FingerprintInterface identifies the network by the interface and
+	// source address of the default route
+	FingerprintInterface = "interface"
+)
+
+// NetworkFingerprint returns a short string that changes when the host
+// moves to another network, e.g. a laptop switching from home Wi-Fi to a
+// hotspot. Computing it sends no packets.
+func NetworkFingerprint(kind string) (string, error) {
+	switch kind {
+	case FingerprintGateway:
+		return gatewayFingerprint()
+	case FingerprintInterface:
+		addr, err := DefaultRouteAddr()
+		if err != nil {
+			return "", err
+		}
+		return InterfaceName(addr) + " " + addr, nil
+	default:
+		return "", fmt.Errorf("unknown network fingerprint: %s", kind)
+	}
+}
@@ -0,0 +1,84 @@
+//go:build linux
+
+package netutil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GatewayFingerprintSupported reports whether FingerprintGateway works on
+// this platform
+const GatewayFingerprintSupported = true
+
+// gatewayFingerprint reads the IPv4 default gateway from /proc/net/route and
+// its MAC from the ARP table. The MAC tells apart networks that use the same
+// gateway address (192.168.1.1 at home and in the office); it is left out
+// while the gateway isn't in the ARP table yet.
+func gatewayFingerprint() (string, error) {
+	iface, gateway, err := defaultGateway()
+	if err != nil {
+		return "", err
+	}
+
+	fingerprint := iface + " " + gateway
+	if mac := arpLookup(gateway, iface); mac != "" {
+		fingerprint += " " + mac
+	}
+	return fingerprint, nil
+}
+
+func defaultGateway() (string, string, error) {
+	file, err := os.Open("/proc/net/route")
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[1] != "00000000" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&0x2 == 0 { // RTF_GATEWAY
+			continue
+		}
+		// The address is printed as the in-memory value in host byte order
+		value, err := strconv.ParseUint(fields[2], 16, 32)
+		if err != nil {
+			continue
+		}
+		ip := make(net.IP, 4)
+		binary.NativeEndian.PutUint32(ip, uint32(value))
+		return fields[0], ip.String(), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+	return "", "", fmt.Errorf("no default gateway")
+}
+
+func arpLookup(ip, iface string) string {
+	data, err := os.ReadFile("/proc/net/arp")
+	if err != nil {
+		return ""
+	}
+
+	// IP address, HW type, Flags, HW address, Mask, Device
+	for _, line := range strings.Split(string(data), "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) >= 6 && fields[0] == ip && fields[5] == iface && fields[3] != "00:00:00:00:00:00" {
+			return fields[3]
+		}
+	}
+	return ""
+}
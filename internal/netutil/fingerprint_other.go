@@ -0,0 +1,16 @@
+//go:build !linux
+
+package netutil
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// GatewayFingerprintSupported reports whether FingerprintGateway works on
+// this platform
+const GatewayFingerprintSupported = false
+
+func gatewayFingerprint() (string, error) {
+	return "", fmt.Errorf("gateway fingerprint is not supported on %s", runtime.GOOS)
+}
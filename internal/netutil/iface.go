@@ -0,0 +1,166 @@
+package netutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger receives the interface availability changes of an InterfaceDialer
+type Logger interface {
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+// InterfaceDialer dials from the current address of a network interface. The
+// address is looked up on every dial, so it follows DHCP and PPPoE changes.
+// While the interface is missing or has no address of the family being
+// dialed, connections are made unbound (the system default route) and the
+// change is logged once.
+type InterfaceDialer struct {
+	name   string
+	dialer net.Dialer
+	logger Logger
+
+	mu       sync.Mutex
+	bound    string // address last dialed from
+	degraded bool   // the last dial couldn't use the interface
+}
+
+func NewInterfaceDialer(name string) *InterfaceDialer {
+	return &InterfaceDialer{
+		name: name,
+		dialer: net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+	}
+}
+
+func (d *InterfaceDialer) SetLogger(logger Logger) {
+	d.logger = logger
+}
+
+// DialContext connects from the interface's address of network's family
+func (d *InterfaceDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	ip, err := InterfaceAddr(d.name, network)
+	d.report(ip, err)
+
+	dialer := d.dialer
+	if err == nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	return dialer.DialContext(ctx, network, address)
+}
+
+// report logs when the interface becomes unusable, and when it is back or
+// its address changed
+func (d *InterfaceDialer) report(ip net.IP, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err != nil {
+		if !d.degraded && d.logger != nil {
+			d.logger.Warnf("⚠️ 网卡 %s 当前不可用，暂不绑定网卡发起请求: %v", d.name, err)
+		}
+		d.degraded = true
+		return
+	}
+
+	addr := ip.String()
+	if (d.degraded || addr != d.bound) && d.logger != nil {
+		d.logger.Infof("🔌 通过网卡 %s 发起请求，源地址: %s", d.name, addr)
+	}
+	d.degraded = false
+	d.bound = addr
+}
+
+// Transport returns an HTTP transport that dials through the interface.
+// forceNetwork, when not empty, replaces the network of every dial, e.g.
+// "tcp6" so a dual-stack host is only reached over IPv6.
+func (d *InterfaceDialer) Transport(forceNetwork string) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if forceNetwork != "" {
+			network = forceNetwork
+		}
+		return d.DialContext(ctx, network, address)
+	}
+	return transport
+}
+
+// ValidateInterface checks that the network interface exists
+func ValidateInterface(name string) error {
+	if _, err := net.InterfaceByName(name); err != nil {
+		return fmt.Errorf("network interface %s not found: %w", name, err)
+	}
+	return nil
+}
+
+// InterfaceAddr returns the address of the named interface to dial network
+// ("tcp4", "tcp6" or "tcp", which prefers IPv4) from
+func InterfaceAddr(name, network string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if iface.Flags&net.FlagUp == 0 {
+		return nil, fmt.Errorf("interface %s is down", name)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	ip := selectInterfaceAddr(addrs, network)
+	if ip == nil {
+		return nil, fmt.Errorf("interface %s has no usable %s address", name, familyName(network))
+	}
+	return ip, nil
+}
+
+// selectInterfaceAddr picks the source address for network among an
+// interface's addresses. Link-local addresses are skipped: they can't reach
+// a detection endpoint. "tcp" takes the first IPv4 address, or the first
+// IPv6 one when there is none.
+func selectInterfaceAddr(addrs []net.Addr, network string) net.IP {
+	var ipv4, ipv6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			if ipv4 == nil {
+				ipv4 = ip4
+			}
+		} else if ipv6 == nil {
+			ipv6 = ipNet.IP
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(network, "4"):
+		return ipv4
+	case strings.HasSuffix(network, "6"):
+		return ipv6
+	case ipv4 != nil:
+		return ipv4
+	default:
+		return ipv6
+	}
+}
+
+func familyName(network string) string {
+	switch {
+	case strings.HasSuffix(network, "4"):
+		return "IPv4"
+	case strings.HasSuffix(network, "6"):
+		return "IPv6"
+	}
+	return "IP"
+}
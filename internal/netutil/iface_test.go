@@ -0,0 +1,129 @@
+package netutil
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordingLogger struct {
+	infos, warnings []string
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.infos = append(l.infos, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Warnf(format string, args ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+func ipNet(s string) *net.IPNet {
+	ip, ipnet, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	ipnet.IP = ip
+	return ipnet
+}
+
+func TestSelectInterfaceAddr(t *testing.T) {
+	dual := []net.Addr{
+		ipNet("fe80::1/64"),
+		ipNet("2001:db8::1/64"),
+		ipNet("169.254.0.1/16"),
+		ipNet("192.0.2.1/24"),
+		ipNet("192.0.2.2/24"),
+	}
+	v6only := []net.Addr{ipNet("fe80::1/64"), ipNet("2001:db8::1/64")}
+
+	tests := []struct {
+		addrs   []net.Addr
+		network string
+		want    string
+	}{
+		{dual, "tcp4", "192.0.2.1"},
+		{dual, "tcp6", "2001:db8::1"},
+		{dual, "tcp", "192.0.2.1"},
+		{v6only, "tcp", "2001:db8::1"},
+		{v6only, "tcp4", "<nil>"},
+		{[]net.Addr{ipNet("fe80::1/64")}, "tcp6", "<nil>"},
+	}
+	for _, tt := range tests {
+		if got := selectInterfaceAddr(tt.addrs, tt.network).String(); got != tt.want {
+			t.Errorf("selectInterfaceAddr(%v, %s) = %s, want %s", tt.addrs, tt.network, got, tt.want)
+		}
+	}
+}
+
+func loopbackName(t *testing.T) string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 && iface.Flags&net.FlagUp != 0 {
+			return iface.Name
+		}
+	}
+	t.Skip("no loopback interface")
+	return ""
+}
+
+func newRemoteAddrServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		fmt.Fprint(w, host)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// get returns the address the request was made from
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestInterfaceDialerBindsInterfaceAddress(t *testing.T) {
+	server := newRemoteAddrServer(t)
+	log := &recordingLogger{}
+	d := NewInterfaceDialer(loopbackName(t))
+	d.SetLogger(log)
+	client := &http.Client{Transport: d.Transport("tcp4")}
+
+	get(t, client, server.URL)
+	if from := get(t, client, server.URL); from != "127.0.0.1" {
+		t.Fatalf("request made from %s, want 127.0.0.1", from)
+	}
+	if len(log.infos) != 1 || len(log.warnings) != 0 {
+		t.Fatalf("infos = %q, warnings = %q, want the bound address logged once", log.infos, log.warnings)
+	}
+}
+
+func TestInterfaceDialerFallsBackWhenMissing(t *testing.T) {
+	server := newRemoteAddrServer(t)
+	log := &recordingLogger{}
+	d := NewInterfaceDialer("ipupdater-test0")
+	d.SetLogger(log)
+	client := &http.Client{Transport: d.Transport("")}
+
+	get(t, client, server.URL)
+	get(t, client, server.URL)
+
+	if len(log.warnings) != 1 || len(log.infos) != 0 {
+		t.Fatalf("infos = %q, warnings = %q, want one warning", log.infos, log.warnings)
+	}
+	if !d.degraded {
+		t.Fatal("dialer not marked degraded")
+	}
+}
@@ -0,0 +1,111 @@
+package netutil
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// LocalAddrAuto makes outbound requests follow the current default route:
+// the source address is re-resolved periodically and the shared transport is
+// rebound when the active uplink changes.
+const LocalAddrAuto = "auto"
+
+// routeProbeAddr is only used to ask the kernel which source address it
+// would pick; connecting a UDP socket sends no packets.
+const routeProbeAddr = "8.8.8.8:53"
+
+var autoState struct {
+	mu      sync.Mutex
+	enabled bool
+	addr    string
+}
+
+// DefaultRouteAddr returns the source address the routing table selects for
+// public destinations, i.e. the address of the default route's interface
+func DefaultRouteAddr() (string, error) {
+	conn, err := net.Dial("udp", routeProbeAddr)
+	if err != nil {
+		return "", fmt.Errorf("no default route: %w", err)
+	}
+	defer conn.Close()
+
+	udpAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok || udpAddr.IP.IsUnspecified() {
+		return "", fmt.Errorf("no default route")
+	}
+	return udpAddr.IP.String(), nil
+}
+
+// InterfaceName returns the name of the interface that holds the address,
+// or an empty string when it can't be determined
+func InterfaceName(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return ""
+	}
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+
+	for _, iface := range interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return iface.Name
+			}
+		}
+	}
+	return ""
+}
+
+// RefreshDefaultRoute re-resolves the default route in auto mode and rebinds
+// the shared transport when the source address changed. It reports whether
+// the route changed and the address now in use; outside auto mode it does
+// nothing.
+func RefreshDefaultRoute() (bool, string, error) {
+	autoState.mu.Lock()
+	defer autoState.mu.Unlock()
+
+	if !autoState.enabled {
+		return false, "", nil
+	}
+
+	addr, err := DefaultRouteAddr()
+	if err != nil {
+		return false, autoState.addr, err
+	}
+	if addr == autoState.addr {
+		return false, addr, nil
+	}
+
+	bindTransport(addr)
+	autoState.addr = addr
+	return true, addr, nil
+}
+
+// setAutoMode enables or disables route following. When enabling, the
+// transport is bound to the current default route address if one exists;
+// otherwise it stays unbound until RefreshDefaultRoute finds a route.
+func setAutoMode(enabled bool) {
+	autoState.mu.Lock()
+	defer autoState.mu.Unlock()
+
+	autoState.enabled = enabled
+	autoState.addr = ""
+	if !enabled {
+		return
+	}
+
+	if addr, err := DefaultRouteAddr(); err == nil {
+		bindTransport(addr)
+		autoState.addr = addr
+		return
+	}
+	bindTransport("")
+}
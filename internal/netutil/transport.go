@@ -0,0 +1,159 @@
+package netutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// current holds the transport every outbound request goes through. It is
+// replaced as a whole when the settings change, so requests in flight keep
+// using the transport they started with.
+var current atomic.Pointer[http.Transport]
+
+func init() {
+	current.Store(newTransport(nil))
+}
+
+type sharedTransport struct{}
+
+// Transport returns the shared round tripper used by IP detection and all
+// DNS providers. It always delegates to the most recently configured
+// transport.
+func Transport() http.RoundTripper {
+	return sharedTransport{}
+}
+
+func (sharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return current.Load().RoundTrip(req)
+}
+
+// DialContext connects like the shared transport does, i.e. from local_addr
+// when one is set
+func DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return current.Load().DialContext(ctx, network, address)
+}
+
+// CloneTransport returns a copy of the shared transport for requests that
+// need their own TLS or connection settings. It keeps the local_addr the
+// shared transport was bound to when it was made.
+func CloneTransport() *http.Transport {
+	return current.Load().Clone()
+}
+
+// ProxyTransport returns a round tripper like Transport that sends requests
+// through the given proxy instead of the one from the environment
+func ProxyTransport(proxy *url.URL) http.RoundTripper {
+	return proxyTransport{proxy: proxy}
+}
+
+type proxyTransport struct {
+	proxy *url.URL
+}
+
+// Proxied transports are clones of the current shared transport, rebuilt
+// after it is replaced so they follow local_addr too
+var (
+	proxiedMu   sync.Mutex
+	proxiedBase *http.Transport
+	proxied     map[string]*http.Transport
+)
+
+func (t proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return proxiedTransport(t.proxy).RoundTrip(req)
+}
+
+func proxiedTransport(proxy *url.URL) *http.Transport {
+	proxiedMu.Lock()
+	defer proxiedMu.Unlock()
+
+	base := current.Load()
+	if base != proxiedBase {
+		for _, transport := range proxied {
+			transport.CloseIdleConnections()
+		}
+		proxiedBase = base
+		proxied = make(map[string]*http.Transport)
+	}
+
+	key := proxy.String()
+	transport, ok := proxied[key]
+	if !ok {
+		transport = base.Clone()
+		transport.Proxy = http.ProxyURL(proxy)
+		proxied[key] = transport
+	}
+	return transport
+}
+
+// SetLocalAddr makes outbound connections use the given source address. An
+// empty address restores the system default, and LocalAddrAuto follows the
+// default route (see RefreshDefaultRoute).
+func SetLocalAddr(localAddr string) error {
+	if localAddr == LocalAddrAuto {
+		setAutoMode(true)
+		return nil
+	}
+
+	if localAddr != "" {
+		if err := ValidateLocalAddr(localAddr); err != nil {
+			return err
+		}
+	}
+
+	setAutoMode(false)
+	bindTransport(localAddr)
+	return nil
+}
+
+// bindTransport replaces the shared transport with one bound to the source
+// address (unbound when empty) and drops the idle connections of the old one
+func bindTransport(localAddr string) {
+	var addr *net.TCPAddr
+	if localAddr != "" {
+		addr = &net.TCPAddr{IP: net.ParseIP(localAddr)}
+	}
+
+	previous := current.Swap(newTransport(addr))
+	previous.CloseIdleConnections()
+}
+
+// ValidateLocalAddr checks that the address is an IP assigned to this host
+func ValidateLocalAddr(localAddr string) error {
+	ip := net.ParseIP(localAddr)
+	if ip == nil {
+		return fmt.Errorf("invalid local address: %s", localAddr)
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return fmt.Errorf("failed to list interface addresses: %w", err)
+	}
+
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("local address %s is not assigned to this host", localAddr)
+}
+
+func newTransport(localAddr *net.TCPAddr) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if localAddr != nil {
+		dialer.LocalAddr = localAddr
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return transport
+}
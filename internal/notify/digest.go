@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"ip-updater/internal/schedule"
+)
+
+// EventDigest carries the events a digest webhook collected since its last
+// digest; like EventSummary its Events lists them and Summary has one line
+// per event
+const EventDigest = "digest"
+
+// digestState collects the events of a webhook with digest or
+// digest_max_events instead of sending them one by one
+type digestState struct {
+	schedule  *schedule.Cron // nil: sent by count only
+	maxEvents int
+
+	mu     sync.Mutex
+	events []Event
+	timer  *time.Timer
+}
+
+func newDigest(config WebhookConfig) (*digestState, error) {
+	if config.Digest == "" && config.DigestMaxEvents == 0 {
+		return nil, nil
+	}
+	if config.DigestMaxEvents < 0 {
+		return nil, fmt.Errorf("invalid digest_max_events: %d", config.DigestMaxEvents)
+	}
+
+	d := &digestState{maxEvents: config.DigestMaxEvents}
+	if config.Digest != "" {
+		cron, err := schedule.ParseCron(config.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("digest: %w", err)
+		}
+		d.schedule = cron
+	}
+	return d, nil
+}
+
+// collect adds the event to the webhook's digest, sending the digest when
+// it reached digest_max_events
+func (n *Notifier) collect(w *webhook, event Event) {
+	d := w.digest
+	d.mu.Lock()
+	d.events = append(d.events, event)
+	full := d.maxEvents > 0 && len(d.events) >= d.maxEvents
+	if !full && d.timer == nil && d.schedule != nil {
+		d.timer = time.AfterFunc(time.Until(d.schedule.Next(time.Now())), func() { n.flushDigest(w) })
+	}
+	d.mu.Unlock()
+
+	if full {
+		n.flushDigest(w)
+	}
+}
+
+// flushDigest sends what the webhook collected as one digest event
+func (n *Notifier) flushDigest(w *webhook) {
+	events := w.digest.take()
+	if len(events) == 0 {
+		return
+	}
+	if n.logger != nil {
+		n.logger.Infof("📬 发送通知汇总 (%s): %d 条", w.name, len(events))
+	}
+	n.sendTo(w, summaryEvent(EventDigest, events, n.hostname))
+}
+
+// take empties the digest and stops its timer
+func (d *digestState) take() []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	events := d.events
+	d.events = nil
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	return events
+}
+
+// takeOverDigests moves the events collected by old's digest webhooks to the
+// webhook of the same name in n. Webhooks that no longer collect a digest
+// get theirs right away.
+func (n *Notifier) takeOverDigests(old *Notifier) {
+	for _, oldWebhook := range old.webhooks {
+		if oldWebhook.digest == nil {
+			continue
+		}
+		events := oldWebhook.digest.take()
+		if len(events) == 0 {
+			continue
+		}
+
+		var target *webhook
+		for _, w := range n.webhooks {
+			if w.name == oldWebhook.name && w.digest != nil {
+				target = w
+			}
+		}
+		if target == nil {
+			n.sendTo(oldWebhook, summaryEvent(EventDigest, events, n.hostname))
+			continue
+		}
+
+		// Re-added in order; digest_max_events and the schedule apply as usual
+		for _, event := range events {
+			n.collect(target, event)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"ip-updater/internal/schedule"
+)
+
+// Event types sent to notifiers
+const (
+	EventDNSUpdate  = "dns_update"
+	EventFileUpdate = "file_update"
+	// EventStart is sent once after the startup detection when
+	// notify_on_start is enabled
+	EventStart = "start"
+	// EventDetectionFailed is sent once public IP detection has failed
+	// failure_alert_after times in a row, EventDetectionRecovered when it
+	// succeeds again after that
+	EventDetectionFailed    = "detection_failed"
+	EventDetectionRecovered = "detection_recovered"
+	// EventUnreachable is sent when the external reachability check finds
+	// ports on the detected IP closed from outside, EventReachable when they
+	// are open again
+	EventUnreachable = "unreachable"
+	EventReachable   = "reachable"
+	// EventFallback is sent when DNS updaters switch to their fallback_ip
+	// after detection has been failing for fallback_after,
+	// EventFallbackEnded when they are switched back to the detected IP
+	EventFallback      = "fallback"
+	EventFallbackEnded = "fallback_ended"
+)
+
+// Config is the [notify] section of the configuration file
+type Config struct {
+	NotifyOnStart bool            `toml:"notify_on_start"` // 启动检测完成后发送一次 start 通知
+	Webhooks      []WebhookConfig `toml:"webhook"`
+
+	// Quiet hours: notifications in these daily windows are held back and
+	// sent as one summary when the window ends (see quiet.go)
+	QuietHours        []string `toml:"quiet_hours"`         // 如 ["23:00-07:00"]
+	QuietSummary      *bool    `toml:"quiet_summary"`       // false: 丢弃静默时段内的通知，不发送汇总
+	QuietSendFailures *bool    `toml:"quiet_send_failures"` // false: 失败告警也推迟到汇总中
+}
+
+// Validate checks the quiet hours and every webhook, including that its
+// body template parses
+func (c Config) Validate() error {
+	if _, err := quietGate(c.QuietHours); err != nil {
+		return err
+	}
+	for i, webhook := range c.Webhooks {
+		if err := webhook.validate(); err != nil {
+			name := webhook.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return fmt.Errorf("webhook %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Result is the outcome of one updater during an update pass
+type Result struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"` // dns or file
+	Provider string `json:"provider,omitempty"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Event is the change context rendered into notification payloads
+type Event struct {
+	Type      string    `json:"type"`
+	OldIP     string    `json:"old_ip"`
+	NewIP     string    `json:"new_ip"`
+	OldIPv6   string    `json:"old_ipv6,omitempty"` // only with AAAA or A+AAAA records
+	NewIPv6   string    `json:"new_ipv6,omitempty"`
+	Success   bool      `json:"success"`
+	Results   []Result  `json:"results"`
+	Error     string    `json:"error,omitempty"`    // detection error, start, detection_failed and unreachable events
+	Failures  int       `json:"failures,omitempty"` // consecutive failed detections, detection_* events
+	Hostname  string    `json:"hostname"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// Events and Summary are only set on summary events
+	Events  []Event `json:"events,omitempty"`
+	Summary string  `json:"summary,omitempty"`
+}
+
+type Logger interface {
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+// Notifier delivers events to all configured webhooks. Deliveries run in the
+// background so a slow endpoint never holds up the update loop.
+type Notifier struct {
+	webhooks []*webhook
+	logger   Logger
+	hostname string
+	wg       sync.WaitGroup
+
+	realtime bool // some webhook sends events as they happen
+
+	quiet             *schedule.Gate
+	quietSummary      bool
+	quietSendFailures bool
+
+	mu         sync.Mutex
+	queued     []Event // held back during quiet hours
+	quietTimer *time.Timer
+}
+
+func New(config Config) (*Notifier, error) {
+	n := &Notifier{
+		quietSummary:      config.QuietSummary == nil || *config.QuietSummary,
+		quietSendFailures: config.QuietSendFailures == nil || *config.QuietSendFailures,
+	}
+	n.hostname, _ = os.Hostname()
+
+	var err error
+	if n.quiet, err = quietGate(config.QuietHours); err != nil {
+		return nil, err
+	}
+
+	for _, webhookConfig := range config.Webhooks {
+		w, err := newWebhook(webhookConfig)
+		if err != nil {
+			return nil, err
+		}
+		n.webhooks = append(n.webhooks, w)
+		if w.digest == nil {
+			n.realtime = true
+		}
+	}
+
+	return n, nil
+}
+
+func (n *Notifier) SetLogger(logger Logger) {
+	n.logger = logger
+}
+
+// Enabled reports whether any notification target is configured
+func (n *Notifier) Enabled() bool {
+	return n != nil && len(n.webhooks) > 0
+}
+
+// Notify fills in the common fields and sends the event to every webhook.
+// Digest webhooks collect it for their next digest; the others may hold it
+// back during quiet hours.
+func (n *Notifier) Notify(event Event) {
+	if !n.Enabled() {
+		return
+	}
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if event.Hostname == "" {
+		event.Hostname = n.hostname
+	}
+
+	for _, w := range n.webhooks {
+		if w.digest != nil {
+			n.collect(w, event)
+		}
+	}
+	if !n.realtime {
+		return
+	}
+
+	if n.holdBack(event) {
+		return
+	}
+	n.deliver(event)
+}
+
+// deliver sends the event to every webhook without a digest
+func (n *Notifier) deliver(event Event) {
+	for _, w := range n.webhooks {
+		if w.digest == nil {
+			n.sendTo(w, event)
+		}
+	}
+}
+
+// sendTo sends the event to one webhook in the background
+func (n *Notifier) sendTo(w *webhook, event Event) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+
+		if err := w.send(event); err != nil {
+			if n.logger != nil {
+				n.logger.Warnf("⚠️ Webhook通知发送失败 (%s): %v", w.name, err)
+			}
+			return
+		}
+		if n.logger != nil {
+			n.logger.Infof("📨 Webhook通知已发送: %s (%s)", w.name, event.Type)
+		}
+	}()
+}
+
+// Wait blocks until every notification sent so far has been delivered or
+// has failed
+func (n *Notifier) Wait() {
+	if n == nil {
+		return
+	}
+	n.wg.Wait()
+}
+
+// WaitContext is Wait giving up when ctx is done; it reports whether every
+// notification finished
+func (n *Notifier) WaitContext(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		n.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
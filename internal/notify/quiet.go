@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ip-updater/internal/schedule"
+)
+
+// EventSummary carries the notifications held back during quiet hours,
+// sent when the quiet hours end. Its Events lists them in order and Summary
+// has one line per event for chat webhooks.
+const EventSummary = "summary"
+
+// quietGate builds the gate that is closed during quiet_hours, or nil
+// without quiet hours
+func quietGate(quietHours []string) (*schedule.Gate, error) {
+	if len(quietHours) == 0 {
+		return nil, nil
+	}
+
+	gate, err := schedule.NewGate(nil, quietHours)
+	if err != nil {
+		return nil, fmt.Errorf("quiet_hours: %w", err)
+	}
+	if gate.NextOpen(time.Now()).IsZero() {
+		return nil, fmt.Errorf("quiet_hours cover the whole day")
+	}
+	return gate, nil
+}
+
+// holdBack queues the event when it falls in quiet hours, and reports
+// whether it did. Failure alerts are still sent right away unless
+// quiet_send_failures = false.
+func (n *Notifier) holdBack(event Event) bool {
+	if n.quiet == nil || n.quiet.Allows(event.Timestamp) {
+		return false
+	}
+	if !event.Success && n.quietSendFailures {
+		return false
+	}
+	if !n.quietSummary {
+		if n.logger != nil {
+			n.logger.Infof("🔕 静默时段，不发送通知: %s", event.Type)
+		}
+		return true
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.queued = append(n.queued, event)
+	if n.quietTimer == nil {
+		end := n.quiet.NextOpen(event.Timestamp)
+		n.quietTimer = time.AfterFunc(time.Until(end), n.Flush)
+	}
+	if n.logger != nil {
+		n.logger.Infof("🔕 静默时段，通知将在结束后汇总发送: %s", event.Type)
+	}
+	return true
+}
+
+// Flush sends the notifications held back so far as one summary, e.g. when
+// the quiet hours end. On shutdown, FlushAll also sends the digests.
+func (n *Notifier) Flush() {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	queued := n.queued
+	n.queued = nil
+	if n.quietTimer != nil {
+		n.quietTimer.Stop()
+		n.quietTimer = nil
+	}
+	n.mu.Unlock()
+
+	if len(queued) > 0 {
+		n.deliver(summaryEvent(EventSummary, queued, n.hostname))
+	}
+}
+
+// FlushAll sends the quiet hours summary and every pending digest, so
+// nothing collected is lost on shutdown
+func (n *Notifier) FlushAll() {
+	if n == nil {
+		return
+	}
+	n.Flush()
+	for _, w := range n.webhooks {
+		if w.digest != nil {
+			n.flushDigest(w)
+		}
+	}
+}
+
+// TakeOver moves the notifications another notifier is holding back or
+// collecting for digests to n, so a config reload doesn't lose them
+func (n *Notifier) TakeOver(old *Notifier) {
+	if n == nil || old == nil || old == n {
+		return
+	}
+	n.takeOverDigests(old)
+
+	old.mu.Lock()
+	queued := old.queued
+	old.queued = nil
+	if old.quietTimer != nil {
+		old.quietTimer.Stop()
+		old.quietTimer = nil
+	}
+	old.mu.Unlock()
+
+	if len(queued) == 0 {
+		return
+	}
+	if !n.Enabled() || n.quiet == nil || n.quiet.Allows(time.Now()) {
+		// Quiet hours were removed or have ended with the new config
+		n.deliver(summaryEvent(EventSummary, queued, n.hostname))
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.queued = append(queued, n.queued...)
+	if n.quietTimer == nil {
+		n.quietTimer = time.AfterFunc(time.Until(n.quiet.NextOpen(time.Now())), n.Flush)
+	}
+}
+
+// summaryEvent combines queued events into one summary or digest event: the
+// addresses go from the first old to the last new ones, and it succeeds
+// when all of them did
+func summaryEvent(eventType string, events []Event, hostname string) Event {
+	summary := Event{
+		Type:      eventType,
+		Success:   true,
+		Events:    events,
+		Hostname:  hostname,
+		Timestamp: time.Now(),
+	}
+
+	var lines []string
+	for _, event := range events {
+		if event.NewIP != "" {
+			if summary.OldIP == "" {
+				summary.OldIP = event.OldIP
+			}
+			summary.NewIP = event.NewIP
+		}
+		if event.NewIPv6 != "" {
+			if summary.OldIPv6 == "" {
+				summary.OldIPv6 = event.OldIPv6
+			}
+			summary.NewIPv6 = event.NewIPv6
+		}
+		if !event.Success {
+			summary.Success = false
+		}
+		lines = append(lines, summaryLine(event))
+	}
+	summary.Summary = strings.Join(lines, "\n")
+
+	return summary
+}
+
+func summaryLine(event Event) string {
+	status := "ok"
+	if !event.Success {
+		status = "failed"
+	}
+
+	line := fmt.Sprintf("%s %s %s", event.Timestamp.Format("01-02 15:04"), event.Type, status)
+	if event.NewIP != "" {
+		line += fmt.Sprintf(": %s -> %s", event.OldIP, event.NewIP)
+	}
+	if event.Error != "" {
+		line += " (" + event.Error + ")"
+	}
+	return line
+}
@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"ip-updater/internal/netutil"
+)
+
+const defaultWebhookTimeout = 10
+
+// DefaultBodyTemplate renders the whole event as JSON
+const DefaultBodyTemplate = `{"type":{{json .Type}},"old_ip":{{json .OldIP}},"new_ip":{{json .NewIP}},` +
+	`{{if .NewIPv6}}"old_ipv6":{{json .OldIPv6}},"new_ipv6":{{json .NewIPv6}},{{end}}` +
+	`"success":{{json .Success}},{{if .Error}}"error":{{json .Error}},{{end}}{{if .Failures}}"failures":{{json .Failures}},{{end}}"hostname":{{json .Hostname}},` +
+	`{{if .Events}}"summary":{{json .Summary}},"events":{{json .Events}},{{end}}` +
+	`"timestamp":{{json .Timestamp}},"results":{{json .Results}}}`
+
+type WebhookConfig struct {
+	Name         string            `toml:"name"`
+	URL          string            `toml:"url"`
+	Method       string            `toml:"method"`        // 默认 POST
+	ContentType  string            `toml:"content_type"`  // 默认 application/json
+	BodyTemplate string            `toml:"body_template"` // Go text/template，为空时使用默认JSON
+	Headers      map[string]string `toml:"headers"`
+	Timeout      int               `toml:"timeout"` // seconds
+
+	// Digest collects events and sends them as one digest event on a cron
+	// schedule and/or once DigestMaxEvents have been collected (see digest.go)
+	Digest          string `toml:"digest"`            // cron表达式，如 "0 8 * * *"
+	DigestMaxEvents int    `toml:"digest_max_events"` // 累计达到此条数时提前发送
+}
+
+func (c WebhookConfig) validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if parsed, err := url.Parse(c.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("invalid url: %s", c.URL)
+	}
+
+	if _, err := newDigest(c); err != nil {
+		return err
+	}
+
+	_, err := parseBodyTemplate(c.BodyTemplate)
+	return err
+}
+
+var templateFuncs = template.FuncMap{
+	// json encodes a value so it can be embedded in a JSON payload safely
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+// parseBodyTemplate parses the body template and renders it once with a
+// sample event, so mistakes are reported when the config is loaded
+func parseBodyTemplate(body string) (*template.Template, error) {
+	if body == "" {
+		body = DefaultBodyTemplate
+	}
+
+	tmpl, err := template.New("body").Funcs(templateFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body_template: %w", err)
+	}
+
+	if err := tmpl.Execute(io.Discard, sampleEvent()); err != nil {
+		return nil, fmt.Errorf("invalid body_template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func sampleEvent() Event {
+	return Event{
+		Type:    EventDNSUpdate,
+		OldIP:   "192.0.2.1",
+		NewIP:   "192.0.2.2",
+		Success: true,
+		Results: []Result{
+			{Name: "example", Kind: "dns", Provider: "cloudflare", Success: true},
+		},
+		Hostname:  "localhost",
+		Timestamp: time.Now(),
+	}
+}
+
+type webhook struct {
+	name        string
+	url         string
+	method      string
+	contentType string
+	headers     map[string]string
+	body        *template.Template
+	client      *http.Client
+	digest      *digestState // nil: events are sent as they happen
+}
+
+func newWebhook(config WebhookConfig) (*webhook, error) {
+	body, err := parseBodyTemplate(config.BodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := newDigest(config)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &webhook{
+		digest:      digest,
+		name:        config.Name,
+		url:         config.URL,
+		method:      strings.ToUpper(config.Method),
+		contentType: config.ContentType,
+		headers:     config.Headers,
+		body:        body,
+	}
+	if w.name == "" {
+		w.name = config.URL
+	}
+	if w.method == "" {
+		w.method = http.MethodPost
+	}
+	if w.contentType == "" {
+		w.contentType = "application/json"
+	}
+
+	timeout := defaultWebhookTimeout
+	if config.Timeout > 0 {
+		timeout = config.Timeout
+	}
+	w.client = &http.Client{
+		Timeout:   time.Duration(timeout) * time.Second,
+		Transport: netutil.Transport(),
+	}
+
+	return w, nil
+}
+
+// Render returns the payload the webhook would send for the event
+func (w *webhook) render(event Event) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := w.body.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("failed to render body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (w *webhook) send(event Event) error {
+	payload, err := w.render(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(w.method, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", w.contentType)
+	for key, value := range w.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+	return nil
+}
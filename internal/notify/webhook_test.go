@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEvent() Event {
+	return Event{
+		Type:    EventDNSUpdate,
+		OldIP:   "192.0.2.1",
+		NewIP:   "192.0.2.2",
+		Success: true,
+		Results: []Result{
+			{Name: "home \"lab\"", Kind: "dns", Provider: "cloudflare", Success: true},
+		},
+		Hostname:  "router",
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestDefaultBodyTemplateIsValidJSON(t *testing.T) {
+	w, err := newWebhook(WebhookConfig{URL: "http://127.0.0.1/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := w.render(testEvent())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("payload is not JSON: %v\n%s", err, payload)
+	}
+	if got["type"] != EventDNSUpdate || got["old_ip"] != "192.0.2.1" || got["new_ip"] != "192.0.2.2" || got["hostname"] != "router" {
+		t.Fatalf("payload = %s", payload)
+	}
+	if _, ok := got["old_ipv6"]; ok {
+		t.Fatalf("payload has IPv6 fields without IPv6 addresses: %s", payload)
+	}
+	results := got["results"].([]interface{})
+	if name := results[0].(map[string]interface{})["name"]; name != "home \"lab\"" {
+		t.Fatalf("result name = %v, want it escaped and round-tripped", name)
+	}
+}
+
+func TestCustomBodyTemplate(t *testing.T) {
+	var body, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, contentType = string(data), r.Header.Get("Content-Type")
+	}))
+	defer server.Close()
+
+	w, err := newWebhook(WebhookConfig{
+		URL:          server.URL,
+		ContentType:  "text/plain",
+		BodyTemplate: `{{.Hostname}}: {{.OldIP}} -> {{.NewIP}}{{range .Results}} [{{.Name}}]{{end}}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.send(testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	if want := `router: 192.0.2.1 -> 192.0.2.2 [home "lab"]`; body != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+	if contentType != "text/plain" {
+		t.Fatalf("Content-Type = %q, want text/plain", contentType)
+	}
+}
+
+func TestInvalidBodyTemplateRejected(t *testing.T) {
+	for _, body := range []string{
+		`{{.NewIP`,         // parse error
+		`{{.NoSuchField}}`, // only found by rendering the sample event
+	} {
+		err := WebhookConfig{URL: "https://example.com/hook", BodyTemplate: body}.validate()
+		if err == nil || !strings.Contains(err.Error(), "invalid body_template") {
+			t.Errorf("body_template %q: err = %v, want invalid body_template", body, err)
+		}
+	}
+}
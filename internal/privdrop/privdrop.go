@@ -0,0 +1,61 @@
+// Package privdrop switches the process to an unprivileged user after the
+// privileged setup (status port, log file) is done. Only Linux is supported.
+package privdrop
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+)
+
+// Credentials is the user and group to switch to
+type Credentials struct {
+	User  string
+	UID   int
+	GID   int
+	Group string
+}
+
+// Lookup resolves a user and optional group (names or numeric IDs). Without
+// a group, the user's primary group is used.
+func Lookup(userName, groupName string) (*Credentials, error) {
+	u, err := user.Lookup(userName)
+	if err != nil {
+		if _, numErr := strconv.Atoi(userName); numErr != nil {
+			return nil, fmt.Errorf("unknown user %q: %w", userName, err)
+		}
+		if u, err = user.LookupId(userName); err != nil {
+			return nil, fmt.Errorf("unknown user %q: %w", userName, err)
+		}
+	}
+
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return nil, fmt.Errorf("user %q has non-numeric uid %q", userName, u.Uid)
+	}
+
+	gidStr := u.Gid
+	group := gidStr
+	if groupName != "" {
+		g, err := user.LookupGroup(groupName)
+		if err != nil {
+			if _, numErr := strconv.Atoi(groupName); numErr != nil {
+				return nil, fmt.Errorf("unknown group %q: %w", groupName, err)
+			}
+			if g, err = user.LookupGroupId(groupName); err != nil {
+				return nil, fmt.Errorf("unknown group %q: %w", groupName, err)
+			}
+		}
+		gidStr = g.Gid
+		group = g.Name
+	} else if g, err := user.LookupGroupId(gidStr); err == nil {
+		group = g.Name
+	}
+
+	gid, err := strconv.Atoi(gidStr)
+	if err != nil {
+		return nil, fmt.Errorf("group %q has non-numeric gid %q", group, gidStr)
+	}
+
+	return &Credentials{User: u.Username, UID: uid, GID: gid, Group: group}, nil
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package privdrop
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Supported reports whether Drop is implemented on this platform
+const Supported = true
+
+// Drop clears the supplementary groups and sets the gid, then the uid, of
+// every thread in the process. It can't be undone.
+func Drop(creds *Credentials) error {
+	if err := syscall.Setgroups([]int{creds.GID}); err != nil {
+		return fmt.Errorf("setgroups: %w", err)
+	}
+	if err := syscall.Setgid(creds.GID); err != nil {
+		return fmt.Errorf("setgid %d: %w", creds.GID, err)
+	}
+	if err := syscall.Setuid(creds.UID); err != nil {
+		return fmt.Errorf("setuid %d: %w", creds.UID, err)
+	}
+	return nil
+}
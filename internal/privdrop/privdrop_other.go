@@ -0,0 +1,16 @@
+//go:build !linux
+
+package privdrop
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Supported reports whether Drop is implemented on this platform
+const Supported = false
+
+// Drop is only implemented on Linux
+func Drop(creds *Credentials) error {
+	return fmt.Errorf("dropping privileges is not supported on %s", runtime.GOOS)
+}
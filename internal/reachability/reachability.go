@@ -0,0 +1,132 @@
+// Package reachability asks an external port-checking service whether ports
+// on the detected IP are reachable from the internet. Unlike health_check,
+// which connects from this host, the probe comes from outside, so broken
+// port forwarding or a carrier-grade NAT shows up.
+package reachability
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ip-updater/internal/netutil"
+)
+
+const (
+	defaultInterval = 3600
+	defaultTimeout  = 10
+)
+
+// maxResponseSize caps how much of a checker response is read
+const maxResponseSize = 64 << 10
+
+// Config is the [reachability] section
+type Config struct {
+	// URL of the checker; {ip} and {port} are replaced, e.g.
+	// "https://check.example.com/port?ip={ip}&port={port}"
+	URL   string `toml:"url"`
+	Ports []int  `toml:"ports"`
+	// Expect is a regular expression the response body matches when the
+	// port is open. Without it any 2xx response means open.
+	Expect   string `toml:"expect"`
+	Interval int    `toml:"interval"` // seconds between checks of an unchanged IP
+	Timeout  int    `toml:"timeout"`  // seconds per request
+}
+
+// Result is the outcome for one port. Err is set when the checker itself
+// could not be asked; Open is meaningless then.
+type Result struct {
+	Port int
+	Open bool
+	Err  error
+}
+
+// Validate checks the settings without contacting the checker
+func (c *Config) Validate() error {
+	u, err := url.Parse(c.URL)
+	if c.URL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid reachability url: %q", c.URL)
+	}
+	if len(c.Ports) == 0 {
+		return fmt.Errorf("reachability.ports is required")
+	}
+	for _, port := range c.Ports {
+		if port <= 0 || port > 65535 {
+			return fmt.Errorf("invalid reachability port: %d", port)
+		}
+	}
+	if _, err := regexp.Compile(c.Expect); err != nil {
+		return fmt.Errorf("invalid reachability expect: %w", err)
+	}
+	if c.Interval < 0 {
+		return fmt.Errorf("invalid reachability interval: %d", c.Interval)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("invalid reachability timeout: %d", c.Timeout)
+	}
+	return nil
+}
+
+// CheckInterval is how long the result for an unchanged IP is trusted
+func (c *Config) CheckInterval() time.Duration {
+	if c.Interval > 0 {
+		return time.Duration(c.Interval) * time.Second
+	}
+	return defaultInterval * time.Second
+}
+
+// Check asks the checker about every configured port on ip
+func (c *Config) Check(ip string) []Result {
+	timeout := time.Duration(defaultTimeout) * time.Second
+	if c.Timeout > 0 {
+		timeout = time.Duration(c.Timeout) * time.Second
+	}
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: netutil.Transport(),
+	}
+
+	results := make([]Result, 0, len(c.Ports))
+	for _, port := range c.Ports {
+		open, err := c.checkPort(client, ip, port)
+		results = append(results, Result{Port: port, Open: open, Err: err})
+	}
+	return results
+}
+
+func (c *Config) checkPort(client *http.Client, ip string, port int) (bool, error) {
+	target := strings.NewReplacer(
+		"{ip}", url.QueryEscape(ip),
+		"{port}", strconv.Itoa(port),
+	).Replace(c.URL)
+
+	resp, err := client.Get(target)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return false, err
+	}
+
+	if c.Expect == "" {
+		// 4xx/5xx may be the checker's way of saying "closed", or an error;
+		// only a 5xx is treated as the checker failing
+		if resp.StatusCode >= 500 {
+			return false, fmt.Errorf("checker returned status %d", resp.StatusCode)
+		}
+		return resp.StatusCode < 300, nil
+	}
+
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("checker returned status %d", resp.StatusCode)
+	}
+	return regexp.MustCompile(c.Expect).Match(body), nil
+}
@@ -0,0 +1,174 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a standard 5-field cron expression: minute hour day-of-month
+// month day-of-week, evaluated in local time. Fields accept *, lists (1,15),
+// ranges (1-5), steps (*/10, 8-18/2) and month/weekday names (JAN, MON);
+// 0 and 7 are both Sunday. The descriptors @hourly, @daily (@midnight),
+// @weekly, @monthly and @yearly (@annually) are accepted as well.
+//
+// As in cron, when both day-of-month and day-of-week are restricted, a day
+// matching either one fires.
+type Cron struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var weekdayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+// cronSearchLimit bounds the search for the next activation, so an
+// expression that never fires (0 0 30 2 *) doesn't loop forever
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// ParseCron parses a cron expression, rejecting one that never fires
+func ParseCron(expr string) (*Cron, error) {
+	spec := strings.TrimSpace(expr)
+	if descriptor, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q (expected 5 fields: minute hour day month weekday)", expr)
+	}
+
+	c := &Cron{expr: expr}
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: minute: %w", expr, err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: hour: %w", expr, err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: day of month: %w", expr, err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: month: %w", expr, err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: day of week: %w", expr, err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday too
+	}
+	c.domStar = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	c.dowStar = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid cron expression %q: never fires", expr)
+	}
+	return c, nil
+}
+
+// parseCronField returns the set of values a field matches as a bitmask
+func parseCronField(field string, lo, hi int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = n
+		}
+
+		start, end := lo, hi
+		if rangePart != "*" {
+			first, last, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = cronValue(first, lo, hi, names); err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				if end, err = cronValue(last, lo, hi, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = hi // "5/15" means from 5 to the end
+			}
+			if end < start {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(s string, lo, hi int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < lo || v > hi {
+		return 0, fmt.Errorf("invalid value %q (%d-%d)", s, lo, hi)
+	}
+	return v, nil
+}
+
+// Next returns the first activation after t, or the zero time when there
+// is none within five years
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func (c *Cron) String() string {
+	return c.expr
+}
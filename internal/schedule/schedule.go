@@ -0,0 +1,127 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// Window is a daily time range such as "01:00-05:00". Ranges whose end is
+// before their start wrap around midnight ("23:00-02:00").
+type Window struct {
+	start int // minutes since midnight
+	end   int
+}
+
+func ParseWindow(s string) (Window, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return Window{}, fmt.Errorf("invalid time window %q (expected HH:MM-HH:MM)", s)
+	}
+
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+
+	if start == end {
+		return Window{}, fmt.Errorf("invalid time window %q: start equals end", s)
+	}
+
+	return Window{start: start, end: end}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether t (in its own location) falls inside the window
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+// Gate decides whether updates may be applied at a given time
+type Gate struct {
+	active []Window
+	freeze []Window
+}
+
+// NewGate builds a gate from active-hour and freeze windows. With no active
+// hours configured, updates are allowed at any time outside the freeze windows.
+func NewGate(activeHours, freezeWindows []string) (*Gate, error) {
+	gate := &Gate{}
+
+	for _, s := range activeHours {
+		w, err := ParseWindow(s)
+		if err != nil {
+			return nil, fmt.Errorf("active_hours: %w", err)
+		}
+		gate.active = append(gate.active, w)
+	}
+
+	for _, s := range freezeWindows {
+		w, err := ParseWindow(s)
+		if err != nil {
+			return nil, fmt.Errorf("freeze_windows: %w", err)
+		}
+		gate.freeze = append(gate.freeze, w)
+	}
+
+	return gate, nil
+}
+
+func (g *Gate) Enabled() bool {
+	return len(g.active) > 0 || len(g.freeze) > 0
+}
+
+func (g *Gate) Allows(t time.Time) bool {
+	for _, w := range g.freeze {
+		if w.Contains(t) {
+			return false
+		}
+	}
+
+	if len(g.active) == 0 {
+		return true
+	}
+
+	for _, w := range g.active {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the first minute at or after t when updates are allowed.
+// It returns the zero time if the configured windows never allow updates.
+func (g *Gate) NextOpen(t time.Time) time.Time {
+	if g.Allows(t) {
+		return t
+	}
+
+	next := t.Truncate(time.Minute)
+	for i := 0; i <= minutesPerDay; i++ {
+		next = next.Add(time.Minute)
+		if g.Allows(next) {
+			return next
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,152 @@
+package statefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ip-updater/internal/crypto"
+)
+
+// State is what the daemon remembers across restarts: the IP each kind of
+// updater last applied successfully
+type State struct {
+	DNSIP  string `json:"dns_ip,omitempty"`
+	FileIP string `json:"file_ip,omitempty"`
+
+	// DNSIPv6 is the address last applied to AAAA records
+	DNSIPv6 string `json:"dns_ipv6,omitempty"`
+
+	// ListEntries maps list-mode file updaters to the entry they manage
+	ListEntries map[string]string `json:"list_entries,omitempty"`
+
+	// Fallback maps the DNS updaters publishing their fallback_ip to it, so
+	// a restart during an outage still switches them back
+	Fallback map[string]string `json:"fallback,omitempty"`
+
+	// DNSAsserted maps DNS updaters to when they last applied their records
+	// successfully, for reassert_interval
+	DNSAsserted map[string]time.Time `json:"dns_asserted,omitempty"`
+
+	// MatchedRecords maps the records configured with match_value to the
+	// ID (or value) of the record they manage among duplicates
+	MatchedRecords map[string]string `json:"matched_records,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SignatureSuffix is appended to the state file's path for the file holding
+// its HMAC, see SaveSigned
+const SignatureSuffix = ".hmac"
+
+// ErrSignatureMismatch is returned by LoadSigned when the state file doesn't
+// match its HMAC, i.e. it was modified, corrupted or never signed
+var ErrSignatureMismatch = errors.New("state file signature mismatch")
+
+// Load reads the state file. A missing file is not an error and yields an
+// empty state, as on the very first run.
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return &State{}, err
+	}
+	return parse(path, data)
+}
+
+// LoadSigned is Load for a state file written by SaveSigned: the state is
+// only used when the HMAC next to it matches, otherwise an empty state is
+// returned with ErrSignatureMismatch
+func LoadSigned(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return &State{}, err
+	}
+
+	signature, err := os.ReadFile(path + SignatureSuffix)
+	if err != nil && !os.IsNotExist(err) {
+		return &State{}, err
+	}
+	if !crypto.Verify(data, strings.TrimSpace(string(signature))) {
+		return &State{}, fmt.Errorf("%w: %s", ErrSignatureMismatch, path)
+	}
+	return parse(path, data)
+}
+
+func parse(path string, data []byte) (*State, error) {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return &State{}, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// Save writes the state atomically, creating the directory if needed
+func Save(path string, state *State) error {
+	_, err := save(path, state)
+	return err
+}
+
+// SaveSigned is Save followed by writing the state's HMAC, keyed by the
+// machine key, to path + SignatureSuffix
+func SaveSigned(path string, state *State) error {
+	data, err := save(path, state)
+	if err != nil {
+		return err
+	}
+	return writeFile(path+SignatureSuffix, []byte(crypto.Sign(data)+"\n"))
+}
+
+func save(path string, state *State) ([]byte, error) {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return data, writeFile(path, data)
+}
+
+// writeFile replaces the file atomically through a temp file
+func writeFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tempFile, err := os.CreateTemp(dir, ".tmp_"+filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempPath := tempFile.Name()
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	// The rename must not reach the disk before the data, or a crash could
+	// leave an empty state file
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
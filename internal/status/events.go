@@ -0,0 +1,83 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Event types recorded in the buffer
+const (
+	EventDetection = "detection"
+	EventChange    = "change"
+	EventUpdate    = "update"
+	EventError     = "error"
+	EventWarning   = "warning"
+)
+
+const defaultEventCapacity = 50
+
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+}
+
+// EventBuffer keeps the most recent events in a fixed-size ring buffer.
+// It is safe for concurrent use.
+type EventBuffer struct {
+	mu     sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+func NewEventBuffer(capacity int) *EventBuffer {
+	if capacity <= 0 {
+		capacity = defaultEventCapacity
+	}
+
+	return &EventBuffer{
+		events: make([]Event, capacity),
+	}
+}
+
+// Add records a new event, evicting the oldest one when the buffer is full
+func (b *EventBuffer) Add(eventType, format string, args ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events[b.next] = Event{
+		Time:    time.Now(),
+		Type:    eventType,
+		Message: fmt.Sprintf(format, args...),
+	}
+
+	b.next++
+	if b.next == len(b.events) {
+		b.next = 0
+		b.full = true
+	}
+}
+
+// Events returns a copy of the buffered events, oldest first
+func (b *EventBuffer) Events() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.full {
+		result := make([]Event, b.next)
+		copy(result, b.events[:b.next])
+		return result
+	}
+
+	result := make([]Event, 0, len(b.events))
+	result = append(result, b.events[b.next:]...)
+	result = append(result, b.events[:b.next]...)
+	return result
+}
+
+func (b *EventBuffer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.Events())
+}
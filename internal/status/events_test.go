@@ -0,0 +1,47 @@
+package status
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEventBufferEvictsOldest(t *testing.T) {
+	buffer := NewEventBuffer(3)
+	for i := 1; i <= 5; i++ {
+		buffer.Add(EventChange, "event %d", i)
+	}
+
+	events := buffer.Events()
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want the capacity (3)", len(events))
+	}
+	for i, event := range events {
+		if want := fmt.Sprintf("event %d", i+3); event.Message != want {
+			t.Errorf("events[%d] = %q, want %q (oldest first)", i, event.Message, want)
+		}
+	}
+}
+
+func TestEventBufferNotFull(t *testing.T) {
+	buffer := NewEventBuffer(0)
+	buffer.Add(EventError, "only %s", "one")
+
+	events := buffer.Events()
+	if len(events) != 1 || events[0].Message != "only one" || events[0].Type != EventError {
+		t.Fatalf("events = %+v, want the one added", events)
+	}
+	if cap := len(buffer.events); cap != defaultEventCapacity {
+		t.Fatalf("capacity = %d, want the default %d", cap, defaultEventCapacity)
+	}
+}
+
+func TestEventBufferEventsIsACopy(t *testing.T) {
+	buffer := NewEventBuffer(2)
+	buffer.Add(EventUpdate, "first")
+	events := buffer.Events()
+	events[0].Message = "changed"
+
+	if got := buffer.Events()[0].Message; got != "first" {
+		t.Fatalf("buffer modified through Events: %q", got)
+	}
+}
@@ -0,0 +1,175 @@
+package status
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Auth protects the endpoints with a bearer token and/or basic auth. When
+// both are configured either one is accepted; when neither is, requests are
+// not authenticated.
+type Auth struct {
+	Token    string
+	Username string
+	Password string
+}
+
+func (a Auth) enabled() bool {
+	return a.Token != "" || a.Username != ""
+}
+
+type Server struct {
+	httpServer *http.Server
+	state      *State
+	version    string
+	startedAt  time.Time
+	auth       Auth
+}
+
+type Snapshot struct {
+	Version   string          `json:"version"`
+	StartedAt time.Time       `json:"started_at"`
+	Deferred  *DeferredUpdate `json:"deferred,omitempty"`
+	APIUsage  interface{}     `json:"api_usage,omitempty"`
+	History   interface{}     `json:"history,omitempty"`
+	Events    []Event         `json:"events"`
+}
+
+func NewServer(listenAddr, version string, state *State) *Server {
+	s := &Server{
+		state:     state,
+		version:   version,
+		startedAt: time.Now(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+
+	s.httpServer = &http.Server{
+		Addr:              LocalListenAddr(listenAddr),
+		Handler:           s.requireAuth(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return s
+}
+
+// LocalListenAddr binds to the loopback interface when the address has no
+// host part (":8080"), so the endpoints are not exposed to the network unless
+// a host such as "0.0.0.0" is given explicitly
+func LocalListenAddr(listenAddr string) string {
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil || host != "" {
+		return listenAddr
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// IsLoopback reports whether the listen address only accepts local connections
+func IsLoopback(listenAddr string) bool {
+	host, _, err := net.SplitHostPort(LocalListenAddr(listenAddr))
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) SetAuth(auth Auth) {
+	s.auth = auth
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	return s.httpServer.Addr
+}
+
+// Start binds the listen address and serves requests in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	go s.httpServer.Serve(listener)
+	return nil
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *Server) Snapshot() Snapshot {
+	return Snapshot{
+		Version:   s.version,
+		StartedAt: s.startedAt,
+		Deferred:  s.state.Deferred(),
+		APIUsage:  s.state.APIUsage(),
+		History:   s.state.History(),
+		Events:    s.state.Events.Events(),
+	}
+}
+
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.auth.enabled() || s.authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.auth.Username != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="ip_updater"`)
+		}
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.auth.Token != "" {
+		if token, ok := bearerToken(r); ok && secureEqual(token, s.auth.Token) {
+			return true
+		}
+	}
+
+	if s.auth.Username != "" {
+		if username, password, ok := r.BasicAuth(); ok &&
+			secureEqual(username, s.auth.Username) && secureEqual(password, s.auth.Password) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return header[len(prefix):], true
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(s.Snapshot())
+}
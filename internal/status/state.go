@@ -0,0 +1,105 @@
+package status
+
+import (
+	"sync"
+	"time"
+)
+
+// DeferredUpdate describes a detected change that is waiting to be applied
+type DeferredUpdate struct {
+	IP         string    `json:"ip"`
+	DetectedAt time.Time `json:"detected_at"`
+	Reason     string    `json:"reason"`
+	ApplyAfter time.Time `json:"apply_after,omitempty"`
+}
+
+// State aggregates the runtime information reported by /status
+type State struct {
+	Events  *EventBuffer
+	Metrics *Metrics
+
+	mu       sync.Mutex
+	deferred *DeferredUpdate
+	apiUsage func() interface{}
+	history  func() interface{}
+}
+
+func NewState(eventCapacity int) *State {
+	return &State{
+		Events:  NewEventBuffer(eventCapacity),
+		Metrics: NewMetrics(),
+	}
+}
+
+func (s *State) SetDeferred(ip, reason string, applyAfter time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	detectedAt := time.Now()
+	if s.deferred != nil && s.deferred.IP == ip {
+		detectedAt = s.deferred.DetectedAt
+	}
+
+	s.deferred = &DeferredUpdate{
+		IP:         ip,
+		DetectedAt: detectedAt,
+		Reason:     reason,
+		ApplyAfter: applyAfter,
+	}
+}
+
+func (s *State) ClearDeferred() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deferred = nil
+}
+
+// Deferred returns a copy of the pending deferred update, or nil
+func (s *State) Deferred() *DeferredUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.deferred == nil {
+		return nil
+	}
+	deferred := *s.deferred
+	return &deferred
+}
+
+// SetHistorySource registers the function that reports recent IP changes
+func (s *State) SetHistorySource(source func() interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = source
+}
+
+// History returns the recent IP changes, or nil when no source is set
+func (s *State) History() interface{} {
+	s.mu.Lock()
+	source := s.history
+	s.mu.Unlock()
+
+	if source == nil {
+		return nil
+	}
+	return source()
+}
+
+// SetAPIUsageSource registers the function that reports API call counters
+func (s *State) SetAPIUsageSource(source func() interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiUsage = source
+}
+
+// APIUsage returns the current API call counters, or nil when no source is set
+func (s *State) APIUsage() interface{} {
+	s.mu.Lock()
+	source := s.apiUsage
+	s.mu.Unlock()
+
+	if source == nil {
+		return nil
+	}
+	return source()
+}
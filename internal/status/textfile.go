@@ -0,0 +1,167 @@
+package status
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TextfileName is the file written to status.textfile_dir
+const TextfileName = "ip_updater.prom"
+
+// Address is an address currently applied, reported in the textfile
+type Address struct {
+	Kind   string // dns or file
+	Family string // ipv4 or ipv6
+	IP     string
+}
+
+// updateKey identifies an updater in the update counters
+type updateKey struct {
+	kind    string // dns or file
+	updater string
+}
+
+// Metrics counts checks and updates for the node_exporter textfile
+// collector. Counters start at zero with the process, which Prometheus
+// handles as a counter reset. It is safe for concurrent use.
+type Metrics struct {
+	mu                sync.Mutex
+	lastCheck         time.Time
+	lastUpdate        time.Time
+	detectionFailures int
+	successes         map[updateKey]int
+	failures          map[updateKey]int
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		successes: make(map[updateKey]int),
+		failures:  make(map[updateKey]int),
+	}
+}
+
+// ObserveDetection records the detection every check starts with
+func (m *Metrics) ObserveDetection(ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastCheck = time.Now()
+	if !ok {
+		m.detectionFailures++
+	}
+}
+
+// ObserveUpdate counts the outcome of one updater's update
+func (m *Metrics) ObserveUpdate(kind, updater string, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := updateKey{kind, updater}
+	if success {
+		m.successes[key]++
+		m.lastUpdate = time.Now()
+		return
+	}
+	m.failures[key]++
+}
+
+// Format renders the metrics in the Prometheus text exposition format.
+// Addresses without an IP are left out.
+func (m *Metrics) Format(addresses []Address) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	metric := func(name, help, kind string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	metric("ip_updater_last_check_timestamp_seconds", "Unix time of the last check.", "gauge")
+	fmt.Fprintf(&b, "ip_updater_last_check_timestamp_seconds %d\n", unixOrZero(m.lastCheck))
+	metric("ip_updater_last_update_timestamp_seconds", "Unix time of the last successful update.", "gauge")
+	fmt.Fprintf(&b, "ip_updater_last_update_timestamp_seconds %d\n", unixOrZero(m.lastUpdate))
+
+	metric("ip_updater_current_ip", "Address currently applied, in the ip label.", "gauge")
+	for _, address := range addresses {
+		if address.IP == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "ip_updater_current_ip{kind=\"%s\",family=\"%s\",ip=\"%s\"} 1\n",
+			escapeLabel(address.Kind), escapeLabel(address.Family), escapeLabel(address.IP))
+	}
+
+	metric("ip_updater_detection_failures_total", "Failed public IP detections.", "counter")
+	fmt.Fprintf(&b, "ip_updater_detection_failures_total %d\n", m.detectionFailures)
+
+	metric("ip_updater_updates_total", "Updates by updater and result.", "counter")
+	keys := make([]updateKey, 0, len(m.successes)+len(m.failures))
+	for key := range m.successes {
+		keys = append(keys, key)
+	}
+	for key := range m.failures {
+		if _, ok := m.successes[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].kind != keys[j].kind {
+			return keys[i].kind < keys[j].kind
+		}
+		return keys[i].updater < keys[j].updater
+	})
+	for _, key := range keys {
+		labels := fmt.Sprintf("kind=\"%s\",updater=\"%s\"", escapeLabel(key.kind), escapeLabel(key.updater))
+		fmt.Fprintf(&b, "ip_updater_updates_total{%s,result=\"success\"} %d\n", labels, m.successes[key])
+		fmt.Fprintf(&b, "ip_updater_updates_total{%s,result=\"failure\"} %d\n", labels, m.failures[key])
+	}
+
+	return []byte(b.String())
+}
+
+// WriteTextfile writes the metrics to TextfileName in dir. The file is
+// replaced through a temp file, so the collector never reads a partial one;
+// the temp name doesn't end in .prom, so it is never collected either.
+func (m *Metrics) WriteTextfile(dir string, addresses []Address) error {
+	tempFile, err := os.CreateTemp(dir, "."+TextfileName+".tmp_")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tempPath := tempFile.Name()
+
+	if _, err := tempFile.Write(m.Format(addresses)); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	// Readable by node_exporter running as another user (CreateTemp uses 0600)
+	if err := tempFile.Chmod(0644); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	if err := os.Rename(tempPath, filepath.Join(dir, TextfileName)); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to replace metrics file: %w", err)
+	}
+	return nil
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// escapeLabel escapes a label value as the exposition format requires
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,102 @@
+package status
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsFormat(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveDetection(true)
+	m.ObserveDetection(false)
+	m.ObserveUpdate("file", "app", true)
+	m.ObserveUpdate("dns", "home", true)
+	m.ObserveUpdate("dns", "home", true)
+	m.ObserveUpdate("dns", `we"ird`, false)
+	m.lastCheck = time.Unix(1700000100, 0)
+	m.lastUpdate = time.Unix(1700000000, 0)
+
+	got := string(m.Format([]Address{
+		{Kind: "dns", Family: "ipv4", IP: "203.0.113.7"},
+		{Kind: "dns", Family: "ipv6"},
+		{Kind: "file", Family: "ipv4", IP: "203.0.113.7"},
+	}))
+	want := `# HELP ip_updater_last_check_timestamp_seconds Unix time of the last check.
+# TYPE ip_updater_last_check_timestamp_seconds gauge
+ip_updater_last_check_timestamp_seconds 1700000100
+# HELP ip_updater_last_update_timestamp_seconds Unix time of the last successful update.
+# TYPE ip_updater_last_update_timestamp_seconds gauge
+ip_updater_last_update_timestamp_seconds 1700000000
+# HELP ip_updater_current_ip Address currently applied, in the ip label.
+# TYPE ip_updater_current_ip gauge
+ip_updater_current_ip{kind="dns",family="ipv4",ip="203.0.113.7"} 1
+ip_updater_current_ip{kind="file",family="ipv4",ip="203.0.113.7"} 1
+# HELP ip_updater_detection_failures_total Failed public IP detections.
+# TYPE ip_updater_detection_failures_total counter
+ip_updater_detection_failures_total 1
+# HELP ip_updater_updates_total Updates by updater and result.
+# TYPE ip_updater_updates_total counter
+ip_updater_updates_total{kind="dns",updater="home",result="success"} 2
+ip_updater_updates_total{kind="dns",updater="home",result="failure"} 0
+ip_updater_updates_total{kind="dns",updater="we\"ird",result="success"} 0
+ip_updater_updates_total{kind="dns",updater="we\"ird",result="failure"} 1
+ip_updater_updates_total{kind="file",updater="app",result="success"} 1
+ip_updater_updates_total{kind="file",updater="app",result="failure"} 0
+`
+	if got != want {
+		t.Fatalf("Format =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMetricsFormatBeforeAnyCheck(t *testing.T) {
+	got := string(NewMetrics().Format(nil))
+	for _, line := range []string{
+		"ip_updater_last_check_timestamp_seconds 0\n",
+		"ip_updater_last_update_timestamp_seconds 0\n",
+		"ip_updater_detection_failures_total 0\n",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("Format is missing %q:\n%s", line, got)
+		}
+	}
+}
+
+func TestWriteTextfile(t *testing.T) {
+	dir := t.TempDir()
+	m := NewMetrics()
+	addresses := []Address{{Kind: "dns", Family: "ipv4", IP: "203.0.113.7"}}
+
+	for i := 0; i < 2; i++ {
+		if err := m.WriteTextfile(dir, addresses); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != TextfileName {
+		t.Fatalf("dir holds %v, want only %s (no temp files left)", entries, TextfileName)
+	}
+	info, err := os.Stat(filepath.Join(dir, TextfileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0644 {
+		t.Fatalf("mode = %v, want 0644 for node_exporter", perm)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, TextfileName))
+	if string(data) != string(m.Format(addresses)) {
+		t.Fatalf("textfile =\n%s\nwant the formatted metrics", data)
+	}
+}
+
+func TestWriteTextfileMissingDir(t *testing.T) {
+	if err := NewMetrics().WriteTextfile(filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
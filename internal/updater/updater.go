@@ -1,19 +1,80 @@
 package updater
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"ip-updater/internal/config"
+	"ip-updater/internal/healthcheck"
 	"ip-updater/internal/logger"
+	"ip-updater/internal/netutil"
+	"ip-updater/internal/notify"
+	"ip-updater/internal/status"
 	"ip-updater/pkg/dns"
 	"ip-updater/pkg/fileupdate"
 )
 
+// fallbackPrimaryRetries bounds the primary provider's retries when a
+// fallback is configured and max_retries is infinite
+const fallbackPrimaryRetries = 3
+
+// replicaRetries bounds each provider's retries when replicas are configured
+// and max_retries is infinite, so a provider that is down doesn't hold back
+// the others; the next check tries it again
+const replicaRetries = 3
+
+// ErrOrderSkipped is returned by UpdateConcurrently for the pass that
+// update_order puts second when the first one failed; it runs on the next
+// check instead
+var ErrOrderSkipped = errors.New("skipped by update_order")
+
 type Updater struct {
 	config     *config.Config
 	logger     *logger.Logger
 	dnsManager *dns.DNSManager
+	events     *status.EventBuffer
+	metrics    *status.Metrics
+
+	// applied records the IP each updater last applied successfully, so
+	// updaters with depends_on can wait for their prerequisites
+	applied map[string]string
+
+	// asserted records when each DNS updater last applied its records
+	// successfully, for reassert_interval
+	asserted map[string]time.Time
+
+	// lastResults holds the per-updater outcome of the latest update pass
+	lastResults []notify.Result
+
+	// listEntries maps list-mode file updaters to the list entry they wrote
+	listEntries map[string]string
+
+	// ipv6 provides the address for AAAA records; currentIPv6 is the address
+	// used in the running DNS pass ("" when IPv6 is unavailable)
+	ipv6        IPv6Source
+	currentIPv6 string
+
+	// cronManaged leaves updaters with their own cron out of the regular
+	// passes; only, when set, restricts a pass to the named updaters
+	cronManaged bool
+	only        map[string]bool
+
+	// mu guards applied, asserted, listEntries and lastResults while a DNS
+	// and a file pass run at the same time (UpdateConcurrently)
+	mu         sync.Mutex
+	concurrent bool
+}
+
+// IPv6Source detects the public IPv6 address and reports whether IPv6 is
+// available, caching unavailability (see detector.GetPublicIPv6)
+type IPv6Source interface {
+	GetPublicIPv6() (string, bool)
 }
 
 func New(cfg *config.Config, log *logger.Logger) *Updater {
@@ -21,10 +82,32 @@ func New(cfg *config.Config, log *logger.Logger) *Updater {
 	dnsManager.SetLogger(log)
 	dnsManager.InitializeProviders()
 
+	// 调用计数是全局的，重新加载配置时只更新预算
+	dns.APIUsage.SetHourlyBudget(cfg.APIQuota.HourlyBudget, cfg.APIQuota.WarnPercent)
+
 	return &Updater{
-		config:     cfg,
-		logger:     log,
-		dnsManager: dnsManager,
+		config:      cfg,
+		logger:      log,
+		dnsManager:  dnsManager,
+		applied:     make(map[string]string),
+		asserted:    make(map[string]time.Time),
+		listEntries: make(map[string]string),
+	}
+}
+
+// SetEvents attaches the buffer that records per-updater results
+func (u *Updater) SetEvents(events *status.EventBuffer) {
+	u.events = events
+}
+
+// SetMetrics attaches the counters of per-updater results
+func (u *Updater) SetMetrics(metrics *status.Metrics) {
+	u.metrics = metrics
+}
+
+func (u *Updater) recordEvent(eventType, format string, args ...interface{}) {
+	if u.events != nil {
+		u.events.Add(eventType, format, args...)
 	}
 }
 
@@ -48,24 +131,100 @@ func (u *Updater) UpdateAll(newIP string) error {
 	return nil
 }
 
+// UpdateDNS applies newIP to the DNS records, detecting the IPv6 address for
+// AAAA records through the IPv6 source
 func (u *Updater) UpdateDNS(newIP string) error {
+	ipv6 := ""
+	if u.ipv6 != nil && u.config.HasIPv6Records() {
+		if detected, ok := u.ipv6.GetPublicIPv6(); ok {
+			ipv6 = detected
+		}
+	}
+	return u.UpdateDNSAddresses(newIP, ipv6)
+}
+
+// UpdateDNSAddresses applies already detected addresses: newIP to A records
+// and ipv6 to AAAA records, both halves of an A+AAAA pair in the same pass.
+// An empty ipv6 leaves the AAAA records untouched.
+func (u *Updater) UpdateDNSAddresses(newIP, ipv6 string) error {
 	// Skip if no DNS updaters configured
 	if len(u.config.DNSUpdaters) == 0 {
 		u.logger.Debugf("No DNS updaters configured, skipping DNS update")
 		return nil
 	}
 
+	if err := u.checkAddresses(newIP, ipv6); err != nil {
+		return fmt.Errorf("DNS updates failed: %w", err)
+	}
+
 	var errors []string
+	u.beginResults()
+
+	dns.APIUsage.BeginCycle()
+	defer u.reportAPIUsage()
+
+	u.currentIPv6 = ipv6
+	applied := newIP
+	if ipv6 != "" {
+		applied = newIP + ", " + ipv6
+	}
 
 	// Update DNS records
 	for _, dnsUpdater := range u.config.DNSUpdaters {
-		if err := u.updateDNSWithRetry(dnsUpdater, newIP); err != nil {
+		if !u.inPass(dnsUpdater.Name, dnsUpdater.Cron) {
+			continue
+		}
+		if pending := u.pendingDependencies(dnsUpdater.DependsOn, newIP); len(pending) > 0 {
+			errMsg := fmt.Sprintf("DNS update skipped for %s: waiting for %s", dnsUpdater.Name, strings.Join(pending, ", "))
+			u.logger.WarnHighlight(errMsg)
+			u.recordEvent(status.EventError, "%s", errMsg)
+			errors = append(errors, errMsg)
+			u.addResult(dnsUpdater.Name, "dns", dnsUpdater.Provider, errMsg)
+			continue
+		}
+
+		if err := u.checkHealth(dnsUpdater.Name, dnsUpdater.HealthCheck, newIP); err != nil {
+			errMsg := fmt.Sprintf("DNS update skipped for %s: %v", dnsUpdater.Name, err)
+			errors = append(errors, errMsg)
+			u.addResult(dnsUpdater.Name, "dns", dnsUpdater.Provider, errMsg)
+			continue
+		}
+
+		appliedBy, err := u.updateDNSWithFallback(dnsUpdater, newIP)
+		if err != nil {
 			errMsg := fmt.Sprintf("DNS update failed for %s: %v", dnsUpdater.Name, err)
 			u.logger.ErrorHighlight(errMsg)
+			u.recordEvent(status.EventError, "%s", errMsg)
 			errors = append(errors, errMsg)
+			u.addResult(dnsUpdater.Name, "dns", dnsUpdater.Provider, err.Error())
+		} else {
+			u.logger.Successf("DNS记录更新成功: %s (服务商: %s)", dnsUpdater.Name, appliedBy)
+			u.recordEvent(status.EventUpdate, "DNS updater %s applied %s via %s", dnsUpdater.Name, applied, appliedBy)
+			u.addResult(dnsUpdater.Name, "dns", appliedBy, "")
+		}
+
+		// Replicas get the same change whatever the primary's outcome; the
+		// updater only counts as applied when every provider has it
+		failed := 0
+		if err != nil {
+			failed++
+		}
+		for _, replicaErr := range u.updateDNSReplicas(dnsUpdater, newIP) {
+			errors = append(errors, replicaErr)
+			failed++
+		}
+		if replicas := len(dnsUpdater.Replicas); replicas > 0 && failed > 0 && failed <= replicas {
+			u.logger.WarnHighlightf("DNS更新部分成功: %s (%d/%d 个服务商已更新)", dnsUpdater.Name, replicas+1-failed, replicas+1)
+		}
+
+		u.mu.Lock()
+		if failed == 0 {
+			u.applied[dnsUpdater.Name] = newIP
+			u.asserted[dnsUpdater.Name] = time.Now()
 		} else {
-			u.logger.Successf("DNS记录更新成功: %s", dnsUpdater.Name)
+			delete(u.applied, dnsUpdater.Name)
 		}
+		u.mu.Unlock()
 	}
 
 	if len(errors) > 0 {
@@ -75,6 +234,405 @@ func (u *Updater) UpdateDNS(newIP string) error {
 	return nil
 }
 
+// UpdateConcurrently runs the DNS pass (newIP to A records, ipv6 to AAAA
+// records) and the file pass of one check at the same time, for
+// combined_updates. When updaters of one kind depend on the other kind, or
+// update_order is set, the passes run one after the other instead,
+// prerequisites (or the configured first kind) first; with update_order the
+// second pass is skipped (ErrOrderSkipped) when the first fails. LastResults
+// then holds the results of both passes.
+func (u *Updater) UpdateConcurrently(newIP, ipv6 string, runDNS, runFiles bool) (dnsErr, fileErr error) {
+	u.mu.Lock()
+	u.lastResults = nil
+	u.concurrent = true
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		u.concurrent = false
+		u.mu.Unlock()
+	}()
+
+	switch {
+	case !runDNS:
+		fileErr = u.UpdateFiles(newIP)
+	case !runFiles:
+		dnsErr = u.UpdateDNSAddresses(newIP, ipv6)
+	case u.config.FileUpdatersDependOnDNS(), u.config.UpdateOrder == config.UpdateOrderDNSFirst:
+		dnsErr = u.UpdateDNSAddresses(newIP, ipv6)
+		if dnsErr != nil && u.config.UpdateOrder == config.UpdateOrderDNSFirst {
+			fileErr = ErrOrderSkipped
+		} else {
+			fileErr = u.UpdateFiles(newIP)
+		}
+	case u.config.DNSUpdatersDependOnFiles(), u.config.UpdateOrder == config.UpdateOrderFilesFirst:
+		fileErr = u.UpdateFiles(newIP)
+		if fileErr != nil && u.config.UpdateOrder == config.UpdateOrderFilesFirst {
+			dnsErr = ErrOrderSkipped
+		} else {
+			dnsErr = u.UpdateDNSAddresses(newIP, ipv6)
+		}
+	default:
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fileErr = u.UpdateFiles(newIP)
+		}()
+		dnsErr = u.UpdateDNSAddresses(newIP, ipv6)
+		wg.Wait()
+	}
+	return dnsErr, fileErr
+}
+
+// beginResults starts collecting the results of a pass. Outside
+// UpdateConcurrently they replace the previous pass's results.
+func (u *Updater) beginResults() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.concurrent {
+		u.lastResults = nil
+	}
+}
+
+// checkAddresses refuses a pass with an empty or unspecified address; an
+// empty ipv6 only means AAAA records are skipped
+func (u *Updater) checkAddresses(newIP, ipv6 string) error {
+	err := netutil.CheckUsableAddress(newIP)
+	if err == nil && ipv6 != "" {
+		err = netutil.CheckUsableAddress(ipv6)
+	}
+	if err != nil {
+		u.logger.ErrorHighlightf("拒绝更新: %v", err)
+		u.recordEvent(status.EventError, "update refused: %v", err)
+	}
+	return err
+}
+
+// recordAddresses returns the addresses a pass writes to the A and AAAA
+// records. With ip_mode = "ipv6" the detected address is the IPv6 one, so
+// only AAAA records get it.
+func (u *Updater) recordAddresses(newIP, ipv6 string) (string, string) {
+	if u.config.IPMode == config.IPModeIPv6 {
+		return "", newIP
+	}
+	return newIP, ipv6
+}
+
+// SetIPv6Source sets where AAAA records get their address from. Without one,
+// AAAA records are skipped.
+func (u *Updater) SetIPv6Source(source IPv6Source) {
+	u.ipv6 = source
+}
+
+// SetListEntries restores the list entries managed by list-mode file
+// updaters, as saved in the state file
+func (u *Updater) SetListEntries(entries map[string]string) {
+	for name, entry := range entries {
+		u.listEntries[name] = entry
+	}
+}
+
+// ListEntries returns a copy of the list entries managed by list-mode file
+// updaters, for the state file
+func (u *Updater) ListEntries() map[string]string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	entries := make(map[string]string, len(u.listEntries))
+	for name, entry := range u.listEntries {
+		entries[name] = entry
+	}
+	return entries
+}
+
+// SetMatchedRecords restores the records tracked for match_value, as saved
+// in the state file
+func (u *Updater) SetMatchedRecords(matched map[string]string) {
+	u.dnsManager.SetMatchedRecords(matched)
+}
+
+// MatchedRecords returns the records tracked for match_value, for the state
+// file
+func (u *Updater) MatchedRecords() map[string]string {
+	return u.dnsManager.MatchedRecords()
+}
+
+// LastResults returns the per-updater outcome of the latest DNS or file
+// update pass, for notifications
+func (u *Updater) LastResults() []notify.Result {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return slices.Clone(u.lastResults)
+}
+
+// NotifyResults returns LastResults without the updaters configured with
+// notify = false
+func (u *Updater) NotifyResults() []notify.Result {
+	var results []notify.Result
+	for _, result := range u.LastResults() {
+		if u.notifyEnabled(result.Kind, result.Name) {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func (u *Updater) notifyEnabled(kind, name string) bool {
+	switch kind {
+	case "dns":
+		for _, dnsUpdater := range u.config.DNSUpdaters {
+			if dnsUpdater.Name == name {
+				return dnsUpdater.NotifyEnabled()
+			}
+		}
+	case "file":
+		for _, fileUpdater := range u.config.FileUpdaters {
+			if fileUpdater.Name == name {
+				return fileUpdater.NotifyEnabled()
+			}
+		}
+	}
+	return true
+}
+
+func (u *Updater) addResult(name, kind, provider, errMsg string) {
+	if u.metrics != nil {
+		u.metrics.ObserveUpdate(kind, name, errMsg == "")
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.lastResults = append(u.lastResults, notify.Result{
+		Name:     name,
+		Kind:     kind,
+		Provider: provider,
+		Success:  errMsg == "",
+		Error:    errMsg,
+	})
+}
+
+// SetCronManaged makes the regular passes skip updaters that have their own
+// cron; they are then only run through UpdateDNSFor and UpdateFilesFor
+func (u *Updater) SetCronManaged(managed bool) {
+	u.cronManaged = managed
+}
+
+// UpdateDNSFor runs a DNS pass for the named updaters only
+func (u *Updater) UpdateDNSFor(names []string, newIP, ipv6 string) error {
+	u.only = make(map[string]bool, len(names))
+	for _, name := range names {
+		u.only[name] = true
+	}
+	defer func() { u.only = nil }()
+	return u.UpdateDNSAddresses(newIP, ipv6)
+}
+
+// UpdateFilesFor runs a file pass for the named updaters only
+func (u *Updater) UpdateFilesFor(names []string, newIP string) error {
+	u.only = make(map[string]bool, len(names))
+	for _, name := range names {
+		u.only[name] = true
+	}
+	defer func() { u.only = nil }()
+	return u.UpdateFiles(newIP)
+}
+
+// Applied returns the IP the updater last applied successfully
+func (u *Updater) Applied(name string) string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.applied[name]
+}
+
+func (u *Updater) inPass(name, cron string) bool {
+	if u.only != nil {
+		return u.only[name]
+	}
+	return !u.cronManaged || cron == ""
+}
+
+// MarkDNSApplied records that every DNS updater already has ip, e.g. when
+// the startup update is skipped because the state file shows no change
+func (u *Updater) MarkDNSApplied(ip string) {
+	for _, dnsUpdater := range u.config.DNSUpdaters {
+		u.applied[dnsUpdater.Name] = ip
+	}
+}
+
+// SeedDNSApplied checks, on a first run without a state file, which DNS
+// updaters' records already hold the detected addresses (on the primary and
+// every replica) and marks them applied. It reports whether all of them do,
+// in which case the startup pass has nothing to write.
+func (u *Updater) SeedDNSApplied(ipv4, ipv6 string) bool {
+	recordIPv4, recordIPv6 := u.recordAddresses(ipv4, ipv6)
+	all := true
+	for _, dnsUpdater := range u.config.DNSUpdaters {
+		current, err := u.recordsCurrent(dnsUpdater, recordIPv4, recordIPv6)
+		if err != nil {
+			u.logger.Debugf("无法读取现有DNS记录 %s: %v", dnsUpdater.Name, err)
+		}
+
+		if current {
+			u.applied[dnsUpdater.Name] = ipv4
+			u.asserted[dnsUpdater.Name] = time.Now()
+			u.logger.Infof("DNS记录已是当前IP，记为已应用: %s", dnsUpdater.Name)
+		} else {
+			all = false
+		}
+	}
+	return all
+}
+
+// SetAsserted restores when the DNS updaters last applied their records, as
+// saved in the state file
+func (u *Updater) SetAsserted(asserted map[string]time.Time) {
+	for name, at := range asserted {
+		u.asserted[name] = at
+	}
+}
+
+// Asserted returns a copy of when each DNS updater last applied its
+// records, for the state file
+func (u *Updater) Asserted() map[string]time.Time {
+	return maps.Clone(u.asserted)
+}
+
+// DueForReassert returns the DNS updaters that last applied their records
+// more than interval ago. Updaters holding another IP than ip (a fallback_ip,
+// or waiting for their cron) are left out; those whose last pass failed are
+// included, so a failed reassertion is retried. An updater without a recorded
+// time (e.g. a state file from an older version) starts counting now.
+func (u *Updater) DueForReassert(ip string, interval time.Duration) []string {
+	var due []string
+	now := time.Now()
+	for _, dnsUpdater := range u.config.DNSUpdaters {
+		if applied := u.applied[dnsUpdater.Name]; applied != "" && applied != ip {
+			continue
+		}
+		at, ok := u.asserted[dnsUpdater.Name]
+		if !ok {
+			u.asserted[dnsUpdater.Name] = now
+			continue
+		}
+		if now.Sub(at) >= interval {
+			due = append(due, dnsUpdater.Name)
+		}
+	}
+	return due
+}
+
+// recordsCurrent reports whether the updater's records hold the addresses
+// on the primary provider and every replica
+func (u *Updater) recordsCurrent(dnsUpdater config.DNSUpdater, recordIPv4, recordIPv6 string) (bool, error) {
+	for _, target := range append([]config.DNSUpdater{dnsUpdater}, dnsUpdater.ReplicaUpdaters()...) {
+		ok, err := u.dnsManager.RecordsCurrent(target, recordIPv4, recordIPv6)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", target.Provider, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// StaleDNS reads the records of the DNS updaters holding ip (see
+// DueForReassert for which ones) and returns those whose records, on the
+// primary provider or a replica, don't hold the detected addresses: edited
+// outside the program, or never written. Updaters whose records couldn't be
+// read are left out with a warning.
+func (u *Updater) StaleDNS(ipv4, ipv6 string) []string {
+	recordIPv4, recordIPv6 := u.recordAddresses(ipv4, ipv6)
+	var stale []string
+	for _, dnsUpdater := range u.config.DNSUpdaters {
+		if applied := u.applied[dnsUpdater.Name]; applied != "" && applied != ipv4 {
+			continue
+		}
+		if !u.dnsManager.HasRecordsFor(dnsUpdater, recordIPv4, recordIPv6) {
+			continue
+		}
+
+		current, err := u.recordsCurrent(dnsUpdater, recordIPv4, recordIPv6)
+		if err != nil {
+			u.logger.Warnf("⚠️ 无法读取现有DNS记录，跳过本次核对: %s (%v)", dnsUpdater.Name, err)
+			continue
+		}
+		if !current {
+			stale = append(stale, dnsUpdater.Name)
+		}
+	}
+	return stale
+}
+
+// MarkFilesApplied is the file updater counterpart of MarkDNSApplied
+func (u *Updater) MarkFilesApplied(ip string) {
+	for _, fileUpdater := range u.config.FileUpdaters {
+		u.applied[fileUpdater.Name] = ip
+	}
+}
+
+// pendingDependencies returns the prerequisites that haven't applied newIP
+// yet. A dependent is skipped, and retried on the next pass, until all of its
+// prerequisites have succeeded with the same IP.
+func (u *Updater) pendingDependencies(dependsOn []string, newIP string) []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var pending []string
+	for _, dep := range dependsOn {
+		if u.applied[dep] != newIP {
+			pending = append(pending, dep)
+		}
+	}
+	return pending
+}
+
+// checkHealth runs the updater's health gate, if any, against the new IP. A
+// failed check leaves the updater unapplied so the next pass tries again,
+// which delays publishing an IP until the service behind it is reachable.
+func (u *Updater) checkHealth(name string, check *healthcheck.Config, newIP string) error {
+	if check == nil {
+		return nil
+	}
+
+	target := check.Describe(newIP)
+	if err := check.Check(newIP, u.config.IPDetection.Interface); err != nil {
+		u.logger.WarnHighlightf("⏳ 健康检查未通过，暂不发布新IP: %s (%s): %v", name, target, err)
+		u.recordEvent(status.EventError, "updater %s health check %s failed: %v", name, target, err)
+		u.mu.Lock()
+		delete(u.applied, name)
+		u.mu.Unlock()
+		return fmt.Errorf("health check %s failed: %w", target, err)
+	}
+
+	u.logger.Infof("✅ 健康检查通过: %s (%s)", name, target)
+	return nil
+}
+
+// reportAPIUsage logs the provider API calls made during this cycle and warns
+// when a provider approaches its hourly budget
+func (u *Updater) reportAPIUsage() {
+	counts := dns.APIUsage.CycleCounts()
+	if len(counts) > 0 {
+		providers := make([]string, 0, len(counts))
+		for provider := range counts {
+			providers = append(providers, provider)
+		}
+		sort.Strings(providers)
+
+		parts := make([]string, 0, len(providers))
+		for _, provider := range providers {
+			parts = append(parts, fmt.Sprintf("%s=%d", provider, counts[provider]))
+		}
+		u.logger.Infof("📊 本轮API调用次数: %s", strings.Join(parts, ", "))
+	}
+
+	for _, warning := range dns.APIUsage.BudgetWarnings() {
+		u.logger.WarnHighlightf("⚠️ %s 最近一小时API调用 %d 次，接近预算 %d 次，请考虑增大检查间隔",
+			warning.Provider, warning.LastHour, warning.Budget)
+		u.recordEvent(status.EventWarning, "%s API calls in the last hour: %d (budget %d)",
+			warning.Provider, warning.LastHour, warning.Budget)
+	}
+}
+
 func (u *Updater) UpdateFiles(newIP string) error {
 	// Skip if no file updaters configured
 	if len(u.config.FileUpdaters) == 0 {
@@ -82,16 +640,50 @@ func (u *Updater) UpdateFiles(newIP string) error {
 		return nil
 	}
 
+	if err := u.checkAddresses(newIP, ""); err != nil {
+		return fmt.Errorf("File updates failed: %w", err)
+	}
+
 	var errors []string
+	u.beginResults()
 
 	// Update configuration files
 	for _, fileUpdater := range u.config.FileUpdaters {
+		if !u.inPass(fileUpdater.Name, fileUpdater.Cron) {
+			continue
+		}
+		if pending := u.pendingDependencies(fileUpdater.DependsOn, newIP); len(pending) > 0 {
+			errMsg := fmt.Sprintf("File update skipped for %s: waiting for %s", fileUpdater.Name, strings.Join(pending, ", "))
+			u.logger.WarnHighlight(errMsg)
+			u.recordEvent(status.EventError, "%s", errMsg)
+			errors = append(errors, errMsg)
+			u.addResult(fileUpdater.Name, "file", "", errMsg)
+			continue
+		}
+
+		if err := u.checkHealth(fileUpdater.Name, fileUpdater.HealthCheck, newIP); err != nil {
+			errMsg := fmt.Sprintf("File update skipped for %s: %v", fileUpdater.Name, err)
+			errors = append(errors, errMsg)
+			u.addResult(fileUpdater.Name, "file", "", errMsg)
+			continue
+		}
+
 		if err := u.updateFileWithRetry(fileUpdater, newIP); err != nil {
 			errMsg := fmt.Sprintf("File update failed for %s: %v", fileUpdater.Name, err)
 			u.logger.ErrorHighlight(errMsg)
+			u.recordEvent(status.EventError, "%s", errMsg)
 			errors = append(errors, errMsg)
+			u.mu.Lock()
+			delete(u.applied, fileUpdater.Name)
+			u.mu.Unlock()
+			u.addResult(fileUpdater.Name, "file", "", err.Error())
 		} else {
 			u.logger.Successf("文件更新成功: %s", fileUpdater.Name)
+			u.recordEvent(status.EventUpdate, "File updater %s applied %s", fileUpdater.Name, newIP)
+			u.mu.Lock()
+			u.applied[fileUpdater.Name] = newIP
+			u.mu.Unlock()
+			u.addResult(fileUpdater.Name, "file", "", "")
 		}
 	}
 
@@ -102,8 +694,69 @@ func (u *Updater) UpdateFiles(newIP string) error {
 	return nil
 }
 
-func (u *Updater) updateDNSWithRetry(dnsUpdater config.DNSUpdater, newIP string) error {
+// updateDNSWithFallback applies the update with the primary provider and,
+// if that still fails after retries, with the fallback provider. It returns
+// the provider that applied the change.
+func (u *Updater) updateDNSWithFallback(dnsUpdater config.DNSUpdater, newIP string) (string, error) {
+	fallback, hasFallback := dnsUpdater.FallbackUpdater()
+
+	// With infinite retries the primary would never give up, so the number of
+	// attempts is bounded when there is a fallback to switch to
+	maxRetries := u.config.Retry.MaxRetries
+	if hasFallback && maxRetries == -1 {
+		maxRetries = fallbackPrimaryRetries
+	}
+	if len(dnsUpdater.Replicas) > 0 && maxRetries == -1 {
+		maxRetries = replicaRetries
+	}
+
+	err := u.updateDNSWithRetry(dnsUpdater, newIP, maxRetries)
+	if err == nil {
+		return dnsUpdater.Provider, nil
+	}
+	if !hasFallback {
+		return "", err
+	}
+
+	u.logger.WarnHighlightf("主服务商 %s 更新失败，改用备用服务商 %s: %s", dnsUpdater.Provider, fallback.Provider, dnsUpdater.Name)
+	u.recordEvent(status.EventError, "DNS updater %s: primary provider %s failed (%v), trying fallback %s",
+		dnsUpdater.Name, dnsUpdater.Provider, err, fallback.Provider)
+
+	// Bounded like the primary, so a fallback that is down too doesn't hold
+	// back the replicas, the other updaters and the following checks
+	if fallbackErr := u.updateDNSWithRetry(fallback, newIP, maxRetries); fallbackErr != nil {
+		return "", fmt.Errorf("primary %s: %v; fallback %s: %v", dnsUpdater.Provider, err, fallback.Provider, fallbackErr)
+	}
+	return fallback.Provider, nil
+}
+
+// updateDNSReplicas applies the update with each replica provider, one
+// result per provider, and returns the error messages of the failed ones
+func (u *Updater) updateDNSReplicas(dnsUpdater config.DNSUpdater, newIP string) []string {
 	maxRetries := u.config.Retry.MaxRetries
+	if maxRetries == -1 {
+		maxRetries = replicaRetries
+	}
+
+	var errs []string
+	for _, replica := range dnsUpdater.ReplicaUpdaters() {
+		if err := u.updateDNSWithRetry(replica, newIP, maxRetries); err != nil {
+			errMsg := fmt.Sprintf("DNS update failed for %s (replica %s): %v", dnsUpdater.Name, replica.Provider, err)
+			u.logger.ErrorHighlight(errMsg)
+			u.recordEvent(status.EventError, "%s", errMsg)
+			errs = append(errs, errMsg)
+			u.addResult(dnsUpdater.Name, "dns", replica.Provider, err.Error())
+			continue
+		}
+
+		u.logger.Successf("DNS记录更新成功: %s (副本服务商: %s)", dnsUpdater.Name, replica.Provider)
+		u.recordEvent(status.EventUpdate, "DNS updater %s replicated to %s", dnsUpdater.Name, replica.Provider)
+		u.addResult(dnsUpdater.Name, "dns", replica.Provider, "")
+	}
+	return errs
+}
+
+func (u *Updater) updateDNSWithRetry(dnsUpdater config.DNSUpdater, newIP string, maxRetries int) error {
 	if maxRetries == -1 {
 		maxRetries = 999999 // Set a very high number for "infinite" retries
 	}
@@ -114,13 +767,18 @@ func (u *Updater) updateDNSWithRetry(dnsUpdater config.DNSUpdater, newIP string)
 			time.Sleep(time.Duration(u.config.Retry.Interval) * time.Second)
 		}
 
-		err := u.dnsManager.UpdateDNSRecord(dnsUpdater, newIP)
+		ipv4, ipv6 := u.recordAddresses(newIP, u.currentIPv6)
+		err := u.dnsManager.UpdateDNSRecordAddresses(dnsUpdater, ipv4, ipv6)
 		if err == nil {
 			return nil
 		}
 
 		u.logger.ErrorHighlightf("DNS update attempt %d failed for %s: %v", attempt+1, dnsUpdater.Name, err)
 
+		if errors.Is(err, dns.ErrClockSkew) {
+			u.logger.ErrorHighlightf("⏰ %s 拒绝了请求时间戳，本机时钟可能不准确，请同步系统时间(NTP)后再试，暂不重试", dnsUpdater.Provider)
+		}
+
 		// Don't retry on certain errors
 		if isNonRetryableError(err) {
 			return err
@@ -130,13 +788,62 @@ func (u *Updater) updateDNSWithRetry(dnsUpdater config.DNSUpdater, newIP string)
 	return fmt.Errorf("DNS update failed after %d attempts", maxRetries+1)
 }
 
-func (u *Updater) updateFileWithRetry(fileUpdater config.FileUpdater, newIP string) error {
+// newFileUpdater builds the file updater for a file_updater section
+func (u *Updater) newFileUpdater(fileUpdater config.FileUpdater) *fileupdate.FileUpdater {
 	updater := fileupdate.New(
 		fileUpdater.FilePath,
 		fileUpdater.Format,
 		fileUpdater.KeyPath,
 		fileUpdater.Backup,
 	)
+	updater.VerifyWrite = fileUpdater.VerifyWrite
+	updater.KeySeparator = fileUpdater.KeySeparator
+	updater.BackupDir = fileUpdater.BackupDir
+	updater.BackupTimestamp = fileUpdater.BackupTimestamp
+	updater.BackupKeep = fileUpdater.BackupKeep
+	if fileUpdater.MaxFileSize != 0 {
+		updater.MaxFileSize = int64(fileUpdater.MaxFileSize) << 20
+	}
+	updater.RefuseOversize = fileUpdater.MaxFileSizeAction == config.FileSizeActionError
+	if fileUpdater.List {
+		updater.ListDelimiter = fileUpdater.ListDelimiter
+		u.mu.Lock()
+		updater.ManagedValue = u.listEntries[fileUpdater.Name]
+		u.mu.Unlock()
+	}
+	return updater
+}
+
+// FilePreview is the change a file updater would make, see PreviewFiles
+type FilePreview struct {
+	Name     string
+	FilePath string
+	Old      []byte
+	New      []byte
+	Err      error
+}
+
+// PreviewFiles runs every file updater against newIP without writing
+// anything, for -diff. List updaters start from the entries restored with
+// SetListEntries, as in a real pass; depends_on and health checks are not
+// evaluated.
+func (u *Updater) PreviewFiles(newIP string) []FilePreview {
+	previews := make([]FilePreview, 0, len(u.config.FileUpdaters))
+	for _, fileUpdater := range u.config.FileUpdaters {
+		preview := FilePreview{Name: fileUpdater.Name, FilePath: fileUpdater.FilePath}
+		updater := u.newFileUpdater(fileUpdater)
+		if err := updater.ValidateFile(); err != nil {
+			preview.Err = fmt.Errorf("file validation failed: %w", err)
+		} else {
+			preview.Old, preview.New, preview.Err = updater.Preview(newIP)
+		}
+		previews = append(previews, preview)
+	}
+	return previews
+}
+
+func (u *Updater) updateFileWithRetry(fileUpdater config.FileUpdater, newIP string) error {
+	updater := u.newFileUpdater(fileUpdater)
 	updater.SetLogger(u.logger)
 
 	// Validate file first
@@ -157,6 +864,11 @@ func (u *Updater) updateFileWithRetry(fileUpdater config.FileUpdater, newIP stri
 
 		err := updater.UpdateIP(newIP)
 		if err == nil {
+			if fileUpdater.List {
+				u.mu.Lock()
+				u.listEntries[fileUpdater.Name] = updater.ManagedValue
+				u.mu.Unlock()
+			}
 			return nil
 		}
 
@@ -172,6 +884,18 @@ func (u *Updater) updateFileWithRetry(fileUpdater config.FileUpdater, newIP stri
 }
 
 func isNonRetryableError(err error) bool {
+	// A skewed clock fails every attempt until it is fixed
+	if errors.Is(err, dns.ErrClockSkew) || errors.Is(err, dns.ErrAmbiguousRecord) {
+		return true
+	}
+
+	// The HTTP status decides when the provider reported one: 5xx and 429
+	// are retried, other 4xx are not, whatever the message says
+	var statusErr *dns.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return !statusErr.Retryable()
+	}
+
 	// Define errors that shouldn't be retried
 	errorString := err.Error()
 
@@ -0,0 +1,96 @@
+package updater
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ip-updater/internal/config"
+	"ip-updater/internal/logger"
+	"ip-updater/internal/netutil"
+)
+
+func newTestUpdater(cfg *config.Config) *Updater {
+	log := logger.New()
+	log.SetOutput(io.Discard)
+	return New(cfg, log)
+}
+
+func TestFallbackRetriesAreBounded(t *testing.T) {
+	cfg := &config.Config{Retry: config.RetryConfig{Interval: 0, MaxRetries: -1}}
+	u := newTestUpdater(cfg)
+
+	updater := config.DNSUpdater{
+		Name:        "home",
+		Provider:    "null",
+		Domain:      "example.com",
+		Records:     []config.DNSRecord{{Name: "www", Type: "A"}},
+		ExtraConfig: map[string]string{"fail_rate": "1"},
+		Fallback:    &config.FallbackProvider{Provider: "mock"},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := u.updateDNSWithFallback(updater, "203.0.113.7")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error when both providers fail")
+		}
+		for _, want := range []string{"primary null", "fallback mock"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q doesn't mention %q", err, want)
+			}
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("fallback kept retrying with max_retries = -1")
+	}
+}
+
+func TestUnusableAddressesAreRefused(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	if err := os.WriteFile(path, []byte(`{"ip": "192.0.2.1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		DNSUpdaters: []config.DNSUpdater{{
+			Name:     "home",
+			Provider: "null",
+			Domain:   "example.com",
+			Records:  []config.DNSRecord{{Name: "www", Type: "A"}, {Name: "www", Type: "AAAA"}},
+		}},
+		FileUpdaters: []config.FileUpdater{{Name: "app", FilePath: path, Format: "json", KeyPath: "ip"}},
+	}
+	u := newTestUpdater(cfg)
+
+	for _, tc := range []struct{ ipv4, ipv6 string }{
+		{"", ""},
+		{" ", ""},
+		{"0.0.0.0", ""},
+		{"203.0.113.7", "::"},
+	} {
+		if err := u.UpdateDNSAddresses(tc.ipv4, tc.ipv6); !errors.Is(err, netutil.ErrUnusableAddress) {
+			t.Errorf("UpdateDNSAddresses(%q, %q) = %v, want ErrUnusableAddress", tc.ipv4, tc.ipv6, err)
+		}
+	}
+	for _, ip := range []string{"", "0.0.0.0", "::"} {
+		if err := u.UpdateFiles(ip); !errors.Is(err, netutil.ErrUnusableAddress) {
+			t.Errorf("UpdateFiles(%q) = %v, want ErrUnusableAddress", ip, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"ip": "192.0.2.1"}` {
+		t.Fatalf("file was rewritten: %s", data)
+	}
+}
@@ -0,0 +1,460 @@
+// Package app drives ip_updater: it detects the public IP and applies it to
+// the configured DNS records and files. cmd/ip_updater is a thin wrapper
+// around it, and other Go programs can embed the same logic:
+//
+//	cfg, err := app.LoadConfig("/etc/ip_updater/config.conf")
+//	if err != nil {
+//		return err
+//	}
+//	err = app.New(cfg).RunOnce(ctx)
+//
+// An App is not safe for concurrent use: call either RunOnce or Run, from one
+// goroutine. Reload may be called from any goroutine.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"ip-updater/internal/config"
+	"ip-updater/internal/detector"
+	"ip-updater/internal/logger"
+	"ip-updater/internal/netutil"
+	"ip-updater/internal/notify"
+	"ip-updater/internal/privdrop"
+	"ip-updater/internal/schedule"
+	"ip-updater/internal/statefile"
+	"ip-updater/internal/status"
+	"ip-updater/internal/updater"
+	"ip-updater/pkg/dns"
+)
+
+// Config is the ip_updater configuration, as loaded by LoadConfig
+type Config = config.Config
+
+// Logger is the logger used by the App and everything it drives
+type Logger = logger.Logger
+
+// LoadConfig reads, validates and decrypts a configuration file
+func LoadConfig(path string) (*Config, error) {
+	return config.Load(path)
+}
+
+// NewLogger returns a logger writing to stdout; Run and RunOnce configure it
+// from the [logging] section
+func NewLogger() *Logger {
+	return logger.New()
+}
+
+// configWatchDebounce coalesces bursts of writes to the config file
+const configWatchDebounce = time.Second
+
+// routeCheckInterval is how often the default route is re-resolved when
+// local_addr = "auto"
+const routeCheckInterval = 30 * time.Second
+
+type App struct {
+	cfg        *config.Config
+	configPath string
+	version    string
+	force      bool
+	log        *logger.Logger
+
+	detector *detector.Detector
+	updater  *updater.Updater
+	notifier *notify.Notifier
+	dnsGate  *schedule.Gate
+	state    *status.State
+	history  *history
+
+	// IPs the DNS and file checks last applied, and the persisted state
+	dnsLastIP   string
+	dnsLastIPv6 string
+	fileLastIP  string
+	savedState  *statefile.State
+
+	// Consecutive failed detections, for failure_alert_after
+	detectFailures     int
+	detectFailingSince time.Time
+
+	// DNS updaters publishing their fallback_ip, see fallback.go
+	fallback map[string]string
+
+	// External reachability checks, see reachability.go
+	reachability reachabilityState
+
+	// Last error writing status.textfile_dir, logged once until it changes
+	textfileErr string
+
+	// shutdown_timeout in nanoseconds, read by signal handlers
+	shutdownTimeout atomic.Int64
+
+	ready      bool
+	reloadChan chan string
+
+	// Only used by Run
+	dnsTicker     *time.Ticker
+	fileTicker    *time.Ticker
+	deferTimer    *time.Timer
+	cronTimer     *time.Timer
+	cronJobs      []*cronJob
+	configWatcher *config.Watcher
+}
+
+func New(cfg *Config) *App {
+	return &App{
+		cfg:        cfg,
+		log:        logger.New(),
+		state:      status.NewState(cfg.Status.EventBufferSize),
+		history:    newHistory(cfg.Status.HistorySize),
+		reloadChan: make(chan string, 1),
+	}
+}
+
+func (a *App) SetLogger(log *Logger) {
+	a.log = log
+}
+
+// SetConfigPath sets the file the configuration was loaded from. It is
+// needed for Reload and watch_config.
+func (a *App) SetConfigPath(path string) {
+	a.configPath = path
+}
+
+// SetVersion sets the version reported by the status endpoint and logs
+func (a *App) SetVersion(version string) {
+	a.version = version
+}
+
+// SetForce makes the startup pass update everything regardless of
+// startup_update and the state file
+func (a *App) SetForce(force bool) {
+	a.force = force
+}
+
+// State returns the runtime status (recent events, deferred updates)
+func (a *App) State() *status.State {
+	return a.state
+}
+
+// History returns the recent public IP changes, oldest first
+func (a *App) History() []Change {
+	return a.history.entries()
+}
+
+// Reload asks a running Run loop to re-read the config file. It never blocks;
+// a reload already pending absorbs the request.
+func (a *App) Reload(reason string) {
+	select {
+	case a.reloadChan <- reason:
+	default:
+	}
+}
+
+// setup builds the detector, updater and notifier from the configuration.
+// It runs once, on the first call to Run or RunOnce.
+func (a *App) setup() error {
+	if a.ready {
+		return nil
+	}
+	cfg := a.cfg
+	log := a.log
+
+	// Configure logger with loaded settings
+	if err := log.Configure(cfg.Logging.Level, cfg.Logging.Output, cfg.Logging.FilePath, cfg.Logging.MaxSize, cfg.Logging.MaxAge, cfg.Logging.Compress, cfg.Logging.Syslog()); err != nil {
+		log.Warnf("Failed to configure logger: %v", err)
+	} else if log.SyslogEnabled() {
+		log.Infof("📨 日志发送到syslog: %s", cfg.Logging.Syslog())
+	}
+	log.SetMaskIPs(cfg.Logging.MaskIPInLogs)
+	for _, warning := range cfg.Warnings {
+		log.WarnHighlightf("配置警告: %s", warning)
+	}
+
+	// Route outbound requests through the configured source address
+	if err := netutil.SetLocalAddr(cfg.LocalAddr); err != nil {
+		return fmt.Errorf("invalid local_addr: %w", err)
+	}
+	if cfg.LocalAddr == netutil.LocalAddrAuto {
+		if addr, err := netutil.DefaultRouteAddr(); err == nil {
+			log.Infof("出站请求跟随默认路由，当前源地址: %s (%s)", addr, netutil.InterfaceName(addr))
+		} else {
+			log.WarnHighlightf("出站请求跟随默认路由，但当前无法确定默认路由: %v", err)
+		}
+	} else if cfg.LocalAddr != "" {
+		log.Infof("出站请求使用源地址: %s", cfg.LocalAddr)
+	}
+
+	a.state.SetAPIUsageSource(func() interface{} {
+		return dns.APIUsage.Snapshot()
+	})
+	a.state.SetHistorySource(func() interface{} {
+		return a.history.entries()
+	})
+
+	// DNS update schedule gate (already validated by config.Load)
+	dnsGate, err := schedule.NewGate(cfg.Schedule.ActiveHours, cfg.Schedule.FreezeWindows)
+	if err != nil {
+		return fmt.Errorf("invalid schedule configuration: %w", err)
+	}
+
+	// Webhook notifications (templates already validated by config.Load)
+	notifier, err := notify.New(cfg.Notify)
+	if err != nil {
+		return fmt.Errorf("invalid notify configuration: %w", err)
+	}
+	notifier.SetLogger(log)
+
+	// 上次成功应用的IP，用于重启后判断是否需要更新
+	loadState := statefile.Load
+	if cfg.SignStateFile {
+		loadState = statefile.LoadSigned
+	}
+	savedState, err := loadState(cfg.StateFile)
+	if errors.Is(err, statefile.ErrSignatureMismatch) {
+		log.WarnHighlightf("状态文件签名校验失败，可能已被篡改或损坏，将按首次运行处理: %s", cfg.StateFile)
+		a.state.Events.Add(status.EventWarning, "state file %s failed signature verification, ignored", cfg.StateFile)
+	} else if err != nil {
+		log.Warnf("读取状态文件失败，将按首次运行处理: %v", err)
+	}
+	a.savedState = savedState
+	a.fallback = maps.Clone(savedState.Fallback)
+	if a.fallback == nil {
+		a.fallback = make(map[string]string)
+	}
+
+	// A change against the last applied IP shows up in the history at startup
+	lastIP := savedState.DNSIP
+	if lastIP == "" {
+		lastIP = savedState.FileIP
+	}
+	a.history.seed(a.primaryFamily(), lastIP)
+	a.history.seed(familyIPv6, savedState.DNSIPv6)
+
+	a.install(cfg, dnsGate, notifier, savedState)
+	a.ready = true
+	return nil
+}
+
+// install swaps in the components built from cfg. The new updater takes
+// over what restore remembers about the updaters.
+func (a *App) install(cfg *config.Config, dnsGate *schedule.Gate, notifier *notify.Notifier, restore *statefile.State) {
+	a.cfg = cfg
+	a.dnsGate = dnsGate
+	a.notifier = notifier
+	a.shutdownTimeout.Store(int64(time.Duration(cfg.ShutdownTimeout) * time.Second))
+
+	a.detector = detector.New(cfg.IPDetection)
+	a.detector.SetLogger(a.log)
+	a.detector.SetEscalated(a.detectionAlerted())
+
+	a.updater = updater.New(cfg, a.log)
+	a.updater.SetEvents(a.state.Events)
+	a.updater.SetMetrics(a.state.Metrics)
+	a.updater.SetIPv6Source(a.detector)
+	a.updater.SetListEntries(restore.ListEntries)
+	a.updater.SetAsserted(restore.DNSAsserted)
+	a.updater.SetMatchedRecords(restore.MatchedRecords)
+}
+
+// RunOnce detects the public IP once, applies it where needed and returns.
+// Like the startup pass of Run, updaters whose IP matches the state file are
+// skipped unless SetForce or startup_update = "always" is used. The returned
+// error joins the detection and update failures.
+func (a *App) RunOnce(ctx context.Context) error {
+	if err := a.setup(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := a.dropPrivileges(); err != nil {
+		return err
+	}
+
+	_, err := a.startup()
+
+	// 等待通知发送完成，避免进程退出时丢失；暂缓和汇总中的通知也立即发送
+	a.notifier.FlushAll()
+	a.notifier.Wait()
+	return err
+}
+
+// Run performs the startup pass and then keeps checking on the configured
+// intervals until ctx is cancelled. It also serves the status endpoint,
+// follows default route changes and handles Reload and watch_config.
+func (a *App) Run(ctx context.Context) error {
+	if err := a.setup(); err != nil {
+		return err
+	}
+	log := a.log
+
+	statusServer := a.startStatusServer()
+	if err := a.dropPrivileges(); err != nil {
+		if statusServer != nil {
+			statusServer.Shutdown(context.Background())
+		}
+		return err
+	}
+
+	log.Infof("IP-Updater v%s started", a.version)
+	if a.cfg.CombinedUpdates {
+		log.Infof("Combined DNS and file check interval: %d minutes", a.cfg.DNSCheckInterval/60)
+	} else {
+		log.Infof("DNS check interval: %d minutes", a.cfg.DNSCheckInterval/60)
+		log.Infof("File check interval: %d minutes", a.cfg.FileCheckInterval/60)
+	}
+	log.Infof("Configured DNS updaters: %d", len(a.cfg.DNSUpdaters))
+	log.Infof("Configured file updaters: %d", len(a.cfg.FileUpdaters))
+
+	// 创建分离的定时器
+	a.dnsTicker = time.NewTicker(time.Duration(a.cfg.DNSCheckInterval) * time.Second)
+	defer a.dnsTicker.Stop()
+
+	a.fileTicker = time.NewTicker(time.Duration(a.cfg.FileCheckInterval) * time.Second)
+	defer a.fileTicker.Stop()
+
+	routeTicker := time.NewTicker(routeCheckInterval)
+	defer routeTicker.Stop()
+
+	// 冻结时段结束时触发一次DNS检查
+	a.deferTimer = time.NewTimer(time.Hour)
+	a.deferTimer.Stop()
+	defer a.deferTimer.Stop()
+
+	// dns_check_cron, file_check_cron and per-updater cron schedules
+	a.cronTimer = time.NewTimer(time.Hour)
+	a.cronTimer.Stop()
+	defer a.cronTimer.Stop()
+
+	// Optional config file watcher, toggled by watch_config
+	a.syncWatcher()
+	defer func() {
+		if a.configWatcher != nil {
+			a.configWatcher.Close()
+			a.configWatcher = nil
+		}
+	}()
+
+	// 启动时立即执行一次检测和更新；失败已记录日志，之后按周期重试
+	startEvent, _ := a.startup()
+
+	// notify_on_start: 确认服务已启动以及检测到的IP
+	if a.cfg.Notify.NotifyOnStart {
+		a.notifier.Notify(startEvent)
+	}
+
+	// 启动更新包含所有更新器，之后设置了cron的按cron检查
+	a.setupCron()
+
+	for {
+		var watchChanges <-chan struct{}
+		var watchErrors <-chan error
+		if a.configWatcher != nil {
+			watchChanges = a.configWatcher.Changes
+			watchErrors = a.configWatcher.Errors
+		}
+
+		select {
+		case <-ctx.Done():
+			a.shutdown(statusServer)
+			return nil
+
+		case <-a.dnsTicker.C:
+			if a.cfg.CombinedUpdates {
+				a.checkCombined()
+			} else {
+				a.checkDNS()
+			}
+
+		case <-a.deferTimer.C:
+			log.Info("DNS更新时间窗口已打开，应用最新IP...")
+			a.checkDNS()
+
+		case <-a.fileTicker.C:
+			a.checkFiles()
+
+		case <-a.cronTimer.C:
+			a.runCron()
+
+		case <-routeTicker.C:
+			// 双WAN切换后立即按新线路检测，不等待下一个检查周期
+			changed, addr, err := netutil.RefreshDefaultRoute()
+			if err != nil {
+				log.Debugf("默认路由检测失败: %v", err)
+			} else if changed {
+				log.WarnHighlightf("默认路由已切换，出站源地址改为: %s (%s)", addr, netutil.InterfaceName(addr))
+				a.state.Events.Add(status.EventChange, "default route changed, outbound source address is now %s", addr)
+				a.detector.InvalidateCache()
+				a.checkAll()
+			}
+
+		case reason := <-a.reloadChan:
+			a.reload(reason)
+
+		case <-watchChanges:
+			a.reload("config file changed")
+
+		case err := <-watchErrors:
+			log.Warnf("配置文件监视错误: %v", err)
+		}
+	}
+}
+
+// dropPrivileges switches to run_as_user once the log file is open and the
+// status port is bound. Refusing to continue as root when the switch fails is
+// deliberate: the user asked not to run privileged.
+func (a *App) dropPrivileges() error {
+	cfg := a.cfg
+	if cfg.RunAsUser == "" {
+		return nil
+	}
+	if os.Geteuid() != 0 {
+		a.log.Warnf("run_as_user = %q 仅在以root启动时生效，当前用户 uid=%d，保持不变", cfg.RunAsUser, os.Geteuid())
+		return nil
+	}
+
+	creds, err := privdrop.Lookup(cfg.RunAsUser, cfg.RunAsGroup)
+	if err != nil {
+		return fmt.Errorf("invalid run_as_user: %w", err)
+	}
+	if err := privdrop.Drop(creds); err != nil {
+		return fmt.Errorf("failed to drop privileges to %s: %w", creds.User, err)
+	}
+
+	a.log.Infof("🔒 已切换到用户 %s (uid=%d), 用户组 %s (gid=%d)", creds.User, creds.UID, creds.Group, creds.GID)
+	return nil
+}
+
+// startStatusServer starts the status endpoint if configured; a failure to
+// listen is logged and the service runs without it
+func (a *App) startStatusServer() *status.Server {
+	cfg := a.cfg
+	if cfg.Status.ListenAddr == "" {
+		return nil
+	}
+
+	statusServer := status.NewServer(cfg.Status.ListenAddr, a.version, a.state)
+	statusAuth := status.Auth{
+		Token:    cfg.Status.AuthToken,
+		Username: cfg.Status.BasicAuthUser,
+		Password: cfg.Status.BasicAuthPassword,
+	}
+	statusServer.SetAuth(statusAuth)
+	if err := statusServer.Start(); err != nil {
+		a.log.WarnHighlightf("状态服务启动失败 (%s): %v", statusServer.Addr(), err)
+		return nil
+	}
+
+	a.log.Infof("Status endpoint listening on http://%s/status", statusServer.Addr())
+	if statusAuth.Token == "" && statusAuth.Username == "" && !status.IsLoopback(statusServer.Addr()) {
+		a.log.WarnHighlight("状态服务对外监听且未配置认证，当前公网IP等信息可能被他人获取")
+	}
+	return statusServer
+}
@@ -0,0 +1,541 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"ip-updater/internal/config"
+	"ip-updater/internal/detector"
+	"ip-updater/internal/notify"
+	"ip-updater/internal/statefile"
+	"ip-updater/internal/status"
+)
+
+// notifyUpdate reports the per-updater results of the pass that just ran.
+// Updaters with notify = false are left out; when only such updaters ran,
+// nothing is sent.
+func (a *App) notifyUpdate(event notify.Event, updateErr error) {
+	results := resultsFor(event.Type, a.updater.NotifyResults())
+	if len(results) == 0 && len(resultsFor(event.Type, a.updater.LastResults())) > 0 {
+		a.log.Debugf("本次更新的更新器均已关闭通知 (notify = false)，不发送通知")
+		return
+	}
+
+	event.Success = updateErr == nil
+	if !event.Success {
+		// The failure may belong to an updater that is left out
+		event.Success = true
+		for _, result := range results {
+			event.Success = event.Success && result.Success
+		}
+	}
+	event.Results = results
+	a.notifier.Notify(event)
+}
+
+// resultsFor keeps the results of the kind of updater a DNS or file update
+// event reports on: after a combined_updates pass the updater holds both
+func resultsFor(eventType string, results []notify.Result) []notify.Result {
+	var kind string
+	switch eventType {
+	case notify.EventDNSUpdate:
+		kind = "dns"
+	case notify.EventFileUpdate:
+		kind = "file"
+	default:
+		return results
+	}
+	return slices.DeleteFunc(results, func(result notify.Result) bool {
+		return result.Kind != kind
+	})
+}
+
+// detectionAlerted reports whether the current run of failed detections has
+// reached failure_alert_after
+func (a *App) detectionAlerted() bool {
+	threshold := a.cfg.IPDetection.FailureAlertAfter
+	return threshold > 0 && a.detectFailures >= threshold
+}
+
+// detectionFailed counts a failed detection; reaching failure_alert_after
+// raises one alert for the outage and switches on the escalation endpoints
+func (a *App) detectionFailed(err error) {
+	a.state.Metrics.ObserveDetection(false)
+	a.detectFailures++
+	if a.detectFailures == 1 {
+		a.detectFailingSince = time.Now()
+	}
+	defer a.startFallback()
+	if a.detectFailures != a.cfg.IPDetection.FailureAlertAfter {
+		return
+	}
+
+	failingFor := time.Since(a.detectFailingSince).Round(time.Second)
+	a.log.ErrorHighlightf("🚨 公网IP检测已连续失败 %d 次 (持续 %s): %v", a.detectFailures, failingFor, err)
+	a.state.Events.Add(status.EventError, "detection has been failing for %s (%d consecutive failures)", failingFor, a.detectFailures)
+
+	if len(a.cfg.IPDetection.EscalationEndpoints) > 0 {
+		a.log.Warnf("⚠️ 启用备用检测端点: %v", a.cfg.IPDetection.EscalationEndpoints)
+		a.detector.SetEscalated(true)
+	}
+
+	a.notifier.Notify(notify.Event{
+		Type:     notify.EventDetectionFailed,
+		OldIP:    a.lastKnownIP(),
+		Failures: a.detectFailures,
+		Error:    fmt.Sprintf("detection has been failing for %s: %v", failingFor, err),
+	})
+}
+
+// detectionSkipped reports whether detection was skipped by the liveness
+// check. Being offline isn't a detection failure: it is neither logged as an
+// error nor counted towards failure_alert_after or fallback_after.
+func (a *App) detectionSkipped(err error) bool {
+	if !errors.Is(err, detector.ErrOffline) {
+		return false
+	}
+	a.log.Debugf("主机离线，跳过本次检查: %v", err)
+	return true
+}
+
+// detectionSucceeded ends a run of failed detections, reporting the recovery
+// when it had been alerted
+func (a *App) detectionSucceeded(ip string) {
+	a.state.Metrics.ObserveDetection(true)
+	defer a.endFallback(ip)
+	if a.detectFailures == 0 {
+		return
+	}
+	// The regular endpoints are still failing; stay escalated
+	if a.detector.FromEscalation() {
+		return
+	}
+
+	if a.detectionAlerted() {
+		failedFor := time.Since(a.detectFailingSince).Round(time.Second)
+		a.log.Successf("公网IP检测已恢复 (此前连续失败 %d 次，持续 %s): %s", a.detectFailures, failedFor, ip)
+		a.state.Events.Add(status.EventDetection, "detection recovered after %d failures", a.detectFailures)
+		a.detector.SetEscalated(false)
+		a.notifier.Notify(notify.Event{
+			Type:     notify.EventDetectionRecovered,
+			OldIP:    a.lastKnownIP(),
+			NewIP:    ip,
+			Success:  true,
+			Failures: a.detectFailures,
+		})
+	}
+
+	a.detectFailures = 0
+	a.detectFailingSince = time.Time{}
+}
+
+// lastKnownIP is the IP last applied, for detection notifications
+func (a *App) lastKnownIP() string {
+	if a.dnsLastIP != "" {
+		return a.dnsLastIP
+	}
+	if a.fileLastIP != "" {
+		return a.fileLastIP
+	}
+	return a.savedState.DNSIP
+}
+
+// persistState saves the applied IPs whenever they change. It runs after
+// every check, so the textfile metrics are refreshed here too.
+func (a *App) persistState() {
+	a.writeTextfile()
+
+	// Only IPs actually applied by configured updaters are remembered
+	next := *a.savedState
+	if a.dnsLastIP != "" && len(a.cfg.DNSUpdaters) > 0 {
+		next.DNSIP = a.dnsLastIP
+	}
+	if a.dnsLastIPv6 != "" && len(a.cfg.DNSUpdaters) > 0 {
+		next.DNSIPv6 = a.dnsLastIPv6
+	}
+	if a.fileLastIP != "" && len(a.cfg.FileUpdaters) > 0 {
+		next.FileIP = a.fileLastIP
+	}
+	next.ListEntries = a.updater.ListEntries()
+	next.Fallback = maps.Clone(a.fallback)
+	next.DNSAsserted = a.updater.Asserted()
+	next.MatchedRecords = a.updater.MatchedRecords()
+	if next.DNSIP == a.savedState.DNSIP && next.FileIP == a.savedState.FileIP &&
+		next.DNSIPv6 == a.savedState.DNSIPv6 && maps.Equal(next.ListEntries, a.savedState.ListEntries) &&
+		maps.Equal(next.Fallback, a.savedState.Fallback) &&
+		maps.EqualFunc(next.DNSAsserted, a.savedState.DNSAsserted, time.Time.Equal) &&
+		maps.Equal(next.MatchedRecords, a.savedState.MatchedRecords) {
+		return
+	}
+
+	next.UpdatedAt = time.Now()
+	saveState := statefile.Save
+	if a.cfg.SignStateFile {
+		saveState = statefile.SaveSigned
+	}
+	if err := saveState(a.cfg.StateFile, &next); err != nil {
+		a.log.Warnf("保存状态文件失败: %v", err)
+		return
+	}
+	*a.savedState = next
+}
+
+// dnsUpdateAllowed checks the schedule gate; outside the allowed window the
+// change is recorded as deferred and re-checked when the window opens.
+func (a *App) dnsUpdateAllowed(ip string) bool {
+	now := time.Now()
+	if a.dnsGate.Allows(now) {
+		a.state.ClearDeferred()
+		return true
+	}
+
+	openAt := a.dnsGate.NextOpen(now)
+	a.state.SetDeferred(ip, "schedule", openAt)
+	a.state.Events.Add(status.EventChange, "DNS update to %s deferred by schedule", ip)
+	if openAt.IsZero() {
+		a.log.WarnHighlightf("DNS更新处于冻结时段，推迟应用新IP: %s", ip)
+		return false
+	}
+
+	a.log.WarnHighlightf("DNS更新处于冻结时段，推迟应用新IP: %s (将于 %s 应用)", ip, openAt.Format("2006-01-02 15:04"))
+	if a.deferTimer != nil {
+		a.deferTimer.Reset(time.Until(openAt))
+	}
+	return false
+}
+
+func (a *App) checkDNS() {
+	defer a.persistState()
+	log := a.log
+	events := a.state.Events
+
+	// Both families are detected together for AAAA and A+AAAA records
+	currentIP, currentIPv6, err := a.detector.GetPublicIPs(a.cfg.HasIPv6Records())
+	if err != nil {
+		if a.detectionSkipped(err) {
+			return
+		}
+		log.ErrorHighlightf("获取公网IP失败(DNS检查): %v", err)
+		events.Add(status.EventError, "DNS check detection failed: %v", err)
+		a.detectionFailed(err)
+		return
+	}
+	a.detectionSucceeded(currentIP)
+	a.checkReachability(currentIP)
+	events.Add(status.EventDetection, "DNS check detected %s", joinAddresses(currentIP, currentIPv6))
+	a.history.observe(a.primaryFamily(), currentIP)
+	a.history.observe(familyIPv6, currentIPv6)
+
+	// IPv6 becoming unavailable is not a change: AAAA records keep their address
+	ipv6Changed := currentIPv6 != "" && currentIPv6 != a.dnsLastIPv6
+	if currentIP == a.dnsLastIP && !ipv6Changed {
+		log.Debugf("DNS check: IP unchanged (%s)", joinAddresses(currentIP, currentIPv6))
+		a.state.ClearDeferred()
+		a.verifyDNS(currentIP, currentIPv6)
+		a.reassertDNS(currentIP, currentIPv6)
+		return
+	}
+
+	if currentIP != a.dnsLastIP {
+		log.Infof("DNS check: IP changed from %s to %s", a.dnsLastIP, currentIP)
+		events.Add(status.EventChange, "DNS check: IP changed from %s to %s", a.dnsLastIP, currentIP)
+	}
+	if ipv6Changed {
+		log.Infof("DNS check: IPv6 changed from %s to %s", a.dnsLastIPv6, currentIPv6)
+		events.Add(status.EventChange, "DNS check: IPv6 changed from %s to %s", a.dnsLastIPv6, currentIPv6)
+	}
+
+	// update_order = "files_first": the files get the new IP before the
+	// records, which wait until they have it
+	if a.cfg.UpdateOrder == config.UpdateOrderFilesFirst && a.fileLastIP != currentIP {
+		log.Debugf("update_order = files_first, updating files before DNS")
+		if !a.updateFiles(currentIP) {
+			a.orderSkipped(currentIP)
+			return
+		}
+	}
+
+	if !a.updateDNS(currentIP, currentIPv6) {
+		return
+	}
+
+	// File updaters that depend on DNS run right after it
+	if a.fileLastIP != currentIP && a.cfg.FileUpdatersDependOnDNS() {
+		a.checkFiles()
+	}
+}
+
+// updateDNS applies the IP to the DNS updaters and reports whether they now
+// hold it
+func (a *App) updateDNS(currentIP, currentIPv6 string) bool {
+	log := a.log
+	if len(a.cfg.DNSUpdaters) == 0 {
+		log.Debugf("No DNS updaters configured, skipping DNS update")
+		a.dnsLastIP = currentIP
+		return true
+	}
+
+	if !a.dnsUpdateAllowed(currentIP) {
+		return false
+	}
+
+	err := a.updater.UpdateDNSAddresses(currentIP, currentIPv6)
+	a.notifyUpdate(a.dnsEvent(a.dnsLastIP, currentIP, a.dnsLastIPv6, currentIPv6), err)
+	if err != nil {
+		log.ErrorHighlightf("DNS更新失败: %v", err)
+		return false
+	}
+
+	log.Successf("DNS更新完成，新IP: %s", joinAddresses(currentIP, currentIPv6))
+	a.dnsLastIP = currentIP
+	if currentIPv6 != "" {
+		a.dnsLastIPv6 = currentIPv6
+	}
+	return true
+}
+
+func (a *App) checkFiles() {
+	defer a.persistState()
+	log := a.log
+	events := a.state.Events
+
+	currentIP, err := a.detector.GetPublicIP()
+	if err != nil {
+		if a.detectionSkipped(err) {
+			return
+		}
+		log.ErrorHighlightf("获取公网IP失败(文件检查): %v", err)
+		events.Add(status.EventError, "file check detection failed: %v", err)
+		a.detectionFailed(err)
+		return
+	}
+	a.detectionSucceeded(currentIP)
+	a.checkReachability(currentIP)
+	events.Add(status.EventDetection, "file check detected %s", currentIP)
+	a.history.observe(a.primaryFamily(), currentIP)
+
+	if currentIP == a.fileLastIP {
+		log.Debugf("File check: IP unchanged (%s)", currentIP)
+		return
+	}
+
+	log.Infof("File check: IP changed from %s to %s", a.fileLastIP, currentIP)
+	events.Add(status.EventChange, "file check: IP changed from %s to %s", a.fileLastIP, currentIP)
+
+	// update_order = "dns_first": the records get the new IP before the
+	// files, which wait until they have it (failed or deferred by schedule).
+	// Only the detected IP is applied here; AAAA records follow on the DNS check.
+	if a.cfg.UpdateOrder == config.UpdateOrderDNSFirst && a.dnsLastIP != currentIP {
+		log.Debugf("update_order = dns_first, updating DNS before files")
+		if !a.updateDNS(currentIP, "") {
+			a.orderSkipped(currentIP)
+			return
+		}
+	}
+
+	if !a.updateFiles(currentIP) {
+		return
+	}
+
+	// DNS updaters that depend on files run right after them
+	if a.dnsLastIP != currentIP && a.cfg.DNSUpdatersDependOnFiles() {
+		a.checkDNS()
+	}
+}
+
+// orderSkipped logs the pass update_order holds back because the kind that
+// goes first doesn't hold the IP yet; it is retried on the next check
+func (a *App) orderSkipped(ip string) {
+	first, skipped := "DNS", "文件"
+	if a.cfg.UpdateOrder == config.UpdateOrderFilesFirst {
+		first, skipped = "文件", "DNS"
+	}
+	a.log.WarnHighlightf("update_order = %s: %s尚未更新为 %s，跳过本次%s更新，下次检查时重试", a.cfg.UpdateOrder, first, ip, skipped)
+	a.state.Events.Add(status.EventWarning, "update to %s held back: update_order = %s and the first update has not applied it", ip, a.cfg.UpdateOrder)
+}
+
+// updateFiles applies the IP to the file updaters and reports whether they
+// now hold it
+func (a *App) updateFiles(currentIP string) bool {
+	log := a.log
+	if len(a.cfg.FileUpdaters) == 0 {
+		log.Debugf("No file updaters configured, skipping file update")
+		a.fileLastIP = currentIP
+		return true
+	}
+
+	err := a.updater.UpdateFiles(currentIP)
+	a.notifyUpdate(notify.Event{Type: notify.EventFileUpdate, OldIP: a.fileLastIP, NewIP: currentIP}, err)
+	if err != nil {
+		log.ErrorHighlightf("文件更新失败: %v", err)
+		return false
+	}
+
+	log.Successf("文件更新完成，新IP: %s", currentIP)
+	a.fileLastIP = currentIP
+	return true
+}
+
+// startup runs the initial detection and update. It returns the start event
+// for notify_on_start and the joined detection and update errors.
+func (a *App) startup() (notify.Event, error) {
+	log := a.log
+	events := a.state.Events
+	cfg := a.cfg
+
+	log.Info("执行启动时的立即检测...")
+
+	// startup_update = "if_changed" 时，IP与状态文件一致则跳过启动更新；-force 总是更新
+	forceStartup := a.force || cfg.StartupUpdate == config.StartupUpdateAlways
+	if forceStartup {
+		log.Infof("启动时执行完整更新 (startup_update=%s, force=%v)", cfg.StartupUpdate, a.force)
+	}
+
+	// 启动通知使用的上次记录的IP和本次启动更新的结果
+	startEvent := notify.Event{
+		Type:  notify.EventStart,
+		OldIP: a.savedState.DNSIP,
+	}
+	if startEvent.OldIP == "" {
+		startEvent.OldIP = a.savedState.FileIP
+	}
+
+	// DNS检测和更新
+	currentIP, currentIPv6, err := a.detector.GetPublicIPs(cfg.HasIPv6Records())
+	if err != nil {
+		if !a.detectionSkipped(err) {
+			log.ErrorHighlightf("获取公网IP失败(启动检测): %v", err)
+			events.Add(status.EventError, "startup detection failed: %v", err)
+			a.detectionFailed(err)
+		}
+		startEvent.Error = err.Error()
+		return startEvent, fmt.Errorf("detection failed: %w", err)
+	}
+	a.detectionSucceeded(currentIP)
+	a.checkReachability(currentIP)
+	log.Infof("当前公网IP: %s", joinAddresses(currentIP, currentIPv6))
+	events.Add(status.EventDetection, "startup detection: %s", joinAddresses(currentIP, currentIPv6))
+	a.history.observe(a.primaryFamily(), currentIP)
+	a.history.observe(familyIPv6, currentIPv6)
+	startEvent.NewIP = currentIP
+	startEvent.OldIPv6 = a.savedState.DNSIPv6
+	startEvent.NewIPv6 = currentIPv6
+
+	dnsEvent := a.dnsEvent(a.savedState.DNSIP, currentIP, a.savedState.DNSIPv6, currentIPv6)
+	ipv6Unchanged := currentIPv6 == "" || currentIPv6 == a.savedState.DNSIPv6
+
+	var errs []error
+
+	// The DNS and file passes run in update_order, DNS first by default
+	updateDNS := func() {
+		if len(cfg.DNSUpdaters) == 0 {
+			log.Debugf("未配置DNS更新器，跳过DNS更新(启动检测)")
+			a.dnsLastIP = currentIP
+			return
+		}
+		if !forceStartup && currentIP == a.savedState.DNSIP && ipv6Unchanged {
+			log.Infof("IP与上次应用的一致，跳过DNS更新(启动检测): %s", joinAddresses(currentIP, currentIPv6))
+			a.dnsLastIP = currentIP
+			a.dnsLastIPv6 = a.savedState.DNSIPv6
+			a.updater.MarkDNSApplied(currentIP)
+		} else if !forceStartup && a.savedState.DNSIP == "" && a.updater.SeedDNSApplied(currentIP, currentIPv6) {
+			// First run: the records already point at this host
+			log.Infof("首次运行，现有DNS记录已是当前IP，跳过DNS更新(启动检测): %s", joinAddresses(currentIP, currentIPv6))
+			a.dnsLastIP = currentIP
+			a.dnsLastIPv6 = currentIPv6
+		} else if !a.dnsUpdateAllowed(currentIP) {
+			log.Infof("DNS更新已推迟(启动检测)")
+		} else if err := a.updater.UpdateDNSAddresses(currentIP, currentIPv6); err != nil {
+			log.ErrorHighlightf("DNS更新失败(启动检测): %v", err)
+			a.notifyUpdate(dnsEvent, err)
+			startEvent.Results = append(startEvent.Results, a.updater.NotifyResults()...)
+			errs = append(errs, fmt.Errorf("DNS update failed: %w", err))
+		} else {
+			log.Successf("DNS更新完成(启动检测)，新IP: %s", joinAddresses(currentIP, currentIPv6))
+			a.notifyUpdate(dnsEvent, nil)
+			startEvent.Results = append(startEvent.Results, a.updater.NotifyResults()...)
+			a.dnsLastIP = currentIP
+			if currentIPv6 != "" {
+				a.dnsLastIPv6 = currentIPv6
+			}
+		}
+	}
+
+	updateFiles := func(dnsFollows bool) {
+		if len(cfg.FileUpdaters) == 0 {
+			log.Debugf("未配置文件更新器，跳过文件更新(启动检测)")
+			a.fileLastIP = currentIP
+			return
+		}
+		if !forceStartup && currentIP == a.savedState.FileIP {
+			log.Infof("IP与上次应用的一致，跳过文件更新(启动检测): %s", currentIP)
+			a.fileLastIP = currentIP
+			a.updater.MarkFilesApplied(currentIP)
+		} else if err := a.updater.UpdateFiles(currentIP); err != nil {
+			log.ErrorHighlightf("文件更新失败(启动检测): %v", err)
+			a.notifyUpdate(notify.Event{Type: notify.EventFileUpdate, OldIP: a.savedState.FileIP, NewIP: currentIP}, err)
+			startEvent.Results = append(startEvent.Results, a.updater.NotifyResults()...)
+			errs = append(errs, fmt.Errorf("file update failed: %w", err))
+		} else {
+			log.Successf("文件更新完成(启动检测)，新IP: %s", currentIP)
+			a.notifyUpdate(notify.Event{Type: notify.EventFileUpdate, OldIP: a.savedState.FileIP, NewIP: currentIP}, nil)
+			startEvent.Results = append(startEvent.Results, a.updater.NotifyResults()...)
+			a.fileLastIP = currentIP
+
+			if !dnsFollows && a.dnsLastIP != currentIP && cfg.DNSUpdatersDependOnFiles() {
+				a.checkDNS()
+			}
+		}
+	}
+
+	// With update_order set, the second kind waits for the first to hold the
+	// IP; by default both run
+	switch {
+	case cfg.UpdateOrder == config.UpdateOrderFilesFirst:
+		updateFiles(true)
+		if a.fileLastIP != currentIP {
+			a.orderSkipped(currentIP)
+			break
+		}
+		updateDNS()
+	case cfg.UpdateOrder == config.UpdateOrderDNSFirst:
+		updateDNS()
+		if a.dnsLastIP != currentIP {
+			a.orderSkipped(currentIP)
+			break
+		}
+		updateFiles(false)
+	default:
+		updateDNS()
+		updateFiles(false)
+	}
+
+	a.persistState()
+
+	startEvent.Success = a.dnsLastIP == currentIP && a.fileLastIP == currentIP
+	return startEvent, errors.Join(errs...)
+}
+
+// dnsEvent builds the notification for a DNS pass. The IPv6 fields are only
+// filled in when the pass applied an IPv6 address, so A+AAAA pairs are
+// reported as one change.
+func (a *App) dnsEvent(oldIP, newIP, oldIPv6, newIPv6 string) notify.Event {
+	event := notify.Event{Type: notify.EventDNSUpdate, OldIP: oldIP, NewIP: newIP}
+	if newIPv6 != "" {
+		event.OldIPv6 = oldIPv6
+		event.NewIPv6 = newIPv6
+	}
+	return event
+}
+
+// joinAddresses formats the detected addresses for logs: "203.0.113.5" or
+// "203.0.113.5, 2001:db8::5"
+func joinAddresses(ipv4, ipv6 string) string {
+	if ipv6 == "" {
+		return ipv4
+	}
+	return ipv4 + ", " + ipv6
+}
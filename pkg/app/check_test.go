@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ip-updater/internal/status"
+)
+
+// newTestApp loads a config that detects ip from a local endpoint and
+// writes it to a JSON file, with the DNS updater and options of extra
+func newTestApp(t *testing.T, ip, extra string) (*App, string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ip)
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.json")
+	if err := os.WriteFile(file, []byte(`{"public_ip": "192.0.2.1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := fmt.Sprintf(`config_version = 1
+state_file = %q
+%s
+
+[ip_detection]
+api_endpoints = [%q]
+web_endpoints = [%q]
+cache_ttl = -1
+
+[retry]
+interval = 1
+max_retries = 1
+
+[[file_updater]]
+name = "app"
+file_path = %q
+format = "json"
+key_path = "public_ip"
+`, filepath.Join(dir, "state.json"), extra, server.URL, server.URL, file)
+
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := New(cfg)
+	log := NewLogger()
+	log.SetOutput(io.Discard)
+	a.SetLogger(log)
+	if err := a.setup(); err != nil {
+		t.Fatal(err)
+	}
+	return a, file
+}
+
+const failingDNSUpdater = `
+[[dns_updater]]
+name = "home"
+provider = "null"
+domain = "example.com"
+extra_config = { fail_rate = "1" }
+[[dns_updater.record]]
+name = "www"
+type = "A"
+`
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDNSFirstSkipsFilesWhenDNSFails(t *testing.T) {
+	a, file := newTestApp(t, "203.0.113.7", `update_order = "dns_first"`+"\n"+failingDNSUpdater)
+
+	a.checkFiles()
+	if content := readFile(t, file); strings.Contains(content, "203.0.113.7") {
+		t.Fatalf("file updated although the DNS update failed: %s", content)
+	}
+	if a.fileLastIP == "203.0.113.7" {
+		t.Fatal("file IP recorded as applied")
+	}
+
+	skipped := false
+	for _, event := range a.state.Events.Events() {
+		if event.Type == status.EventWarning && strings.Contains(event.Message, "update_order = dns_first") {
+			skipped = true
+		}
+	}
+	if !skipped {
+		t.Fatal("no event explains the skipped file update")
+	}
+}
+
+func TestDNSFirstStartupSkipsFilesWhenDNSFails(t *testing.T) {
+	a, file := newTestApp(t, "203.0.113.7", `update_order = "dns_first"`+"\n"+failingDNSUpdater)
+
+	if err := a.RunOnce(context.Background()); err == nil {
+		t.Fatal("RunOnce succeeded although the DNS update failed")
+	}
+	if content := readFile(t, file); strings.Contains(content, "203.0.113.7") {
+		t.Fatalf("file updated although the DNS update failed: %s", content)
+	}
+}
+
+func TestDefaultOrderStillUpdatesFilesWhenDNSFails(t *testing.T) {
+	a, file := newTestApp(t, "203.0.113.7", failingDNSUpdater)
+
+	a.RunOnce(context.Background())
+	if content := readFile(t, file); !strings.Contains(content, "203.0.113.7") {
+		t.Fatalf("file not updated without update_order: %s", content)
+	}
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"errors"
+
+	"ip-updater/internal/config"
+	"ip-updater/internal/notify"
+	"ip-updater/internal/status"
+	"ip-updater/internal/updater"
+)
+
+// checkAll runs a DNS and a file check, as one combined check with
+// combined_updates, in update_order
+func (a *App) checkAll() {
+	if a.cfg.CombinedUpdates {
+		a.checkCombined()
+		return
+	}
+	if a.cfg.UpdateOrder == config.UpdateOrderFilesFirst {
+		a.checkFiles()
+		a.checkDNS()
+		return
+	}
+	a.checkDNS()
+	a.checkFiles()
+}
+
+// checkCombined is the check of combined_updates: the IP is detected once and
+// the DNS and file updaters that don't have it yet are updated together (see
+// updater.UpdateConcurrently), on the single dns_check_interval ticker.
+func (a *App) checkCombined() {
+	defer a.persistState()
+	log := a.log
+	events := a.state.Events
+
+	currentIP, currentIPv6, err := a.detector.GetPublicIPs(a.cfg.HasIPv6Records())
+	if err != nil {
+		if a.detectionSkipped(err) {
+			return
+		}
+		log.ErrorHighlightf("获取公网IP失败: %v", err)
+		events.Add(status.EventError, "detection failed: %v", err)
+		a.detectionFailed(err)
+		return
+	}
+	a.detectionSucceeded(currentIP)
+	a.checkReachability(currentIP)
+	events.Add(status.EventDetection, "check detected %s", joinAddresses(currentIP, currentIPv6))
+	a.history.observe(a.primaryFamily(), currentIP)
+	a.history.observe(familyIPv6, currentIPv6)
+
+	// As in checkDNS, IPv6 becoming unavailable is not a change
+	ipv6Changed := currentIPv6 != "" && currentIPv6 != a.dnsLastIPv6
+	dnsChanged := currentIP != a.dnsLastIP || ipv6Changed
+	fileChanged := currentIP != a.fileLastIP
+	if len(a.cfg.DNSUpdaters) == 0 {
+		a.dnsLastIP = currentIP
+		dnsChanged = false
+	}
+	if len(a.cfg.FileUpdaters) == 0 {
+		a.fileLastIP = currentIP
+		fileChanged = false
+	}
+
+	if !dnsChanged {
+		log.Debugf("DNS check: IP unchanged (%s)", joinAddresses(currentIP, currentIPv6))
+		a.state.ClearDeferred()
+		a.verifyDNS(currentIP, currentIPv6)
+		a.reassertDNS(currentIP, currentIPv6)
+	} else {
+		log.Infof("DNS check: IP changed from %s to %s", joinAddresses(a.dnsLastIP, a.dnsLastIPv6), joinAddresses(currentIP, currentIPv6))
+		events.Add(status.EventChange, "DNS check: IP changed from %s to %s", joinAddresses(a.dnsLastIP, a.dnsLastIPv6), joinAddresses(currentIP, currentIPv6))
+		dnsChanged = a.dnsUpdateAllowed(currentIP)
+	}
+	if !fileChanged {
+		log.Debugf("File check: IP unchanged (%s)", currentIP)
+	} else {
+		log.Infof("File check: IP changed from %s to %s", a.fileLastIP, currentIP)
+		events.Add(status.EventChange, "file check: IP changed from %s to %s", a.fileLastIP, currentIP)
+	}
+	// update_order: a kind that has to wait for the other one, which doesn't
+	// hold the IP and isn't updated now (deferred by schedule), is skipped
+	if fileChanged && !dnsChanged && a.cfg.UpdateOrder == config.UpdateOrderDNSFirst && a.dnsLastIP != currentIP {
+		a.orderSkipped(currentIP)
+		fileChanged = false
+	}
+	if !dnsChanged && !fileChanged {
+		return
+	}
+
+	dnsErr, fileErr := a.updater.UpdateConcurrently(currentIP, currentIPv6, dnsChanged, fileChanged)
+
+	if errors.Is(dnsErr, updater.ErrOrderSkipped) {
+		a.orderSkipped(currentIP)
+		dnsChanged = false
+	}
+	if errors.Is(fileErr, updater.ErrOrderSkipped) {
+		a.orderSkipped(currentIP)
+		fileChanged = false
+	}
+
+	if dnsChanged {
+		a.notifyUpdate(a.dnsEvent(a.dnsLastIP, currentIP, a.dnsLastIPv6, currentIPv6), dnsErr)
+		if dnsErr != nil {
+			log.ErrorHighlightf("DNS更新失败: %v", dnsErr)
+		} else {
+			log.Successf("DNS更新完成，新IP: %s", joinAddresses(currentIP, currentIPv6))
+			a.dnsLastIP = currentIP
+			if currentIPv6 != "" {
+				a.dnsLastIPv6 = currentIPv6
+			}
+		}
+	}
+	if fileChanged {
+		a.notifyUpdate(notify.Event{Type: notify.EventFileUpdate, OldIP: a.fileLastIP, NewIP: currentIP}, fileErr)
+		if fileErr != nil {
+			log.ErrorHighlightf("文件更新失败: %v", fileErr)
+		} else {
+			log.Successf("文件更新完成，新IP: %s", currentIP)
+			a.fileLastIP = currentIP
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"ip-updater/internal/config"
+	"ip-updater/internal/notify"
+	"ip-updater/internal/schedule"
+	"ip-updater/internal/status"
+)
+
+// Check kinds of a cron job
+const (
+	kindDNS  = "dns"
+	kindFile = "file"
+)
+
+// cronJob is a check run on a cron schedule: the global dns_check_cron or
+// file_check_cron (updater empty), or an updater with its own cron
+type cronJob struct {
+	kind     string
+	updater  string
+	schedule *schedule.Cron
+	next     time.Time
+
+	// applied is what the updater's own pass last applied, so a scheduled
+	// pass without a change doesn't notify
+	applied string
+}
+
+// setupCron builds the cron jobs from the current config. Interval tickers
+// are stopped where a global cron replaces them, and updaters with their own
+// cron are taken out of the regular passes. Only used by Run.
+func (a *App) setupCron() {
+	previous := make(map[string]string, len(a.cronJobs))
+	for _, job := range a.cronJobs {
+		previous[job.kind+"/"+job.updater] = job.applied
+	}
+	a.cronJobs = nil
+
+	add := func(kind, updater, expr string) {
+		if expr == "" {
+			return
+		}
+		cron, err := schedule.ParseCron(expr)
+		if err != nil {
+			// Already validated when the config was loaded
+			a.log.Warnf("无效的cron表达式 %q: %v", expr, err)
+			return
+		}
+		job := &cronJob{kind: kind, updater: updater, schedule: cron, next: cron.Next(time.Now())}
+		job.applied = previous[kind+"/"+updater]
+		if job.applied == "" && updater != "" {
+			job.applied = a.seedCronApplied(kind, updater)
+		}
+		a.cronJobs = append(a.cronJobs, job)
+		if updater == "" {
+			a.log.Infof("%s检查使用cron: %s (下次: %s)", kind, expr, job.next.Format("2006-01-02 15:04"))
+		} else {
+			a.log.Infof("更新器 %s 使用cron: %s (下次: %s)", updater, expr, job.next.Format("2006-01-02 15:04"))
+		}
+	}
+
+	add(kindDNS, "", a.cfg.DNSCheckCron)
+	if !a.cfg.CombinedUpdates {
+		add(kindFile, "", a.cfg.FileCheckCron)
+	}
+	for _, updater := range a.cfg.DNSUpdaters {
+		add(kindDNS, updater.Name, updater.Cron)
+	}
+	for _, updater := range a.cfg.FileUpdaters {
+		add(kindFile, updater.Name, updater.Cron)
+	}
+
+	if a.cfg.DNSCheckCron != "" {
+		a.dnsTicker.Stop()
+	}
+	if a.cfg.FileCheckCron != "" || a.cfg.CombinedUpdates {
+		// combined_updates checks files together with DNS
+		a.fileTicker.Stop()
+	}
+	a.updater.SetCronManaged(true)
+	a.resetCronTimer()
+}
+
+// seedCronApplied returns what the startup pass applied to the updater
+func (a *App) seedCronApplied(kind, updater string) string {
+	switch {
+	case kind == kindDNS && a.dnsLastIP != "" && a.updater.Applied(updater) == a.dnsLastIP:
+		return joinAddresses(a.dnsLastIP, a.dnsLastIPv6)
+	case kind == kindFile && a.fileLastIP != "" && a.updater.Applied(updater) == a.fileLastIP:
+		return a.fileLastIP
+	}
+	return ""
+}
+
+// resetCronTimer arms the timer for the earliest job
+func (a *App) resetCronTimer() {
+	var next time.Time
+	for _, job := range a.cronJobs {
+		if !job.next.IsZero() && (next.IsZero() || job.next.Before(next)) {
+			next = job.next
+		}
+	}
+
+	a.cronTimer.Stop()
+	if !next.IsZero() {
+		a.cronTimer.Reset(time.Until(next))
+	}
+}
+
+// runCron runs the jobs that are due and re-arms the timer
+func (a *App) runCron() {
+	now := time.Now()
+	var runDNS, runFiles bool
+	var dnsUpdaters, fileUpdaters []*cronJob
+	for _, job := range a.cronJobs {
+		if job.next.IsZero() || job.next.After(now) {
+			continue
+		}
+		job.next = job.schedule.Next(now)
+
+		switch {
+		case job.kind == kindDNS && job.updater == "":
+			runDNS = true
+		case job.kind == kindFile && job.updater == "":
+			runFiles = true
+		case job.kind == kindDNS:
+			dnsUpdaters = append(dnsUpdaters, job)
+		default:
+			fileUpdaters = append(fileUpdaters, job)
+		}
+	}
+
+	// Both schedules firing together run in update_order
+	filesFirst := runFiles && a.cfg.UpdateOrder == config.UpdateOrderFilesFirst
+	if filesFirst {
+		a.checkFiles()
+	}
+	if runDNS && a.cfg.CombinedUpdates {
+		a.checkCombined()
+	} else if runDNS {
+		a.checkDNS()
+	}
+	if runFiles && !filesFirst {
+		a.checkFiles()
+	}
+	if len(dnsUpdaters) > 0 {
+		a.checkScheduledDNS(dnsUpdaters)
+	}
+	if len(fileUpdaters) > 0 {
+		a.checkScheduledFiles(fileUpdaters)
+	}
+	a.resetCronTimer()
+}
+
+// checkScheduledDNS is checkDNS for DNS updaters with their own cron
+func (a *App) checkScheduledDNS(jobs []*cronJob) {
+	defer a.persistState()
+	log := a.log
+
+	currentIP, currentIPv6, err := a.detector.GetPublicIPs(a.cfg.HasIPv6Records())
+	if err != nil {
+		if a.detectionSkipped(err) {
+			return
+		}
+		log.ErrorHighlightf("获取公网IP失败(定时DNS检查): %v", err)
+		a.state.Events.Add(status.EventError, "scheduled DNS check detection failed: %v", err)
+		a.detectionFailed(err)
+		return
+	}
+	a.detectionSucceeded(currentIP)
+	addresses := joinAddresses(currentIP, currentIPv6)
+
+	// As in checkDNS, IPv6 becoming unavailable is not a change
+	var due []*cronJob
+	var names []string
+	for _, job := range jobs {
+		oldIP, oldIPv6 := job.previous()
+		if currentIP != oldIP || (currentIPv6 != "" && currentIPv6 != oldIPv6) {
+			due = append(due, job)
+			names = append(names, job.updater)
+		}
+	}
+	if len(due) == 0 {
+		log.Debugf("定时DNS检查: IP未变化 (%s)", addresses)
+		return
+	}
+	if !a.dnsGate.Allows(time.Now()) {
+		log.Infof("DNS更新处于冻结时段，跳过定时更新: %v", names)
+		return
+	}
+
+	log.Infof("定时DNS检查: 更新 %v -> %s", names, addresses)
+	err = a.updater.UpdateDNSFor(names, currentIP, currentIPv6)
+	oldIP, oldIPv6 := due[0].previous()
+	a.notifyUpdate(a.dnsEvent(oldIP, currentIP, oldIPv6, currentIPv6), err)
+	for _, job := range due {
+		if a.updater.Applied(job.updater) != currentIP {
+			continue
+		}
+		if _, oldIPv6 := job.previous(); currentIPv6 == "" && oldIPv6 != "" {
+			job.applied = joinAddresses(currentIP, oldIPv6)
+		} else {
+			job.applied = addresses
+		}
+	}
+	if err != nil {
+		log.ErrorHighlightf("定时DNS更新失败: %v", err)
+	}
+}
+
+// checkScheduledFiles is checkFiles for file updaters with their own cron
+func (a *App) checkScheduledFiles(jobs []*cronJob) {
+	defer a.persistState()
+	log := a.log
+
+	currentIP, err := a.detector.GetPublicIP()
+	if err != nil {
+		if a.detectionSkipped(err) {
+			return
+		}
+		log.ErrorHighlightf("获取公网IP失败(定时文件检查): %v", err)
+		a.state.Events.Add(status.EventError, "scheduled file check detection failed: %v", err)
+		a.detectionFailed(err)
+		return
+	}
+	a.detectionSucceeded(currentIP)
+
+	var due []*cronJob
+	var names []string
+	for _, job := range jobs {
+		if job.applied != currentIP {
+			due = append(due, job)
+			names = append(names, job.updater)
+		}
+	}
+	if len(due) == 0 {
+		log.Debugf("定时文件检查: IP未变化 (%s)", currentIP)
+		return
+	}
+
+	log.Infof("定时文件检查: 更新 %v -> %s", names, currentIP)
+	err = a.updater.UpdateFilesFor(names, currentIP)
+	oldIP, _ := due[0].previous()
+	a.notifyUpdate(notify.Event{Type: notify.EventFileUpdate, OldIP: oldIP, NewIP: currentIP}, err)
+	for _, job := range due {
+		if a.updater.Applied(job.updater) == currentIP {
+			job.applied = currentIP
+		}
+	}
+	if err != nil {
+		log.ErrorHighlightf("定时文件更新失败: %v", err)
+	}
+}
+
+// previous splits what the job last applied into its IPv4 and IPv6
+// addresses, for notifications
+func (job *cronJob) previous() (ipv4, ipv6 string) {
+	ipv4, ipv6, _ = strings.Cut(job.applied, ", ")
+	return ipv4, ipv6
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"ip-updater/internal/notify"
+	"ip-updater/internal/status"
+)
+
+// startFallback switches the DNS updaters with a fallback_ip to it once
+// detection has been failing for their fallback_after. Updaters sharing a
+// fallback IP are updated in one pass and reported in one notification.
+func (a *App) startFallback() {
+	failingFor := time.Since(a.detectFailingSince)
+
+	var ips []string
+	due := make(map[string][]string) // fallback IP -> updaters
+	for _, dnsUpdater := range a.cfg.DNSUpdaters {
+		if dnsUpdater.FallbackIP == "" || a.fallback[dnsUpdater.Name] != "" {
+			continue
+		}
+		if failingFor < time.Duration(dnsUpdater.FallbackAfter)*time.Second {
+			continue
+		}
+		if _, ok := due[dnsUpdater.FallbackIP]; !ok {
+			ips = append(ips, dnsUpdater.FallbackIP)
+		}
+		due[dnsUpdater.FallbackIP] = append(due[dnsUpdater.FallbackIP], dnsUpdater.Name)
+	}
+	if len(ips) == 0 {
+		return
+	}
+
+	failingFor = failingFor.Round(time.Second)
+	for _, ip := range ips {
+		names := due[ip]
+		a.log.WarnHighlightf("🛟 公网IP检测已持续失败 %s，切换到备用IP %s: %s", failingFor, ip, strings.Join(names, ", "))
+		a.state.Events.Add(status.EventChange, "detection failing for %s, switching %s to fallback IP %s", failingFor, strings.Join(names, ", "), ip)
+
+		err := a.updater.UpdateDNSFor(names, ip, "")
+		a.notifyUpdate(notify.Event{Type: notify.EventFallback, OldIP: a.lastKnownIP(), NewIP: ip}, err)
+		for _, name := range names {
+			if a.updater.Applied(name) == ip {
+				a.fallback[name] = ip
+			}
+		}
+		if err != nil {
+			// Retried on the next failed detection
+			a.log.ErrorHighlightf("切换到备用IP失败: %v", err)
+			continue
+		}
+		a.log.Successf("已切换到备用IP %s: %s", ip, strings.Join(names, ", "))
+	}
+	a.persistState()
+}
+
+// endFallback switches the updaters publishing their fallback_ip back to the
+// detected IP once detection works again. Updaters that are no longer
+// configured are forgotten.
+func (a *App) endFallback(ip string) {
+	if len(a.fallback) == 0 {
+		return
+	}
+
+	configured := make(map[string]bool, len(a.cfg.DNSUpdaters))
+	for _, dnsUpdater := range a.cfg.DNSUpdaters {
+		configured[dnsUpdater.Name] = true
+	}
+	var names []string
+	oldIP := ""
+	for _, dnsUpdater := range a.cfg.DNSUpdaters {
+		if fallbackIP, ok := a.fallback[dnsUpdater.Name]; ok {
+			names = append(names, dnsUpdater.Name)
+			if oldIP == "" {
+				oldIP = fallbackIP
+			}
+		}
+	}
+	for name := range a.fallback {
+		if !configured[name] {
+			delete(a.fallback, name)
+		}
+	}
+
+	if len(names) > 0 {
+		a.log.Infof("公网IP检测已恢复，从备用IP切回检测到的IP %s: %s", ip, strings.Join(names, ", "))
+		a.state.Events.Add(status.EventChange, "detection recovered, switching %s back from the fallback IP to %s", strings.Join(names, ", "), ip)
+
+		err := a.updater.UpdateDNSFor(names, ip, "")
+		a.notifyUpdate(notify.Event{Type: notify.EventFallbackEnded, OldIP: oldIP, NewIP: ip}, err)
+		for _, name := range names {
+			if a.updater.Applied(name) == ip {
+				delete(a.fallback, name)
+			}
+		}
+		if err != nil {
+			// Retried after the next successful detection
+			a.log.ErrorHighlightf("切回检测到的IP失败: %v", err)
+		} else {
+			a.log.Successf("已切回检测到的IP %s: %s", ip, strings.Join(names, ", "))
+		}
+	}
+	a.persistState()
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"sync"
+	"time"
+
+	"ip-updater/internal/config"
+)
+
+const defaultHistorySize = 20
+
+// Address families recorded in the history
+const (
+	familyIPv4 = "ipv4"
+	familyIPv6 = "ipv6"
+)
+
+// primaryFamily is the family of the address the checks detect and apply:
+// IPv6 with ip_mode = "ipv6", IPv4 otherwise
+func (a *App) primaryFamily() string {
+	if a.cfg.IPMode == config.IPModeIPv6 {
+		return familyIPv6
+	}
+	return familyIPv4
+}
+
+// Change is one detected change of the public IP
+type Change struct {
+	Time   time.Time `json:"time"`
+	Family string    `json:"family"` // ipv4 or ipv6
+	OldIP  string    `json:"old_ip"`
+	NewIP  string    `json:"new_ip"`
+}
+
+// history keeps the most recent IP changes in a fixed-size ring buffer. It
+// is safe for concurrent use.
+type history struct {
+	mu      sync.Mutex
+	changes []Change
+	next    int
+	full    bool
+
+	// last detected address per family
+	last map[string]string
+}
+
+func newHistory(capacity int) *history {
+	if capacity <= 0 {
+		capacity = defaultHistorySize
+	}
+
+	return &history{
+		changes: make([]Change, capacity),
+		last:    make(map[string]string),
+	}
+}
+
+// seed sets the last known address of a family without recording a change,
+// e.g. from the state file
+func (h *history) seed(family, ip string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if ip != "" {
+		h.last[family] = ip
+	}
+}
+
+// observe records a change when ip differs from the last address seen for
+// the family. Empty addresses (detection failed or family unavailable) are
+// ignored.
+func (h *history) observe(family, ip string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	old := h.last[family]
+	if ip == "" || ip == old {
+		return
+	}
+	h.last[family] = ip
+
+	h.changes[h.next] = Change{
+		Time:   time.Now(),
+		Family: family,
+		OldIP:  old,
+		NewIP:  ip,
+	}
+
+	h.next++
+	if h.next == len(h.changes) {
+		h.next = 0
+		h.full = true
+	}
+}
+
+// entries returns a copy of the recorded changes, oldest first
+func (h *history) entries() []Change {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.full {
+		result := make([]Change, h.next)
+		copy(result, h.changes[:h.next])
+		return result
+	}
+
+	result := make([]Change, 0, len(h.changes))
+	result = append(result, h.changes[h.next:]...)
+	result = append(result, h.changes[:h.next]...)
+	return result
+}
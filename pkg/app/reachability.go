@@ -0,0 +1,111 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ip-updater/internal/notify"
+	"ip-updater/internal/status"
+)
+
+// reachabilityState tracks the external reachability checks. They run in the
+// background so a slow checker never holds up detection and updates.
+type reachabilityState struct {
+	mu        sync.Mutex
+	running   bool
+	checkedIP string
+	checkedAt time.Time
+	closed    []int // ports last reported closed from outside
+}
+
+// checkReachability starts an external reachability check of ip when it
+// changed or the last check is older than the interval
+func (a *App) checkReachability(ip string) {
+	cfg := a.cfg.Reachability
+	if cfg == nil {
+		return
+	}
+
+	r := &a.reachability
+	r.mu.Lock()
+	if r.running || (ip == r.checkedIP && time.Since(r.checkedAt) < cfg.CheckInterval()) {
+		r.mu.Unlock()
+		return
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	// The App's components may be swapped by a reload meanwhile
+	log, events, notifier := a.log, a.state.Events, a.notifier
+	go func() {
+		results := cfg.Check(ip)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.running = false
+		r.checkedIP = ip
+		r.checkedAt = time.Now()
+
+		var closed, reopened []int
+		checkerFailed := false
+		for _, result := range results {
+			wasClosed := slices.Contains(r.closed, result.Port)
+			switch {
+			case result.Err != nil:
+				// The checker failed; keep what was known about the port
+				log.Warnf("⚠️ 外部可达性检查失败 (%s:%d): %v", ip, result.Port, result.Err)
+				checkerFailed = true
+				if wasClosed {
+					closed = append(closed, result.Port)
+				}
+			case !result.Open:
+				closed = append(closed, result.Port)
+			case wasClosed:
+				reopened = append(reopened, result.Port)
+			}
+		}
+
+		var newlyClosed []int
+		for _, port := range closed {
+			if !slices.Contains(r.closed, port) {
+				newlyClosed = append(newlyClosed, port)
+			}
+		}
+		r.closed = closed
+
+		if len(newlyClosed) > 0 {
+			message := fmt.Sprintf("port %s on %s is not reachable from outside", joinPorts(newlyClosed), ip)
+			log.WarnHighlightf("🚫 外部无法访问 %s 的端口 %s，请检查端口转发/NAT设置", ip, joinPorts(newlyClosed))
+			events.Add(status.EventWarning, "%s", message)
+			notifier.Notify(notify.Event{Type: notify.EventUnreachable, NewIP: ip, Error: message})
+		}
+		if len(reopened) > 0 {
+			log.Successf("外部已可访问 %s 的端口 %s", ip, joinPorts(reopened))
+			events.Add(status.EventDetection, "port %s on %s is reachable from outside again", joinPorts(reopened), ip)
+			notifier.Notify(notify.Event{Type: notify.EventReachable, NewIP: ip, Success: true})
+		}
+		if len(closed) == 0 && !checkerFailed {
+			log.Debugf("外部可达性检查通过: %s", ip)
+		}
+	}()
+}
+
+// reset makes the next detection check again, e.g. after a reload changed
+// the checker or the ports
+func (r *reachabilityState) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkedIP = ""
+}
+
+func joinPorts(ports []int) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(parts, ", ")
+}
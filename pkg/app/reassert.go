@@ -0,0 +1,40 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"ip-updater/internal/status"
+)
+
+// reassertDNS runs a DNS pass, while the IP is unchanged, for the updaters
+// that last applied their records more than reassert_interval ago, so records
+// changed outside the program are healed. The pass only writes records that
+// no longer hold the IP. Updaters publishing their fallback_ip and checks
+// outside the schedule's allowed hours are left alone.
+func (a *App) reassertDNS(ip, ipv6 string) {
+	if a.cfg.ReassertInterval <= 0 || !a.dnsGate.Allows(time.Now()) {
+		return
+	}
+
+	interval := time.Duration(a.cfg.ReassertInterval) * time.Second
+	var names []string
+	for _, name := range a.updater.DueForReassert(ip, interval) {
+		if a.fallback[name] == "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	a.log.Infof("🔁 距上次写入已超过 %s，重新确认DNS记录: %s", interval, strings.Join(names, ", "))
+	a.state.Events.Add(status.EventChange, "reasserting %s after %s without a write", strings.Join(names, ", "), interval)
+
+	if err := a.updater.UpdateDNSFor(names, ip, ipv6); err != nil {
+		// Failed updaters keep their old time and are retried on the next check
+		a.log.ErrorHighlightf("重新确认DNS记录失败: %v", err)
+		return
+	}
+	a.log.Successf("DNS记录已重新确认: %s", strings.Join(names, ", "))
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"time"
+
+	"ip-updater/internal/config"
+	"ip-updater/internal/netutil"
+	"ip-updater/internal/notify"
+	"ip-updater/internal/schedule"
+	"ip-updater/internal/statefile"
+	"ip-updater/internal/status"
+)
+
+// syncWatcher starts or stops the config file watcher to match watch_config
+func (a *App) syncWatcher() {
+	if a.cfg.WatchConfig && a.configWatcher == nil {
+		if a.configPath == "" {
+			a.log.Warnf("watch_config 需要配置文件路径，已忽略")
+			return
+		}
+		w, err := config.NewWatcher(a.configPath, configWatchDebounce)
+		if err != nil {
+			a.log.WarnHighlightf("配置文件监视启动失败: %v", err)
+			return
+		}
+		a.configWatcher = w
+		a.log.Infof("👀 监视配置文件变化: %s", a.configPath)
+	} else if !a.cfg.WatchConfig && a.configWatcher != nil {
+		a.configWatcher.Close()
+		a.configWatcher = nil
+		a.log.Infof("已停止监视配置文件")
+	}
+}
+
+// reload re-reads the config file and swaps in the new settings. The
+// running configuration is kept if the new file fails to load.
+func (a *App) reload(reason string) {
+	log := a.log
+	events := a.state.Events
+
+	if a.configPath == "" {
+		log.Warnf("未设置配置文件路径，无法重新加载配置 (%s)", reason)
+		return
+	}
+	log.Infof("🔄 重新加载配置 (%s): %s", reason, a.configPath)
+
+	newCfg, err := config.Load(a.configPath)
+	if err != nil {
+		log.ErrorHighlightf("配置重新加载失败，继续使用当前配置: %v", err)
+		events.Add(status.EventError, "config reload failed: %v", err)
+		return
+	}
+
+	newGate, err := schedule.NewGate(newCfg.Schedule.ActiveHours, newCfg.Schedule.FreezeWindows)
+	if err != nil {
+		log.ErrorHighlightf("配置重新加载失败，继续使用当前配置: %v", err)
+		events.Add(status.EventError, "config reload failed: %v", err)
+		return
+	}
+
+	newNotifier, err := notify.New(newCfg.Notify)
+	if err != nil {
+		log.ErrorHighlightf("配置重新加载失败，继续使用当前配置: %v", err)
+		events.Add(status.EventError, "config reload failed: %v", err)
+		return
+	}
+	newNotifier.SetLogger(log)
+
+	if err := log.Configure(newCfg.Logging.Level, newCfg.Logging.Output, newCfg.Logging.FilePath, newCfg.Logging.MaxSize, newCfg.Logging.MaxAge, newCfg.Logging.Compress, newCfg.Logging.Syslog()); err != nil {
+		log.Warnf("Failed to configure logger: %v", err)
+	}
+	log.SetMaskIPs(newCfg.Logging.MaskIPInLogs)
+	for _, warning := range newCfg.Warnings {
+		log.WarnHighlightf("配置警告: %s", warning)
+	}
+
+	if newCfg.Status != a.cfg.Status {
+		log.WarnHighlight("状态服务配置的变更需要重启服务后生效")
+	}
+	if newCfg.RunAsUser != a.cfg.RunAsUser || newCfg.RunAsGroup != a.cfg.RunAsGroup {
+		log.WarnHighlight("run_as_user/run_as_group 的变更需要重启服务后生效")
+	}
+
+	if newCfg.LocalAddr != a.cfg.LocalAddr {
+		if err := netutil.SetLocalAddr(newCfg.LocalAddr); err != nil {
+			log.ErrorHighlightf("配置重新加载失败，继续使用当前配置: %v", err)
+			events.Add(status.EventError, "config reload failed: %v", err)
+			return
+		}
+		log.Infof("出站请求源地址已变更: '%s' -> '%s'", a.cfg.LocalAddr, newCfg.LocalAddr)
+	}
+
+	a.reachability.reset()
+	newNotifier.TakeOver(a.notifier)
+	a.install(newCfg, newGate, newNotifier, &statefile.State{
+		ListEntries:    a.updater.ListEntries(),
+		DNSAsserted:    a.updater.Asserted(),
+		MatchedRecords: a.updater.MatchedRecords(),
+	})
+	if a.dnsTicker != nil {
+		a.dnsTicker.Reset(time.Duration(a.cfg.DNSCheckInterval) * time.Second)
+	}
+	if a.fileTicker != nil {
+		a.fileTicker.Reset(time.Duration(a.cfg.FileCheckInterval) * time.Second)
+	}
+	if a.cronTimer != nil {
+		a.setupCron()
+	}
+	a.syncWatcher()
+
+	log.Successf("配置重新加载完成: DNS更新器 %d 个, 文件更新器 %d 个", len(a.cfg.DNSUpdaters), len(a.cfg.FileUpdaters))
+	events.Add(status.EventChange, "configuration reloaded (%s)", reason)
+
+	// 新配置可能包含新的记录或文件，立即按新配置检查一次
+	a.dnsLastIP = ""
+	a.dnsLastIPv6 = ""
+	a.fileLastIP = ""
+	a.checkAll()
+}
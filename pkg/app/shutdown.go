@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"ip-updater/internal/config"
+	"ip-updater/internal/status"
+)
+
+// ShutdownTimeout is the shutdown_timeout of the current config. It is safe
+// to call while Run is reloading, e.g. from a signal handler that enforces it.
+func (a *App) ShutdownTimeout() time.Duration {
+	if timeout := a.shutdownTimeout.Load(); timeout > 0 {
+		return time.Duration(timeout)
+	}
+	return config.DefaultShutdownTimeout * time.Second
+}
+
+// shutdown stops the service once Run's context is cancelled. The state is
+// saved first since it is what a restart relies on; notifications held back
+// by quiet hours or digests are then sent and the status server closed, both
+// bounded by shutdown_timeout. The log file is synced last.
+func (a *App) shutdown(statusServer *status.Server) {
+	log := a.log
+	log.Info("收到关闭信号，停止定时器...")
+	a.dnsTicker.Stop()
+	a.fileTicker.Stop()
+	a.deferTimer.Stop()
+	a.cronTimer.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.ShutdownTimeout())
+	defer cancel()
+
+	a.persistState()
+
+	// 静默时段暂缓的通知和未发送的汇总在退出前发送
+	a.notifier.FlushAll()
+
+	if statusServer != nil {
+		if err := statusServer.Shutdown(ctx); err != nil {
+			log.Warnf("关闭状态服务失败: %v", err)
+		}
+	}
+
+	if !a.notifier.WaitContext(ctx) {
+		log.WarnHighlightf("等待通知发送超时 (%s)，未完成的通知将丢失", a.ShutdownTimeout())
+	}
+
+	log.Info("优雅关闭完成")
+	if err := log.Sync(); err != nil {
+		log.Warnf("同步日志文件失败: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package app
+
+import "ip-updater/internal/status"
+
+// writeTextfile refreshes the node_exporter textfile metrics in
+// status.textfile_dir with the addresses currently applied
+func (a *App) writeTextfile() {
+	dir := a.cfg.Status.TextfileDir
+	if dir == "" {
+		return
+	}
+
+	err := a.state.Metrics.WriteTextfile(dir, []status.Address{
+		{Kind: "dns", Family: a.primaryFamily(), IP: a.dnsLastIP},
+		{Kind: "dns", Family: familyIPv6, IP: a.dnsLastIPv6},
+		{Kind: "file", Family: a.primaryFamily(), IP: a.fileLastIP},
+	})
+	if err == nil {
+		if a.textfileErr != "" {
+			a.log.Infof("textfile指标已恢复写入: %s", dir)
+		}
+		a.textfileErr = ""
+		return
+	}
+	if err.Error() != a.textfileErr {
+		a.log.Warnf("写入textfile指标失败: %v", err)
+	}
+	a.textfileErr = err.Error()
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"ip-updater/internal/status"
+)
+
+// verifyDNS compares, while the IP is unchanged, the records at the DNS
+// providers with the detected IP (verify_records) and updates the updaters
+// whose records differ, e.g. after someone edited them. Like reassertDNS it
+// leaves updaters publishing their fallback_ip alone and does nothing outside
+// the schedule's allowed hours.
+func (a *App) verifyDNS(ip, ipv6 string) {
+	if !a.cfg.VerifyRecords || !a.dnsGate.Allows(time.Now()) {
+		return
+	}
+
+	var names []string
+	for _, name := range a.updater.StaleDNS(ip, ipv6) {
+		if a.fallback[name] == "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		a.log.Debugf("DNS check: records hold the detected IP")
+		return
+	}
+
+	a.log.WarnHighlightf("🔎 DNS记录与检测到的IP（或compare_fields中的配置）不一致，可能在程序之外被修改，重新更新: %s", strings.Join(names, ", "))
+	a.state.Events.Add(status.EventChange, "records of %s don't hold %s, updating", strings.Join(names, ", "), joinAddresses(ip, ipv6))
+
+	err := a.updater.UpdateDNSFor(names, ip, ipv6)
+	a.notifyUpdate(a.dnsEvent(a.dnsLastIP, ip, a.dnsLastIPv6, ipv6), err)
+	if err != nil {
+		// Compared again on the next check
+		a.log.ErrorHighlightf("DNS记录修正失败: %v", err)
+		return
+	}
+	a.log.Successf("DNS记录已修正为当前IP: %s", strings.Join(names, ", "))
+}
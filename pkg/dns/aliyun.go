@@ -30,6 +30,9 @@ type AliyunProvider struct {
 	secretKey string
 	endpoint  string
 	client    *http.Client
+
+	// signatureVersion is aliyunSignatureV1 (default) or aliyunSignatureV3
+	signatureVersion string
 }
 
 type AliyunResponse struct {
@@ -46,8 +49,10 @@ func NewAliyunProvider() *AliyunProvider {
 	return &AliyunProvider{
 		endpoint: aliyunEndpoint,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("aliyun"),
 		},
+		signatureVersion: aliyunSignatureV1,
 	}
 }
 
@@ -55,11 +60,30 @@ func (p *AliyunProvider) GetProviderName() string {
 	return "aliyun"
 }
 
+// DefaultTTL is used for records configured without a TTL.
+// 阿里云免费版解析的最小TTL为600秒
+func (p *AliyunProvider) DefaultTTL() int {
+	return 600
+}
+
 func (p *AliyunProvider) SetCredentials(accessKey, secretKey string) {
 	p.accessKey = accessKey
 	p.secretKey = secretKey
 }
 
+// Configure applies the endpoint override, timeout, user agent and proxy,
+// and the signature version (extra_config signature_version)
+func (p *AliyunProvider) Configure(settings ProviderSettings) error {
+	version, err := parseAliyunSignatureVersion(settings.Extra["signature_version"])
+	if err != nil {
+		return err
+	}
+	p.signatureVersion = version
+	p.endpoint = settings.endpointOr(aliyunEndpoint)
+	p.client = settings.httpClient("aliyun")
+	return nil
+}
+
 func (p *AliyunProvider) GetRecords(domain string) ([]DNSRecord, error) {
 	if p.accessKey == "" || p.secretKey == "" {
 		return nil, fmt.Errorf("阿里云凭证未设置 (AccessKey: %s, SecretKey: %s)",
@@ -76,13 +100,10 @@ func (p *AliyunProvider) GetRecords(domain string) ([]DNSRecord, error) {
 		fmt.Printf("📤 GetRecords API请求 (域名: %s)\n", domain)
 	}
 
-	signature := p.generateSignature("GET", params)
-	params["Signature"] = signature
-
 	resp, err := p.makeRequest("GET", params)
 	if err != nil {
 		// Add more context to the error
-		return nil, fmt.Errorf("GetRecords API调用失败 (域名: %s): %v", domain, err)
+		return nil, fmt.Errorf("GetRecords API调用失败 (域名: %s): %w", domain, err)
 	}
 
 	// Debug: Show API response details in debug mode
@@ -92,6 +113,9 @@ func (p *AliyunProvider) GetRecords(domain string) ([]DNSRecord, error) {
 	}
 
 	if resp.Code != "" && resp.Code != "Success" {
+		if err := clockSkewError("aliyun", resp.Code, resp.Message); err != nil {
+			return nil, err
+		}
 		return nil, fmt.Errorf("aliyun API error (GetRecords): %s - %s", resp.Code, resp.Message)
 	}
 
@@ -101,29 +125,23 @@ func (p *AliyunProvider) GetRecords(domain string) ([]DNSRecord, error) {
 	}
 
 	// Extract records from response
-	recordList, ok := resp.DomainRecords["Record"].([]interface{})
-	if !ok {
-		return []DNSRecord{}, nil
-	}
-
 	var records []DNSRecord
-	for _, item := range recordList {
-		record, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
+	for _, record := range aliyunRecordList(resp.DomainRecords) {
 		name, _ := record["RR"].(string)
 		recordType, _ := record["Type"].(string)
 		value, _ := record["Value"].(string)
 		ttlFloat, _ := record["TTL"].(float64)
 		ttl := int(ttlFloat)
+		remark, _ := record["Remark"].(string)
+		id, _ := aliyunRecordID(record)
 
 		records = append(records, DNSRecord{
-			Name:  name,
-			Type:  recordType,
-			Value: value,
-			TTL:   ttl,
+			Name:   name,
+			Type:   recordType,
+			Value:  value,
+			TTL:    ttl,
+			ID:     id,
+			Remark: remark,
 		})
 	}
 
@@ -133,6 +151,27 @@ func (p *AliyunProvider) GetRecords(domain string) ([]DNSRecord, error) {
 	return records, nil
 }
 
+// CheckCredentials lists one domain with DescribeDomains, which confirms the
+// AccessKey and signature without the permission to list records
+func (p *AliyunProvider) CheckCredentials() error {
+	if p.accessKey == "" || p.secretKey == "" {
+		return ErrInvalidCredentials
+	}
+
+	params := p.buildBaseParams()
+	params["Action"] = "DescribeDomains"
+	params["PageSize"] = "1"
+
+	resp, err := p.makeRequest("GET", params)
+	if err != nil {
+		return err
+	}
+	if resp.Code != "" && resp.Code != "Success" {
+		return fmt.Errorf("aliyun API error (DescribeDomains): %s - %s", resp.Code, resp.Message)
+	}
+	return nil
+}
+
 func (p *AliyunProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
 	// First, try to get the record ID
 	recordId, err := p.getRecordId(domain, recordName, recordType)
@@ -144,7 +183,16 @@ func (p *AliyunProvider) UpdateRecord(domain, recordName, recordType, newIP stri
 		return err
 	}
 
-	// Update the existing record
+	return p.updateRecordID(recordId, recordName, recordType, newIP, ttl)
+}
+
+// UpdateRecordByID updates the record with the given ID, one of several
+// sharing its name and type
+func (p *AliyunProvider) UpdateRecordByID(domain, recordID, recordName, recordType, newIP string, ttl int) error {
+	return p.updateRecordID(recordID, recordName, recordType, newIP, ttl)
+}
+
+func (p *AliyunProvider) updateRecordID(recordId, recordName, recordType, newIP string, ttl int) error {
 	params := p.buildBaseParams()
 	params["Action"] = "UpdateDomainRecord"
 	params["RecordId"] = recordId
@@ -153,8 +201,32 @@ func (p *AliyunProvider) UpdateRecord(domain, recordName, recordType, newIP stri
 	params["Value"] = newIP
 	params["TTL"] = fmt.Sprintf("%d", ttl)
 
-	signature := p.generateSignature("POST", params)
-	params["Signature"] = signature
+	resp, err := p.makeRequest("POST", params)
+	if err != nil {
+		return err
+	}
+
+	if resp.Code != "" && resp.Code != "Success" {
+		if err := clockSkewError("aliyun", resp.Code, resp.Message); err != nil {
+			return err
+		}
+		return fmt.Errorf("aliyun API error: %s - %s", resp.Code, resp.Message)
+	}
+
+	return nil
+}
+
+// SetRecordRemark sets the remark of an existing record
+func (p *AliyunProvider) SetRecordRemark(domain, recordName, recordType, remark string) error {
+	recordId, err := p.getRecordId(domain, recordName, recordType)
+	if err != nil {
+		return err
+	}
+
+	params := p.buildBaseParams()
+	params["Action"] = "UpdateDomainRecordRemark"
+	params["RecordId"] = recordId
+	params["Remark"] = remark
 
 	resp, err := p.makeRequest("POST", params)
 	if err != nil {
@@ -162,6 +234,9 @@ func (p *AliyunProvider) UpdateRecord(domain, recordName, recordType, newIP stri
 	}
 
 	if resp.Code != "" && resp.Code != "Success" {
+		if err := clockSkewError("aliyun", resp.Code, resp.Message); err != nil {
+			return err
+		}
 		return fmt.Errorf("aliyun API error: %s - %s", resp.Code, resp.Message)
 	}
 
@@ -175,15 +250,15 @@ func (p *AliyunProvider) getRecordId(domain, recordName, recordType string) (str
 	params["RRKeyWord"] = recordName
 	params["Type"] = recordType
 
-	signature := p.generateSignature("GET", params)
-	params["Signature"] = signature
-
 	resp, err := p.makeRequest("GET", params)
 	if err != nil {
 		return "", err
 	}
 
 	if resp.Code != "" && resp.Code != "Success" {
+		if err := clockSkewError("aliyun", resp.Code, resp.Message); err != nil {
+			return "", err
+		}
 		return "", fmt.Errorf("aliyun API error: %s - %s", resp.Code, resp.Message)
 	}
 
@@ -192,27 +267,47 @@ func (p *AliyunProvider) getRecordId(domain, recordName, recordType string) (str
 		return "", ErrRecordNotFound
 	}
 
-	records, ok := resp.DomainRecords["Record"].([]interface{})
-	if !ok || len(records) == 0 {
-		return "", ErrRecordNotFound
-	}
-
-	record, ok := records[0].(map[string]interface{})
-	if !ok {
-		return "", ErrRecordNotFound
+	// RRKeyWord is a fuzzy match ("www" also finds "www2"), keep the exact name.
+	// Of several records with the name and type the first is used; the DNS
+	// manager applies duplicate_records before that.
+	for _, record := range aliyunRecordList(resp.DomainRecords) {
+		name, _ := record["RR"].(string)
+		rrType, _ := record["Type"].(string)
+		if !strings.EqualFold(name, recordName) || !strings.EqualFold(rrType, recordType) {
+			continue
+		}
+		return aliyunRecordID(record)
 	}
+	return "", ErrRecordNotFound
+}
 
-	// RecordId can be string or number, handle both cases
-	var recordId string
+// aliyunRecordID reads RecordId, which can be a string or a number
+func aliyunRecordID(record map[string]interface{}) (string, error) {
 	if id, ok := record["RecordId"].(string); ok {
-		recordId = id
+		return id, nil
 	} else if id, ok := record["RecordId"].(float64); ok {
-		recordId = fmt.Sprintf("%.0f", id)
-	} else {
-		return "", fmt.Errorf("invalid RecordId format")
+		return fmt.Sprintf("%.0f", id), nil
+	}
+	return "", fmt.Errorf("invalid RecordId format")
+}
+
+// aliyunRecordList extracts DomainRecords.Record, which is normally an array
+// but comes back as a single object in some responses with one record
+func aliyunRecordList(domainRecords map[string]interface{}) []map[string]interface{} {
+	var records []map[string]interface{}
+
+	switch value := domainRecords["Record"].(type) {
+	case []interface{}:
+		for _, item := range value {
+			if record, ok := item.(map[string]interface{}); ok {
+				records = append(records, record)
+			}
+		}
+	case map[string]interface{}:
+		records = append(records, value)
 	}
 
-	return recordId, nil
+	return records
 }
 
 func (p *AliyunProvider) generateSignature(method string, params map[string]string) string {
@@ -243,7 +338,12 @@ func (p *AliyunProvider) generateSignature(method string, params map[string]stri
 	return signature
 }
 
+// buildBaseParams returns the parameters every V1 request carries. V3
+// requests send them as headers instead, see newRequestV3.
 func (p *AliyunProvider) buildBaseParams() map[string]string {
+	if p.signatureVersion == aliyunSignatureV3 {
+		return map[string]string{}
+	}
 	return map[string]string{
 		"Format":           "JSON",
 		"Version":          aliyunAPIVersion,
@@ -255,6 +355,26 @@ func (p *AliyunProvider) buildBaseParams() map[string]string {
 	}
 }
 
+// newRequestV1 builds a request signed with the V1 HMAC-SHA1 signature
+func (p *AliyunProvider) newRequestV1(method string, params map[string]string) (*http.Request, error) {
+	params["Signature"] = p.generateSignature(method, params)
+
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+
+	if method == "POST" {
+		req, err := http.NewRequest("POST", p.endpoint, strings.NewReader(values.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}
+	return http.NewRequest("GET", p.endpoint+"?"+values.Encode(), nil)
+}
+
 func maskCredential(credential string) string {
 	if len(credential) <= 8 {
 		if len(credential) < 2 {
@@ -274,41 +394,33 @@ func (p *AliyunProvider) addRecord(domain, recordName, recordType, value string,
 	params["Value"] = value
 	params["TTL"] = fmt.Sprintf("%d", ttl)
 
-	signature := p.generateSignature("POST", params)
-	params["Signature"] = signature
-
 	resp, err := p.makeRequest("POST", params)
 	if err != nil {
 		return err
 	}
 
 	if resp.Code != "" && resp.Code != "Success" {
+		if err := clockSkewError("aliyun", resp.Code, resp.Message); err != nil {
+			return err
+		}
 		return fmt.Errorf("aliyun API error: %s - %s", resp.Code, resp.Message)
 	}
 
 	return nil
 }
 
+// makeRequest signs and sends a request with the configured signature
+// version
 func (p *AliyunProvider) makeRequest(method string, params map[string]string) (*AliyunResponse, error) {
-	values := url.Values{}
-	for k, v := range params {
-		values.Set(k, v)
-	}
-
 	var req *http.Request
 	var err error
-
-	if method == "POST" {
-		req, err = http.NewRequest("POST", p.endpoint, strings.NewReader(values.Encode()))
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.signatureVersion == aliyunSignatureV3 {
+		req, err = p.newRequestV3(method, params)
 	} else {
-		req, err = http.NewRequest("GET", p.endpoint+"?"+values.Encode(), nil)
-		if err != nil {
-			return nil, err
-		}
+		req, err = p.newRequestV1(method, params)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := p.client.Do(req)
@@ -327,8 +439,18 @@ func (p *AliyunProvider) makeRequest(method string, params map[string]string) (*
 
 	var aliyunResp AliyunResponse
 	if err := json.Unmarshal(body, &aliyunResp); err != nil {
+		if resp.StatusCode >= 400 {
+			return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error: %d", resp.StatusCode))
+		}
 		return nil, fmt.Errorf("JSON解析失败: %v", err)
 	}
 
+	if resp.StatusCode >= 400 && aliyunResp.Code != "" {
+		if err := clockSkewError("aliyun", aliyunResp.Code, aliyunResp.Message); err != nil {
+			return nil, err
+		}
+		return nil, statusError(resp.StatusCode, fmt.Errorf("aliyun API error: %s - %s", aliyunResp.Code, aliyunResp.Message))
+	}
+
 	return &aliyunResp, nil
 }
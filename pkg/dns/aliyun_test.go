@@ -0,0 +1,104 @@
+package dns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newAliyunServer is a mock Alidns API answering DescribeDomainRecords with
+// the given DomainRecords.Record value and recording the other actions
+func newAliyunServer(t *testing.T, record interface{}, actions *[]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		action := r.Form.Get("Action")
+		if action != "DescribeDomainRecords" {
+			*actions = append(*actions, action+" "+r.Form.Get("RecordId")+" "+r.Form.Get("Value"))
+			json.NewEncoder(w).Encode(map[string]string{"RequestId": "req"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"RequestId":     "req",
+			"TotalCount":    1,
+			"DomainRecords": map[string]interface{}{"Record": record},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestAliyunProvider(t *testing.T, endpoint string) *AliyunProvider {
+	p := NewAliyunProvider()
+	p.SetCredentials("LTAIexample", "secret")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+var aliyunWWWRecord = map[string]interface{}{
+	"RR": "www", "Type": "A", "Value": "1.1.1.1", "TTL": 600, "RecordId": 1234567890123,
+}
+
+func TestAliyunSingleRecordObject(t *testing.T) {
+	var actions []string
+	server := newAliyunServer(t, aliyunWWWRecord, &actions)
+	p := newTestAliyunProvider(t, server.URL)
+
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DNSRecord{{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600, ID: "1234567890123"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("GetRecords = %+v, want %+v", records, want)
+	}
+
+	// The single record is found and updated rather than added again
+	if err := p.UpdateRecord("example.com", "www", "A", "2.2.2.2", 600); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"UpdateDomainRecord 1234567890123 2.2.2.2"}; !reflect.DeepEqual(actions, want) {
+		t.Fatalf("actions = %v, want %v", actions, want)
+	}
+}
+
+func TestAliyunRecordArray(t *testing.T) {
+	var actions []string
+	server := newAliyunServer(t, []interface{}{
+		map[string]interface{}{"RR": "www2", "Type": "A", "Value": "3.3.3.3", "TTL": 600, "RecordId": "1"},
+		aliyunWWWRecord,
+	}, &actions)
+	p := newTestAliyunProvider(t, server.URL)
+
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("GetRecords = %+v, want two records", records)
+	}
+
+	// RRKeyWord matches www2 as well; the exact name is updated
+	if err := p.UpdateRecord("example.com", "www", "A", "2.2.2.2", 600); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"UpdateDomainRecord 1234567890123 2.2.2.2"}; !reflect.DeepEqual(actions, want) {
+		t.Fatalf("actions = %v, want %v", actions, want)
+	}
+}
+
+func TestAliyunNoRecords(t *testing.T) {
+	var actions []string
+	server := newAliyunServer(t, []interface{}{}, &actions)
+	p := newTestAliyunProvider(t, server.URL)
+
+	if err := p.UpdateRecord("example.com", "www", "A", "2.2.2.2", 600); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"AddDomainRecord  2.2.2.2"}; !reflect.DeepEqual(actions, want) {
+		t.Fatalf("actions = %v, want %v", actions, want)
+	}
+}
@@ -0,0 +1,158 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"ip-updater/internal/config"
+)
+
+// DefaultParallelUpdates is how many records of an updater are updated at
+// the same time when parallel_updates isn't set
+const DefaultParallelUpdates = 4
+
+// RecordChange is one record a BatchUpdater should set to Value. Current is
+// the record as returned by GetRecords and Exists tells whether there was
+// one; new records are created.
+type RecordChange struct {
+	Name    string
+	Type    string
+	Value   string
+	TTL     int
+	Current DNSRecord
+	Exists  bool
+}
+
+// BatchUpdater is implemented by providers that can apply several record
+// changes in one API call. It is used whenever more than one record of an
+// updater changes; if the batch fails, the records are updated one by one.
+type BatchUpdater interface {
+	UpdateRecords(domain string, changes []RecordChange) error
+}
+
+// recordChange is a record that needs updating, found by
+// UpdateDNSRecordAddresses
+type recordChange struct {
+	record  config.DNSRecord
+	ip      string
+	current DNSRecord
+	found   bool
+	key     string // for logging
+
+	// matchKey tracks the updated record of a match_value record
+	matchKey string
+	// byID updates current by its ID on providers that support it, as it
+	// is one of several records sharing the name and type
+	byID bool
+}
+
+// applyChanges applies the changed records of an updater: in one batch when
+// the provider supports it, otherwise through a pool of parallel_updates
+// workers. Every record is attempted; the failures are returned together.
+func (dm *DNSManager) applyChanges(provider Provider, updater config.DNSUpdater, changes []recordChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if batch, ok := provider.(BatchUpdater); ok && len(changes) > 1 {
+		err := dm.applyBatch(batch, updater.Domain, changes)
+		if err == nil {
+			for _, change := range changes {
+				dm.changeApplied(provider, updater.Domain, change)
+			}
+			return nil
+		}
+		if dm.logger != nil {
+			dm.logger.Warnf("⚠️ 批量更新 %d 条DNS记录失败，改为逐条更新: %v", len(changes), err)
+		}
+	}
+
+	workers := updater.ParallelUpdates
+	if workers <= 0 {
+		workers = DefaultParallelUpdates
+	}
+	workers = min(workers, len(changes))
+
+	errs := make([]error, len(changes))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				change := changes[i]
+				if err := dm.applyRecord(provider, updater.Domain, change.record, change.ip, change.current, change.byID); err != nil {
+					if dm.logger != nil {
+						dm.logger.Errorf("❌ DNS记录更新失败: %s: %v", change.key, err)
+					}
+					errs[i] = err
+					continue
+				}
+				dm.changeApplied(provider, updater.Domain, change)
+			}
+		}()
+	}
+	for i := range changes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(changes) > 1 && dm.logger != nil {
+		dm.logger.Infof("📊 %s: %d/%d 条DNS记录更新成功", updater.DisplayDomain(), len(changes)-len(failed), len(changes))
+	}
+
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return failed[0]
+	default:
+		// One line for the log, still matched by errors.Is/As
+		format := "%d/%d DNS records failed: " + strings.TrimSuffix(strings.Repeat("%w; ", len(failed)), "; ")
+		args := []interface{}{len(failed), len(changes)}
+		for _, err := range failed {
+			args = append(args, err)
+		}
+		return fmt.Errorf(format, args...)
+	}
+}
+
+func (dm *DNSManager) applyBatch(batch BatchUpdater, domain string, changes []recordChange) error {
+	batchChanges := make([]RecordChange, 0, len(changes))
+	for _, change := range changes {
+		batchChanges = append(batchChanges, RecordChange{
+			Name:    change.record.Name,
+			Type:    change.record.Type,
+			Value:   change.ip,
+			TTL:     change.record.TTL,
+			Current: change.current,
+			Exists:  change.found,
+		})
+	}
+
+	if dm.logger != nil {
+		dm.logger.Infof("📦 批量更新 %d 条DNS记录: %s", len(changes), domain)
+	}
+	return batch.UpdateRecords(domain, batchChanges)
+}
+
+// changeApplied logs an applied change and brings the remark up to date
+func (dm *DNSManager) changeApplied(provider Provider, domain string, change recordChange) {
+	if dm.logger != nil {
+		dm.logger.Infof("✅ DNS记录更新成功: %s = '%s' (TTL: %d)", change.key, change.ip, change.record.TTL)
+	}
+	dm.setMatched(change.matchKey, DNSRecord{ID: change.current.ID, Value: change.ip})
+	record := change.record
+	if record.Remark != "" && (!change.found || change.current.Remark != record.Remark) {
+		dm.syncRemark(provider, domain, record, change.key)
+	}
+}
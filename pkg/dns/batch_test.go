@@ -0,0 +1,173 @@
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ip-updater/internal/config"
+)
+
+// manyRecords returns n A records named h0, h1, ... with a TTL of 600
+func manyRecords(n int) []config.DNSRecord {
+	records := make([]config.DNSRecord, n)
+	for i := range records {
+		records[i] = config.DNSRecord{Name: fmt.Sprintf("h%d", i), Type: "A", TTL: 600}
+	}
+	return records
+}
+
+func newNullManager(extra map[string]string, records []config.DNSRecord) (*DNSManager, *NullDNSProvider, config.DNSUpdater) {
+	p := NewNullProvider("null")
+	dm := NewDNSManager()
+	dm.RegisterProvider("null", p)
+	updater := config.DNSUpdater{
+		Name:        "many",
+		Provider:    "null",
+		Domain:      "example.com",
+		ExtraConfig: extra,
+		Records:     records,
+	}
+	return dm, p, updater
+}
+
+// nullUpdates returns the UpdateRecord calls made to p
+func nullUpdates(p *NullDNSProvider) []NullCall {
+	var updates []NullCall
+	for _, call := range p.Calls() {
+		if call.Method == "UpdateRecord" {
+			updates = append(updates, call)
+		}
+	}
+	return updates
+}
+
+func TestManyRecordsSkipCurrentAndUpdateInParallel(t *testing.T) {
+	dm, p, updater := newNullManager(map[string]string{"latency": "50ms"}, manyRecords(20))
+
+	// Five records already have the address
+	for i := 0; i < 5; i++ {
+		if err := p.UpdateRecord("example.com", fmt.Sprintf("h%d", i), "A", "203.0.113.7", 600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seeded := len(nullUpdates(p))
+
+	start := time.Now()
+	if err := dm.UpdateDNSRecord(updater, "203.0.113.7"); err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+
+	calls := nullUpdates(p)[seeded:]
+	if len(calls) != 15 {
+		t.Fatalf("updates = %d, want 15 (records already current skipped)", len(calls))
+	}
+	for _, call := range calls {
+		switch call.Name {
+		case "h0", "h1", "h2", "h3", "h4":
+			t.Errorf("%s was already current but updated", call.Name)
+		}
+	}
+	// 15 updates of 50ms each take 750ms one by one, 200ms in four workers
+	if elapsed >= 600*time.Millisecond {
+		t.Fatalf("updates took %s, want them run in parallel", elapsed)
+	}
+}
+
+func TestManyRecordsAttemptAllAndReportFailures(t *testing.T) {
+	dm, p, updater := newNullManager(map[string]string{"fail_first": "3"}, manyRecords(10))
+	updater.ParallelUpdates = 1
+
+	err := dm.UpdateDNSRecord(updater, "203.0.113.7")
+	if err == nil || !strings.HasPrefix(err.Error(), "3/10 DNS records failed: ") {
+		t.Fatalf("err = %v, want the three failures reported together", err)
+	}
+	if n := len(nullUpdates(p)); n != 10 {
+		t.Fatalf("updates = %d, want every record attempted", n)
+	}
+	records, _ := p.GetRecords("example.com")
+	if len(records) != 7 {
+		t.Fatalf("records = %d, want the seven that succeeded", len(records))
+	}
+}
+
+func TestCloudflareBatchesManyRecords(t *testing.T) {
+	var existing []CloudflareRecord
+	for i := 0; i < 8; i++ {
+		existing = append(existing, CloudflareRecord{ID: fmt.Sprintf("r%d", i), Type: "A", Name: fmt.Sprintf("h%d.example.com", i), Content: "192.0.2.1", TTL: 600})
+	}
+
+	var mu sync.Mutex
+	var batches []cloudflareBatch
+	var other []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/zones", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(CloudflareResponse{Success: true, Result: []CloudflareZone{{ID: "zone1", Name: "example.com"}}})
+	})
+	mux.HandleFunc("/zones/zone1/dns_records", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(CloudflareRecordList{
+			Success:    true,
+			Result:     existing,
+			ResultInfo: &CloudflareResultInfo{Page: 1, PerPage: 100, Count: len(existing), TotalCount: len(existing), TotalPages: 1},
+		})
+	})
+	mux.HandleFunc("/zones/zone1/dns_records/batch", func(w http.ResponseWriter, r *http.Request) {
+		var batch cloudflareBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(CloudflareResponse{Success: true})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		other = append(other, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	dm := NewDNSManager()
+	dm.RegisterProvider("cloudflare", NewCloudflareProvider())
+	updater := config.DNSUpdater{
+		Name:        "cf",
+		Provider:    "cloudflare",
+		Token:       "token",
+		Domain:      "example.com",
+		ExtraConfig: map[string]string{SettingEndpoint: server.URL},
+		Records:     manyRecords(10),
+	}
+	if err := dm.UpdateDNSRecord(updater, "203.0.113.7"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batches) != 1 || len(other) != 0 {
+		t.Fatalf("batches = %d, other requests = %v, want one batch only", len(batches), other)
+	}
+	batch := batches[0]
+	if len(batch.Patches) != 8 || len(batch.Posts) != 2 {
+		t.Fatalf("batch = %d patches, %d posts, want 8 and 2", len(batch.Patches), len(batch.Posts))
+	}
+	if patch := batch.Patches[3]; patch.ID != "r3" || patch.Content != "203.0.113.7" || patch.TTL != 600 {
+		t.Fatalf("patch = %+v", patch)
+	}
+	if post := batch.Posts[0]; post.Name != "h8.example.com" || post.Content != "203.0.113.7" {
+		t.Fatalf("post = %+v", post)
+	}
+}
+
+func BenchmarkManyRecords(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		dm, _, updater := newNullManager(map[string]string{"latency": "1ms"}, manyRecords(100))
+		if err := dm.UpdateDNSRecord(updater, "203.0.113.7"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
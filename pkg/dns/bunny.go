@@ -0,0 +1,262 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const bunnyEndpoint = "https://api.bunny.net"
+
+const bunnyPageSize = 1000
+
+// bunnyRecordTypes maps record types to Bunny's numeric type enum
+var bunnyRecordTypes = map[string]int{
+	"A":     0,
+	"AAAA":  1,
+	"CNAME": 2,
+	"TXT":   3,
+	"MX":    4,
+	"SRV":   8,
+	"CAA":   9,
+	"PTR":   10,
+	"NS":    12,
+}
+
+type BunnyDNSProvider struct {
+	accessKey string
+	endpoint  string
+	client    *http.Client
+}
+
+type BunnyZone struct {
+	ID      int64         `json:"Id"`
+	Domain  string        `json:"Domain"`
+	Records []BunnyRecord `json:"Records"`
+}
+
+type BunnyZoneList struct {
+	Items        []BunnyZone `json:"Items"`
+	CurrentPage  int         `json:"CurrentPage"`
+	TotalItems   int         `json:"TotalItems"`
+	HasMoreItems bool        `json:"HasMoreItems"`
+}
+
+type BunnyRecord struct {
+	ID    int64  `json:"Id,omitempty"`
+	Type  int    `json:"Type"`
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+	TTL   int    `json:"Ttl,omitempty"`
+}
+
+type BunnyError struct {
+	ErrorKey string `json:"ErrorKey"`
+	Field    string `json:"Field"`
+	Message  string `json:"Message"`
+}
+
+func NewBunnyProvider() *BunnyDNSProvider {
+	return &BunnyDNSProvider{
+		endpoint: bunnyEndpoint,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("bunny"),
+		},
+	}
+}
+
+func (p *BunnyDNSProvider) GetProviderName() string {
+	return "bunny"
+}
+
+// DefaultTTL is used for records configured without a TTL.
+// Bunny's default record TTL
+func (p *BunnyDNSProvider) DefaultTTL() int {
+	return 300
+}
+
+func (p *BunnyDNSProvider) SetCredentials(accessKey, secretKey string) {
+	p.accessKey = accessKey
+}
+
+// Configure applies the endpoint override, timeout, user agent and proxy
+func (p *BunnyDNSProvider) Configure(settings ProviderSettings) error {
+	p.endpoint = settings.endpointOr(bunnyEndpoint)
+	p.client = settings.httpClient("bunny")
+	return nil
+}
+
+func (p *BunnyDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
+	zone, err := p.getZone(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]DNSRecord, 0, len(zone.Records))
+	for _, rec := range zone.Records {
+		recordType, ok := bunnyTypeName(rec.Type)
+		if !ok {
+			// Redirect, Flatten, PullZone, Script: nothing an IP can go into
+			continue
+		}
+		records = append(records, DNSRecord{
+			Name:  p.toRecordName(rec.Name),
+			Type:  recordType,
+			Value: rec.Value,
+			TTL:   rec.TTL,
+		})
+	}
+
+	return records, nil
+}
+
+func (p *BunnyDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
+	typeID, ok := bunnyRecordTypes[recordType]
+	if !ok {
+		return fmt.Errorf("unsupported record type for bunny: %s", recordType)
+	}
+
+	zone, err := p.getZone(domain)
+	if err != nil {
+		return err
+	}
+
+	record := BunnyRecord{
+		Type:  typeID,
+		Name:  p.toBunnyName(recordName),
+		Value: newIP,
+		TTL:   ttl,
+	}
+
+	for _, rec := range zone.Records {
+		if rec.Type == typeID && strings.EqualFold(rec.Name, record.Name) {
+			record.ID = rec.ID
+			jsonData, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			path := fmt.Sprintf("/dnszone/%d/records/%d", zone.ID, rec.ID)
+			_, err = p.makeRequest("POST", path, bytes.NewReader(jsonData))
+			return err
+		}
+	}
+
+	// Record doesn't exist, create it
+	jsonData, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/dnszone/%d/records", zone.ID)
+	_, err = p.makeRequest("PUT", path, bytes.NewReader(jsonData))
+	return err
+}
+
+// getZone finds the zone by name, following the list pagination, and
+// returns it with its records
+func (p *BunnyDNSProvider) getZone(domain string) (*BunnyZone, error) {
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("/dnszone?page=%d&perPage=%d&search=%s", page, bunnyPageSize, url.QueryEscape(domain))
+		body, err := p.makeRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var response BunnyZoneList
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse zones response: %v", err)
+		}
+
+		for _, zone := range response.Items {
+			if strings.EqualFold(strings.TrimSuffix(zone.Domain, "."), domain) {
+				return p.getZoneRecords(zone.ID)
+			}
+		}
+
+		if !response.HasMoreItems || len(response.Items) == 0 {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("zone not found: %s", domain)
+}
+
+// getZoneRecords reads the zone itself, which carries the full record list
+func (p *BunnyDNSProvider) getZoneRecords(zoneId int64) (*BunnyZone, error) {
+	body, err := p.makeRequest("GET", fmt.Sprintf("/dnszone/%d", zoneId), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var zone BunnyZone
+	if err := json.Unmarshal(body, &zone); err != nil {
+		return nil, fmt.Errorf("failed to parse zone response: %v", err)
+	}
+	zone.ID = zoneId
+
+	return &zone, nil
+}
+
+func bunnyTypeName(typeID int) (string, bool) {
+	for name, id := range bunnyRecordTypes {
+		if id == typeID {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Bunny uses an empty name for the zone apex
+func (p *BunnyDNSProvider) toBunnyName(recordName string) string {
+	if recordName == "@" {
+		return ""
+	}
+	return recordName
+}
+
+func (p *BunnyDNSProvider) toRecordName(name string) string {
+	if name == "" || name == "@" {
+		return "@"
+	}
+	return strings.ToLower(name)
+}
+
+func (p *BunnyDNSProvider) makeRequest(method, path string, body io.Reader) ([]byte, error) {
+	fullURL := p.endpoint + path
+
+	req, err := http.NewRequest(method, fullURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("AccessKey", p.accessKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		var bunnyErr BunnyError
+		if err := json.Unmarshal(respBody, &bunnyErr); err == nil && bunnyErr.Message != "" {
+			return nil, statusError(resp.StatusCode, fmt.Errorf("bunny API error: %s (status: %d)", bunnyErr.Message, resp.StatusCode))
+		}
+		return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error: %d", resp.StatusCode))
+	}
+
+	return respBody, nil
+}
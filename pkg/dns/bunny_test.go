@@ -0,0 +1,120 @@
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// bunnyServer is a mock Bunny API; the zone search returns a decoy zone on
+// the first page and example.com (zone 77) on the second
+type bunnyServer struct {
+	records  []BunnyRecord
+	requests []string
+}
+
+func newBunnyServer(t *testing.T, records ...BunnyRecord) (*bunnyServer, *httptest.Server) {
+	s := &bunnyServer{records: records}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dnszone", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("AccessKey") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(BunnyError{ErrorKey: "unauthorized", Message: "Authorization has been denied"})
+			return
+		}
+		if r.URL.Query().Get("page") == "1" {
+			json.NewEncoder(w).Encode(BunnyZoneList{Items: []BunnyZone{{ID: 1, Domain: "sub.example.com"}}, CurrentPage: 1, HasMoreItems: true})
+			return
+		}
+		json.NewEncoder(w).Encode(BunnyZoneList{Items: []BunnyZone{{ID: 77, Domain: "example.com."}}, CurrentPage: 2})
+	})
+	mux.HandleFunc("/dnszone/77", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(BunnyZone{Domain: "example.com", Records: s.records})
+	})
+	mux.HandleFunc("/dnszone/77/records", func(w http.ResponseWriter, r *http.Request) {
+		var rec BunnyRecord
+		json.NewDecoder(r.Body).Decode(&rec)
+		s.requests = append(s.requests, fmt.Sprintf("%s %s type=%d name=%s %s", r.Method, r.URL.Path, rec.Type, rec.Name, rec.Value))
+	})
+	mux.HandleFunc("/dnszone/77/records/", func(w http.ResponseWriter, r *http.Request) {
+		var rec BunnyRecord
+		json.NewDecoder(r.Body).Decode(&rec)
+		s.requests = append(s.requests, fmt.Sprintf("%s %s id=%d %s", r.Method, r.URL.Path, rec.ID, rec.Value))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func newTestBunnyProvider(t *testing.T, endpoint, key string) *BunnyDNSProvider {
+	p := NewBunnyProvider()
+	p.SetCredentials(key, "")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestBunnyGetRecordsMapsTypes(t *testing.T) {
+	_, server := newBunnyServer(t,
+		BunnyRecord{ID: 1, Type: 0, Name: "", Value: "1.1.1.1", TTL: 300},
+		BunnyRecord{ID: 2, Type: 1, Name: "WWW", Value: "2001:db8::1", TTL: 300},
+		// Pull zone records carry no address and are skipped
+		BunnyRecord{ID: 3, Type: 7, Name: "cdn", Value: "pullzone", TTL: 300},
+	)
+	p := newTestBunnyProvider(t, server.URL, "key")
+
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DNSRecord{
+		{Name: "@", Type: "A", Value: "1.1.1.1", TTL: 300},
+		{Name: "www", Type: "AAAA", Value: "2001:db8::1", TTL: 300},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("GetRecords = %+v, want %+v", records, want)
+	}
+}
+
+func TestBunnyUpdateRecord(t *testing.T) {
+	server, ts := newBunnyServer(t, BunnyRecord{ID: 5, Type: 0, Name: "", Value: "1.1.1.1", TTL: 300})
+	p := newTestBunnyProvider(t, ts.URL, "key")
+
+	if err := p.UpdateRecord("example.com", "@", "A", "2.2.2.2", 300); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UpdateRecord("example.com", "www", "AAAA", "2001:db8::2", 300); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"POST /dnszone/77/records/5 id=5 2.2.2.2",
+		"PUT /dnszone/77/records type=1 name=www 2001:db8::2",
+	}
+	if !reflect.DeepEqual(server.requests, want) {
+		t.Fatalf("requests = %v, want %v", server.requests, want)
+	}
+}
+
+func TestBunnyErrors(t *testing.T) {
+	_, server := newBunnyServer(t)
+
+	p := newTestBunnyProvider(t, server.URL, "key")
+	if err := p.UpdateRecord("example.com", "@", "HTTPS", "x", 300); err == nil {
+		t.Fatal("unsupported record type was accepted")
+	}
+	if _, err := p.GetRecords("missing.com"); err == nil || err.Error() != "zone not found: missing.com" {
+		t.Fatalf("GetRecords error = %v, want zone not found", err)
+	}
+
+	p = newTestBunnyProvider(t, server.URL, "wrong")
+	_, err := p.GetRecords("example.com")
+	if err == nil || err.Error() != "bunny API error: Authorization has been denied (status: 401)" {
+		t.Fatalf("GetRecords error = %v, want the Bunny error message", err)
+	}
+}
@@ -0,0 +1,49 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+)
+
+// clockSkewSignature identifies a provider error caused by the request
+// timestamp being too far from the provider's clock. An empty code matches
+// any code; an empty message matches any message.
+type clockSkewSignature struct {
+	code    string
+	message string
+}
+
+// clockSkewSignatures maps the providers that sign requests with a timestamp
+// to the errors they return when the host clock is off
+var clockSkewSignatures = map[string][]clockSkewSignature{
+	"aliyun": {
+		{code: "InvalidTimeStamp.Expired"},
+		{code: "InvalidTimeStamp.Format"},
+	},
+	"tencent": {
+		{code: "AuthFailure.SignatureExpire"},
+		{code: "AuthFailure.RequestTimeExpired"},
+	},
+	"huawei": {
+		// API gateway errors only say so in the message
+		{message: "x-sdk-date"},
+		{message: "request expired"},
+		{message: "signature expired"},
+	},
+}
+
+// clockSkewError returns an ErrClockSkew error when the provider's error code
+// and message indicate a rejected timestamp, or nil otherwise
+func clockSkewError(provider, code, message string) error {
+	for _, signature := range clockSkewSignatures[provider] {
+		if signature.code != "" && !strings.EqualFold(signature.code, code) {
+			continue
+		}
+		if signature.message != "" && !strings.Contains(strings.ToLower(message), signature.message) {
+			continue
+		}
+		return fmt.Errorf("%w: %s API error %s - %s; the request timestamp was rejected, check that the system clock is synchronized (NTP)",
+			ErrClockSkew, provider, code, message)
+	}
+	return nil
+}
@@ -3,12 +3,22 @@ package dns
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const cloudflareEndpoint = "https://api.cloudflare.com/client/v4"
+
+// cloudflareMaxPageWait caps how long a page request waits for the rate
+// limit to reset
+const cloudflareMaxPageWait = time.Minute
+
 type CloudflareDNSProvider struct {
 	apiToken string
 	endpoint string
@@ -32,12 +42,29 @@ type CloudflareZone struct {
 }
 
 type CloudflareRecord struct {
-	ID      string `json:"id"`
-	Type    string `json:"type"`
-	Name    string `json:"name"`
-	Content string `json:"content"`
-	TTL     int    `json:"ttl"`
-	ZoneID  string `json:"zone_id"`
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	TTL        int    `json:"ttl"`
+	ZoneID     string `json:"zone_id"`
+	ModifiedOn string `json:"modified_on"`
+}
+
+type CloudflareRecordList struct {
+	Success    bool                  `json:"success"`
+	Errors     []CloudflareError     `json:"errors"`
+	Result     []CloudflareRecord    `json:"result"`
+	ResultInfo *CloudflareResultInfo `json:"result_info"`
+}
+
+// CloudflareResultInfo describes the page of a list response
+type CloudflareResultInfo struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Count      int `json:"count"`
+	TotalCount int `json:"total_count"`
+	TotalPages int `json:"total_pages"`
 }
 
 type CloudflareRecordRequest struct {
@@ -49,26 +76,147 @@ type CloudflareRecordRequest struct {
 
 func NewCloudflareProvider() *CloudflareDNSProvider {
 	return &CloudflareDNSProvider{
-		endpoint: "https://api.cloudflare.com/client/v4",
+		endpoint: cloudflareEndpoint,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("cloudflare"),
 		},
 	}
 }
 
 func (p *CloudflareDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
-	// TODO: 待验证 - Cloudflare DNS记录获取功能需要验证和完善
-	return []DNSRecord{}, fmt.Errorf("Cloudflare GetRecords功能待验证 - 需要测试API调用")
+	zoneId, err := p.getZoneId(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/zones/%s/dns_records?per_page=100", zoneId)
+	cfRecords, err := p.listRecords(path)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]DNSRecord, 0, len(cfRecords))
+	for _, rec := range cfRecords {
+		records = append(records, DNSRecord{
+			Name:    p.getRelativeRecordName(rec.Name, domain),
+			Type:    rec.Type,
+			Value:   rec.Content,
+			TTL:     rec.TTL,
+			ID:      rec.ID,
+			Version: rec.ModifiedOn,
+		})
+	}
+
+	return records, nil
+}
+
+// cloudflareBatch is the body of POST /zones/{zone_id}/dns_records/batch,
+// which applies all of its changes in one transaction
+type cloudflareBatch struct {
+	Patches []cloudflareBatchPatch    `json:"patches,omitempty"`
+	Posts   []CloudflareRecordRequest `json:"posts,omitempty"`
+}
+
+type cloudflareBatchPatch struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+// UpdateRecords applies several changes with one batch request: existing
+// records are patched by ID, missing ones created
+func (p *CloudflareDNSProvider) UpdateRecords(domain string, changes []RecordChange) error {
+	zoneId, err := p.getZoneId(domain)
+	if err != nil {
+		return err
+	}
+
+	var batch cloudflareBatch
+	for _, change := range changes {
+		if change.Exists {
+			if change.Current.ID == "" {
+				return fmt.Errorf("record ID of %s (%s) is unknown", change.Name, change.Type)
+			}
+			batch.Patches = append(batch.Patches, cloudflareBatchPatch{ID: change.Current.ID, Content: change.Value, TTL: change.TTL})
+			continue
+		}
+		batch.Posts = append(batch.Posts, CloudflareRecordRequest{
+			Type:    change.Type,
+			Name:    p.getFullRecordName(change.Name, domain),
+			Content: change.Value,
+			TTL:     change.TTL,
+		})
+	}
+
+	jsonData, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	respBody, err := p.makeRequest("POST", fmt.Sprintf("/zones/%s/dns_records/batch", zoneId), bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+
+	var cfResp CloudflareResponse
+	if err := json.Unmarshal(respBody, &cfResp); err != nil {
+		return err
+	}
+	if !cfResp.Success {
+		return p.formatCloudflareErrors(cfResp.Errors)
+	}
+	return nil
+}
+
+// CheckCredentials verifies the API token with /user/tokens/verify, which
+// needs no permission besides a valid token
+func (p *CloudflareDNSProvider) CheckCredentials() error {
+	respBody, err := p.makeRequest("GET", "/user/tokens/verify", nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Success bool              `json:"success"`
+		Errors  []CloudflareError `json:"errors"`
+		Result  struct {
+			Status string `json:"status"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return p.formatCloudflareErrors(result.Errors)
+	}
+	if result.Result.Status != "active" {
+		return fmt.Errorf("cloudflare API token is %s", result.Result.Status)
+	}
+	return nil
 }
 
 func (p *CloudflareDNSProvider) GetProviderName() string {
 	return "cloudflare"
 }
 
+// DefaultTTL is used for records configured without a TTL.
+// Cloudflare treats a TTL of 1 as "automatic"
+func (p *CloudflareDNSProvider) DefaultTTL() int {
+	return 1
+}
+
 func (p *CloudflareDNSProvider) SetCredentials(accessKey, secretKey string) {
 	p.apiToken = accessKey
 }
 
+// Configure applies the endpoint override, timeout, user agent and proxy
+func (p *CloudflareDNSProvider) Configure(settings ProviderSettings) error {
+	p.endpoint = settings.endpointOr(cloudflareEndpoint)
+	p.client = settings.httpClient("cloudflare")
+	return nil
+}
+
 func (p *CloudflareDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
 	zoneId, err := p.getZoneId(domain)
 	if err != nil {
@@ -80,6 +228,20 @@ func (p *CloudflareDNSProvider) UpdateRecord(domain, recordName, recordType, new
 		return err
 	}
 
+	return p.putRecord(zoneId, recordId, recordName, recordType, newIP, ttl, domain)
+}
+
+// UpdateRecordByID updates the record with the given ID, one of several
+// sharing its name and type
+func (p *CloudflareDNSProvider) UpdateRecordByID(domain, recordID, recordName, recordType, newIP string, ttl int) error {
+	zoneId, err := p.getZoneId(domain)
+	if err != nil {
+		return err
+	}
+	return p.putRecord(zoneId, recordID, recordName, recordType, newIP, ttl, domain)
+}
+
+func (p *CloudflareDNSProvider) putRecord(zoneId, recordId, recordName, recordType, newIP string, ttl int, domain string) error {
 	recordData := CloudflareRecordRequest{
 		Type:    recordType,
 		Name:    p.getFullRecordName(recordName, domain),
@@ -92,11 +254,33 @@ func (p *CloudflareDNSProvider) UpdateRecord(domain, recordName, recordType, new
 		return err
 	}
 
-	url := fmt.Sprintf("/zones/%s/dns_records/%s", zoneId, recordId)
-	_, err = p.makeRequest("PUT", url, bytes.NewReader(jsonData))
+	path := fmt.Sprintf("/zones/%s/dns_records/%s", zoneId, recordId)
+	_, err = p.makeRequest("PUT", path, bytes.NewReader(jsonData))
 	return err
 }
 
+// UpdateRecordIfMatch updates the record only if its modified_on timestamp
+// still equals version. Cloudflare has no If-Match support for DNS records,
+// so the record is re-read right before the write; this narrows the race
+// with concurrent edits but cannot close it entirely.
+func (p *CloudflareDNSProvider) UpdateRecordIfMatch(domain, recordName, recordType, newIP string, ttl int, version string) error {
+	zoneId, err := p.getZoneId(domain)
+	if err != nil {
+		return err
+	}
+
+	record, err := p.getRecord(zoneId, recordName, recordType, domain)
+	if err != nil {
+		return err
+	}
+
+	if record.ModifiedOn != version {
+		return ErrPreconditionFailed
+	}
+
+	return p.putRecord(zoneId, record.ID, recordName, recordType, newIP, ttl, domain)
+}
+
 func (p *CloudflareDNSProvider) getZoneId(domain string) (string, error) {
 	url := fmt.Sprintf("/zones?name=%s", domain)
 	body, err := p.makeRequest("GET", url, nil)
@@ -132,39 +316,115 @@ func (p *CloudflareDNSProvider) getZoneId(domain string) (string, error) {
 }
 
 func (p *CloudflareDNSProvider) getRecordId(zoneId, recordName, recordType, domain string) (string, error) {
-	fullRecordName := p.getFullRecordName(recordName, domain)
-	url := fmt.Sprintf("/zones/%s/dns_records?name=%s&type=%s", zoneId, fullRecordName, recordType)
-
-	body, err := p.makeRequest("GET", url, nil)
+	record, err := p.getRecord(zoneId, recordName, recordType, domain)
 	if err != nil {
 		return "", err
 	}
+	return record.ID, nil
+}
 
-	var response CloudflareResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to parse records response: %v", err)
+func (p *CloudflareDNSProvider) getRecord(zoneId, recordName, recordType, domain string) (*CloudflareRecord, error) {
+	fullRecordName := p.getFullRecordName(recordName, domain)
+	path := fmt.Sprintf("/zones/%s/dns_records?name=%s&type=%s", zoneId, url.QueryEscape(fullRecordName), url.QueryEscape(recordType))
+
+	records, err := p.listRecords(path)
+	if err != nil {
+		return nil, err
 	}
 
-	if !response.Success {
-		return "", p.formatCloudflareErrors(response.Errors)
+	if len(records) == 0 {
+		return nil, ErrRecordNotFound
 	}
 
-	records, ok := response.Result.([]interface{})
-	if !ok || len(records) == 0 {
-		return "", ErrRecordNotFound
+	return &records[0], nil
+}
+
+// listRecords returns the records of every page of a list request. Page
+// requests are paced by the rate-limit headers, and a page that hits the
+// limit (429) is retried once the limit resets.
+func (p *CloudflareDNSProvider) listRecords(path string) ([]CloudflareRecord, error) {
+	var records []CloudflareRecord
+	for page := 1; ; page++ {
+		body, header, err := p.doRequest("GET", fmt.Sprintf("%s&page=%d", path, page), nil)
+		if wait := cloudflareRateLimitWait(header, 1); err != nil && wait > 0 && isRateLimited(err) {
+			time.Sleep(wait)
+			body, header, err = p.doRequest("GET", fmt.Sprintf("%s&page=%d", path, page), nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var response CloudflareRecordList
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse records response: %v", err)
+		}
+
+		if !response.Success {
+			return nil, p.formatCloudflareErrors(response.Errors)
+		}
+		records = append(records, response.Result...)
+
+		info := response.ResultInfo
+		if info == nil || page >= info.TotalPages || len(response.Result) == 0 {
+			return records, nil
+		}
+		if wait := cloudflareRateLimitWait(header, info.TotalPages-page); wait > 0 {
+			time.Sleep(wait)
+		}
 	}
+}
 
-	recordData, ok := records[0].(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("invalid record data format")
+// isRateLimited reports whether err is an HTTP 429 response
+func isRateLimited(err error) bool {
+	var statusErr *HTTPStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests
+}
+
+// cloudflareRateLimitWait returns how long to wait before the next of
+// pending requests: Retry-After when given, otherwise the reset time from
+// the Ratelimit header ("default";r=<remaining>;t=<seconds>) once the
+// remaining budget is used up, spread over the window when it is lower than
+// the number of requests still to make
+func cloudflareRateLimitWait(header http.Header, pending int) time.Duration {
+	if header == nil {
+		return 0
 	}
 
-	recordId, ok := recordData["id"].(string)
-	if !ok {
-		return "", fmt.Errorf("record ID not found")
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	} else if remaining, reset, ok := parseRateLimit(header.Get("Ratelimit")); ok {
+		switch {
+		case remaining <= 0:
+			wait = reset
+		case remaining < pending:
+			wait = reset / time.Duration(remaining)
+		}
 	}
+	return min(wait, cloudflareMaxPageWait)
+}
 
-	return recordId, nil
+// parseRateLimit reads the remaining requests (r) and the seconds until
+// the window resets (t) from a Ratelimit header
+func parseRateLimit(value string) (remaining int, reset time.Duration, ok bool) {
+	hasR, hasT := false, false
+	for _, param := range strings.Split(value, ";") {
+		key, val, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found {
+			continue
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "r":
+			remaining, hasR = n, true
+		case "t":
+			reset, hasT = time.Duration(n)*time.Second, true
+		}
+	}
+	return remaining, reset, hasR && hasT
 }
 
 func (p *CloudflareDNSProvider) getFullRecordName(recordName, domain string) string {
@@ -174,12 +434,29 @@ func (p *CloudflareDNSProvider) getFullRecordName(recordName, domain string) str
 	return fmt.Sprintf("%s.%s", recordName, domain)
 }
 
+// getRelativeRecordName converts the FQDN returned by the API to the relative
+// form used in config ("www", "@")
+func (p *CloudflareDNSProvider) getRelativeRecordName(fullName, domain string) string {
+	fullName = strings.TrimSuffix(fullName, ".")
+	if strings.EqualFold(fullName, domain) {
+		return "@"
+	}
+	return strings.TrimSuffix(fullName, "."+domain)
+}
+
 func (p *CloudflareDNSProvider) makeRequest(method, path string, body io.Reader) ([]byte, error) {
+	respBody, _, err := p.doRequest(method, path, body)
+	return respBody, err
+}
+
+// doRequest is makeRequest that also returns the response headers, which
+// carry the rate-limit state, even when the request failed
+func (p *CloudflareDNSProvider) doRequest(method, path string, body io.Reader) ([]byte, http.Header, error) {
 	fullURL := p.endpoint + path
 
 	req, err := http.NewRequest(method, fullURL, body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -187,24 +464,24 @@ func (p *CloudflareDNSProvider) makeRequest(method, path string, body io.Reader)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, resp.Header, err
 	}
 
 	if resp.StatusCode >= 400 {
 		var cfResp CloudflareResponse
 		if err := json.Unmarshal(respBody, &cfResp); err == nil && !cfResp.Success {
-			return nil, p.formatCloudflareErrors(cfResp.Errors)
+			return nil, resp.Header, statusError(resp.StatusCode, p.formatCloudflareErrors(cfResp.Errors))
 		}
-		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+		return nil, resp.Header, statusError(resp.StatusCode, fmt.Errorf("HTTP error: %d", resp.StatusCode))
 	}
 
-	return respBody, nil
+	return respBody, resp.Header, nil
 }
 
 func (p *CloudflareDNSProvider) formatCloudflareErrors(errors []CloudflareError) error {
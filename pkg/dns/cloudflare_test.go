@@ -0,0 +1,122 @@
+package dns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCloudflareServer is a mock Cloudflare API with zone "zone1" for
+// example.com whose records are split into pages of one. The first request
+// for page 2 is answered with 429 when rateLimited is set.
+func newCloudflareServer(t *testing.T, records []CloudflareRecord, rateLimited bool) (*httptest.Server, *atomic.Int32) {
+	var limited atomic.Bool
+	limited.Store(rateLimited)
+	var requests atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/zones", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(CloudflareResponse{
+			Success: true,
+			Result:  []CloudflareZone{{ID: "zone1", Name: r.URL.Query().Get("name")}},
+		})
+	})
+	mux.HandleFunc("/zones/zone1/dns_records", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 2 && limited.CompareAndSwap(true, false) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(CloudflareRecordList{Errors: []CloudflareError{{Code: 971, Message: "Please wait and consider throttling your request speed"}}})
+			return
+		}
+		json.NewEncoder(w).Encode(CloudflareRecordList{
+			Success:    true,
+			Result:     records[page-1 : page],
+			ResultInfo: &CloudflareResultInfo{Page: page, PerPage: 1, Count: 1, TotalCount: len(records), TotalPages: len(records)},
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestCloudflareProvider(t *testing.T, endpoint string) *CloudflareDNSProvider {
+	p := NewCloudflareProvider()
+	p.SetCredentials("token", "")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+var cloudflareTwoPages = []CloudflareRecord{
+	{ID: "r1", Type: "A", Name: "example.com", Content: "192.0.2.1", TTL: 1, ModifiedOn: "t1"},
+	{ID: "r2", Type: "A", Name: "www.example.com", Content: "192.0.2.2", TTL: 300, ModifiedOn: "t2"},
+}
+
+func TestCloudflareGetRecordsPages(t *testing.T) {
+	server, requests := newCloudflareServer(t, cloudflareTwoPages, false)
+	p := newTestCloudflareProvider(t, server.URL)
+
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Name != "@" || records[1].Name != "www" || records[1].ID != "r2" || records[1].Version != "t2" {
+		t.Fatalf("GetRecords = %+v, want both pages", records)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("record list requests = %d, want 2", n)
+	}
+}
+
+func TestCloudflareGetRecordsRetriesRateLimitedPage(t *testing.T) {
+	server, requests := newCloudflareServer(t, cloudflareTwoPages, true)
+	p := newTestCloudflareProvider(t, server.URL)
+
+	start := time.Now()
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("GetRecords = %+v, want both pages", records)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Fatalf("record list requests = %d, want 3 (page 2 retried once)", n)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("retried after %s, want Retry-After (1s) respected", elapsed)
+	}
+}
+
+func TestCloudflareRateLimitWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  http.Header
+		pending int
+		want    time.Duration
+	}{
+		{"no headers", nil, 3, 0},
+		{"retry-after", http.Header{"Retry-After": {"5"}}, 1, 5 * time.Second},
+		{"budget left", http.Header{"Ratelimit": {`"default";r=50;t=30`}}, 3, 0},
+		{"budget used up", http.Header{"Ratelimit": {`"default";r=0;t=30`}}, 3, 30 * time.Second},
+		{"spread over window", http.Header{"Ratelimit": {`"default";r=2;t=30`}}, 4, 15 * time.Second},
+		{"capped", http.Header{"Retry-After": {"3600"}}, 1, cloudflareMaxPageWait},
+		{"malformed", http.Header{"Ratelimit": {"r=x;t=30"}}, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cloudflareRateLimitWait(tt.header, tt.pending); got != tt.want {
+				t.Errorf("wait = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package dns
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// CredentialChecker is implemented by providers with a cheap authenticated
+// endpoint that confirms the credentials work without listing records, so
+// tokens scoped to editing records can still be validated by --test-dns
+type CredentialChecker interface {
+	CheckCredentials() error
+}
+
+// permissionErrorHints are matched against error messages from providers
+// that report authorization failures in the body with a 200 status, or
+// whose errors lost the HTTP status on the way up
+var permissionErrorHints = []string{
+	"forbidden",
+	"unauthorized",
+	"permission",
+	"not authorized",
+	"access denied",
+	"authentication",
+	"authfailure",
+}
+
+// IsPermissionError reports whether err means the credentials were rejected
+// or lack a permission, rather than a network or server problem
+func IsPermissionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrInvalidCredentials) {
+		return true
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, hint := range permissionErrorHints {
+		if strings.Contains(message, hint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// deSEC rejects RRsets with a TTL below the account minimum (3600 by default)
+const desecMinTTL = 3600
+
+var desecNextLinkRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+const desecEndpoint = "https://desec.io/api/v1"
+
+type DesecDNSProvider struct {
+	apiToken string
+	endpoint string
+	client   *http.Client
+
+	// mu serializes RRset read-modify-writes
+	mu sync.Mutex
+}
+
+type DesecRRset struct {
+	Subname string   `json:"subname"`
+	Type    string   `json:"type"`
+	TTL     int      `json:"ttl"`
+	Records []string `json:"records"`
+}
+
+func NewDesecProvider() *DesecDNSProvider {
+	return &DesecDNSProvider{
+		endpoint: desecEndpoint,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("desec"),
+		},
+	}
+}
+
+func (p *DesecDNSProvider) GetProviderName() string {
+	return "desec"
+}
+
+// DefaultTTL is used for records configured without a TTL
+func (p *DesecDNSProvider) DefaultTTL() int {
+	return desecMinTTL
+}
+
+func (p *DesecDNSProvider) SetCredentials(accessKey, secretKey string) {
+	p.apiToken = accessKey
+}
+
+// Configure applies the endpoint override, timeout, user agent and proxy
+func (p *DesecDNSProvider) Configure(settings ProviderSettings) error {
+	p.endpoint = settings.endpointOr(desecEndpoint)
+	p.client = settings.httpClient("desec")
+	return nil
+}
+
+func (p *DesecDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
+	var records []DNSRecord
+
+	nextURL := fmt.Sprintf("%s/domains/%s/rrsets/", p.endpoint, url.PathEscape(domain))
+	for nextURL != "" {
+		body, header, err := p.makeRequest("GET", nextURL, nil)
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil, fmt.Errorf("domain not found: %s", domain)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var rrsets []DesecRRset
+		if err := json.Unmarshal(body, &rrsets); err != nil {
+			return nil, fmt.Errorf("failed to parse rrsets response: %v", err)
+		}
+
+		// One DNSRecord per value so multi-value RRsets are fully visible;
+		// the value identifies it within its RRset for UpdateRecordByID
+		for _, rrset := range rrsets {
+			for _, value := range rrset.Records {
+				records = append(records, DNSRecord{
+					Name:  p.toRecordName(rrset.Subname),
+					Type:  rrset.Type,
+					Value: value,
+					TTL:   rrset.TTL,
+					ID:    value,
+				})
+			}
+		}
+
+		nextURL = p.nextPageURL(header)
+	}
+
+	return records, nil
+}
+
+// UpdateRecord sets the RRset to the new address. A multi-value RRset keeps
+// its other values; its first value is the one replaced, as for providers
+// with one record per value.
+func (p *DesecDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
+	return p.updateRRset(domain, recordName, recordType, "", newIP, ttl)
+}
+
+// UpdateRecordByID replaces one value of a multi-value RRset; GetRecords
+// uses the value as the record ID
+func (p *DesecDNSProvider) UpdateRecordByID(domain, recordID, recordName, recordType, newIP string, ttl int) error {
+	return p.updateRRset(domain, recordName, recordType, recordID, newIP, ttl)
+}
+
+// updateRRset reads the RRset and PUTs its full records array with oldValue
+// (the first value when empty) replaced, creating the RRset when it doesn't
+// exist yet. PUT replaces the whole RRset, so the read-modify-write is
+// serialized for records of the same RRset updated in parallel.
+func (p *DesecDNSProvider) updateRRset(domain, recordName, recordType, oldValue, newIP string, ttl int) error {
+	if ttl < desecMinTTL {
+		ttl = desecMinTTL
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	subname := p.toSubname(recordName)
+	rrsetURL := fmt.Sprintf("%s/domains/%s/rrsets/%s/%s/", p.endpoint, url.PathEscape(domain), p.urlSubname(subname), url.PathEscape(recordType))
+	value := absoluteTarget(recordType, newIP)
+
+	body, _, err := p.makeRequest("GET", rrsetURL, nil)
+	if errors.Is(err, ErrRecordNotFound) {
+		// RRset doesn't exist, create it
+		jsonData, err := json.Marshal(DesecRRset{Subname: subname, Type: recordType, TTL: ttl, Records: []string{value}})
+		if err != nil {
+			return err
+		}
+		createURL := fmt.Sprintf("%s/domains/%s/rrsets/", p.endpoint, url.PathEscape(domain))
+		_, _, err = p.makeRequest("POST", createURL, bytes.NewReader(jsonData))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	var current DesecRRset
+	if err := json.Unmarshal(body, &current); err != nil {
+		return fmt.Errorf("failed to parse rrset response: %v", err)
+	}
+
+	jsonData, err := json.Marshal(DesecRRset{
+		Subname: subname,
+		Type:    recordType,
+		TTL:     ttl,
+		Records: replaceRRsetValue(current.Records, oldValue, value),
+	})
+	if err != nil {
+		return err
+	}
+	_, _, err = p.makeRequest("PUT", rrsetURL, bytes.NewReader(jsonData))
+	return err
+}
+
+// replaceRRsetValue returns values with oldValue (the first value when
+// empty) replaced by newValue, without duplicating newValue. When oldValue
+// is gone, newValue is added and the other values are kept.
+func replaceRRsetValue(values []string, oldValue, newValue string) []string {
+	index := -1
+	for i, value := range values {
+		if oldValue == "" || value == oldValue {
+			index = i
+			break
+		}
+	}
+
+	records := make([]string, 0, len(values)+1)
+	if index < 0 {
+		records = append(records, newValue)
+	}
+	for i, value := range values {
+		if i == index {
+			value = newValue
+		} else if value == newValue {
+			continue
+		}
+		records = append(records, value)
+	}
+	if len(records) == 0 {
+		records = append(records, newValue)
+	}
+	return records
+}
+
+// deSEC uses an empty subname for the zone apex, and "@" in URLs
+func (p *DesecDNSProvider) toSubname(recordName string) string {
+	if recordName == "@" {
+		return ""
+	}
+	return recordName
+}
+
+func (p *DesecDNSProvider) toRecordName(subname string) string {
+	if subname == "" {
+		return "@"
+	}
+	return subname
+}
+
+func (p *DesecDNSProvider) urlSubname(subname string) string {
+	if subname == "" {
+		return "@"
+	}
+	return url.PathEscape(subname)
+}
+
+// nextPageURL extracts the cursor link used once a zone has more RRsets
+// than fit in one response
+func (p *DesecDNSProvider) nextPageURL(header http.Header) string {
+	for _, link := range header.Values("Link") {
+		if matches := desecNextLinkRegex.FindStringSubmatch(link); len(matches) == 2 {
+			return matches[1]
+		}
+	}
+	return ""
+}
+
+func (p *DesecDNSProvider) makeRequest(method, fullURL string, body io.Reader) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(method, fullURL, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Token "+p.apiToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, ErrRecordNotFound
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, nil, statusError(resp.StatusCode, fmt.Errorf("desec API error: HTTP %d - %s", resp.StatusCode, strings.TrimSpace(string(respBody))))
+	}
+
+	return respBody, resp.Header, nil
+}
@@ -0,0 +1,183 @@
+package dns
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"ip-updater/internal/config"
+)
+
+// desecServer is a mock deSEC API holding the RRsets of one domain, keyed
+// by "subname/type"
+type desecServer struct {
+	mu     sync.Mutex
+	rrsets map[string]DesecRRset
+}
+
+func newDesecServer(t *testing.T, rrsets ...DesecRRset) (*desecServer, *httptest.Server) {
+	s := &desecServer{rrsets: make(map[string]DesecRRset)}
+	for _, rrset := range rrsets {
+		s.rrsets[rrset.Subname+"/"+rrset.Type] = rrset
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/domains/example.com/rrsets/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		rest := r.URL.Path[len("/domains/example.com/rrsets/"):]
+		if rest == "" {
+			switch r.Method {
+			case "GET":
+				var list []DesecRRset
+				for _, rrset := range s.rrsets {
+					list = append(list, rrset)
+				}
+				json.NewEncoder(w).Encode(list)
+			case "POST":
+				var rrset DesecRRset
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &rrset); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				s.rrsets[rrset.Subname+"/"+rrset.Type] = rrset
+				w.WriteHeader(http.StatusCreated)
+			}
+			return
+		}
+
+		var subname, recordType string
+		for i := 0; i < len(rest); i++ {
+			if rest[i] == '/' {
+				subname, recordType = rest[:i], rest[i+1:len(rest)-1]
+				break
+			}
+		}
+		if subname == "@" {
+			subname = ""
+		}
+		key := subname + "/" + recordType
+		rrset, ok := s.rrsets[key]
+		switch r.Method {
+		case "GET":
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(rrset)
+		case "PUT":
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &rrset); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			s.rrsets[key] = rrset
+			json.NewEncoder(w).Encode(rrset)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func (s *desecServer) records(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rrsets[key].Records
+}
+
+func newTestDesecProvider(t *testing.T, endpoint string) *DesecDNSProvider {
+	p := NewDesecProvider()
+	p.SetCredentials("secret", "")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestDesecGetRecordsSplitsRRsets(t *testing.T) {
+	_, server := newDesecServer(t, DesecRRset{Subname: "", Type: "A", TTL: 3600, Records: []string{"1.1.1.1", "2.2.2.2"}})
+	p := newTestDesecProvider(t, server.URL)
+
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DNSRecord{
+		{Name: "@", Type: "A", Value: "1.1.1.1", TTL: 3600, ID: "1.1.1.1"},
+		{Name: "@", Type: "A", Value: "2.2.2.2", TTL: 3600, ID: "2.2.2.2"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("GetRecords = %+v, want %+v", records, want)
+	}
+}
+
+func TestDesecUpdateKeepsOtherRRsetValues(t *testing.T) {
+	server, ts := newDesecServer(t, DesecRRset{Subname: "www", Type: "A", TTL: 3600, Records: []string{"1.1.1.1", "2.2.2.2"}})
+	p := newTestDesecProvider(t, ts.URL)
+
+	if err := p.UpdateRecordByID("example.com", "1.1.1.1", "www", "A", "3.3.3.3", 3600); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := server.records("www/A"), []string{"3.3.3.3", "2.2.2.2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("records = %v, want %v", got, want)
+	}
+
+	if err := p.UpdateRecord("example.com", "www", "A", "4.4.4.4", 3600); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := server.records("www/A"), []string{"4.4.4.4", "2.2.2.2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("records = %v, want %v", got, want)
+	}
+}
+
+func TestDesecManagerUpdatesMatchedValueOnly(t *testing.T) {
+	server, ts := newDesecServer(t, DesecRRset{Subname: "www", Type: "A", TTL: 3600, Records: []string{"1.1.1.1", "2.2.2.2"}})
+
+	dm := NewDNSManager()
+	dm.InitializeProviders()
+	updater := config.DNSUpdater{
+		Name:        "desec",
+		Provider:    "desec",
+		Token:       "secret",
+		Domain:      "example.com",
+		ExtraConfig: map[string]string{SettingEndpoint: ts.URL},
+		Records:     []config.DNSRecord{{Name: "www", Type: "A", MatchValue: "2.2.2.2"}},
+	}
+	if err := dm.UpdateDNSRecord(updater, "5.5.5.5"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := server.records("www/A"), []string{"1.1.1.1", "5.5.5.5"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("records = %v, want %v", got, want)
+	}
+}
+
+func TestDesecUpdateCreatesMissingRRset(t *testing.T) {
+	server, ts := newDesecServer(t)
+	p := newTestDesecProvider(t, ts.URL)
+
+	if err := p.UpdateRecord("example.com", "@", "A", "1.2.3.4", 60); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := server.records("/A"), []string{"1.2.3.4"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("records = %v, want %v", got, want)
+	}
+	if ttl := server.rrsets["/A"].TTL; ttl != desecMinTTL {
+		t.Fatalf("TTL = %d, want the minimum %d", ttl, desecMinTTL)
+	}
+}
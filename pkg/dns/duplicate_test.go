@@ -0,0 +1,166 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"ip-updater/internal/config"
+)
+
+// roundRobinProvider holds records that may share a name and type. Like
+// the real providers, UpdateRecord changes the first match.
+type roundRobinProvider struct {
+	mu      sync.Mutex
+	records []DNSRecord
+	updates []string // ID of every record updated
+}
+
+func (p *roundRobinProvider) GetProviderName() string                   { return "roundrobin" }
+func (p *roundRobinProvider) SetCredentials(string, string)             {}
+func (p *roundRobinProvider) Configure(settings ProviderSettings) error { return nil }
+
+func (p *roundRobinProvider) GetRecords(domain string) ([]DNSRecord, error) {
+	return append([]DNSRecord(nil), p.records...), nil
+}
+
+func (p *roundRobinProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
+	for _, record := range p.records {
+		if record.Name == recordName && record.Type == recordType {
+			return p.UpdateRecordByID(domain, record.ID, recordName, recordType, newIP, ttl)
+		}
+	}
+	return fmt.Errorf("%s not found", recordName)
+}
+
+func (p *roundRobinProvider) UpdateRecordByID(domain, recordID, recordName, recordType, newIP string, ttl int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.records {
+		if p.records[i].ID == recordID {
+			p.records[i].Value = newIP
+			p.updates = append(p.updates, recordID)
+			return nil
+		}
+	}
+	return fmt.Errorf("record %s not found", recordID)
+}
+
+// firstOnlyProvider hides UpdateRecordByID, like providers that can only
+// update by name
+type firstOnlyProvider struct {
+	Provider
+}
+
+type warningLogger struct {
+	warnings []string
+}
+
+func (l *warningLogger) Debugf(format string, args ...interface{}) {}
+func (l *warningLogger) Infof(format string, args ...interface{})  {}
+func (l *warningLogger) Errorf(format string, args ...interface{}) {}
+func (l *warningLogger) Warnf(format string, args ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+func newRoundRobin() *roundRobinProvider {
+	return &roundRobinProvider{records: []DNSRecord{
+		{Name: "www", Type: "A", Value: "192.0.2.1", ID: "r1"},
+		{Name: "www", Type: "A", Value: "192.0.2.2", ID: "r2"},
+	}}
+}
+
+func newRoundRobinManager(provider Provider) (*DNSManager, *warningLogger) {
+	log := &warningLogger{}
+	dm := NewDNSManager()
+	dm.SetLogger(log)
+	dm.RegisterProvider("roundrobin", provider)
+	return dm, log
+}
+
+func roundRobinUpdater(policy string) config.DNSUpdater {
+	return config.DNSUpdater{
+		Name:             "rr",
+		Provider:         "roundrobin",
+		Domain:           "example.com",
+		DuplicateRecords: policy,
+		Records:          []config.DNSRecord{{Name: "www", Type: "A"}},
+	}
+}
+
+func updateRoundRobin(provider Provider, policy, ip string) (*warningLogger, error) {
+	dm, log := newRoundRobinManager(provider)
+	return log, dm.UpdateDNSRecord(roundRobinUpdater(policy), ip)
+}
+
+func TestDuplicateRecordsWarn(t *testing.T) {
+	p := newRoundRobin()
+	log, err := updateRoundRobin(p, "", "203.0.113.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"r1"}; !reflect.DeepEqual(p.updates, want) {
+		t.Fatalf("updated %v, want %v", p.updates, want)
+	}
+	if len(log.warnings) != 1 || !strings.Contains(log.warnings[0], "192.0.2.1, 192.0.2.2") {
+		t.Fatalf("warnings = %q, want one listing both records", log.warnings)
+	}
+}
+
+func TestDuplicateRecordsError(t *testing.T) {
+	p := newRoundRobin()
+	_, err := updateRoundRobin(p, config.DuplicateError, "203.0.113.7")
+	if !errors.Is(err, ErrAmbiguousRecord) {
+		t.Fatalf("err = %v, want ErrAmbiguousRecord", err)
+	}
+	if len(p.updates) != 0 {
+		t.Fatalf("updated %v, want both records left alone", p.updates)
+	}
+}
+
+func TestDuplicateRecordsAll(t *testing.T) {
+	p := newRoundRobin()
+	if _, err := updateRoundRobin(p, config.DuplicateAll, "203.0.113.7"); err != nil {
+		t.Fatal(err)
+	}
+	// The records are updated in parallel
+	sort.Strings(p.updates)
+	if want := []string{"r1", "r2"}; !reflect.DeepEqual(p.updates, want) {
+		t.Fatalf("updated %v, want %v", p.updates, want)
+	}
+
+	// Without updates by ID only the first record could be changed
+	_, err := updateRoundRobin(firstOnlyProvider{newRoundRobin()}, config.DuplicateAll, "203.0.113.7")
+	if !errors.Is(err, ErrAmbiguousRecord) {
+		t.Fatalf("err = %v, want ErrAmbiguousRecord", err)
+	}
+}
+
+func TestDuplicateRecordsValue(t *testing.T) {
+	p := newRoundRobin()
+
+	// Neither record holds the address or was written before
+	if _, err := updateRoundRobin(p, config.DuplicateValue, "203.0.113.7"); !errors.Is(err, ErrAmbiguousRecord) {
+		t.Fatalf("err = %v, want ErrAmbiguousRecord", err)
+	}
+
+	// The record holding the address is tracked and follows its changes
+	p.records[1].Value = "203.0.113.7"
+	dm, _ := newRoundRobinManager(p)
+	updater := roundRobinUpdater(config.DuplicateValue)
+	for _, ip := range []string{"203.0.113.7", "203.0.113.8", "203.0.113.9"} {
+		if err := dm.UpdateDNSRecord(updater, ip); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"r2", "r2"}; !reflect.DeepEqual(p.updates, want) {
+		t.Fatalf("updated %v, want %v", p.updates, want)
+	}
+	if p.records[0].Value != "192.0.2.1" {
+		t.Fatalf("other record changed to %s", p.records[0].Value)
+	}
+}
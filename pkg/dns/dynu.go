@@ -0,0 +1,297 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const dynuEndpoint = "https://api.dynu.com/v2"
+
+type DynuDNSProvider struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+type DynuDomain struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	IPv4Address string `json:"ipv4Address"`
+	IPv6Address string `json:"ipv6Address"`
+	TTL         int    `json:"ttl"`
+}
+
+type DynuDomainList struct {
+	Domains []DynuDomain `json:"domains"`
+}
+
+type DynuRecord struct {
+	ID          int    `json:"id,omitempty"`
+	NodeName    string `json:"nodeName"`
+	RecordType  string `json:"recordType"`
+	TTL         int    `json:"ttl"`
+	State       bool   `json:"state"`
+	Content     string `json:"content,omitempty"`
+	IPv4Address string `json:"ipv4Address,omitempty"`
+	IPv6Address string `json:"ipv6Address,omitempty"`
+}
+
+type DynuRecordList struct {
+	DNSRecords []DynuRecord `json:"dnsRecords"`
+}
+
+type DynuError struct {
+	StatusCode int    `json:"statusCode"`
+	Type       string `json:"type"`
+	Message    string `json:"message"`
+}
+
+func NewDynuProvider() *DynuDNSProvider {
+	return &DynuDNSProvider{
+		endpoint: dynuEndpoint,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("dynu"),
+		},
+	}
+}
+
+func (p *DynuDNSProvider) GetProviderName() string {
+	return "dynu"
+}
+
+// DefaultTTL is used for records configured without a TTL.
+// Dynu's default record TTL
+func (p *DynuDNSProvider) DefaultTTL() int {
+	return 120
+}
+
+func (p *DynuDNSProvider) SetCredentials(accessKey, secretKey string) {
+	p.apiKey = accessKey
+}
+
+// Configure applies the endpoint override, timeout, user agent and proxy
+func (p *DynuDNSProvider) Configure(settings ProviderSettings) error {
+	p.endpoint = settings.endpointOr(dynuEndpoint)
+	p.client = settings.httpClient("dynu")
+	return nil
+}
+
+func (p *DynuDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
+	dynuDomain, err := p.getDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// The apex addresses are properties of the domain itself, not records
+	var records []DNSRecord
+	if dynuDomain.IPv4Address != "" {
+		records = append(records, DNSRecord{Name: "@", Type: "A", Value: dynuDomain.IPv4Address, TTL: dynuDomain.TTL})
+	}
+	if dynuDomain.IPv6Address != "" {
+		records = append(records, DNSRecord{Name: "@", Type: "AAAA", Value: dynuDomain.IPv6Address, TTL: dynuDomain.TTL})
+	}
+
+	dynuRecords, err := p.listRecords(dynuDomain.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range dynuRecords {
+		records = append(records, DNSRecord{
+			Name:  p.toRecordName(rec.NodeName),
+			Type:  rec.RecordType,
+			Value: p.recordValue(rec),
+			TTL:   rec.TTL,
+		})
+	}
+
+	return records, nil
+}
+
+func (p *DynuDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
+	dynuDomain, err := p.getDomain(domain)
+	if err != nil {
+		return err
+	}
+
+	if recordName == "@" && (recordType == "A" || recordType == "AAAA") {
+		return p.updateApex(dynuDomain.ID, recordType, newIP, ttl)
+	}
+
+	dynuRecords, err := p.listRecords(dynuDomain.ID)
+	if err != nil {
+		return err
+	}
+
+	record := DynuRecord{
+		NodeName:   p.toNodeName(recordName),
+		RecordType: recordType,
+		TTL:        ttl,
+		State:      true,
+	}
+	p.setRecordValue(&record, newIP)
+
+	jsonData, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range dynuRecords {
+		if rec.RecordType == recordType && strings.EqualFold(rec.NodeName, record.NodeName) {
+			path := fmt.Sprintf("/dns/%d/record/%d", dynuDomain.ID, rec.ID)
+			_, err = p.makeRequest("POST", path, bytes.NewReader(jsonData))
+			return err
+		}
+	}
+
+	// Record doesn't exist, create it
+	path := fmt.Sprintf("/dns/%d/record", dynuDomain.ID)
+	_, err = p.makeRequest("POST", path, bytes.NewReader(jsonData))
+	return err
+}
+
+// updateApex sets the apex address on the domain. The domain is read and
+// posted back as-is so that settings we don't manage are preserved.
+func (p *DynuDNSProvider) updateApex(domainId int, recordType, newIP string, ttl int) error {
+	path := fmt.Sprintf("/dns/%d", domainId)
+	body, err := p.makeRequest("GET", path, nil)
+	if err != nil {
+		return err
+	}
+
+	var domainData map[string]interface{}
+	if err := json.Unmarshal(body, &domainData); err != nil {
+		return fmt.Errorf("failed to parse domain response: %v", err)
+	}
+	delete(domainData, "statusCode")
+
+	if recordType == "AAAA" {
+		domainData["ipv6Address"] = newIP
+		domainData["ipv6"] = true
+	} else {
+		domainData["ipv4Address"] = newIP
+		domainData["ipv4"] = true
+	}
+	if ttl > 0 {
+		domainData["ttl"] = ttl
+	}
+
+	jsonData, err := json.Marshal(domainData)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.makeRequest("POST", path, bytes.NewReader(jsonData))
+	return err
+}
+
+func (p *DynuDNSProvider) getDomain(domain string) (*DynuDomain, error) {
+	body, err := p.makeRequest("GET", "/dns", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DynuDomainList
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse domains response: %v", err)
+	}
+
+	for _, d := range response.Domains {
+		if strings.EqualFold(d.Name, domain) {
+			return &d, nil
+		}
+	}
+
+	return nil, fmt.Errorf("domain not found: %s", domain)
+}
+
+func (p *DynuDNSProvider) listRecords(domainId int) ([]DynuRecord, error) {
+	body, err := p.makeRequest("GET", fmt.Sprintf("/dns/%d/record", domainId), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DynuRecordList
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse records response: %v", err)
+	}
+
+	return response.DNSRecords, nil
+}
+
+// Dynu keeps addresses in type-specific fields; other types use content
+func (p *DynuDNSProvider) recordValue(rec DynuRecord) string {
+	switch rec.RecordType {
+	case "A":
+		return rec.IPv4Address
+	case "AAAA":
+		return rec.IPv6Address
+	}
+	return rec.Content
+}
+
+func (p *DynuDNSProvider) setRecordValue(rec *DynuRecord, value string) {
+	switch rec.RecordType {
+	case "A":
+		rec.IPv4Address = value
+	case "AAAA":
+		rec.IPv6Address = value
+	default:
+		rec.Content = value
+	}
+}
+
+// Dynu uses an empty node name for the zone apex
+func (p *DynuDNSProvider) toNodeName(recordName string) string {
+	if recordName == "@" {
+		return ""
+	}
+	return recordName
+}
+
+func (p *DynuDNSProvider) toRecordName(nodeName string) string {
+	if nodeName == "" {
+		return "@"
+	}
+	return strings.ToLower(nodeName)
+}
+
+func (p *DynuDNSProvider) makeRequest(method, path string, body io.Reader) ([]byte, error) {
+	fullURL := p.endpoint + path
+
+	req, err := http.NewRequest(method, fullURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("API-Key", p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		var dynuErr DynuError
+		if err := json.Unmarshal(respBody, &dynuErr); err == nil && dynuErr.Message != "" {
+			return nil, statusError(resp.StatusCode, fmt.Errorf("dynu API error: %s (status: %d)", dynuErr.Message, resp.StatusCode))
+		}
+		return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error: %d", resp.StatusCode))
+	}
+
+	return respBody, nil
+}
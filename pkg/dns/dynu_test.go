@@ -0,0 +1,134 @@
+package dns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// dynuServer is a mock Dynu API with example.com as domain 5; the domain
+// object carries an unmanaged setting that apex updates must keep
+type dynuServer struct {
+	domain   map[string]interface{}
+	records  []DynuRecord
+	requests []string
+}
+
+func newDynuServer(t *testing.T, records ...DynuRecord) (*dynuServer, *httptest.Server) {
+	s := &dynuServer{
+		domain: map[string]interface{}{
+			"id": 5, "name": "example.com", "ipv4Address": "1.1.1.1", "ttl": 120, "group": "home",
+		},
+		records: records,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dns", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("API-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(DynuError{StatusCode: 401, Message: "Authentication failed"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"domains": []interface{}{s.domain}})
+	})
+	mux.HandleFunc("/dns/5", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			s.domain = make(map[string]interface{})
+			json.NewDecoder(r.Body).Decode(&s.domain)
+			s.requests = append(s.requests, "POST /dns/5")
+			return
+		}
+		json.NewEncoder(w).Encode(s.domain)
+	})
+	mux.HandleFunc("/dns/5/record", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			var rec DynuRecord
+			json.NewDecoder(r.Body).Decode(&rec)
+			s.requests = append(s.requests, "POST /dns/5/record "+rec.NodeName+" "+rec.RecordType+" "+rec.IPv4Address+rec.IPv6Address)
+			return
+		}
+		json.NewEncoder(w).Encode(DynuRecordList{DNSRecords: s.records})
+	})
+	mux.HandleFunc("/dns/5/record/", func(w http.ResponseWriter, r *http.Request) {
+		var rec DynuRecord
+		json.NewDecoder(r.Body).Decode(&rec)
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path+" "+rec.IPv4Address+rec.IPv6Address)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func newTestDynuProvider(t *testing.T, endpoint, key string) *DynuDNSProvider {
+	p := NewDynuProvider()
+	p.SetCredentials(key, "")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestDynuGetRecordsIncludesApex(t *testing.T) {
+	_, server := newDynuServer(t,
+		DynuRecord{ID: 1, NodeName: "WWW", RecordType: "AAAA", TTL: 300, IPv6Address: "2001:db8::1"},
+		DynuRecord{ID: 2, NodeName: "", RecordType: "TXT", TTL: 300, Content: "hello"},
+	)
+	p := newTestDynuProvider(t, server.URL, "key")
+
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DNSRecord{
+		{Name: "@", Type: "A", Value: "1.1.1.1", TTL: 120},
+		{Name: "www", Type: "AAAA", Value: "2001:db8::1", TTL: 300},
+		{Name: "@", Type: "TXT", Value: "hello", TTL: 300},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("GetRecords = %+v, want %+v", records, want)
+	}
+}
+
+func TestDynuUpdateApexKeepsDomainSettings(t *testing.T) {
+	server, ts := newDynuServer(t)
+	p := newTestDynuProvider(t, ts.URL, "key")
+
+	if err := p.UpdateRecord("example.com", "@", "A", "2.2.2.2", 60); err != nil {
+		t.Fatal(err)
+	}
+	if server.domain["ipv4Address"] != "2.2.2.2" || server.domain["ttl"] != float64(60) {
+		t.Fatalf("domain = %v, want the new address and TTL", server.domain)
+	}
+	if server.domain["group"] != "home" {
+		t.Fatalf("domain = %v, the unmanaged group setting was lost", server.domain)
+	}
+}
+
+func TestDynuUpdateRecord(t *testing.T) {
+	server, ts := newDynuServer(t, DynuRecord{ID: 8, NodeName: "www", RecordType: "A", TTL: 120, IPv4Address: "1.1.1.1"})
+	p := newTestDynuProvider(t, ts.URL, "key")
+
+	if err := p.UpdateRecord("example.com", "WWW", "A", "3.3.3.3", 120); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UpdateRecord("example.com", "nas", "AAAA", "2001:db8::2", 120); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"POST /dns/5/record/8 3.3.3.3", "POST /dns/5/record nas AAAA 2001:db8::2"}
+	if !reflect.DeepEqual(server.requests, want) {
+		t.Fatalf("requests = %v, want %v", server.requests, want)
+	}
+}
+
+func TestDynuAuthError(t *testing.T) {
+	_, server := newDynuServer(t)
+	p := newTestDynuProvider(t, server.URL, "wrong")
+
+	_, err := p.GetRecords("example.com")
+	if err == nil || err.Error() != "dynu API error: Authentication failed (status: 401)" {
+		t.Fatalf("GetRecords error = %v, want the Dynu error message", err)
+	}
+}
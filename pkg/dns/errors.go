@@ -1,6 +1,9 @@
 package dns
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
 var (
 	ErrProviderNotFound   = errors.New("DNS provider not found")
@@ -10,4 +13,41 @@ var (
 	ErrRateLimitExceeded  = errors.New("rate limit exceeded")
 	ErrInvalidDomain      = errors.New("invalid domain")
 	ErrInvalidRecordType  = errors.New("invalid record type")
+	ErrPreconditionFailed = errors.New("DNS record changed since it was read")
+	// ErrClockSkew means a signed request was rejected because its timestamp
+	// is too far from the provider's clock; retrying won't help until the
+	// system clock is fixed
+	ErrClockSkew = errors.New("system clock skew")
+	// ErrAmbiguousRecord means the record a match_value record manages can't
+	// be told apart from others sharing its name and type; retrying won't
+	// help until the records or the config change
+	ErrAmbiguousRecord = errors.New("ambiguous DNS record")
 )
+
+// HTTPStatusError is a provider API error together with the HTTP status of
+// the response, so retries can be decided by status class rather than by
+// the provider's wording
+type HTTPStatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *HTTPStatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HTTPStatusError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the request may succeed when repeated: server
+// errors (5xx), rate limiting (429) and request timeouts (408). Other 4xx
+// responses mean the request itself is wrong.
+func (e *HTTPStatusError) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusRequestTimeout
+}
+
+// statusError attaches the HTTP status of a failed response to its error
+func statusError(statusCode int, err error) error {
+	return &HTTPStatusError{StatusCode: statusCode, Err: err}
+}
@@ -0,0 +1,202 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Gandi LiveDNS rejects RRsets with a TTL below 300 seconds
+const gandiMinTTL = 300
+
+const gandiEndpoint = "https://api.gandi.net/v5/livedns"
+
+type GandiDNSProvider struct {
+	apiToken string
+	endpoint string
+	client   *http.Client
+
+	// mu serializes RRset read-modify-writes
+	mu sync.Mutex
+}
+
+type GandiRRset struct {
+	Name   string   `json:"rrset_name"`
+	Type   string   `json:"rrset_type"`
+	TTL    int      `json:"rrset_ttl"`
+	Values []string `json:"rrset_values"`
+}
+
+type GandiRRsetRequest struct {
+	TTL    int      `json:"rrset_ttl"`
+	Values []string `json:"rrset_values"`
+}
+
+type GandiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Cause   string `json:"cause"`
+}
+
+func NewGandiProvider() *GandiDNSProvider {
+	return &GandiDNSProvider{
+		endpoint: gandiEndpoint,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("gandi"),
+		},
+	}
+}
+
+func (p *GandiDNSProvider) GetProviderName() string {
+	return "gandi"
+}
+
+// DefaultTTL is used for records configured without a TTL
+func (p *GandiDNSProvider) DefaultTTL() int {
+	return gandiMinTTL
+}
+
+// AliasRecordType is LiveDNS's apex alias, used for ALIAS/ANAME records
+func (p *GandiDNSProvider) AliasRecordType() string {
+	return "ALIAS"
+}
+
+func (p *GandiDNSProvider) SetCredentials(accessKey, secretKey string) {
+	p.apiToken = accessKey
+}
+
+// Configure applies the endpoint override, timeout, user agent and proxy
+func (p *GandiDNSProvider) Configure(settings ProviderSettings) error {
+	p.endpoint = settings.endpointOr(gandiEndpoint)
+	p.client = settings.httpClient("gandi")
+	return nil
+}
+
+func (p *GandiDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
+	path := fmt.Sprintf("/domains/%s/records", url.PathEscape(domain))
+	body, err := p.makeRequest("GET", path, nil)
+	if errors.Is(err, ErrRecordNotFound) {
+		return nil, fmt.Errorf("domain not found: %s", domain)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rrsets []GandiRRset
+	if err := json.Unmarshal(body, &rrsets); err != nil {
+		return nil, fmt.Errorf("failed to parse records response: %v", err)
+	}
+
+	// One DNSRecord per value so multi-value RRsets are fully visible; the
+	// value identifies it within its RRset for UpdateRecordByID
+	var records []DNSRecord
+	for _, rrset := range rrsets {
+		for _, value := range rrset.Values {
+			records = append(records, DNSRecord{
+				Name:  rrset.Name,
+				Type:  rrset.Type,
+				Value: value,
+				TTL:   rrset.TTL,
+				ID:    value,
+			})
+		}
+	}
+
+	return records, nil
+}
+
+// UpdateRecord sets the RRset to the new address. A multi-value RRset keeps
+// its other values; its first value is the one replaced, as for providers
+// with one record per value.
+func (p *GandiDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
+	return p.updateRRset(domain, recordName, recordType, "", newIP, ttl)
+}
+
+// UpdateRecordByID replaces one value of a multi-value RRset; GetRecords
+// uses the value as the record ID
+func (p *GandiDNSProvider) UpdateRecordByID(domain, recordID, recordName, recordType, newIP string, ttl int) error {
+	return p.updateRRset(domain, recordName, recordType, recordID, newIP, ttl)
+}
+
+// updateRRset reads the RRset and PUTs its full values array with oldValue
+// (the first value when empty) replaced. Gandi creates the RRset when it
+// doesn't exist yet. PUT replaces the whole RRset, so the read-modify-write
+// is serialized for records of the same RRset updated in parallel.
+func (p *GandiDNSProvider) updateRRset(domain, recordName, recordType, oldValue, newIP string, ttl int) error {
+	if ttl < gandiMinTTL {
+		ttl = gandiMinTTL
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	path := fmt.Sprintf("/domains/%s/records/%s/%s", url.PathEscape(domain), url.PathEscape(recordName), url.PathEscape(recordType))
+	value := absoluteTarget(recordType, newIP)
+
+	var current GandiRRset
+	body, err := p.makeRequest("GET", path, nil)
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(body, &current); err != nil {
+			return fmt.Errorf("failed to parse rrset response: %v", err)
+		}
+	}
+
+	jsonData, err := json.Marshal(GandiRRsetRequest{
+		TTL:    ttl,
+		Values: replaceRRsetValue(current.Values, oldValue, value),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = p.makeRequest("PUT", path, bytes.NewReader(jsonData))
+	return err
+}
+
+func (p *GandiDNSProvider) makeRequest(method, path string, body io.Reader) ([]byte, error) {
+	fullURL := p.endpoint + path
+
+	req, err := http.NewRequest(method, fullURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrRecordNotFound
+	}
+
+	if resp.StatusCode >= 400 {
+		var gandiErr GandiError
+		if err := json.Unmarshal(respBody, &gandiErr); err == nil && gandiErr.Message != "" {
+			return nil, statusError(resp.StatusCode, fmt.Errorf("gandi API error: %s (status: %d)", gandiErr.Message, resp.StatusCode))
+		}
+		return nil, statusError(resp.StatusCode, fmt.Errorf("gandi API error: HTTP %d - %s", resp.StatusCode, strings.TrimSpace(string(respBody))))
+	}
+
+	return respBody, nil
+}
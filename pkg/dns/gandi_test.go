@@ -0,0 +1,230 @@
+package dns
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"ip-updater/internal/config"
+)
+
+// gandiServer is a mock LiveDNS API for example.com holding its RRsets and
+// recording the PUT requests by "name/type"
+type gandiServer struct {
+	mu     sync.Mutex
+	rrsets []GandiRRset
+	puts   map[string]GandiRRsetRequest
+}
+
+func newGandiServer(t *testing.T, rrsets ...GandiRRset) (*gandiServer, *httptest.Server) {
+	s := &gandiServer{rrsets: rrsets, puts: make(map[string]GandiRRsetRequest)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/domains/example.com/records", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		json.NewEncoder(w).Encode(s.rrsets)
+	})
+	mux.HandleFunc("/domains/example.com/records/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		key := r.URL.Path[len("/domains/example.com/records/"):]
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		index := -1
+		for i, rrset := range s.rrsets {
+			if rrset.Name+"/"+rrset.Type == key {
+				index = i
+			}
+		}
+		switch r.Method {
+		case "GET":
+			if index < 0 {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(GandiError{Code: 404, Message: "Can't find the DNS record"})
+				return
+			}
+			json.NewEncoder(w).Encode(s.rrsets[index])
+		case "PUT":
+			var request GandiRRsetRequest
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &request); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			s.puts[key] = request
+			name, recordType, _ := strings.Cut(key, "/")
+			rrset := GandiRRset{Name: name, Type: recordType, TTL: request.TTL, Values: request.Values}
+			if index < 0 {
+				s.rrsets = append(s.rrsets, rrset)
+			} else {
+				s.rrsets[index] = rrset
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func TestGandiAliasRecord(t *testing.T) {
+	s, server := newGandiServer(t, GandiRRset{Name: "@", Type: "ALIAS", TTL: 300, Values: []string{"old.example.net."}})
+
+	dm := NewDNSManager()
+	dm.InitializeProviders()
+	updater := config.DNSUpdater{
+		Name:        "apex",
+		Provider:    "gandi",
+		Token:       "token",
+		Domain:      "example.com",
+		ExtraConfig: map[string]string{SettingEndpoint: server.URL},
+		Records:     []config.DNSRecord{{Name: "@", Type: "ANAME", Target: "home.example.com"}},
+	}
+	if err := dm.UpdateDNSRecord(updater, "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	put, ok := s.puts["@/ALIAS"]
+	if !ok {
+		t.Fatalf("no PUT of the apex ALIAS record, got %v", s.puts)
+	}
+	if len(put.Values) != 1 || put.Values[0] != "home.example.com." {
+		t.Fatalf("ALIAS values = %v, want the target in absolute form", put.Values)
+	}
+
+	// Already pointing at the target: nothing to write
+	s.rrsets[0].Values = []string{"HOME.example.com."}
+	s.puts = make(map[string]GandiRRsetRequest)
+	if err := dm.UpdateDNSRecord(updater, "192.0.2.2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.puts) != 0 {
+		t.Fatalf("unchanged ALIAS record was written: %v", s.puts)
+	}
+}
+
+func newTestGandiProvider(t *testing.T, endpoint string) *GandiDNSProvider {
+	p := NewGandiProvider()
+	p.SetCredentials("token", "")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestGandiGetRecordsSplitsRRsets(t *testing.T) {
+	_, server := newGandiServer(t,
+		GandiRRset{Name: "@", Type: "A", TTL: 300, Values: []string{"1.1.1.1", "2.2.2.2"}},
+		GandiRRset{Name: "www", Type: "AAAA", TTL: 600, Values: []string{"2001:db8::1"}},
+	)
+	p := newTestGandiProvider(t, server.URL)
+
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DNSRecord{
+		{Name: "@", Type: "A", Value: "1.1.1.1", TTL: 300, ID: "1.1.1.1"},
+		{Name: "@", Type: "A", Value: "2.2.2.2", TTL: 300, ID: "2.2.2.2"},
+		{Name: "www", Type: "AAAA", Value: "2001:db8::1", TTL: 600, ID: "2001:db8::1"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("GetRecords = %+v, want %+v", records, want)
+	}
+}
+
+func TestGandiUpdateRecordRaisesTTL(t *testing.T) {
+	s, server := newGandiServer(t)
+	p := newTestGandiProvider(t, server.URL)
+
+	if err := p.UpdateRecord("example.com", "www", "A", "1.2.3.4", 60); err != nil {
+		t.Fatal(err)
+	}
+	want := GandiRRsetRequest{TTL: gandiMinTTL, Values: []string{"1.2.3.4"}}
+	if put := s.puts["www/A"]; !reflect.DeepEqual(put, want) {
+		t.Fatalf("PUT www/A = %+v, want %+v", put, want)
+	}
+}
+
+func TestGandiUnknownDomain(t *testing.T) {
+	_, server := newGandiServer(t)
+	p := newTestGandiProvider(t, server.URL)
+
+	if _, err := p.GetRecords("missing.com"); err == nil || !strings.Contains(err.Error(), "domain not found") {
+		t.Fatalf("GetRecords error = %v, want domain not found", err)
+	}
+}
+
+func (s *gandiServer) values(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rrset := range s.rrsets {
+		if rrset.Name+"/"+rrset.Type == key {
+			return rrset.Values
+		}
+	}
+	return nil
+}
+
+func TestGandiUpdateKeepsOtherRRsetValues(t *testing.T) {
+	s, server := newGandiServer(t, GandiRRset{Name: "www", Type: "A", TTL: 300, Values: []string{"1.1.1.1", "2.2.2.2"}})
+	p := newTestGandiProvider(t, server.URL)
+
+	if err := p.UpdateRecordByID("example.com", "2.2.2.2", "www", "A", "3.3.3.3", 300); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.values("www/A"), []string{"1.1.1.1", "3.3.3.3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("values = %v, want %v", got, want)
+	}
+
+	if err := p.UpdateRecord("example.com", "www", "A", "4.4.4.4", 300); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.values("www/A"), []string{"4.4.4.4", "3.3.3.3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("values = %v, want %v", got, want)
+	}
+}
+
+func TestGandiDuplicateRecordsPolicies(t *testing.T) {
+	for _, tt := range []struct {
+		policy string
+		want   []string
+	}{
+		// Only the first value is replaced, as the warning says
+		{"", []string{"5.5.5.5", "2.2.2.2"}},
+		// Both values are replaced one after the other, collapsing into one
+		{config.DuplicateAll, []string{"5.5.5.5"}},
+	} {
+		s, server := newGandiServer(t, GandiRRset{Name: "www", Type: "A", TTL: 300, Values: []string{"1.1.1.1", "2.2.2.2"}})
+
+		dm := NewDNSManager()
+		dm.InitializeProviders()
+		updater := config.DNSUpdater{
+			Name:             "gandi",
+			Provider:         "gandi",
+			Token:            "token",
+			Domain:           "example.com",
+			DuplicateRecords: tt.policy,
+			ExtraConfig:      map[string]string{SettingEndpoint: server.URL},
+			Records:          []config.DNSRecord{{Name: "www", Type: "A"}},
+		}
+		if err := dm.UpdateDNSRecord(updater, "5.5.5.5"); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.values("www/A"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("duplicate_records = %q: values = %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
@@ -3,17 +3,43 @@ package dns
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
+const godaddyEndpoint = "https://api.godaddy.com/v1"
+
+// godaddyPageSize is the number of records GetRecords asks for per request
+const godaddyPageSize = 500
+
+// GoDaddy update endpoints, selected with extra_config update_endpoint.
+// Some accounts reject the per-name endpoint; "auto" then retries through
+// the per-type one.
+const (
+	// godaddyUpdateName uses PUT /domains/{domain}/records/{type}/{name}
+	godaddyUpdateName = "name"
+	// godaddyUpdateType uses PUT /domains/{domain}/records/{type} with the
+	// whole record set of the type, or PATCH /domains/{domain}/records to
+	// add a record that doesn't exist yet
+	godaddyUpdateType = "type"
+	// godaddyUpdateAuto tries the per-name endpoint first (default)
+	godaddyUpdateAuto = "auto"
+)
+
 type GoDaddyDNSProvider struct {
 	apiKey    string
 	apiSecret string
 	endpoint  string
 	client    *http.Client
+	logger    Logger
+
+	// updateEndpoint is godaddyUpdateAuto, godaddyUpdateName or
+	// godaddyUpdateType
+	updateEndpoint string
 }
 
 type GoDaddyRecord struct {
@@ -36,28 +62,120 @@ type GoDaddyError struct {
 
 func NewGoDaddyProvider() *GoDaddyDNSProvider {
 	return &GoDaddyDNSProvider{
-		endpoint: "https://api.godaddy.com/v1",
+		endpoint: godaddyEndpoint,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("godaddy"),
 		},
+		updateEndpoint: godaddyUpdateAuto,
 	}
 }
 
+func (p *GoDaddyDNSProvider) SetLogger(logger Logger) {
+	p.logger = logger
+}
+
+// GetRecords lists the domain's records a page at a time; GoDaddy returns
+// names relative to the domain, with "@" for the apex
 func (p *GoDaddyDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
-	// TODO: 待验证 - GoDaddy DNS记录获取功能需要验证和完善
-	return []DNSRecord{}, fmt.Errorf("GoDaddy GetRecords功能待验证 - 需要测试API调用")
+	var records []DNSRecord
+	for offset := 0; ; offset += godaddyPageSize {
+		path := fmt.Sprintf("/domains/%s/records?offset=%d&limit=%d", domain, offset, godaddyPageSize)
+		body, err := p.makeRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []GoDaddyRecord
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse records response: %v", err)
+		}
+		for _, rec := range page {
+			records = append(records, DNSRecord{
+				Name:  rec.Name,
+				Type:  rec.Type,
+				Value: rec.Data,
+				TTL:   rec.TTL,
+			})
+		}
+
+		if len(page) < godaddyPageSize {
+			return records, nil
+		}
+	}
 }
 
 func (p *GoDaddyDNSProvider) GetProviderName() string {
 	return "godaddy"
 }
 
+// DefaultTTL is used for records configured without a TTL.
+// GoDaddy rejects TTLs below 600 seconds
+func (p *GoDaddyDNSProvider) DefaultTTL() int {
+	return 600
+}
+
 func (p *GoDaddyDNSProvider) SetCredentials(accessKey, secretKey string) {
 	p.apiKey = accessKey
 	p.apiSecret = secretKey
 }
 
+// Configure applies the endpoint override, timeout, user agent and proxy,
+// and the update endpoint (extra_config update_endpoint)
+func (p *GoDaddyDNSProvider) Configure(settings ProviderSettings) error {
+	switch mode := strings.ToLower(strings.TrimSpace(settings.Extra["update_endpoint"])); mode {
+	case "":
+		p.updateEndpoint = godaddyUpdateAuto
+	case godaddyUpdateAuto, godaddyUpdateName, godaddyUpdateType:
+		p.updateEndpoint = mode
+	default:
+		return fmt.Errorf("invalid update_endpoint: %s (supported: %s, %s, %s)",
+			mode, godaddyUpdateAuto, godaddyUpdateName, godaddyUpdateType)
+	}
+	p.endpoint = settings.endpointOr(godaddyEndpoint)
+	p.client = settings.httpClient("godaddy")
+	return nil
+}
+
 func (p *GoDaddyDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
+	switch p.updateEndpoint {
+	case godaddyUpdateName:
+		return p.updateByName(domain, recordName, recordType, newIP, ttl)
+	case godaddyUpdateType:
+		return p.updateByType(domain, recordName, recordType, newIP, ttl)
+	}
+
+	err := p.updateByName(domain, recordName, recordType, newIP, ttl)
+	if !godaddyNameEndpointRejected(err) {
+		return err
+	}
+	if p.logger != nil {
+		p.logger.Warnf("⚠️ GoDaddy按名称更新失败 (%v)，改用按类型更新的接口；可设置 update_endpoint = \"type\" 直接使用", err)
+	}
+	if typeErr := p.updateByType(domain, recordName, recordType, newIP, ttl); typeErr != nil {
+		return fmt.Errorf("%w (per-type endpoint: %v)", err, typeErr)
+	}
+	return nil
+}
+
+// godaddyNameEndpointRejected reports whether the per-name endpoint failed in
+// a way the per-type endpoint may not: the path isn't available (404/405) or
+// the request was refused as unprocessable (422). Authentication, rate limit
+// and server errors would fail there too.
+func godaddyNameEndpointRejected(err error) bool {
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
+
+// updateByName replaces the records of the name and type
+func (p *GoDaddyDNSProvider) updateByName(domain, recordName, recordType, newIP string, ttl int) error {
 	// GoDaddy uses a different approach - we update all records of the same name/type at once
 	records := []GoDaddyRecord{
 		{
@@ -78,6 +196,47 @@ func (p *GoDaddyDNSProvider) UpdateRecord(domain, recordName, recordType, newIP
 	return err
 }
 
+// updateByType replaces the record through the per-type endpoint. That PUT
+// replaces every record of the type in the domain, so the current set is
+// read first and sent back with only this name changed; a name without
+// records is added with PATCH instead, leaving the others untouched.
+func (p *GoDaddyDNSProvider) updateByType(domain, recordName, recordType, newIP string, ttl int) error {
+	body, err := p.makeRequest("GET", fmt.Sprintf("/domains/%s/records/%s", domain, recordType), nil)
+	if err != nil {
+		return err
+	}
+	var current []GoDaddyRecord
+	if err := json.Unmarshal(body, &current); err != nil {
+		return fmt.Errorf("failed to parse records response: %v", err)
+	}
+
+	updated := GoDaddyRecord{Data: newIP, Name: recordName, TTL: ttl, Type: recordType}
+	records := make([]GoDaddyRecord, 0, len(current))
+	found := false
+	for _, record := range current {
+		if !strings.EqualFold(record.Name, recordName) {
+			records = append(records, record)
+		} else if !found {
+			// Like the per-name PUT, the name ends up with one record
+			records = append(records, updated)
+			found = true
+		}
+	}
+
+	method, path := "PUT", fmt.Sprintf("/domains/%s/records/%s", domain, recordType)
+	if !found {
+		method, path = "PATCH", fmt.Sprintf("/domains/%s/records", domain)
+		records = []GoDaddyRecord{updated}
+	}
+
+	jsonData, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	_, err = p.makeRequest(method, path, bytes.NewReader(jsonData))
+	return err
+}
+
 func (p *GoDaddyDNSProvider) getRecord(domain, recordName, recordType string) (*GoDaddyRecord, error) {
 	url := fmt.Sprintf("/domains/%s/records/%s/%s", domain, recordType, recordName)
 
@@ -125,9 +284,9 @@ func (p *GoDaddyDNSProvider) makeRequest(method, path string, body io.Reader) ([
 	if resp.StatusCode >= 400 {
 		var gdError GoDaddyError
 		if err := json.Unmarshal(respBody, &gdError); err == nil {
-			return nil, p.formatGoDaddyError(gdError)
+			return nil, statusError(resp.StatusCode, p.formatGoDaddyError(gdError))
 		}
-		return nil, fmt.Errorf("HTTP error: %d - %s", resp.StatusCode, string(respBody))
+		return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error: %d - %s", resp.StatusCode, string(respBody)))
 	}
 
 	return respBody, nil
@@ -0,0 +1,182 @@
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newTestGoDaddyProvider(t *testing.T, endpoint string, extra map[string]string) *GoDaddyDNSProvider {
+	p := NewGoDaddyProvider()
+	p.SetCredentials("key", "secret")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint, Extra: extra}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestGoDaddyGetRecordsPages(t *testing.T) {
+	var all []GoDaddyRecord
+	for i := 0; i < godaddyPageSize+2; i++ {
+		all = append(all, GoDaddyRecord{Name: fmt.Sprintf("host%d", i), Type: "A", Data: "192.0.2.1", TTL: 600})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/domains/example.com/records" || r.Header.Get("Authorization") != "sso-key key:secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		end := min(offset+limit, len(all))
+		json.NewEncoder(w).Encode(all[min(offset, end):end])
+	}))
+	defer server.Close()
+
+	records, err := newTestGoDaddyProvider(t, server.URL, nil).GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(all) {
+		t.Fatalf("got %d records, want %d", len(records), len(all))
+	}
+	last := records[len(records)-1]
+	if want := (DNSRecord{Name: fmt.Sprintf("host%d", len(all)-1), Type: "A", Value: "192.0.2.1", TTL: 600}); last != want {
+		t.Fatalf("last record = %+v, want %+v", last, want)
+	}
+}
+
+// godaddyServer is a mock GoDaddy API holding the A records of example.com.
+// nameStatus, when set, is returned by the per-name endpoint.
+type godaddyServer struct {
+	records    []GoDaddyRecord
+	nameStatus int
+	requests   []string
+}
+
+func newGoDaddyServer(t *testing.T, nameStatus int, records ...GoDaddyRecord) (*godaddyServer, *httptest.Server) {
+	s := &godaddyServer{records: records, nameStatus: nameStatus}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		var body []GoDaddyRecord
+		json.NewDecoder(r.Body).Decode(&body)
+
+		switch path := strings.TrimPrefix(r.URL.Path, "/domains/example.com/records"); {
+		case strings.HasPrefix(path, "/A/"):
+			if s.nameStatus != 0 {
+				w.WriteHeader(s.nameStatus)
+				json.NewEncoder(w).Encode(GoDaddyError{Code: "UNABLE_TO_UPDATE", Message: "rejected"})
+				return
+			}
+			name := strings.TrimPrefix(path, "/A/")
+			kept := []GoDaddyRecord{}
+			for _, record := range s.records {
+				if record.Name != name {
+					kept = append(kept, record)
+				}
+			}
+			s.records = append(kept, body...)
+		case path == "/A" && r.Method == "GET":
+			json.NewEncoder(w).Encode(s.records)
+		case path == "/A" && r.Method == "PUT":
+			s.records = body
+		case path == "" && r.Method == "PATCH":
+			s.records = append(s.records, body...)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+var godaddyRecords = []GoDaddyRecord{
+	{Name: "@", Type: "A", Data: "192.0.2.1", TTL: 600},
+	{Name: "www", Type: "A", Data: "192.0.2.1", TTL: 600},
+}
+
+func TestGoDaddyUpdateByName(t *testing.T) {
+	s, server := newGoDaddyServer(t, 0, godaddyRecords...)
+	p := newTestGoDaddyProvider(t, server.URL, map[string]string{"update_endpoint": "name"})
+
+	if err := p.UpdateRecord("example.com", "www", "A", "198.51.100.7", 600); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"PUT /domains/example.com/records/A/www"}; !reflect.DeepEqual(s.requests, want) {
+		t.Fatalf("requests = %v, want %v", s.requests, want)
+	}
+}
+
+func TestGoDaddyUpdateByType(t *testing.T) {
+	s, server := newGoDaddyServer(t, 0, godaddyRecords...)
+	p := newTestGoDaddyProvider(t, server.URL, map[string]string{"update_endpoint": "type"})
+
+	if err := p.UpdateRecord("example.com", "www", "A", "198.51.100.7", 600); err != nil {
+		t.Fatal(err)
+	}
+	want := []GoDaddyRecord{
+		{Name: "@", Type: "A", Data: "192.0.2.1", TTL: 600},
+		{Name: "www", Type: "A", Data: "198.51.100.7", TTL: 600},
+	}
+	if !reflect.DeepEqual(s.records, want) {
+		t.Fatalf("records = %+v, want the other name kept", s.records)
+	}
+
+	// A name without records is added, not PUT over the whole type
+	s.requests = nil
+	if err := p.UpdateRecord("example.com", "nas", "A", "198.51.100.8", 600); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"GET /domains/example.com/records/A", "PATCH /domains/example.com/records"}; !reflect.DeepEqual(s.requests, want) {
+		t.Fatalf("requests = %v, want %v", s.requests, want)
+	}
+	if len(s.records) != 3 {
+		t.Fatalf("records = %+v, want three", s.records)
+	}
+}
+
+func TestGoDaddyAutoFallsBackToType(t *testing.T) {
+	s, server := newGoDaddyServer(t, http.StatusUnprocessableEntity, godaddyRecords...)
+	p := newTestGoDaddyProvider(t, server.URL, nil)
+
+	if err := p.UpdateRecord("example.com", "www", "A", "198.51.100.7", 600); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"PUT /domains/example.com/records/A/www",
+		"GET /domains/example.com/records/A",
+		"PUT /domains/example.com/records/A",
+	}
+	if !reflect.DeepEqual(s.requests, want) {
+		t.Fatalf("requests = %v, want %v", s.requests, want)
+	}
+	if s.records[1].Data != "198.51.100.7" {
+		t.Fatalf("records = %+v, www was not updated", s.records)
+	}
+}
+
+func TestGoDaddyAutoKeepsAuthErrors(t *testing.T) {
+	s, server := newGoDaddyServer(t, http.StatusUnauthorized, godaddyRecords...)
+	p := newTestGoDaddyProvider(t, server.URL, nil)
+
+	err := p.UpdateRecord("example.com", "www", "A", "198.51.100.7", 600)
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("err = %v, want the per-name error", err)
+	}
+	if len(s.requests) != 1 {
+		t.Fatalf("requests = %v, an authentication error must not fall back", s.requests)
+	}
+}
+
+func TestGoDaddyInvalidUpdateEndpoint(t *testing.T) {
+	p := NewGoDaddyProvider()
+	if err := p.Configure(ProviderSettings{Extra: map[string]string{"update_endpoint": "patch"}}); err == nil {
+		t.Fatal("update_endpoint = patch was accepted")
+	}
+}
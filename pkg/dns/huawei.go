@@ -12,6 +12,11 @@ import (
 	"time"
 )
 
+const huaweiEndpoint = "https://dns.myhuaweicloud.com"
+
+// huaweiPageSize is the largest page of record sets the API returns
+const huaweiPageSize = 500
+
 type HuaweiDNSProvider struct {
 	accessKey string
 	secretKey string
@@ -22,10 +27,16 @@ type HuaweiDNSProvider struct {
 type HuaweiResponse struct {
 	ErrorCode string `json:"errorCode"`
 	ErrorMsg  string `json:"errorMsg"`
+	// The API gateway reports authentication errors as error_code/error_msg
+	GatewayCode string `json:"error_code"`
+	GatewayMsg  string `json:"error_msg"`
 }
 
 type HuaweiRecordSetList struct {
 	Recordsets []HuaweiRecordSet `json:"recordsets"`
+	Metadata   struct {
+		TotalCount int `json:"total_count"`
+	} `json:"metadata"`
 }
 
 type HuaweiRecordSet struct {
@@ -48,27 +59,78 @@ type HuaweiZoneList struct {
 
 func NewHuaweiProvider() *HuaweiDNSProvider {
 	return &HuaweiDNSProvider{
-		endpoint: "https://dns.myhuaweicloud.com",
+		endpoint: huaweiEndpoint,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("huawei"),
 		},
 	}
 }
 
+// GetRecords lists the zone's record sets a page at a time, one DNSRecord
+// per value. Names are returned as FQDNs with the trailing dot.
 func (p *HuaweiDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
-	// TODO: 待验证 - 华为云DNS记录获取功能需要验证和完善
-	return []DNSRecord{}, fmt.Errorf("华为云 GetRecords功能待验证 - 需要测试API调用")
+	zoneId, err := p.getZoneId(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []DNSRecord
+	for offset := 0; ; offset += huaweiPageSize {
+		path := fmt.Sprintf("/v2/zones/%s/recordsets?limit=%d&offset=%d", zoneId, huaweiPageSize, offset)
+		body, err := p.makeRequest("GET", path, "")
+		if err != nil {
+			return nil, err
+		}
+
+		var page HuaweiRecordSetList
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse recordsets response: %v", err)
+		}
+		for _, recordset := range page.Recordsets {
+			for _, value := range recordset.Records {
+				records = append(records, DNSRecord{
+					Name:  recordset.Name,
+					Type:  recordset.Type,
+					Value: value,
+					TTL:   recordset.TTL,
+				})
+			}
+		}
+
+		if len(page.Recordsets) < huaweiPageSize || offset+len(page.Recordsets) >= page.Metadata.TotalCount {
+			return records, nil
+		}
+	}
 }
 
 func (p *HuaweiDNSProvider) GetProviderName() string {
 	return "huawei"
 }
 
+// DefaultTTL is used for records configured without a TTL.
+// 华为云解析的默认TTL为300秒
+func (p *HuaweiDNSProvider) DefaultTTL() int {
+	return 300
+}
+
 func (p *HuaweiDNSProvider) SetCredentials(accessKey, secretKey string) {
 	p.accessKey = accessKey
 	p.secretKey = secretKey
 }
 
+// Configure applies the endpoint override, timeout, user agent and proxy. A
+// region selects the regional endpoint, e.g. dns.ap-southeast-1.myhuaweicloud.com
+func (p *HuaweiDNSProvider) Configure(settings ProviderSettings) error {
+	defaultEndpoint := huaweiEndpoint
+	if settings.Region != "" {
+		defaultEndpoint = "https://dns." + settings.Region + ".myhuaweicloud.com"
+	}
+	p.endpoint = settings.endpointOr(defaultEndpoint)
+	p.client = settings.httpClient("huawei")
+	return nil
+}
+
 func (p *HuaweiDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
 	zoneId, err := p.getZoneId(domain)
 	if err != nil {
@@ -172,11 +234,20 @@ func (p *HuaweiDNSProvider) makeRequest(method, path, body string) ([]byte, erro
 	if resp.StatusCode >= 400 {
 		var huaweiResp HuaweiResponse
 		if err := json.Unmarshal(respBody, &huaweiResp); err == nil {
+			if huaweiResp.GatewayCode != "" {
+				if err := clockSkewError("huawei", huaweiResp.GatewayCode, huaweiResp.GatewayMsg); err != nil {
+					return nil, err
+				}
+				return nil, statusError(resp.StatusCode, fmt.Errorf("huawei API error: %s - %s", huaweiResp.GatewayCode, huaweiResp.GatewayMsg))
+			}
 			if huaweiResp.ErrorCode != "" {
-				return nil, fmt.Errorf("huawei API error: %s - %s", huaweiResp.ErrorCode, huaweiResp.ErrorMsg)
+				if err := clockSkewError("huawei", huaweiResp.ErrorCode, huaweiResp.ErrorMsg); err != nil {
+					return nil, err
+				}
+				return nil, statusError(resp.StatusCode, fmt.Errorf("huawei API error: %s - %s", huaweiResp.ErrorCode, huaweiResp.ErrorMsg))
 			}
 		}
-		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+		return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error: %d", resp.StatusCode))
 	}
 
 	return respBody, nil
@@ -203,11 +274,12 @@ func (p *HuaweiDNSProvider) generateAuthorization(method, path, body, timestamp
 }
 
 func (p *HuaweiDNSProvider) createCanonicalRequest(method, path, body, timestamp string) string {
-	canonicalURI := path
-	canonicalQueryString := ""
+	// The query of paged requests is signed separately, with its parameters
+	// in sorted order
+	canonicalURI, canonicalQueryString, _ := strings.Cut(path, "?")
 
 	// Canonical headers (must be sorted)
-	host := "dns.myhuaweicloud.com"
+	host := endpointHost(p.endpoint)
 	canonicalHeaders := fmt.Sprintf("content-type:application/json\nhost:%s\nx-sdk-date:%s\n", host, timestamp)
 	signedHeaders := "content-type;host;x-sdk-date"
 
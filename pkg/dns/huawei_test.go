@@ -0,0 +1,66 @@
+package dns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ip-updater/internal/config"
+)
+
+func TestHuaweiGetRecordsNormalizesNames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/zones":
+			json.NewEncoder(w).Encode(HuaweiZoneList{Zones: []HuaweiZone{{ID: "zone1", Name: "example.com."}}})
+		case "/v2/zones/zone1/recordsets":
+			if r.URL.Query().Get("limit") == "" {
+				t.Errorf("recordsets requested without a page size")
+			}
+			var list HuaweiRecordSetList
+			list.Recordsets = []HuaweiRecordSet{
+				{ID: "rs1", Name: "WWW.example.com.", Type: "A", Records: []string{"192.0.2.1"}, TTL: 300},
+				{ID: "rs2", Name: "example.com.", Type: "A", Records: []string{"192.0.2.2", "192.0.2.3"}, TTL: 600},
+			}
+			list.Metadata.TotalCount = len(list.Recordsets)
+			json.NewEncoder(w).Encode(list)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dm := NewDNSManager()
+	dm.InitializeProviders()
+	updater := config.DNSUpdater{
+		Name:          "huawei",
+		Provider:      "huawei",
+		Domain:        "example.com",
+		ExtraConfig:   map[string]string{SettingEndpoint: server.URL},
+		Records:       []config.DNSRecord{{Name: "www", Type: "A", TTL: 300}},
+		CompareFields: []string{config.CompareTTL},
+	}
+
+	current, err := dm.RecordsCurrent(updater, "192.0.2.1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !current {
+		t.Fatal("www holding the address is not seen as current")
+	}
+
+	updater.Records[0].TTL = 600
+	if current, _ := dm.RecordsCurrent(updater, "192.0.2.1", ""); current {
+		t.Fatal("a TTL-only difference is seen as current")
+	}
+
+	provider, _ := dm.GetProvider("huawei")
+	records, err := provider.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want one per value (3): %+v", len(records), records)
+	}
+}
@@ -1,9 +1,17 @@
 package dns
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
 	"ip-updater/internal/config"
 )
 
+// maxConflictRetries bounds the read+update cycles after a version conflict
+const maxConflictRetries = 3
+
 type Logger interface {
 	Debugf(format string, args ...interface{})
 	Infof(format string, args ...interface{})
@@ -16,6 +24,14 @@ type DNSRecord struct {
 	Type  string `json:"type"`
 	Value string `json:"value"`
 	TTL   int    `json:"ttl"`
+	// ID is the provider's record ID, on providers whose batch updates
+	// address records by ID
+	ID string `json:"id,omitempty"`
+	// Remark is the record's remark/description, on providers that have one
+	Remark string `json:"remark,omitempty"`
+	// Version identifies the revision of the record (ETag, modification
+	// time, ...) for providers that support conditional updates
+	Version string `json:"version,omitempty"`
 }
 
 type Provider interface {
@@ -23,25 +39,104 @@ type Provider interface {
 	GetRecords(domain string) ([]DNSRecord, error)
 	GetProviderName() string
 	SetCredentials(accessKey, secretKey string)
+	// Configure applies the updater's connection settings (see
+	// ProviderSettings); it is called before every use, like SetCredentials
+	Configure(settings ProviderSettings) error
+}
+
+// ConditionalUpdater is implemented by providers that can refuse an update
+// when the record changed since it was read. Implementations return
+// ErrPreconditionFailed on a version mismatch.
+type ConditionalUpdater interface {
+	UpdateRecordIfMatch(domain, recordName, recordType, newIP string, ttl int, version string) error
+}
+
+// RecordIDUpdater is implemented by providers that can update a record by
+// the ID GetRecords returned for it. Records configured with match_value
+// need it when several records share their name and type.
+type RecordIDUpdater interface {
+	UpdateRecordByID(domain, recordID, recordName, recordType, newIP string, ttl int) error
+}
+
+// AliasProvider is implemented by providers that offer an apex pseudo-record
+// (ALIAS, ANAME, ...) pointing at a hostname. Records configured as ALIAS or
+// ANAME are written with the provider's type; config rejects them for
+// providers without one (config.ProviderAliasType).
+type AliasProvider interface {
+	AliasRecordType() string
+}
+
+// RemarkProvider is implemented by providers whose records carry a remark
+// (description). It is used for records configured with a remark.
+type RemarkProvider interface {
+	SetRecordRemark(domain, recordName, recordType, remark string) error
+}
+
+// DefaultTTLProvider is implemented by providers with a minimum or
+// recommended TTL, which is used for records configured without a TTL
+// instead of sending 0
+type DefaultTTLProvider interface {
+	DefaultTTL() int
+}
+
+// loggingProvider is implemented by providers that log on their own; they
+// get the manager's logger
+type loggingProvider interface {
+	SetLogger(logger Logger)
 }
 
 type DNSManager struct {
 	providers map[string]Provider
 	logger    Logger
+
+	// matched maps the records configured with match_value
+	// ("updater/name/type") to the ID of the record they manage, or to the
+	// value last written on providers without record IDs
+	mu      sync.Mutex
+	matched map[string]string
+}
+
+// tokenProviders authenticate with a single API token taken from `token`
+var tokenProviders = map[string]bool{
+	"cloudflare": true,
+	"linode":     true,
+	"vultr":      true,
+	"desec":      true,
+	"gandi":      true,
+	"dynu":       true,
+	"bunny":      true,
+}
+
+// ApplyCredentials sets the credentials of an updater on its provider
+func ApplyCredentials(provider Provider, updater config.DNSUpdater) {
+	if tokenProviders[updater.Provider] && updater.Token != "" {
+		provider.SetCredentials(updater.Token, "")
+		return
+	}
+	provider.SetCredentials(updater.AccessKey, updater.SecretKey)
 }
 
 func NewDNSManager() *DNSManager {
 	return &DNSManager{
 		providers: make(map[string]Provider),
+		matched:   make(map[string]string),
 	}
 }
 
 func (dm *DNSManager) SetLogger(logger Logger) {
 	dm.logger = logger
+	for _, provider := range dm.providers {
+		if p, ok := provider.(loggingProvider); ok {
+			p.SetLogger(logger)
+		}
+	}
 }
 
 func (dm *DNSManager) RegisterProvider(name string, provider Provider) {
 	dm.providers[name] = provider
+	if p, ok := provider.(loggingProvider); ok && dm.logger != nil {
+		p.SetLogger(dm.logger)
+	}
 }
 
 func (dm *DNSManager) GetProvider(name string) (Provider, bool) {
@@ -50,6 +145,26 @@ func (dm *DNSManager) GetProvider(name string) (Provider, bool) {
 }
 
 func (dm *DNSManager) UpdateDNSRecord(updater config.DNSUpdater, ip string) error {
+	if strings.Contains(ip, ":") {
+		return dm.UpdateDNSRecordAddresses(updater, "", ip)
+	}
+	return dm.UpdateDNSRecordAddresses(updater, ip, "")
+}
+
+// UpdateDNSRecordAddresses updates the updater's records with the address of
+// the matching family: AAAA records get ipv6, A records ipv4, and
+// CNAME/ALIAS/ANAME records their target. Records whose family has no
+// address are skipped, and so are existing create_only records.
+func (dm *DNSManager) UpdateDNSRecordAddresses(updater config.DNSUpdater, ipv4, ipv6 string) error {
+	records := dm.expandRecords(updater, ipv4, ipv6)
+
+	if !dm.HasRecordsFor(updater, ipv4, ipv6) {
+		if dm.logger != nil {
+			dm.logger.Debugf("%s 没有可用地址族的记录需要更新", updater.DisplayDomain())
+		}
+		return nil
+	}
+
 	provider, exists := dm.GetProvider(updater.Provider)
 	if !exists {
 		if dm.logger != nil {
@@ -58,15 +173,17 @@ func (dm *DNSManager) UpdateDNSRecord(updater config.DNSUpdater, ip string) erro
 		return ErrProviderNotFound
 	}
 
-	// Set credentials for the provider before using it
-	if updater.Provider == "cloudflare" && updater.Token != "" {
-		provider.SetCredentials(updater.Token, "")
-	} else {
-		provider.SetCredentials(updater.AccessKey, updater.SecretKey)
+	// Set credentials and settings for the provider before using it
+	ApplyCredentials(provider, updater)
+	if err := ApplySettings(provider, updater); err != nil {
+		if dm.logger != nil {
+			dm.logger.Errorf("%v", err)
+		}
+		return err
 	}
 
 	if dm.logger != nil {
-		dm.logger.Infof("📋 DNS查询开始 - 提供商: %s, 域名: %s", updater.Provider, updater.Domain)
+		dm.logger.Infof("📋 DNS查询开始 - 提供商: %s, 域名: %s", updater.Provider, updater.DisplayDomain())
 	}
 
 	// 优化：对同一域名只查询一次DNS记录
@@ -74,52 +191,132 @@ func (dm *DNSManager) UpdateDNSRecord(updater config.DNSUpdater, ip string) erro
 		dm.logger.Infof("📡 获取域名 %s 的所有DNS记录...", updater.Domain)
 	}
 
-	records, err := provider.GetRecords(updater.Domain)
-	var recordsMap map[string]string // key: "name/type", value: current IP
+	existing, err := provider.GetRecords(updater.Domain)
+	var recordsMap map[string]DNSRecord // key: "name/type"
+	sameKey := make(map[string][]DNSRecord)
+	listed := err == nil
 
 	if err != nil {
 		if dm.logger != nil {
 			dm.logger.Warnf("⚠️ 无法获取DNS记录列表 %s: %v", updater.Domain, err)
 			dm.logger.Infof("🔄 将对所有记录尝试直接更新...")
 		}
-		recordsMap = make(map[string]string) // 空映射，所有记录都将被视为新记录
+		recordsMap = make(map[string]DNSRecord) // 空映射，所有记录都将被视为新记录
 	} else {
 		if dm.logger != nil {
-			dm.logger.Infof("✅ 成功获取到 %d 条DNS记录", len(records))
+			dm.logger.Infof("✅ 成功获取到 %d 条DNS记录", len(existing))
 		}
 
 		// 构建记录映射表，便于快速查找
-		recordsMap = make(map[string]string)
-		for _, rec := range records {
-			key := rec.Name + "/" + rec.Type
-			recordsMap[key] = rec.Value
+		recordsMap = make(map[string]DNSRecord)
+		for _, rec := range existing {
+			key := recordLookupKey(rec.Name, rec.Type, updater.Domain)
+			// Providers update the first of several records sharing a name
+			// and type, so that is the one compared
+			if _, ok := recordsMap[key]; !ok {
+				recordsMap[key] = rec
+			}
+			sameKey[key] = append(sameKey[key], rec)
 		}
 	}
 
-	// 处理每个配置的记录
-	for _, record := range updater.Records {
-		recordKey := updater.Domain + "/" + record.Name + "/" + record.Type
+	// 处理每个配置的记录; the changes are applied together afterwards
+	var changes []recordChange
+	var skipped []error
+	for _, record := range records {
+		ip := recordValue(record, ipv4, ipv6)
+		if ip == "" {
+			if dm.logger != nil {
+				dm.logger.Debugf("跳过DNS记录 %s/%s (%s): 没有对应地址族的IP", updater.DisplayDomain(), record.Name, record.Type)
+			}
+			continue
+		}
+		record.Type = dm.resolveRecordType(provider, record.Type)
+
+		recordKey := updater.DisplayDomain() + "/" + record.Name + "/" + record.Type
+		record.TTL = dm.recordTTL(provider, record, recordKey)
 
 		if dm.logger != nil {
 			dm.logger.Infof("🔍 处理DNS记录: %s (类型: %s)", recordKey, record.Type)
 		}
 
 		// 在已获取的记录中查找匹配项
-		lookupKey := record.Name + "/" + record.Type
-		if currentIP, found := recordsMap[lookupKey]; found {
+		lookupKey := recordLookupKey(record.Name, record.Type, updater.Domain)
+		current, found := recordsMap[lookupKey]
+		candidates := sameKey[lookupKey]
+		matchKey := ""
+		byID := false
+		var others []DNSRecord // further records to update, duplicate_records = "all"
+		// duplicate_records = "value" tracks the record even while it is the
+		// only one, so it is still known once others are added
+		if record.MatchValue != "" || updater.DuplicateRecords == config.DuplicateValue {
+			matchKey = updater.Name + "/" + record.Name + "/" + record.Type
+			var err error
+			current, found, err = dm.matchRecord(provider, matchKey, record, ip, candidates, listed)
+			if err != nil {
+				if dm.logger != nil {
+					dm.logger.Warnf("⚠️ 无法确定要更新同名同类型记录中的哪一条，为避免覆盖其他记录，本次不更新: %s: %v", recordKey, err)
+				}
+				skipped = append(skipped, fmt.Errorf("%s: %w", recordKey, err))
+				continue
+			}
+			byID = true
+		} else if len(candidates) > 1 {
+			var err error
+			others, err = dm.duplicateRecords(provider, updater, recordKey, candidates)
+			if err != nil {
+				if dm.logger != nil {
+					dm.logger.Warnf("⚠️ %v，本次不更新", err)
+				}
+				skipped = append(skipped, fmt.Errorf("%s: %w", recordKey, err))
+				continue
+			}
+			byID = true
+		}
+		for _, other := range others {
+			if sameValue(record.Type, other.Value, ip) && !ttlDiffers(updater, other, record.TTL) {
+				continue
+			}
+			otherKey := recordKey + " #" + other.ID
+			if dm.logger != nil {
+				dm.logger.Infof("📝 DNS记录值需要更新: %s 从 '%s' 更新为 '%s'", otherKey, other.Value, ip)
+			}
+			changes = append(changes, recordChange{record: record, ip: ip, current: other, found: true, key: otherKey, byID: true})
+		}
+		if record.CreateOnly && (found || !listed) {
+			if dm.logger != nil {
+				if found {
+					dm.logger.Infof("📌 记录已存在且设置了create_only，不修改: %s = '%s'", recordKey, current.Value)
+				} else {
+					dm.logger.Warnf("⚠️ 无法确认记录是否存在，create_only记录本次不创建: %s", recordKey)
+				}
+			}
+			continue
+		}
+		if found {
+			currentIP := current.Value
 			if dm.logger != nil {
 				dm.logger.Infof("✅ 找到现有DNS记录: %s = '%s'", recordKey, currentIP)
 			}
 
-			if currentIP == ip {
+			valueChanged := !sameValue(record.Type, currentIP, ip)
+			if !valueChanged && !ttlDiffers(updater, current, record.TTL) {
 				if dm.logger != nil {
 					dm.logger.Infof("✔️ DNS记录值未变化，跳过更新: %s = '%s'", recordKey, currentIP)
 				}
+				dm.setMatched(matchKey, current)
+				if record.Remark != "" && current.Remark != record.Remark {
+					dm.syncRemark(provider, updater.Domain, record, recordKey)
+				}
 				continue
 			}
 
 			if dm.logger != nil {
-				dm.logger.Infof("📝 DNS记录值需要更新: %s 从 '%s' 更新为 '%s'", recordKey, currentIP, ip)
+				if valueChanged {
+					dm.logger.Infof("📝 DNS记录值需要更新: %s 从 '%s' 更新为 '%s'", recordKey, currentIP, ip)
+				} else {
+					dm.logger.Infof("📝 DNS记录TTL与配置不一致，需要更新: %s TTL 从 %d 更新为 %d", recordKey, current.TTL, record.TTL)
+				}
 			}
 		} else {
 			if dm.logger != nil {
@@ -127,19 +324,406 @@ func (dm *DNSManager) UpdateDNSRecord(updater config.DNSUpdater, ip string) erro
 			}
 		}
 
-		if err := provider.UpdateRecord(updater.Domain, record.Name, record.Type, ip, record.TTL); err != nil {
-			if dm.logger != nil {
-				dm.logger.Errorf("❌ DNS记录更新失败: %s: %v", recordKey, err)
+		changes = append(changes, recordChange{record: record, ip: ip, current: current, found: found, key: recordKey, matchKey: matchKey, byID: byID})
+	}
+
+	return errors.Join(append(skipped, dm.applyChanges(provider, updater, changes))...)
+}
+
+// ttlDiffers reports whether the updater compares TTLs (compare_fields) and
+// the record at the provider has another TTL than ttl. Providers that don't
+// report TTLs, and records without a configured or default TTL, are not
+// compared.
+func ttlDiffers(updater config.DNSUpdater, current DNSRecord, ttl int) bool {
+	return updater.ComparesTTL() && ttl > 0 && current.TTL > 0 && current.TTL != ttl
+}
+
+// matchRecord finds the record a match_value record manages among the
+// records sharing its name and type: the one tracked since the last update
+// (by ID, or by value without IDs), else the one holding match_value or
+// already holding ip. With several candidates the provider must be able to
+// update by ID; without any, the record is created. duplicate_records =
+// "value" uses it without match_value, a single record is then the one.
+func (dm *DNSManager) matchRecord(provider Provider, matchKey string, record config.DNSRecord, ip string, candidates []DNSRecord, listed bool) (DNSRecord, bool, error) {
+	if !listed {
+		return DNSRecord{}, false, errors.New("the record list could not be read")
+	}
+	if len(candidates) == 0 {
+		return DNSRecord{}, false, nil
+	}
+	if record.MatchValue == "" && len(candidates) == 1 {
+		return candidates[0], true, nil
+	}
+	if _, ok := provider.(RecordIDUpdater); !ok && len(candidates) > 1 {
+		return DNSRecord{}, false, fmt.Errorf("%w: %d records share the name and type and %s can't update a record by ID", ErrAmbiguousRecord, len(candidates), provider.GetProviderName())
+	}
+
+	if tracked := dm.matchedRecord(matchKey); tracked != "" {
+		for _, candidate := range candidates {
+			if candidate.ID == tracked || sameValue(record.Type, candidate.Value, tracked) {
+				return candidate, true, nil
 			}
-			return err
 		}
+	}
+	for _, want := range []string{record.MatchValue, ip} {
+		for _, candidate := range candidates {
+			if sameValue(record.Type, candidate.Value, want) {
+				return candidate, true, nil
+			}
+		}
+	}
+	if record.MatchValue == "" {
+		return DNSRecord{}, false, fmt.Errorf("%w: none of the %d records sharing the name and type was written last or holds %s", ErrAmbiguousRecord, len(candidates), ip)
+	}
+	return DNSRecord{}, false, fmt.Errorf("%w: none of the %d records sharing the name and type holds %s", ErrAmbiguousRecord, len(candidates), record.MatchValue)
+}
 
+// duplicateRecords applies duplicate_records to a record that several
+// records share their name and type with. The first candidate is updated
+// like a single record; the others are returned for DuplicateAll.
+func (dm *DNSManager) duplicateRecords(provider Provider, updater config.DNSUpdater, recordKey string, candidates []DNSRecord) ([]DNSRecord, error) {
+	_, byID := provider.(RecordIDUpdater)
+	switch updater.DuplicateRecords {
+	case config.DuplicateError:
+		return nil, fmt.Errorf("%w: %s: %d records share the name and type (duplicate_records = %s)", ErrAmbiguousRecord, recordKey, len(candidates), config.DuplicateError)
+	case config.DuplicateAll:
+		if !byID {
+			return nil, fmt.Errorf("%w: %s: %d records share the name and type and %s can't update a record by ID", ErrAmbiguousRecord, recordKey, len(candidates), provider.GetProviderName())
+		}
 		if dm.logger != nil {
-			dm.logger.Infof("✅ DNS记录更新成功: %s = '%s' (TTL: %d)", recordKey, ip, record.TTL)
+			dm.logger.Infof("🔁 %s 有 %d 条同名同类型记录，全部更新 (duplicate_records = %s)", recordKey, len(candidates), config.DuplicateAll)
 		}
+		return candidates[1:], nil
 	}
 
-	return nil
+	if dm.logger != nil {
+		values := make([]string, len(candidates))
+		for i, candidate := range candidates {
+			values[i] = candidate.Value
+		}
+		dm.logger.Warnf("⚠️ %s 有 %d 条同名同类型记录 (%s)，只更新第一条 '%s'；如需指定或全部更新，请设置duplicate_records或match_value",
+			recordKey, len(candidates), strings.Join(values, ", "), candidates[0].Value)
+	}
+	return nil, nil
+}
+
+func (dm *DNSManager) matchedRecord(matchKey string) string {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	return dm.matched[matchKey]
+}
+
+// setMatched tracks the record a match_value record manages, now holding
+// record.Value; matchKey is empty for other records
+func (dm *DNSManager) setMatched(matchKey string, record DNSRecord) {
+	if matchKey == "" {
+		return
+	}
+	tracked := record.ID
+	if tracked == "" {
+		tracked = record.Value
+	}
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.matched[matchKey] = tracked
+}
+
+// SetMatchedRecords restores the records tracked for match_value, as saved
+// in the state file
+func (dm *DNSManager) SetMatchedRecords(matched map[string]string) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	for key, id := range matched {
+		dm.matched[key] = id
+	}
+}
+
+// MatchedRecords returns a copy of the records tracked for match_value,
+// for the state file
+func (dm *DNSManager) MatchedRecords() map[string]string {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	matched := make(map[string]string, len(dm.matched))
+	for key, id := range dm.matched {
+		matched[key] = id
+	}
+	return matched
+}
+
+// HasRecordsFor reports whether any of the updater's records has an address
+// (or target) to write, i.e. an update pass would touch the provider
+func (dm *DNSManager) HasRecordsFor(updater config.DNSUpdater, ipv4, ipv6 string) bool {
+	for _, record := range dm.expandRecords(updater, ipv4, ipv6) {
+		if recordValue(record, ipv4, ipv6) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordsCurrent reads the updater's records with one GetRecords call and
+// reports whether every record that has an address (or target) already
+// holds it, with the configured TTL when compare_fields includes it
+// (create_only records only need to exist), i.e. an update pass
+// would change nothing. It is used to seed the applied state on a first run
+// without a state file.
+func (dm *DNSManager) RecordsCurrent(updater config.DNSUpdater, ipv4, ipv6 string) (bool, error) {
+	provider, exists := dm.GetProvider(updater.Provider)
+	if !exists {
+		return false, ErrProviderNotFound
+	}
+	ApplyCredentials(provider, updater)
+	if err := ApplySettings(provider, updater); err != nil {
+		return false, err
+	}
+
+	existing, err := provider.GetRecords(updater.Domain)
+	if err != nil {
+		return false, err
+	}
+	current := make(map[string]DNSRecord, len(existing))
+	sameKey := make(map[string][]DNSRecord)
+	for _, rec := range existing {
+		key := recordLookupKey(rec.Name, rec.Type, updater.Domain)
+		current[key] = rec
+		sameKey[key] = append(sameKey[key], rec)
+	}
+
+	checked := 0
+	for _, record := range dm.expandRecords(updater, ipv4, ipv6) {
+		ip := recordValue(record, ipv4, ipv6)
+		if ip == "" {
+			continue
+		}
+		recordType := dm.resolveRecordType(provider, record.Type)
+		key := recordLookupKey(record.Name, recordType, updater.Domain)
+		rec, found := current[key]
+		if record.MatchValue != "" {
+			// Current when one of the records sharing the name and type has it
+			rec = DNSRecord{}
+			for _, candidate := range sameKey[key] {
+				if sameValue(recordType, candidate.Value, ip) {
+					rec = candidate
+				}
+			}
+		}
+		if found && record.CreateOnly {
+			checked++
+			continue
+		}
+		ttl := record.TTL
+		if defaults, ok := provider.(DefaultTTLProvider); ok && ttl == 0 {
+			ttl = defaults.DefaultTTL()
+		}
+		if !found || !sameValue(recordType, rec.Value, ip) || ttlDiffers(updater, rec, ttl) {
+			return false, nil
+		}
+		checked++
+	}
+	return checked > 0, nil
+}
+
+// expandRecords splits A+AAAA entries into their A and AAAA records. When
+// only one family has an address the pair is updated partially and the other
+// record is left as it is.
+func (dm *DNSManager) expandRecords(updater config.DNSUpdater, ipv4, ipv6 string) []config.DNSRecord {
+	records := make([]config.DNSRecord, 0, len(updater.Records))
+	for _, record := range updater.Records {
+		if record.Type != config.RecordTypeDualStack {
+			records = append(records, record)
+			continue
+		}
+
+		if dm.logger != nil {
+			if ipv4 == "" && ipv6 != "" {
+				dm.logger.Infof("双栈记录 %s/%s: IPv4不可用，仅更新AAAA记录", updater.DisplayDomain(), record.Name)
+			} else if ipv6 == "" && ipv4 != "" {
+				dm.logger.Infof("双栈记录 %s/%s: IPv6不可用，仅更新A记录", updater.DisplayDomain(), record.Name)
+			}
+		}
+
+		a, aaaa := record, record
+		a.Type = "A"
+		aaaa.Type = "AAAA"
+		records = append(records, a, aaaa)
+	}
+	return records
+}
+
+// recordLookupKey builds the lookup key for a record. Providers differ in
+// how they return names (relative or FQDN, with or without a trailing dot,
+// in any case), so names are compared in a normalized form.
+func recordLookupKey(name, recordType, domain string) string {
+	return NormalizeRecordName(name, domain) + "/" + strings.ToUpper(recordType)
+}
+
+// NormalizeRecordName lowercases a record name, strips a trailing dot and
+// makes it relative to domain, with "@" for the apex: "WWW.Example.com."
+// becomes "www", and "example.com." or "" becomes "@".
+func NormalizeRecordName(name, domain string) string {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+
+	if name == "" || name == "@" || name == domain {
+		return "@"
+	}
+	if domain != "" && strings.HasSuffix(name, "."+domain) {
+		return strings.TrimSuffix(name, "."+domain)
+	}
+	return name
+}
+
+// recordValue returns the value a record should hold: the configured target
+// for CNAME/ALIAS/ANAME records, the address of the matching family otherwise
+func recordValue(record config.DNSRecord, ipv4, ipv6 string) string {
+	if hasTarget(record.Type) {
+		return record.Target
+	}
+	return addressFor(record.Type, ipv4, ipv6)
+}
+
+// hasTarget reports whether records of recordType hold a hostname rather
+// than an address
+func hasTarget(recordType string) bool {
+	switch recordType {
+	case "CNAME", "ALIAS", "ANAME":
+		return true
+	}
+	return false
+}
+
+// sameValue compares a record's current value with the wanted one. Targets
+// are hostnames, returned by providers in any case and with or without the
+// trailing dot.
+func sameValue(recordType, current, wanted string) bool {
+	if hasTarget(recordType) {
+		return strings.EqualFold(strings.TrimSuffix(current, "."), strings.TrimSuffix(wanted, "."))
+	}
+	return current == wanted
+}
+
+// absoluteTarget adds the trailing dot to targets for providers that take
+// record data in zone file form, where a name without it is relative
+func absoluteTarget(recordType, value string) string {
+	if hasTarget(recordType) && !strings.HasSuffix(value, ".") {
+		return value + "."
+	}
+	return value
+}
+
+// addressFor picks the address a record of recordType should hold
+func addressFor(recordType, ipv4, ipv6 string) string {
+	if recordType == "AAAA" {
+		return ipv6
+	}
+	return ipv4
+}
+
+// syncRemark sets the configured remark on a record. The remark is only
+// informational, so a failure is logged and does not fail the update.
+func (dm *DNSManager) syncRemark(provider Provider, domain string, record config.DNSRecord, recordKey string) {
+	remarks, ok := provider.(RemarkProvider)
+	if !ok {
+		if dm.logger != nil {
+			dm.logger.Debugf("提供商 %s 不支持记录备注，忽略remark: %s", provider.GetProviderName(), recordKey)
+		}
+		return
+	}
+
+	if err := remarks.SetRecordRemark(domain, record.Name, record.Type, record.Remark); err != nil {
+		if dm.logger != nil {
+			dm.logger.Warnf("⚠️ DNS记录备注更新失败: %s: %v", recordKey, err)
+		}
+		return
+	}
+	if dm.logger != nil {
+		dm.logger.Infof("📝 DNS记录备注已更新: %s = '%s'", recordKey, record.Remark)
+	}
+}
+
+// recordTTL returns the TTL to send for a record: the configured one, or the
+// provider's default when the record has none
+func (dm *DNSManager) recordTTL(provider Provider, record config.DNSRecord, recordKey string) int {
+	if record.TTL != 0 {
+		return record.TTL
+	}
+
+	defaults, ok := provider.(DefaultTTLProvider)
+	if !ok {
+		return 0
+	}
+
+	ttl := defaults.DefaultTTL()
+	if dm.logger != nil {
+		dm.logger.Infof("未配置TTL，使用 %s 的默认TTL: %s = %d", provider.GetProviderName(), recordKey, ttl)
+	}
+	return ttl
+}
+
+// resolveRecordType maps the ALIAS/ANAME pseudo-types to the one the
+// provider supports. Config rejects them for providers without one; such a
+// record is sent as configured and fails at the provider rather than being
+// turned into another type.
+func (dm *DNSManager) resolveRecordType(provider Provider, recordType string) string {
+	if recordType != "ALIAS" && recordType != "ANAME" {
+		return recordType
+	}
+
+	if aliasProvider, ok := provider.(AliasProvider); ok {
+		return aliasProvider.AliasRecordType()
+	}
+
+	if dm.logger != nil {
+		dm.logger.Warnf("提供商 %s 不支持 %s 记录", provider.GetProviderName(), recordType)
+	}
+	return recordType
+}
+
+// applyRecord updates a record, using a conditional update when the provider
+// supports it and the record version is known. On a version conflict the
+// record is re-read and the update retried.
+func (dm *DNSManager) applyRecord(provider Provider, domain string, record config.DNSRecord, ip string, current DNSRecord, targeted bool) error {
+	// A record may share its name and type with others
+	if byID, ok := provider.(RecordIDUpdater); ok && targeted && current.ID != "" {
+		return byID.UpdateRecordByID(domain, current.ID, record.Name, record.Type, ip, record.TTL)
+	}
+
+	conditional, ok := provider.(ConditionalUpdater)
+	if !ok || current.Version == "" {
+		return provider.UpdateRecord(domain, record.Name, record.Type, ip, record.TTL)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := conditional.UpdateRecordIfMatch(domain, record.Name, record.Type, ip, record.TTL, current.Version)
+		if !errors.Is(err, ErrPreconditionFailed) || attempt >= maxConflictRetries {
+			return err
+		}
+
+		if dm.logger != nil {
+			dm.logger.Warnf("⚠️ DNS记录在读取后被修改: %s/%s (%s)，重新读取后重试", domain, record.Name, record.Type)
+		}
+
+		records, err := provider.GetRecords(domain)
+		if err != nil {
+			return err
+		}
+
+		current = DNSRecord{}
+		wanted := recordLookupKey(record.Name, record.Type, domain)
+		for _, rec := range records {
+			if recordLookupKey(rec.Name, rec.Type, domain) == wanted {
+				current = rec
+				break
+			}
+		}
+
+		if sameValue(record.Type, current.Value, ip) {
+			return nil
+		}
+		if current.Version == "" {
+			return provider.UpdateRecord(domain, record.Name, record.Type, ip, record.TTL)
+		}
+	}
 }
 
 // Initialize all DNS providers
@@ -149,4 +733,15 @@ func (dm *DNSManager) InitializeProviders() {
 	dm.RegisterProvider("huawei", NewHuaweiProvider())
 	dm.RegisterProvider("cloudflare", NewCloudflareProvider())
 	dm.RegisterProvider("godaddy", NewGoDaddyProvider())
+	dm.RegisterProvider("linode", NewLinodeProvider())
+	dm.RegisterProvider("vultr", NewVultrProvider())
+	dm.RegisterProvider("desec", NewDesecProvider())
+	dm.RegisterProvider("gandi", NewGandiProvider())
+	dm.RegisterProvider("namecom", NewNameComProvider())
+	dm.RegisterProvider("dynu", NewDynuProvider())
+	dm.RegisterProvider("bunny", NewBunnyProvider())
+
+	// Test doubles: log and record what would be done, see NullDNSProvider
+	dm.RegisterProvider("null", NewNullProvider("null"))
+	dm.RegisterProvider("mock", NewNullProvider("mock"))
 }
@@ -0,0 +1,223 @@
+package dns
+
+import (
+	"errors"
+	"testing"
+
+	"ip-updater/internal/config"
+)
+
+// config validates ALIAS/ANAME records against its own list of providers
+// with an apex alias; it has to match the providers implementing it
+func TestAliasProvidersMatchConfig(t *testing.T) {
+	dm := NewDNSManager()
+	dm.InitializeProviders()
+
+	for name, provider := range dm.providers {
+		want := config.ProviderAliasType(name)
+		got := ""
+		if alias, ok := provider.(AliasProvider); ok {
+			got = alias.AliasRecordType()
+		}
+		if got != want {
+			t.Errorf("provider %s: AliasRecordType = %q, config declares %q", name, got, want)
+		}
+	}
+}
+
+// config checks extra_config keys against its own list of providers;
+// every registered provider needs an entry there
+func TestProvidersDeclareExtraKeys(t *testing.T) {
+	dm := NewDNSManager()
+	dm.InitializeProviders()
+
+	for name := range dm.providers {
+		if _, ok := config.ProviderExtraKeys(name); !ok {
+			t.Errorf("provider %s has no extra_config declaration in config", name)
+		}
+	}
+}
+
+func TestResolveRecordTypeDoesNotDowngradeAlias(t *testing.T) {
+	dm := NewDNSManager()
+	if got := dm.resolveRecordType(NewGandiProvider(), "ANAME"); got != "ALIAS" {
+		t.Errorf("gandi: ANAME resolved to %s, want ALIAS", got)
+	}
+	if got := dm.resolveRecordType(NewNameComProvider(), "ALIAS"); got != "ANAME" {
+		t.Errorf("namecom: ALIAS resolved to %s, want ANAME", got)
+	}
+	if got := dm.resolveRecordType(NewCloudflareProvider(), "ALIAS"); got != "ALIAS" {
+		t.Errorf("cloudflare: ALIAS resolved to %s, want it left as ALIAS", got)
+	}
+}
+
+// The normalizer maps every name form the providers use onto the configured
+// one; names_test.go checks them against real GetRecords responses
+func TestNormalizeRecordNameForms(t *testing.T) {
+	tests := []struct {
+		provider string
+		www      string
+		apex     string
+	}{
+		{"aliyun", "www", "@"},
+		{"tencent", "www", "@"},
+		{"huawei", "www.example.com.", "example.com."},
+		{"cloudflare", "www.example.com", "example.com"},
+		{"godaddy", "www", "@"},
+		{"linode", "www", ""},
+		{"vultr", "www", ""},
+		{"desec", "www", ""},
+		{"gandi", "www", "@"},
+		{"namecom", "WWW", ""},
+		{"namecom fqdn", "www.example.com.", "example.com."},
+		{"dynu", "www.Example.com", "example.com"},
+		{"bunny", "www", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			if got := recordLookupKey(tt.www, "a", "example.com"); got != recordLookupKey("www", "A", "example.com") {
+				t.Errorf("%q: lookup key %q doesn't match the configured www", tt.www, got)
+			}
+			if got := NormalizeRecordName(tt.apex, "Example.com."); got != "@" {
+				t.Errorf("apex %q normalized to %q, want @", tt.apex, got)
+			}
+		})
+	}
+}
+
+// defaultTTLProvider is a null provider with a default TTL
+type defaultTTLProvider struct {
+	*NullDNSProvider
+}
+
+func (p defaultTTLProvider) DefaultTTL() int {
+	return 900
+}
+
+func TestDefaultTTLOnlyForRecordsWithoutTTL(t *testing.T) {
+	provider := defaultTTLProvider{NewNullProvider("ttl")}
+	plain := NewNullProvider("plain")
+
+	dm := NewDNSManager()
+	dm.RegisterProvider("ttl", provider)
+	dm.RegisterProvider("plain", plain)
+
+	records := []config.DNSRecord{{Name: "unset", Type: "A"}, {Name: "set", Type: "A", TTL: 60}}
+	for _, name := range []string{"ttl", "plain"} {
+		updater := config.DNSUpdater{Name: name, Provider: name, Domain: "example.com", Records: records}
+		if err := dm.UpdateDNSRecord(updater, "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ttls := func(p *NullDNSProvider) map[string]int {
+		got := make(map[string]int)
+		for _, call := range p.Calls() {
+			if call.Method == "UpdateRecord" {
+				got[call.Name] = call.TTL
+			}
+		}
+		return got
+	}
+	if got := ttls(provider.NullDNSProvider); got["unset"] != 900 || got["set"] != 60 {
+		t.Errorf("provider with a default: TTLs = %v, want unset=900 set=60", got)
+	}
+	if got := ttls(plain); got["unset"] != 0 || got["set"] != 60 {
+		t.Errorf("provider without a default: TTLs = %v, want unset=0 set=60", got)
+	}
+}
+
+// unlistedProvider is a null provider whose records can't be listed
+type unlistedProvider struct {
+	*NullDNSProvider
+}
+
+func (p unlistedProvider) GetRecords(domain string) ([]DNSRecord, error) {
+	return nil, errors.New("listing not permitted")
+}
+
+func TestCreateOnlyRecords(t *testing.T) {
+	dm, p, updater := newNullManager(nil, []config.DNSRecord{
+		{Name: "manual", Type: "A", CreateOnly: true},
+		{Name: "missing", Type: "A", CreateOnly: true},
+	})
+	if err := p.UpdateRecord("example.com", "manual", "A", "192.0.2.1", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dm.UpdateDNSRecord(updater, "203.0.113.7"); err != nil {
+		t.Fatal(err)
+	}
+	updates := nullUpdates(p)[1:]
+	if len(updates) != 1 || updates[0].Name != "missing" {
+		t.Fatalf("updates = %+v, want only the missing record created", updates)
+	}
+	records, _ := p.GetRecords("example.com")
+	for _, record := range records {
+		if record.Name == "manual" && record.Value != "192.0.2.1" {
+			t.Fatalf("existing create_only record overwritten with %s", record.Value)
+		}
+	}
+
+	// Both exist now, so an update pass would change nothing
+	current, err := dm.RecordsCurrent(updater, "198.51.100.1", "")
+	if err != nil || !current {
+		t.Fatalf("RecordsCurrent = %v, %v, want true", current, err)
+	}
+}
+
+func TestCreateOnlyNotCreatedWhenUnlisted(t *testing.T) {
+	p := unlistedProvider{NewNullProvider("unlisted")}
+	dm := NewDNSManager()
+	dm.RegisterProvider("unlisted", p)
+	updater := config.DNSUpdater{
+		Name:     "unlisted",
+		Provider: "unlisted",
+		Domain:   "example.com",
+		Records:  []config.DNSRecord{{Name: "manual", Type: "A", CreateOnly: true}, {Name: "www", Type: "A"}},
+	}
+
+	if err := dm.UpdateDNSRecord(updater, "203.0.113.7"); err != nil {
+		t.Fatal(err)
+	}
+	updates := nullUpdates(p.NullDNSProvider)
+	if len(updates) != 1 || updates[0].Name != "www" {
+		t.Fatalf("updates = %+v, want the create_only record left alone", updates)
+	}
+}
+
+func TestCompareFieldsTTLOnlyDifference(t *testing.T) {
+	for _, tt := range []struct {
+		fields  []string
+		updates int
+	}{
+		{nil, 0},
+		{[]string{config.CompareValue, config.CompareTTL}, 1},
+	} {
+		dm, p, updater := newNullManager(nil, []config.DNSRecord{{Name: "www", Type: "A", TTL: 600}})
+		updater.CompareFields = tt.fields
+		if err := p.UpdateRecord("example.com", "www", "A", "203.0.113.7", 300); err != nil {
+			t.Fatal(err)
+		}
+
+		current, err := dm.RecordsCurrent(updater, "203.0.113.7", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if current != (tt.updates == 0) {
+			t.Errorf("compare_fields = %v: RecordsCurrent = %v", tt.fields, current)
+		}
+
+		if err := dm.UpdateDNSRecord(updater, "203.0.113.7"); err != nil {
+			t.Fatal(err)
+		}
+		updates := nullUpdates(p)[1:]
+		if len(updates) != tt.updates {
+			t.Fatalf("compare_fields = %v: updates = %+v, want %d", tt.fields, updates, tt.updates)
+		}
+		if tt.updates > 0 && (updates[0].TTL != 600 || updates[0].Value != "203.0.113.7") {
+			t.Fatalf("update = %+v, want the configured TTL with the same value", updates[0])
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const linodePageSize = 500
+
+const linodeEndpoint = "https://api.linode.com/v4"
+
+type LinodeDNSProvider struct {
+	apiToken string
+	endpoint string
+	client   *http.Client
+}
+
+type LinodeDomain struct {
+	ID     int    `json:"id"`
+	Domain string `json:"domain"`
+}
+
+type LinodeRecord struct {
+	ID     int    `json:"id"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	TTLSec int    `json:"ttl_sec"`
+}
+
+type LinodeRecordRequest struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	TTLSec int    `json:"ttl_sec"`
+}
+
+type LinodeError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+type linodePage struct {
+	Data   json.RawMessage `json:"data"`
+	Page   int             `json:"page"`
+	Pages  int             `json:"pages"`
+	Errors []LinodeError   `json:"errors"`
+}
+
+func NewLinodeProvider() *LinodeDNSProvider {
+	return &LinodeDNSProvider{
+		endpoint: linodeEndpoint,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("linode"),
+		},
+	}
+}
+
+func (p *LinodeDNSProvider) GetProviderName() string {
+	return "linode"
+}
+
+// DefaultTTL is used for records configured without a TTL.
+// Linode rounds TTLs up to its supported values, 300 is the smallest
+func (p *LinodeDNSProvider) DefaultTTL() int {
+	return 300
+}
+
+func (p *LinodeDNSProvider) SetCredentials(accessKey, secretKey string) {
+	p.apiToken = accessKey
+}
+
+// Configure applies the endpoint override, timeout, user agent and proxy
+func (p *LinodeDNSProvider) Configure(settings ProviderSettings) error {
+	p.endpoint = settings.endpointOr(linodeEndpoint)
+	p.client = settings.httpClient("linode")
+	return nil
+}
+
+func (p *LinodeDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
+	domainId, err := p.getDomainId(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	linodeRecords, err := p.listRecords(domainId)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]DNSRecord, 0, len(linodeRecords))
+	for _, rec := range linodeRecords {
+		records = append(records, DNSRecord{
+			Name:  p.toRecordName(rec.Name),
+			Type:  rec.Type,
+			Value: rec.Target,
+			TTL:   rec.TTLSec,
+		})
+	}
+
+	return records, nil
+}
+
+func (p *LinodeDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
+	domainId, err := p.getDomainId(domain)
+	if err != nil {
+		return err
+	}
+
+	linodeRecords, err := p.listRecords(domainId)
+	if err != nil {
+		return err
+	}
+
+	name := p.toLinodeName(recordName)
+	recordData := LinodeRecordRequest{
+		Type:   recordType,
+		Name:   name,
+		Target: newIP,
+		TTLSec: ttl,
+	}
+
+	jsonData, err := json.Marshal(recordData)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range linodeRecords {
+		if rec.Type == recordType && strings.EqualFold(rec.Name, name) {
+			path := fmt.Sprintf("/domains/%d/records/%d", domainId, rec.ID)
+			_, err = p.makeRequest("PUT", path, bytes.NewReader(jsonData))
+			return err
+		}
+	}
+
+	// Record doesn't exist, create it
+	path := fmt.Sprintf("/domains/%d/records", domainId)
+	_, err = p.makeRequest("POST", path, bytes.NewReader(jsonData))
+	return err
+}
+
+func (p *LinodeDNSProvider) getDomainId(domain string) (int, error) {
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("/domains?page=%d&page_size=%d", page, linodePageSize)
+		body, err := p.makeRequest("GET", path, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		var response linodePage
+		if err := json.Unmarshal(body, &response); err != nil {
+			return 0, fmt.Errorf("failed to parse domains response: %v", err)
+		}
+
+		var domains []LinodeDomain
+		if err := json.Unmarshal(response.Data, &domains); err != nil {
+			return 0, fmt.Errorf("failed to parse domains response: %v", err)
+		}
+
+		for _, d := range domains {
+			if strings.EqualFold(d.Domain, domain) {
+				return d.ID, nil
+			}
+		}
+
+		if page >= response.Pages {
+			break
+		}
+	}
+
+	return 0, fmt.Errorf("domain not found: %s", domain)
+}
+
+func (p *LinodeDNSProvider) listRecords(domainId int) ([]LinodeRecord, error) {
+	var records []LinodeRecord
+
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("/domains/%d/records?page=%d&page_size=%d", domainId, page, linodePageSize)
+		body, err := p.makeRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var response linodePage
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse records response: %v", err)
+		}
+
+		var pageRecords []LinodeRecord
+		if err := json.Unmarshal(response.Data, &pageRecords); err != nil {
+			return nil, fmt.Errorf("failed to parse records response: %v", err)
+		}
+		records = append(records, pageRecords...)
+
+		if page >= response.Pages {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+// Linode uses an empty name for the zone apex
+func (p *LinodeDNSProvider) toLinodeName(recordName string) string {
+	if recordName == "@" {
+		return ""
+	}
+	return recordName
+}
+
+func (p *LinodeDNSProvider) toRecordName(linodeName string) string {
+	if linodeName == "" {
+		return "@"
+	}
+	return linodeName
+}
+
+func (p *LinodeDNSProvider) makeRequest(method, path string, body io.Reader) ([]byte, error) {
+	fullURL := p.endpoint + path
+
+	req, err := http.NewRequest(method, fullURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		var errResp linodePage
+		if err := json.Unmarshal(respBody, &errResp); err == nil && len(errResp.Errors) > 0 {
+			return nil, statusError(resp.StatusCode, p.formatLinodeErrors(errResp.Errors))
+		}
+		return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error: %d", resp.StatusCode))
+	}
+
+	return respBody, nil
+}
+
+func (p *LinodeDNSProvider) formatLinodeErrors(errors []LinodeError) error {
+	var messages []string
+	for _, err := range errors {
+		if err.Field != "" {
+			messages = append(messages, fmt.Sprintf("%s: %s", err.Field, err.Reason))
+		} else {
+			messages = append(messages, err.Reason)
+		}
+	}
+	return fmt.Errorf("linode API error: %s", strings.Join(messages, "; "))
+}
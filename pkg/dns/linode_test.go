@@ -0,0 +1,121 @@
+package dns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// linodeServer is a mock Linode API; example.com is only on the second
+// page of domains
+type linodeServer struct {
+	records  []LinodeRecord
+	requests []string
+}
+
+func newLinodeServer(t *testing.T, records ...LinodeRecord) (*linodeServer, *httptest.Server) {
+	s := &linodeServer{records: records}
+
+	writePage := func(w http.ResponseWriter, page, pages int, data interface{}) {
+		raw, _ := json.Marshal(data)
+		json.NewEncoder(w).Encode(linodePage{Data: raw, Page: page, Pages: pages})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/domains", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "1" {
+			writePage(w, 1, 2, []LinodeDomain{{ID: 1, Domain: "other.com"}})
+			return
+		}
+		writePage(w, 2, 2, []LinodeDomain{{ID: 42, Domain: "example.com"}})
+	})
+	mux.HandleFunc("/domains/42/records", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			var req LinodeRecordRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			s.requests = append(s.requests, "POST "+req.Name+" "+req.Type+" "+req.Target)
+			return
+		}
+		writePage(w, 1, 1, s.records)
+	})
+	mux.HandleFunc("/domains/42/records/", func(w http.ResponseWriter, r *http.Request) {
+		var req LinodeRecordRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path+" "+req.Target)
+	})
+	mux.HandleFunc("/domains/7/records", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(linodePage{Errors: []LinodeError{{Field: "ttl_sec", Reason: "Invalid TTL"}}})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func newTestLinodeProvider(t *testing.T, endpoint string) *LinodeDNSProvider {
+	p := NewLinodeProvider()
+	p.SetCredentials("token", "")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLinodeGetRecordsMapsApex(t *testing.T) {
+	_, server := newLinodeServer(t,
+		LinodeRecord{ID: 1, Type: "A", Name: "", Target: "1.1.1.1", TTLSec: 300},
+		LinodeRecord{ID: 2, Type: "AAAA", Name: "www", Target: "2001:db8::1", TTLSec: 3600},
+	)
+	p := newTestLinodeProvider(t, server.URL)
+
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DNSRecord{
+		{Name: "@", Type: "A", Value: "1.1.1.1", TTL: 300},
+		{Name: "www", Type: "AAAA", Value: "2001:db8::1", TTL: 3600},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("GetRecords = %+v, want %+v", records, want)
+	}
+}
+
+func TestLinodeUpdateRecord(t *testing.T) {
+	server, ts := newLinodeServer(t, LinodeRecord{ID: 9, Type: "A", Name: "", Target: "1.1.1.1", TTLSec: 300})
+	p := newTestLinodeProvider(t, ts.URL)
+
+	if err := p.UpdateRecord("example.com", "@", "A", "2.2.2.2", 300); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UpdateRecord("example.com", "www", "A", "2.2.2.2", 300); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PUT /domains/42/records/9 2.2.2.2", "POST www A 2.2.2.2"}
+	if !reflect.DeepEqual(server.requests, want) {
+		t.Fatalf("requests = %v, want %v", server.requests, want)
+	}
+}
+
+func TestLinodeUnknownDomain(t *testing.T) {
+	_, server := newLinodeServer(t)
+	p := newTestLinodeProvider(t, server.URL)
+
+	if _, err := p.GetRecords("missing.com"); err == nil || !strings.Contains(err.Error(), "domain not found") {
+		t.Fatalf("GetRecords error = %v, want domain not found", err)
+	}
+}
+
+func TestLinodeAPIErrors(t *testing.T) {
+	_, server := newLinodeServer(t)
+	p := newTestLinodeProvider(t, server.URL)
+
+	_, err := p.listRecords(7)
+	if err == nil || !strings.Contains(err.Error(), "ttl_sec: Invalid TTL") {
+		t.Fatalf("listRecords error = %v, want the Linode field error", err)
+	}
+}
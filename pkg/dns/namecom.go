@@ -0,0 +1,210 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	namecomPageSize = 1000
+	// name.com rejects records with a TTL below 300 seconds
+	namecomMinTTL = 300
+)
+
+const namecomEndpoint = "https://api.name.com/v4"
+
+type NameComDNSProvider struct {
+	username string
+	apiToken string
+	endpoint string
+	client   *http.Client
+}
+
+type NameComRecord struct {
+	ID     int    `json:"id,omitempty"`
+	Host   string `json:"host"`
+	FQDN   string `json:"fqdn,omitempty"`
+	Type   string `json:"type"`
+	Answer string `json:"answer"`
+	TTL    int    `json:"ttl"`
+}
+
+type NameComRecordList struct {
+	Records  []NameComRecord `json:"records"`
+	NextPage int             `json:"nextPage"`
+	LastPage int             `json:"lastPage"`
+}
+
+type NameComError struct {
+	Message string `json:"message"`
+	Details string `json:"details"`
+}
+
+func NewNameComProvider() *NameComDNSProvider {
+	return &NameComDNSProvider{
+		endpoint: namecomEndpoint,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("namecom"),
+		},
+	}
+}
+
+func (p *NameComDNSProvider) GetProviderName() string {
+	return "namecom"
+}
+
+// DefaultTTL is used for records configured without a TTL
+func (p *NameComDNSProvider) DefaultTTL() int {
+	return namecomMinTTL
+}
+
+// AliasRecordType is name.com's apex alias, used for ALIAS/ANAME records
+func (p *NameComDNSProvider) AliasRecordType() string {
+	return "ANAME"
+}
+
+// SetCredentials takes the account username and an API token, used for
+// HTTP basic auth
+func (p *NameComDNSProvider) SetCredentials(accessKey, secretKey string) {
+	p.username = accessKey
+	p.apiToken = secretKey
+}
+
+// Configure applies the endpoint override, timeout, user agent and proxy
+func (p *NameComDNSProvider) Configure(settings ProviderSettings) error {
+	p.endpoint = settings.endpointOr(namecomEndpoint)
+	p.client = settings.httpClient("namecom")
+	return nil
+}
+
+func (p *NameComDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
+	namecomRecords, err := p.listRecords(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]DNSRecord, 0, len(namecomRecords))
+	for _, rec := range namecomRecords {
+		records = append(records, DNSRecord{
+			Name:  NormalizeRecordName(rec.Host, domain),
+			Type:  rec.Type,
+			Value: rec.Answer,
+			TTL:   rec.TTL,
+		})
+	}
+
+	return records, nil
+}
+
+func (p *NameComDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
+	if ttl < namecomMinTTL {
+		ttl = namecomMinTTL
+	}
+
+	namecomRecords, err := p.listRecords(domain)
+	if err != nil {
+		return err
+	}
+
+	request := NameComRecord{
+		Host:   p.toHost(recordName),
+		Type:   recordType,
+		Answer: newIP,
+		TTL:    ttl,
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range namecomRecords {
+		if rec.Type == recordType && NormalizeRecordName(rec.Host, domain) == NormalizeRecordName(recordName, domain) {
+			path := fmt.Sprintf("/domains/%s/records/%d", url.PathEscape(domain), rec.ID)
+			_, err = p.makeRequest("PUT", path, bytes.NewReader(jsonData))
+			return err
+		}
+	}
+
+	// Record doesn't exist, create it
+	path := fmt.Sprintf("/domains/%s/records", url.PathEscape(domain))
+	_, err = p.makeRequest("POST", path, bytes.NewReader(jsonData))
+	return err
+}
+
+// listRecords reads every page of the domain's records
+func (p *NameComDNSProvider) listRecords(domain string) ([]NameComRecord, error) {
+	var records []NameComRecord
+	page := 1
+
+	for {
+		path := fmt.Sprintf("/domains/%s/records?perPage=%d&page=%d", url.PathEscape(domain), namecomPageSize, page)
+		body, err := p.makeRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var response NameComRecordList
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse records response: %v", err)
+		}
+		records = append(records, response.Records...)
+
+		if response.NextPage <= page || len(response.Records) == 0 {
+			break
+		}
+		page = response.NextPage
+	}
+
+	return records, nil
+}
+
+// name.com uses an empty host for the zone apex
+func (p *NameComDNSProvider) toHost(recordName string) string {
+	if recordName == "@" {
+		return ""
+	}
+	return recordName
+}
+
+func (p *NameComDNSProvider) makeRequest(method, path string, body io.Reader) ([]byte, error) {
+	fullURL := p.endpoint + path
+
+	req, err := http.NewRequest(method, fullURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(p.username, p.apiToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		var namecomErr NameComError
+		if err := json.Unmarshal(respBody, &namecomErr); err == nil && namecomErr.Message != "" {
+			if namecomErr.Details != "" {
+				return nil, statusError(resp.StatusCode, fmt.Errorf("name.com API error: %s: %s (status: %d)", namecomErr.Message, namecomErr.Details, resp.StatusCode))
+			}
+			return nil, statusError(resp.StatusCode, fmt.Errorf("name.com API error: %s (status: %d)", namecomErr.Message, resp.StatusCode))
+		}
+		return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error: %d", resp.StatusCode))
+	}
+
+	return respBody, nil
+}
@@ -0,0 +1,141 @@
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"ip-updater/internal/config"
+)
+
+// namecomServer is a mock name.com API that serves the records of
+// example.com one per page
+type namecomServer struct {
+	records  []NameComRecord
+	requests []string
+}
+
+func newNamecomServer(t *testing.T, records ...NameComRecord) (*namecomServer, *httptest.Server) {
+	s := &namecomServer{records: records}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/domains/example.com/records", func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "user" || token != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(NameComError{Message: "Unauthenticated"})
+			return
+		}
+		if r.Method == "POST" {
+			var rec NameComRecord
+			json.NewDecoder(r.Body).Decode(&rec)
+			s.requests = append(s.requests, fmt.Sprintf("POST %s %s %s %d", rec.Host, rec.Type, rec.Answer, rec.TTL))
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var list NameComRecordList
+		if page <= len(s.records) {
+			list.Records = s.records[page-1 : page]
+		}
+		if page < len(s.records) {
+			list.NextPage = page + 1
+		}
+		list.LastPage = len(s.records)
+		json.NewEncoder(w).Encode(list)
+	})
+	mux.HandleFunc("/domains/example.com/records/", func(w http.ResponseWriter, r *http.Request) {
+		var rec NameComRecord
+		json.NewDecoder(r.Body).Decode(&rec)
+		s.requests = append(s.requests, fmt.Sprintf("%s %s %s %s %d", r.Method, r.URL.Path, rec.Type, rec.Answer, rec.TTL))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func newTestNamecomProvider(t *testing.T, endpoint string) *NameComDNSProvider {
+	p := NewNameComProvider()
+	p.SetCredentials("user", "token")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNamecomGetRecordsPages(t *testing.T) {
+	_, server := newNamecomServer(t,
+		NameComRecord{ID: 1, Host: "", FQDN: "example.com.", Type: "A", Answer: "1.1.1.1", TTL: 300},
+		NameComRecord{ID: 2, Host: "www", FQDN: "www.example.com.", Type: "A", Answer: "2.2.2.2", TTL: 600},
+	)
+	p := newTestNamecomProvider(t, server.URL)
+
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DNSRecord{
+		{Name: "@", Type: "A", Value: "1.1.1.1", TTL: 300},
+		{Name: "www", Type: "A", Value: "2.2.2.2", TTL: 600},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("GetRecords = %+v, want %+v", records, want)
+	}
+}
+
+func TestNamecomUpdateRecord(t *testing.T) {
+	server, ts := newNamecomServer(t, NameComRecord{ID: 7, Host: "www", Type: "A", Answer: "1.1.1.1", TTL: 300})
+	p := newTestNamecomProvider(t, ts.URL)
+
+	if err := p.UpdateRecord("example.com", "www", "A", "3.3.3.3", 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UpdateRecord("example.com", "@", "A", "3.3.3.3", 3600); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"PUT /domains/example.com/records/7 A 3.3.3.3 300",
+		"POST  A 3.3.3.3 3600",
+	}
+	if !reflect.DeepEqual(server.requests, want) {
+		t.Fatalf("requests = %v, want %v", server.requests, want)
+	}
+}
+
+func TestNamecomAuthError(t *testing.T) {
+	_, server := newNamecomServer(t)
+	p := NewNameComProvider()
+	p.SetCredentials("user", "wrong")
+	p.Configure(ProviderSettings{Endpoint: server.URL})
+
+	_, err := p.GetRecords("example.com")
+	if err == nil || err.Error() != "name.com API error: Unauthenticated (status: 401)" {
+		t.Fatalf("GetRecords error = %v, want the name.com error message", err)
+	}
+}
+
+func TestNamecomAnameRecord(t *testing.T) {
+	server, ts := newNamecomServer(t)
+
+	dm := NewDNSManager()
+	dm.InitializeProviders()
+	updater := config.DNSUpdater{
+		Name:        "apex",
+		Provider:    "namecom",
+		AccessKey:   "user",
+		SecretKey:   "token",
+		Domain:      "example.com",
+		ExtraConfig: map[string]string{SettingEndpoint: ts.URL},
+		Records:     []config.DNSRecord{{Name: "@", Type: "ALIAS", Target: "home.example.net", TTL: 300}},
+	}
+	if err := dm.UpdateDNSRecord(updater, "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"POST  ANAME home.example.net 300"}; !reflect.DeepEqual(server.requests, want) {
+		t.Fatalf("requests = %v, want %v", server.requests, want)
+	}
+}
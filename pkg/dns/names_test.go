@@ -0,0 +1,142 @@
+package dns
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"ip-updater/internal/config"
+)
+
+// providerListings are canned list responses of each provider, keyed by
+// path (or by Action for the RPC style APIs). www and the apex already hold
+// 203.0.113.7, each named the way the provider's API returns them.
+var providerListings = map[string]map[string]string{
+	"aliyun": {
+		"Action=DescribeDomainRecords": `{"RequestId":"req","TotalCount":2,"DomainRecords":{"Record":[
+			{"RR":"www","Type":"A","Value":"203.0.113.7","TTL":600,"RecordId":"1"},
+			{"RR":"@","Type":"A","Value":"203.0.113.7","TTL":600,"RecordId":"2"}]}}`,
+	},
+	"tencent": {
+		"Action=DescribeRecordList": `{"Response":{"RecordCountInfo":{"TotalCount":2},"RecordList":[
+			{"RecordId":1,"Name":"www","Type":"A","Value":"203.0.113.7","TTL":600},
+			{"RecordId":2,"Name":"@","Type":"A","Value":"203.0.113.7","TTL":600}]}}`,
+	},
+	"huawei": {
+		"/v2/zones": `{"zones":[{"id":"zone1","name":"example.com."}]}`,
+		"/v2/zones/zone1/recordsets": `{"metadata":{"total_count":2},"recordsets":[
+			{"id":"1","name":"www.example.com.","type":"A","records":["203.0.113.7"],"ttl":600},
+			{"id":"2","name":"example.com.","type":"A","records":["203.0.113.7"],"ttl":600}]}`,
+	},
+	"cloudflare": {
+		"/zones": `{"success":true,"result":[{"id":"zone1","name":"example.com"}]}`,
+		"/zones/zone1/dns_records": `{"success":true,"result_info":{"page":1,"per_page":100,"count":2,"total_count":2,"total_pages":1},"result":[
+			{"id":"1","type":"A","name":"www.example.com","content":"203.0.113.7","ttl":600},
+			{"id":"2","type":"A","name":"example.com","content":"203.0.113.7","ttl":600}]}`,
+	},
+	"godaddy": {
+		"/domains/example.com/records": `[
+			{"data":"203.0.113.7","name":"www","ttl":600,"type":"A"},
+			{"data":"203.0.113.7","name":"@","ttl":600,"type":"A"}]`,
+	},
+	"linode": {
+		"/domains": `{"data":[{"id":42,"domain":"example.com"}],"page":1,"pages":1}`,
+		"/domains/42/records": `{"page":1,"pages":1,"data":[
+			{"id":1,"type":"A","name":"www","target":"203.0.113.7","ttl_sec":600},
+			{"id":2,"type":"A","name":"","target":"203.0.113.7","ttl_sec":600}]}`,
+	},
+	"vultr": {
+		"/domains/example.com/records": `{"meta":{"total":2,"links":{"next":""}},"records":[
+			{"id":"1","type":"A","name":"www","data":"203.0.113.7","ttl":600},
+			{"id":"2","type":"A","name":"","data":"203.0.113.7","ttl":600}]}`,
+	},
+	"desec": {
+		"/domains/example.com/rrsets/": `[
+			{"subname":"www","type":"A","ttl":600,"records":["203.0.113.7"]},
+			{"subname":"","type":"A","ttl":600,"records":["203.0.113.7"]}]`,
+	},
+	"gandi": {
+		"/domains/example.com/records": `[
+			{"rrset_name":"www","rrset_type":"A","rrset_ttl":600,"rrset_values":["203.0.113.7"]},
+			{"rrset_name":"@","rrset_type":"A","rrset_ttl":600,"rrset_values":["203.0.113.7"]}]`,
+	},
+	"namecom": {
+		"/domains/example.com/records": `{"records":[
+			{"id":1,"host":"www","fqdn":"www.example.com.","type":"A","answer":"203.0.113.7","ttl":600},
+			{"id":2,"host":"","fqdn":"example.com.","type":"A","answer":"203.0.113.7","ttl":600}]}`,
+	},
+	"dynu": {
+		"/dns":          `{"domains":[{"id":5,"name":"example.com","ipv4Address":"203.0.113.7","ttl":600}]}`,
+		"/dns/5/record": `{"dnsRecords":[{"id":1,"nodeName":"www","recordType":"A","ttl":600,"state":true,"ipv4Address":"203.0.113.7"}]}`,
+	},
+	"bunny": {
+		"/dnszone": `{"Items":[{"Id":77,"Domain":"example.com"}],"CurrentPage":1,"TotalItems":1,"HasMoreItems":false}`,
+		"/dnszone/77": `{"Id":77,"Domain":"example.com","Records":[
+			{"Id":1,"Type":0,"Name":"www","Value":"203.0.113.7","Ttl":600},
+			{"Id":2,"Type":0,"Name":"","Value":"203.0.113.7","Ttl":600}]}`,
+	},
+}
+
+// newListingServer answers the listing reads and records every other
+// request, which would be a write
+func newListingServer(t *testing.T, listing map[string]string) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var writes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		key := r.URL.Path
+		if action := r.Form.Get("Action"); action != "" {
+			key = "Action=" + action
+		} else if r.Method != "GET" {
+			key = ""
+		}
+		if body, ok := listing[key]; ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+			return
+		}
+		mu.Lock()
+		writes = append(writes, r.Method+" "+r.URL.Path+" "+r.Form.Get("Action"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), writes...)
+	}
+}
+
+// A configured "www" and "@" have to match the records each provider's
+// GetRecords returns, so records already holding the address are left alone
+func TestConfiguredNamesMatchProviderRecords(t *testing.T) {
+	for provider, listing := range providerListings {
+		t.Run(provider, func(t *testing.T) {
+			server, writes := newListingServer(t, listing)
+			dm := NewDNSManager()
+			dm.InitializeProviders()
+			updater := config.DNSUpdater{
+				Name:        provider,
+				Provider:    provider,
+				AccessKey:   "key",
+				SecretKey:   "secret",
+				Token:       "token",
+				Domain:      "example.com",
+				ExtraConfig: map[string]string{SettingEndpoint: server.URL},
+				Records: []config.DNSRecord{
+					{Name: "www", Type: "A", TTL: 600},
+					{Name: "@", Type: "A", TTL: 600},
+				},
+			}
+
+			if err := dm.UpdateDNSRecordAddresses(updater, "203.0.113.7", ""); err != nil {
+				t.Fatal(err)
+			}
+			if w := writes(); len(w) != 0 {
+				t.Fatalf("requests %q, want www and @ matched as current", w)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package dns
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NullDNSProvider touches no real DNS service: it logs each call, keeps the
+// records it was asked to write in memory and records the calls, so a config
+// and the update/retry/notify paths can be exercised without credentials.
+// Registered as "null" and "mock".
+//
+// extra_config:
+//
+//	latency    = "500ms"  # delay before every call (Go duration)
+//	fail_rate  = "0.3"    # probability (0-1) that an update fails
+//	fail_first = "2"      # the first N updates fail, e.g. to exercise retries
+type NullDNSProvider struct {
+	name   string
+	logger Logger
+
+	mu        sync.Mutex
+	records   map[string]map[string]DNSRecord // domain -> "name/type" -> record
+	calls     []NullCall
+	updates   int
+	latency   time.Duration
+	failRate  float64
+	failFirst int
+}
+
+// NullCall is one call made to a NullDNSProvider
+type NullCall struct {
+	Time   time.Time
+	Method string
+	Domain string
+	Name   string
+	Type   string
+	Value  string
+	TTL    int
+	Err    error
+}
+
+func NewNullProvider(name string) *NullDNSProvider {
+	return &NullDNSProvider{
+		name:    name,
+		records: make(map[string]map[string]DNSRecord),
+	}
+}
+
+func (p *NullDNSProvider) GetProviderName() string {
+	return p.name
+}
+
+// SetCredentials accepts anything; there is nothing to authenticate against
+func (p *NullDNSProvider) SetCredentials(accessKey, secretKey string) {
+}
+
+func (p *NullDNSProvider) SetLogger(logger Logger) {
+	p.logger = logger
+}
+
+// Configure reads the simulated latency and failures from extra_config.
+// Invalid values are logged and ignored.
+func (p *NullDNSProvider) Configure(settings ProviderSettings) error {
+	extra := settings.Extra
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.latency = 0
+	p.failRate = 0
+	p.failFirst = 0
+
+	if v, ok := extra["latency"]; ok {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			p.latency = d
+		} else {
+			p.warnf("⚠️ %s: 无效的 latency %q，已忽略", p.name, v)
+		}
+	}
+	if v, ok := extra["fail_rate"]; ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+			p.failRate = f
+		} else {
+			p.warnf("⚠️ %s: 无效的 fail_rate %q (应为0-1)，已忽略", p.name, v)
+		}
+	}
+	if v, ok := extra["fail_first"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			p.failFirst = n
+		} else {
+			p.warnf("⚠️ %s: 无效的 fail_first %q，已忽略", p.name, v)
+		}
+	}
+	return nil
+}
+
+func (p *NullDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
+	p.sleep()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var records []DNSRecord
+	for _, record := range p.records[domain] {
+		records = append(records, record)
+	}
+	p.calls = append(p.calls, NullCall{Time: time.Now(), Method: "GetRecords", Domain: domain})
+	return records, nil
+}
+
+func (p *NullDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
+	p.sleep()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.updates++
+	var err error
+	if p.updates <= p.failFirst {
+		err = fmt.Errorf("%w: simulated failure %d/%d (fail_first)", ErrUpdateFailed, p.updates, p.failFirst)
+	} else if p.failRate > 0 && rand.Float64() < p.failRate {
+		err = fmt.Errorf("%w: simulated failure (fail_rate)", ErrUpdateFailed)
+	}
+
+	p.calls = append(p.calls, NullCall{
+		Time:   time.Now(),
+		Method: "UpdateRecord",
+		Domain: domain,
+		Name:   recordName,
+		Type:   recordType,
+		Value:  newIP,
+		TTL:    ttl,
+		Err:    err,
+	})
+
+	if err != nil {
+		p.warnf("🧪 %s: 模拟更新失败 %s.%s (%s): %v", p.name, recordName, domain, recordType, err)
+		return err
+	}
+
+	if p.records[domain] == nil {
+		p.records[domain] = make(map[string]DNSRecord)
+	}
+	p.records[domain][recordName+"/"+recordType] = DNSRecord{Name: recordName, Type: recordType, Value: newIP, TTL: ttl}
+	if p.logger != nil {
+		p.logger.Infof("🧪 %s: 将更新 %s.%s (%s) -> %s, TTL %d（未调用任何DNS服务）", p.name, recordName, domain, recordType, newIP, ttl)
+	}
+	return nil
+}
+
+// Calls returns the calls made so far, oldest first
+func (p *NullDNSProvider) Calls() []NullCall {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]NullCall(nil), p.calls...)
+}
+
+// Reset forgets the recorded calls and records
+func (p *NullDNSProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = nil
+	p.updates = 0
+	p.records = make(map[string]map[string]DNSRecord)
+}
+
+func (p *NullDNSProvider) sleep() {
+	p.mu.Lock()
+	latency := p.latency
+	p.mu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+}
+
+func (p *NullDNSProvider) warnf(format string, args ...interface{}) {
+	if p.logger != nil {
+		p.logger.Warnf(format, args...)
+	}
+}
@@ -2,36 +2,49 @@ package dns
 
 import (
 	"errors"
+
+	"ip-updater/internal/config"
 )
 
 // Factory function to create providers with credentials
 func CreateProvider(providerName, accessKey, secretKey, token string) (Provider, error) {
+	var provider Provider
 	switch providerName {
 	case "aliyun":
-		provider := NewAliyunProvider()
-		provider.SetCredentials(accessKey, secretKey)
-		return provider, nil
+		provider = NewAliyunProvider()
 	case "tencent":
-		provider := NewTencentProvider()
-		provider.SetCredentials(accessKey, secretKey)
-		return provider, nil
+		provider = NewTencentProvider()
 	case "huawei":
-		provider := NewHuaweiProvider()
-		provider.SetCredentials(accessKey, secretKey)
-		return provider, nil
+		provider = NewHuaweiProvider()
 	case "cloudflare":
-		provider := NewCloudflareProvider()
-		if token != "" {
-			provider.SetCredentials(token, "")
-		} else {
-			provider.SetCredentials(accessKey, secretKey)
-		}
-		return provider, nil
+		provider = NewCloudflareProvider()
 	case "godaddy":
-		provider := NewGoDaddyProvider()
-		provider.SetCredentials(accessKey, secretKey)
-		return provider, nil
+		provider = NewGoDaddyProvider()
+	case "linode":
+		provider = NewLinodeProvider()
+	case "vultr":
+		provider = NewVultrProvider()
+	case "desec":
+		provider = NewDesecProvider()
+	case "gandi":
+		provider = NewGandiProvider()
+	case "namecom":
+		provider = NewNameComProvider()
+	case "dynu":
+		provider = NewDynuProvider()
+	case "bunny":
+		provider = NewBunnyProvider()
+	case "null", "mock":
+		provider = NewNullProvider(providerName)
 	default:
 		return nil, errors.New("unsupported DNS provider: " + providerName)
 	}
+
+	ApplyCredentials(provider, config.DNSUpdater{
+		Provider:  providerName,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Token:     token,
+	})
+	return provider, nil
 }
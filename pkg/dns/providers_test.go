@@ -0,0 +1,28 @@
+package dns
+
+import "testing"
+
+func TestCreateProviderCredentials(t *testing.T) {
+	// Token providers prefer the token and fall back to the key pair
+	provider, err := CreateProvider("cloudflare", "key", "secret", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := provider.(*CloudflareDNSProvider).apiToken; got != "token" {
+		t.Errorf("cloudflare token = %q, want token", got)
+	}
+	provider, _ = CreateProvider("cloudflare", "key", "secret", "")
+	if got := provider.(*CloudflareDNSProvider).apiToken; got != "key" {
+		t.Errorf("cloudflare token = %q, want the access key", got)
+	}
+
+	// Key pair providers ignore the token
+	provider, _ = CreateProvider("aliyun", "key", "secret", "token")
+	if p := provider.(*AliyunProvider); p.accessKey != "key" || p.secretKey != "secret" {
+		t.Errorf("aliyun credentials = %q/%q, want key/secret", p.accessKey, p.secretKey)
+	}
+
+	if _, err := CreateProvider("unknown", "", "", ""); err == nil || err.Error() != "unsupported DNS provider: unknown" {
+		t.Errorf("err = %v, want unsupported DNS provider", err)
+	}
+}
@@ -0,0 +1,131 @@
+package dns
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ip-updater/internal/config"
+	"ip-updater/internal/netutil"
+)
+
+// defaultProviderTimeout is the HTTP timeout of provider API requests
+const defaultProviderTimeout = 30 * time.Second
+
+// extra_config keys read into ProviderSettings. config declares the keys
+// each provider recognizes (providerExtraKeys) to warn about typos; keep it
+// in sync when a provider reads a new key.
+const (
+	SettingEndpoint  = "endpoint"
+	SettingTimeout   = "timeout"
+	SettingUserAgent = "user_agent"
+	SettingProxy     = "proxy"
+	SettingRegion    = "region"
+)
+
+// ProviderSettings are the connection options every provider understands.
+// One provider instance serves all updaters using it, so Configure is called
+// with the updater's settings before each use; zero values mean the
+// provider's defaults.
+type ProviderSettings struct {
+	Endpoint  string        // API base URL, e.g. a mock server or a regional endpoint
+	Timeout   time.Duration // HTTP timeout, default 30s
+	UserAgent string        // sent instead of Go's default
+	Proxy     *url.URL      // http, https or socks5 proxy; nil uses HTTPS_PROXY etc.
+	Region    string        // provider region (tencent, huawei)
+
+	// Extra is the updater's whole extra_config, for provider-specific keys
+	Extra map[string]string
+}
+
+// SettingsFromExtraConfig reads the common keys of an updater's extra_config
+func SettingsFromExtraConfig(extra map[string]string) (ProviderSettings, error) {
+	settings := ProviderSettings{
+		Endpoint:  strings.TrimRight(extra[SettingEndpoint], "/"),
+		UserAgent: extra[SettingUserAgent],
+		Region:    extra[SettingRegion],
+		Extra:     extra,
+	}
+
+	if settings.Endpoint != "" {
+		if u, err := url.Parse(settings.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return settings, fmt.Errorf("invalid %s: %s", SettingEndpoint, settings.Endpoint)
+		}
+	}
+
+	if value := extra[SettingTimeout]; value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return settings, fmt.Errorf("invalid %s: %s (seconds)", SettingTimeout, value)
+		}
+		settings.Timeout = time.Duration(seconds) * time.Second
+	}
+
+	if value := extra[SettingProxy]; value != "" {
+		proxy, err := url.Parse(value)
+		if err != nil || proxy.Host == "" {
+			return settings, fmt.Errorf("invalid %s: %s", SettingProxy, value)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return settings, fmt.Errorf("invalid %s: %s (supported: http, https, socks5)", SettingProxy, value)
+		}
+		settings.Proxy = proxy
+	}
+
+	return settings, nil
+}
+
+// ApplySettings configures the provider with the updater's extra_config
+func ApplySettings(provider Provider, updater config.DNSUpdater) error {
+	settings, err := SettingsFromExtraConfig(updater.ExtraConfig)
+	if err != nil {
+		return fmt.Errorf("DNS updater %s: extra_config: %w", updater.Name, err)
+	}
+	return provider.Configure(settings)
+}
+
+// endpointOr returns the endpoint override, or the provider's default
+func (s ProviderSettings) endpointOr(defaultEndpoint string) string {
+	if s.Endpoint != "" {
+		return s.Endpoint
+	}
+	return defaultEndpoint
+}
+
+// endpointHost returns the host of an endpoint URL, for request signatures
+// that cover the Host header
+func endpointHost(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return endpoint
+}
+
+// httpClient builds a provider's API client: counted in APIUsage, with the
+// configured timeout, user agent and proxy. Connections are pooled by the
+// shared transports, so building a client per use is cheap.
+func (s ProviderSettings) httpClient(provider string) *http.Client {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = defaultProviderTimeout
+	}
+
+	transport := &countingTransport{
+		provider:  provider,
+		base:      netutil.Transport(),
+		userAgent: s.UserAgent,
+	}
+	if s.Proxy != nil {
+		transport.base = netutil.ProxyTransport(s.Proxy)
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
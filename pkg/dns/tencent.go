@@ -14,10 +14,20 @@ import (
 	"time"
 )
 
+const (
+	tencentEndpoint = "https://dnspod.tencentcloudapi.com"
+	tencentRegion   = "ap-beijing"
+
+	// tencentPageSize is the number of records GetRecords asks for per
+	// request (the API allows up to 3000)
+	tencentPageSize = 1000
+)
+
 type TencentDNSProvider struct {
 	secretId  string
 	secretKey string
 	endpoint  string
+	region    string
 	client    *http.Client
 }
 
@@ -35,8 +45,11 @@ type TencentError struct {
 
 type TencentRecordList struct {
 	Response struct {
-		RecordList []TencentRecord `json:"RecordList"`
-		Error      *TencentError   `json:"Error"`
+		RecordList      []TencentRecord `json:"RecordList"`
+		RecordCountInfo struct {
+			TotalCount uint64 `json:"TotalCount"`
+		} `json:"RecordCountInfo"`
+		Error *TencentError `json:"Error"`
 	} `json:"Response"`
 }
 
@@ -47,31 +60,91 @@ type TencentRecord struct {
 	Value    string `json:"Value"`
 	TTL      uint64 `json:"TTL"`
 	Status   string `json:"Status"`
+	Remark   string `json:"Remark"`
 }
 
 func NewTencentProvider() *TencentDNSProvider {
 	return &TencentDNSProvider{
-		endpoint: "https://dnspod.tencentcloudapi.com",
+		endpoint: tencentEndpoint,
+		region:   tencentRegion,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("tencent"),
 		},
 	}
 }
 
+// GetRecords lists the domain's records a page at a time; DNSPod returns
+// names relative to the domain, with "@" for the apex
 func (p *TencentDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
-	// TODO: 待验证 - 腾讯云DNS记录获取功能需要验证和完善
-	return []DNSRecord{}, fmt.Errorf("腾讯云 GetRecords功能待验证 - 需要测试API调用")
+	var records []DNSRecord
+	for offset := 0; ; offset += tencentPageSize {
+		params := map[string]string{
+			"Action":  "DescribeRecordList",
+			"Version": "2021-03-23",
+			"Region":  p.region,
+			"Domain":  domain,
+			"Offset":  strconv.Itoa(offset),
+			"Limit":   strconv.Itoa(tencentPageSize),
+		}
+
+		body, err := p.makeRequest(params)
+		if err != nil {
+			// A domain without records is reported as an error
+			if strings.Contains(err.Error(), "ResourceNotFound.NoDataOfRecord") {
+				return records, nil
+			}
+			return nil, err
+		}
+
+		var page TencentRecordList
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %v", err)
+		}
+		for _, rec := range page.Response.RecordList {
+			records = append(records, DNSRecord{
+				Name:   rec.Name,
+				Type:   rec.Type,
+				Value:  rec.Value,
+				TTL:    int(rec.TTL),
+				Remark: rec.Remark,
+			})
+		}
+
+		total := int(page.Response.RecordCountInfo.TotalCount)
+		if len(page.Response.RecordList) < tencentPageSize || offset+len(page.Response.RecordList) >= total {
+			return records, nil
+		}
+	}
 }
 
 func (p *TencentDNSProvider) GetProviderName() string {
 	return "tencent"
 }
 
+// DefaultTTL is used for records configured without a TTL.
+// DNSPod 免费套餐的最小TTL为600秒
+func (p *TencentDNSProvider) DefaultTTL() int {
+	return 600
+}
+
 func (p *TencentDNSProvider) SetCredentials(accessKey, secretKey string) {
 	p.secretId = accessKey
 	p.secretKey = secretKey
 }
 
+// Configure applies the endpoint override, timeout, user agent and proxy,
+// and the API region (default ap-beijing)
+func (p *TencentDNSProvider) Configure(settings ProviderSettings) error {
+	p.endpoint = settings.endpointOr(tencentEndpoint)
+	p.region = tencentRegion
+	if settings.Region != "" {
+		p.region = settings.Region
+	}
+	p.client = settings.httpClient("tencent")
+	return nil
+}
+
 func (p *TencentDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
 	recordId, err := p.getRecordId(domain, recordName, recordType)
 	if err != nil {
@@ -81,7 +154,7 @@ func (p *TencentDNSProvider) UpdateRecord(domain, recordName, recordType, newIP
 	params := map[string]string{
 		"Action":     "ModifyRecord",
 		"Version":    "2021-03-23",
-		"Region":     "ap-beijing",
+		"Region":     p.region,
 		"Domain":     domain,
 		"RecordId":   strconv.FormatUint(recordId, 10),
 		"SubDomain":  recordName,
@@ -95,11 +168,31 @@ func (p *TencentDNSProvider) UpdateRecord(domain, recordName, recordType, newIP
 	return err
 }
 
+// SetRecordRemark sets the remark of an existing record
+func (p *TencentDNSProvider) SetRecordRemark(domain, recordName, recordType, remark string) error {
+	recordId, err := p.getRecordId(domain, recordName, recordType)
+	if err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"Action":   "ModifyRecordRemark",
+		"Version":  "2021-03-23",
+		"Region":   p.region,
+		"Domain":   domain,
+		"RecordId": strconv.FormatUint(recordId, 10),
+		"Remark":   remark,
+	}
+
+	_, err = p.makeRequest(params)
+	return err
+}
+
 func (p *TencentDNSProvider) getRecordId(domain, recordName, recordType string) (uint64, error) {
 	params := map[string]string{
 		"Action":     "DescribeRecordList",
 		"Version":    "2021-03-23",
-		"Region":     "ap-beijing",
+		"Region":     p.region,
 		"Domain":     domain,
 		"Subdomain":  recordName,
 		"RecordType": recordType,
@@ -116,6 +209,9 @@ func (p *TencentDNSProvider) getRecordId(domain, recordName, recordType string)
 	}
 
 	if recordList.Response.Error != nil {
+		if err := clockSkewError("tencent", recordList.Response.Error.Code, recordList.Response.Error.Message); err != nil {
+			return 0, err
+		}
 		return 0, fmt.Errorf("tencent API error: %s - %s", recordList.Response.Error.Code, recordList.Response.Error.Message)
 	}
 
@@ -161,10 +257,16 @@ func (p *TencentDNSProvider) makeRequest(params map[string]string) ([]byte, erro
 
 	var tencentResp TencentResponse
 	if err := json.Unmarshal(body, &tencentResp); err != nil {
+		if resp.StatusCode >= 400 {
+			return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error: %d", resp.StatusCode))
+		}
 		return nil, fmt.Errorf("failed to parse response: %v", err)
 	}
 
 	if tencentResp.Response.Error != nil {
+		if err := clockSkewError("tencent", tencentResp.Response.Error.Code, tencentResp.Response.Error.Message); err != nil {
+			return nil, err
+		}
 		return nil, fmt.Errorf("tencent API error: %s - %s", tencentResp.Response.Error.Code, tencentResp.Response.Error.Message)
 	}
 
@@ -182,7 +284,7 @@ func (p *TencentDNSProvider) generateAuthorization(params map[string]string, tim
 	canonicalQueryString := ""
 
 	// Canonical headers
-	canonicalHeaders := fmt.Sprintf("content-type:%s\nhost:%s\n", "application/x-www-form-urlencoded; charset=utf-8", "dnspod.tencentcloudapi.com")
+	canonicalHeaders := fmt.Sprintf("content-type:%s\nhost:%s\n", "application/x-www-form-urlencoded; charset=utf-8", endpointHost(p.endpoint))
 	signedHeaders := "content-type;host"
 
 	// Request payload
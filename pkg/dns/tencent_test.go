@@ -0,0 +1,65 @@
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func newTestTencentProvider(t *testing.T, endpoint string) *TencentDNSProvider {
+	p := NewTencentProvider()
+	p.SetCredentials("id", "key")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestTencentGetRecordsPages(t *testing.T) {
+	var all []TencentRecord
+	for i := 0; i < tencentPageSize+5; i++ {
+		all = append(all, TencentRecord{RecordId: uint64(i), Name: fmt.Sprintf("host%d", i), Type: "A", Value: "192.0.2.1", TTL: 600})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("Action") != "DescribeRecordList" || r.Form.Get("Domain") != "example.com" {
+			t.Errorf("unexpected request %v", r.Form)
+		}
+		offset, _ := strconv.Atoi(r.Form.Get("Offset"))
+		limit, _ := strconv.Atoi(r.Form.Get("Limit"))
+		end := min(offset+limit, len(all))
+
+		var resp TencentRecordList
+		resp.Response.RecordList = all[min(offset, end):end]
+		resp.Response.RecordCountInfo.TotalCount = uint64(len(all))
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	records, err := newTestTencentProvider(t, server.URL).GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(all) {
+		t.Fatalf("got %d records, want %d", len(records), len(all))
+	}
+	if records[0] != (DNSRecord{Name: "host0", Type: "A", Value: "192.0.2.1", TTL: 600}) {
+		t.Fatalf("first record = %+v", records[0])
+	}
+}
+
+func TestTencentGetRecordsEmptyDomain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Response":{"Error":{"Code":"ResourceNotFound.NoDataOfRecord","Message":"记录列表为空"}}}`)
+	}))
+	defer server.Close()
+
+	records, err := newTestTencentProvider(t, server.URL).GetRecords("example.com")
+	if err != nil || len(records) != 0 {
+		t.Fatalf("GetRecords = %v, %v; want no records and no error", records, err)
+	}
+}
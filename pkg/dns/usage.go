@@ -0,0 +1,198 @@
+package dns
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"ip-updater/internal/netutil"
+)
+
+const defaultBudgetWarnPercent = 80
+
+// APIUsage counts the HTTP requests every provider sends to its API. It is
+// shared by all providers so the counts survive config reloads.
+var APIUsage = NewUsageCounter()
+
+// ProviderUsage is a snapshot of one provider's API call counters
+type ProviderUsage struct {
+	Total        int64 `json:"total"`
+	Cycle        int   `json:"cycle"`
+	LastHour     int   `json:"last_hour"`
+	HourlyBudget int   `json:"hourly_budget,omitempty"`
+}
+
+// BudgetWarning reports a provider whose calls in the last hour reached the
+// warning threshold of the configured budget
+type BudgetWarning struct {
+	Provider string
+	LastHour int
+	Budget   int
+}
+
+type providerUsage struct {
+	total    int64
+	cycle    int
+	recent   []time.Time
+	warnedAt time.Time
+}
+
+// UsageCounter keeps per-provider API call counts: a running total, the
+// calls of the current update cycle, and a sliding one-hour window used for
+// the budget warning. It is safe for concurrent use.
+type UsageCounter struct {
+	mu           sync.Mutex
+	providers    map[string]*providerUsage
+	hourlyBudget int
+	warnPercent  int
+	now          func() time.Time
+}
+
+func NewUsageCounter() *UsageCounter {
+	return &UsageCounter{
+		providers:   make(map[string]*providerUsage),
+		warnPercent: defaultBudgetWarnPercent,
+		now:         time.Now,
+	}
+}
+
+// SetHourlyBudget sets the per-provider hourly call budget (0 disables the
+// warning) and the percentage of it at which a warning is raised
+func (c *UsageCounter) SetHourlyBudget(budget, warnPercent int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if warnPercent <= 0 || warnPercent > 100 {
+		warnPercent = defaultBudgetWarnPercent
+	}
+	c.hourlyBudget = budget
+	c.warnPercent = warnPercent
+}
+
+func (c *UsageCounter) Record(provider string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	usage := c.usage(provider)
+	usage.total++
+	usage.cycle++
+	usage.recent = append(c.prune(usage.recent), c.now())
+}
+
+// BeginCycle resets the per-cycle counters before a round of updates
+func (c *UsageCounter) BeginCycle() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, usage := range c.providers {
+		usage.cycle = 0
+	}
+}
+
+// CycleCounts returns the calls made by each provider since BeginCycle,
+// leaving out providers that made none
+func (c *UsageCounter) CycleCounts() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[string]int)
+	for name, usage := range c.providers {
+		if usage.cycle > 0 {
+			counts[name] = usage.cycle
+		}
+	}
+	return counts
+}
+
+func (c *UsageCounter) Snapshot() map[string]ProviderUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := make(map[string]ProviderUsage, len(c.providers))
+	for name, usage := range c.providers {
+		usage.recent = c.prune(usage.recent)
+		snapshot[name] = ProviderUsage{
+			Total:        usage.total,
+			Cycle:        usage.cycle,
+			LastHour:     len(usage.recent),
+			HourlyBudget: c.hourlyBudget,
+		}
+	}
+	return snapshot
+}
+
+// BudgetWarnings returns the providers approaching the hourly budget. Each
+// provider is reported at most once per hour to avoid repeating the warning
+// every cycle.
+func (c *UsageCounter) BudgetWarnings() []BudgetWarning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hourlyBudget <= 0 {
+		return nil
+	}
+
+	now := c.now()
+	threshold := c.hourlyBudget * c.warnPercent / 100
+	var warnings []BudgetWarning
+	for name, usage := range c.providers {
+		usage.recent = c.prune(usage.recent)
+		if len(usage.recent) < threshold || now.Sub(usage.warnedAt) < time.Hour {
+			continue
+		}
+		usage.warnedAt = now
+		warnings = append(warnings, BudgetWarning{
+			Provider: name,
+			LastHour: len(usage.recent),
+			Budget:   c.hourlyBudget,
+		})
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].Provider < warnings[j].Provider
+	})
+	return warnings
+}
+
+func (c *UsageCounter) usage(provider string) *providerUsage {
+	usage, exists := c.providers[provider]
+	if !exists {
+		usage = &providerUsage{}
+		c.providers[provider] = usage
+	}
+	return usage
+}
+
+// prune drops timestamps that fell out of the one-hour window
+func (c *UsageCounter) prune(recent []time.Time) []time.Time {
+	cutoff := c.now().Add(-time.Hour)
+	i := 0
+	for i < len(recent) && !recent[i].After(cutoff) {
+		i++
+	}
+	return recent[i:]
+}
+
+// countingTransport records every request a provider's HTTP client sends
+type countingTransport struct {
+	provider  string
+	base      http.RoundTripper
+	userAgent string // replaces Go's default User-Agent when set
+}
+
+func newCountingTransport(provider string) http.RoundTripper {
+	return &countingTransport{
+		provider: provider,
+		base:     netutil.Transport(),
+	}
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	APIUsage.Record(t.provider)
+	if t.userAgent != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
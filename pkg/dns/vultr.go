@@ -0,0 +1,217 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const vultrPageSize = 500
+
+const vultrEndpoint = "https://api.vultr.com/v2"
+
+type VultrDNSProvider struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+type VultrRecord struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Data string `json:"data"`
+	TTL  int    `json:"ttl"`
+}
+
+type VultrRecordRequest struct {
+	Type string `json:"type,omitempty"`
+	Name string `json:"name"`
+	Data string `json:"data"`
+	TTL  int    `json:"ttl,omitempty"`
+}
+
+type VultrRecordList struct {
+	Records []VultrRecord `json:"records"`
+	Meta    struct {
+		Total int `json:"total"`
+		Links struct {
+			Next string `json:"next"`
+		} `json:"links"`
+	} `json:"meta"`
+}
+
+type VultrError struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
+func NewVultrProvider() *VultrDNSProvider {
+	return &VultrDNSProvider{
+		endpoint: vultrEndpoint,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: newCountingTransport("vultr"),
+		},
+	}
+}
+
+func (p *VultrDNSProvider) GetProviderName() string {
+	return "vultr"
+}
+
+// DefaultTTL is used for records configured without a TTL.
+// Vultr's default record TTL
+func (p *VultrDNSProvider) DefaultTTL() int {
+	return 300
+}
+
+func (p *VultrDNSProvider) SetCredentials(accessKey, secretKey string) {
+	p.apiKey = accessKey
+}
+
+// Configure applies the endpoint override, timeout, user agent and proxy
+func (p *VultrDNSProvider) Configure(settings ProviderSettings) error {
+	p.endpoint = settings.endpointOr(vultrEndpoint)
+	p.client = settings.httpClient("vultr")
+	return nil
+}
+
+func (p *VultrDNSProvider) GetRecords(domain string) ([]DNSRecord, error) {
+	vultrRecords, err := p.listRecords(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]DNSRecord, 0, len(vultrRecords))
+	for _, rec := range vultrRecords {
+		records = append(records, DNSRecord{
+			Name:  NormalizeRecordName(rec.Name, domain),
+			Type:  rec.Type,
+			Value: rec.Data,
+			TTL:   rec.TTL,
+		})
+	}
+
+	return records, nil
+}
+
+func (p *VultrDNSProvider) UpdateRecord(domain, recordName, recordType, newIP string, ttl int) error {
+	vultrRecords, err := p.listRecords(domain)
+	if err != nil {
+		return err
+	}
+
+	name := p.toVultrName(recordName)
+	for _, rec := range vultrRecords {
+		if rec.Type == recordType && NormalizeRecordName(rec.Name, domain) == NormalizeRecordName(recordName, domain) {
+			return p.patchRecord(domain, rec.ID, VultrRecordRequest{
+				Name: name,
+				Data: newIP,
+				TTL:  ttl,
+			})
+		}
+	}
+
+	// Record doesn't exist, create it
+	jsonData, err := json.Marshal(VultrRecordRequest{
+		Type: recordType,
+		Name: name,
+		Data: newIP,
+		TTL:  ttl,
+	})
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/domains/%s/records", url.PathEscape(domain))
+	_, err = p.makeRequest("POST", path, bytes.NewReader(jsonData))
+	return err
+}
+
+func (p *VultrDNSProvider) patchRecord(domain, recordId string, recordData VultrRecordRequest) error {
+	jsonData, err := json.Marshal(recordData)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/domains/%s/records/%s", url.PathEscape(domain), url.PathEscape(recordId))
+	_, err = p.makeRequest("PATCH", path, bytes.NewReader(jsonData))
+	return err
+}
+
+// listRecords follows the cursor-based pagination until all records are read
+func (p *VultrDNSProvider) listRecords(domain string) ([]VultrRecord, error) {
+	var records []VultrRecord
+	cursor := ""
+
+	for {
+		path := fmt.Sprintf("/domains/%s/records?per_page=%d", url.PathEscape(domain), vultrPageSize)
+		if cursor != "" {
+			path += "&cursor=" + url.QueryEscape(cursor)
+		}
+
+		body, err := p.makeRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var response VultrRecordList
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse records response: %v", err)
+		}
+		records = append(records, response.Records...)
+
+		cursor = response.Meta.Links.Next
+		if cursor == "" || len(response.Records) == 0 {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+// Vultr uses an empty name for the zone apex
+func (p *VultrDNSProvider) toVultrName(recordName string) string {
+	if recordName == "@" {
+		return ""
+	}
+	return recordName
+}
+
+func (p *VultrDNSProvider) makeRequest(method, path string, body io.Reader) ([]byte, error) {
+	fullURL := p.endpoint + path
+
+	req, err := http.NewRequest(method, fullURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		var vultrErr VultrError
+		if err := json.Unmarshal(respBody, &vultrErr); err == nil && vultrErr.Error != "" {
+			return nil, statusError(resp.StatusCode, fmt.Errorf("vultr API error: %s (status: %d)", vultrErr.Error, resp.StatusCode))
+		}
+		return nil, statusError(resp.StatusCode, fmt.Errorf("HTTP error: %d", resp.StatusCode))
+	}
+
+	return respBody, nil
+}
@@ -0,0 +1,119 @@
+package dns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// vultrServer is a mock Vultr API that serves the records of example.com
+// one per page, following the cursor
+type vultrServer struct {
+	records  []VultrRecord
+	requests []string
+}
+
+func newVultrServer(t *testing.T, records ...VultrRecord) (*vultrServer, *httptest.Server) {
+	s := &vultrServer{records: records}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/domains/example.com/records", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(VultrError{Error: "Invalid API token", Status: http.StatusUnauthorized})
+			return
+		}
+		if r.Method == "POST" {
+			var req VultrRecordRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			s.requests = append(s.requests, "POST "+req.Type+" "+req.Name+" "+req.Data)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		var list VultrRecordList
+		index := 0
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			index = int(cursor[0] - '0')
+		}
+		if index < len(s.records) {
+			list.Records = s.records[index : index+1]
+		}
+		if index+1 < len(s.records) {
+			list.Meta.Links.Next = string(rune('0' + index + 1))
+		}
+		json.NewEncoder(w).Encode(list)
+	})
+	mux.HandleFunc("/domains/example.com/records/", func(w http.ResponseWriter, r *http.Request) {
+		var req VultrRecordRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path+" "+req.Name+" "+req.Data)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func newTestVultrProvider(t *testing.T, endpoint, key string) *VultrDNSProvider {
+	p := NewVultrProvider()
+	p.SetCredentials(key, "")
+	if err := p.Configure(ProviderSettings{Endpoint: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestVultrGetRecordsFollowsCursor(t *testing.T) {
+	_, server := newVultrServer(t,
+		VultrRecord{ID: "a", Type: "A", Name: "", Data: "1.1.1.1", TTL: 300},
+		VultrRecord{ID: "b", Type: "A", Name: "www", Data: "2.2.2.2", TTL: 600},
+		VultrRecord{ID: "c", Type: "MX", Name: "", Data: "mail.example.com", TTL: 300},
+	)
+	p := newTestVultrProvider(t, server.URL, "key")
+
+	records, err := p.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DNSRecord{
+		{Name: "@", Type: "A", Value: "1.1.1.1", TTL: 300},
+		{Name: "www", Type: "A", Value: "2.2.2.2", TTL: 600},
+		{Name: "@", Type: "MX", Value: "mail.example.com", TTL: 300},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("GetRecords = %+v, want %+v", records, want)
+	}
+}
+
+func TestVultrUpdateRecord(t *testing.T) {
+	server, ts := newVultrServer(t,
+		VultrRecord{ID: "a", Type: "A", Name: "", Data: "1.1.1.1", TTL: 300},
+		VultrRecord{ID: "b", Type: "AAAA", Name: "www", Data: "2001:db8::1", TTL: 300},
+	)
+	p := newTestVultrProvider(t, ts.URL, "key")
+
+	if err := p.UpdateRecord("example.com", "@", "A", "3.3.3.3", 300); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UpdateRecord("example.com", "www", "A", "3.3.3.3", 300); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PATCH /domains/example.com/records/a  3.3.3.3", "POST A www 3.3.3.3"}
+	if !reflect.DeepEqual(server.requests, want) {
+		t.Fatalf("requests = %v, want %v", server.requests, want)
+	}
+}
+
+func TestVultrAPIError(t *testing.T) {
+	_, server := newVultrServer(t)
+	p := newTestVultrProvider(t, server.URL, "wrong")
+
+	_, err := p.GetRecords("example.com")
+	if err == nil || err.Error() != "vultr API error: Invalid API token (status: 401)" {
+		t.Fatalf("GetRecords error = %v, want the Vultr error message", err)
+	}
+}
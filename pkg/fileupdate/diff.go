@@ -0,0 +1,247 @@
+package fileupdate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// maxDiffEdits bounds the line diff; files differing in more lines than this
+// (e.g. a serializer reformatting a large file) are shown as replaced from
+// the first to the last differing line
+const maxDiffEdits = 2000
+
+// Preview returns the content of the file before and after UpdateIP(newIP)
+// without touching it: no backup is made and nothing is written. updated
+// equals old when the file already holds the address. A missing file (the
+// first render of a template) is previewed as empty.
+func (fu *FileUpdater) Preview(newIP string) (old, updated []byte, err error) {
+	old, err = fu.readFile()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	var written []byte
+	preview := *fu
+	preview.Backup = false
+	preview.VerifyWrite = false
+	preview.Logger = nil
+	preview.dryRun = &written
+	if err := preview.UpdateIP(newIP); err != nil {
+		return nil, nil, err
+	}
+	if written == nil {
+		return old, old, nil
+	}
+	return old, written, nil
+}
+
+// diffLine is one line of an edit script: ' ' kept, '-' removed, '+' added
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// UnifiedDiff formats the changes from old to updated as a unified diff
+// (diff -u) with name in both headers. It is empty when the contents are
+// equal.
+func UnifiedDiff(name string, old, updated []byte) string {
+	if bytes.Equal(old, updated) {
+		return ""
+	}
+	lines := diffLines(splitLines(old), splitLines(updated))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", name, name)
+
+	// Line numbers (0-based) at the start of lines[i]
+	oldLine, newLine := 0, 0
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// A hunk runs from diffContext lines before the change to
+		// diffContext lines after the last change that follows closely
+		start := max(i-diffContext, 0)
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].op != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end = min(end+diffContext, len(lines))
+
+		oldStart, newStart := oldLine-(i-start), newLine-(i-start)
+		oldCount, newCount := 0, 0
+		for _, line := range lines[start:end] {
+			if line.op != '+' {
+				oldCount++
+			}
+			if line.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, line := range lines[start:end] {
+			b.WriteByte(line.op)
+			b.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		for _, line := range lines[i:end] {
+			if line.op != '+' {
+				oldLine++
+			}
+			if line.op != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// hunkRange formats the line range of a hunk header: 1-based start and
+// count, where an empty range starts at the line before it
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits data after every newline; the last line has no newline
+// when the file doesn't end with one
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n') + 1
+		if i == 0 {
+			i = len(data)
+		}
+		lines = append(lines, string(data[:i]))
+		data = data[i:]
+	}
+	return lines
+}
+
+// diffLines returns the edit script from a to b. Common leading and trailing
+// lines are matched first; the rest is diffed with Myers' algorithm.
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	lines = append(lines, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return lines
+}
+
+// myers finds a shortest edit script from a to b. Past maxDiffEdits it gives
+// up and replaces all of a with all of b.
+func myers(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	// trace[d] holds v[-d..d] before step d, for the backtrack
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= offset && d <= maxDiffEdits && !found; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		lines := make([]diffLine, 0, n+m)
+		for _, text := range a {
+			lines = append(lines, diffLine{'-', text})
+		}
+		for _, text := range b {
+			lines = append(lines, diffLine{'+', text})
+		}
+		return lines
+	}
+
+	// Walk back from (n, m), collecting the script in reverse
+	var reversed []diffLine
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d] // v[-d..d] after step d-1, index k+d
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && prev[k-1+d] < prev[k+1+d]) {
+			prevK = k + 1
+		}
+		prevX := prev[prevK+d]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffLine{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, diffLine{'+', b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, diffLine{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, diffLine{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	lines := make([]diffLine, len(reversed))
+	for i, line := range reversed {
+		lines[len(reversed)-1-i] = line
+	}
+	return lines
+}
@@ -1,14 +1,19 @@
 package fileupdate
 
 import (
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
+
+	"ip-updater/internal/netutil"
 
 	"github.com/BurntSushi/toml"
 	"gopkg.in/ini.v1"
@@ -21,6 +26,37 @@ type FileUpdater struct {
 	KeyPath  string
 	Backup   bool
 	Logger   Logger
+
+	// VerifyWrite re-reads the file after the rename and fails the update
+	// if it doesn't contain the value just written
+	VerifyWrite bool
+
+	// BackupDir puts backups in a separate directory instead of next to
+	// the file; BackupTimestamp keeps one backup per update there, of which
+	// BackupKeep limits how many are kept (all when 0)
+	BackupDir       string
+	BackupTimestamp bool
+	BackupKeep      int
+
+	// KeySeparator separates the keys of KeyPath (DefaultKeySeparator when
+	// empty)
+	KeySeparator string
+
+	// ListDelimiter switches to list mode: the key holds a delimited list
+	// and only the entry in ManagedValue is replaced (see list.go)
+	ListDelimiter string
+	ManagedValue  string
+
+	// MaxFileSize is the size in bytes above which the target file is
+	// reported (DefaultMaxFileSize when 0, no limit when negative).
+	// RefuseOversize fails instead of warning. See size.go.
+	MaxFileSize    int64
+	RefuseOversize bool
+	sizeWarned     bool
+
+	// dryRun is set by Preview: the content is kept here instead of being
+	// written to the file
+	dryRun *[]byte
 }
 
 type Logger interface {
@@ -42,6 +78,19 @@ func (fu *FileUpdater) SetLogger(logger Logger) {
 }
 
 func (fu *FileUpdater) UpdateIP(newIP string) error {
+	if err := netutil.CheckUsableAddress(newIP); err != nil {
+		return err
+	}
+
+	// Templates render a whole file and have no single key to compare
+	if strings.ToLower(fu.Format) == "template" {
+		return fu.updateTemplate(newIP)
+	}
+
+	if fu.ListDelimiter != "" {
+		return fu.updateList(newIP)
+	}
+
 	if fu.Logger != nil {
 		fu.Logger.Infof("📁 文件更新开始 - 文件: %s, 格式: %s, 键路径: %s", fu.FilePath, fu.Format, fu.KeyPath)
 	}
@@ -80,20 +129,7 @@ func (fu *FileUpdater) UpdateIP(newIP string) error {
 		}
 	}
 
-	var updateErr error
-	switch strings.ToLower(fu.Format) {
-	case "json":
-		updateErr = fu.updateJSON(newIP)
-	case "yaml", "yml":
-		updateErr = fu.updateYAML(newIP)
-	case "toml":
-		updateErr = fu.updateTOML(newIP)
-	case "ini":
-		updateErr = fu.updateINI(newIP)
-	default:
-		updateErr = fmt.Errorf("unsupported file format: %s", fu.Format)
-	}
-
+	updateErr := fu.writeValue(newIP)
 	if updateErr != nil {
 		if fu.Logger != nil {
 			fu.Logger.Warnf("❌ 文件更新失败: %s:%s: %v", fu.FilePath, fu.KeyPath, updateErr)
@@ -101,6 +137,12 @@ func (fu *FileUpdater) UpdateIP(newIP string) error {
 		return updateErr
 	}
 
+	if fu.VerifyWrite {
+		if err := fu.verifyValue(newIP); err != nil {
+			return err
+		}
+	}
+
 	if fu.Logger != nil {
 		fu.Logger.Infof("✅ 文件更新成功: %s:%s = '%s'", fu.FilePath, fu.KeyPath, newIP)
 	}
@@ -108,8 +150,65 @@ func (fu *FileUpdater) UpdateIP(newIP string) error {
 	return nil
 }
 
+// verifyValue reads the key back after a write and reports a mismatch, so a
+// write that silently didn't take effect becomes an error
+func (fu *FileUpdater) verifyValue(expected string) error {
+	actual, err := fu.GetCurrentValue()
+	if err != nil {
+		if fu.Logger != nil {
+			fu.Logger.Warnf("❌ 写入校验失败，无法读回文件键值 %s:%s: %v", fu.FilePath, fu.KeyPath, err)
+		}
+		return fmt.Errorf("write verification failed: %w", err)
+	}
+
+	if actual != expected {
+		if fu.Logger != nil {
+			fu.Logger.Warnf("❌ 写入校验失败: %s:%s 期望 '%s'，读回 '%s'", fu.FilePath, fu.KeyPath, expected, actual)
+		}
+		return fmt.Errorf("write verification failed: %s:%s is '%s', expected '%s'", fu.FilePath, fu.KeyPath, actual, expected)
+	}
+
+	if fu.Logger != nil {
+		fu.Logger.Infof("🔍 写入校验通过: %s:%s = '%s'", fu.FilePath, fu.KeyPath, actual)
+	}
+	return nil
+}
+
+// backupPath returns where the backup of the file goes: next to it by
+// default, or in BackupDir under backupPrefix (plus a timestamp when
+// BackupTimestamp is set)
+func (fu *FileUpdater) backupPath() string {
+	if fu.BackupDir == "" {
+		return fu.FilePath + ".backup"
+	}
+
+	name := fu.backupPrefix()
+	if fu.BackupTimestamp {
+		name += "." + time.Now().Format(backupTimeFormat)
+	}
+	return filepath.Join(fu.BackupDir, name+".backup")
+}
+
+const backupTimeFormat = "20060102-150405"
+
+// backupPrefix is the file name plus a short hash of its absolute path, so
+// files with the same name sharing a BackupDir don't overwrite each other
+func (fu *FileUpdater) backupPrefix() string {
+	path, err := filepath.Abs(fu.FilePath)
+	if err != nil {
+		path = fu.FilePath
+	}
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Base(fu.FilePath) + "." + hex.EncodeToString(sum[:4])
+}
+
 func (fu *FileUpdater) createBackup() error {
-	backupPath := fu.FilePath + ".backup"
+	backupPath := fu.backupPath()
+	if fu.BackupDir != "" {
+		if err := os.MkdirAll(fu.BackupDir, 0755); err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+	}
 
 	src, err := os.Open(fu.FilePath)
 	if err != nil {
@@ -123,27 +222,79 @@ func (fu *FileUpdater) createBackup() error {
 	}
 	defer dst.Close()
 
-	_, err = io.Copy(dst, src)
-	return err
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+	return fu.pruneBackups()
 }
 
-func (fu *FileUpdater) updateJSON(newIP string) error {
-	// Read and prepare data
-	data, err := os.ReadFile(fu.FilePath)
+// pruneBackups removes the oldest timestamped backups of the file beyond
+// BackupKeep
+func (fu *FileUpdater) pruneBackups() error {
+	if fu.BackupDir == "" || !fu.BackupTimestamp || fu.BackupKeep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(fu.BackupDir)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to list backups: %w", err)
 	}
 
-	var jsonData map[string]interface{}
-	if err := json.Unmarshal(data, &jsonData); err != nil {
-		return err
+	// The timestamps sort in time order, and so do the names
+	prefix := fu.backupPrefix() + "."
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".backup")
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".backup") || len(stamp) != len(backupTimeFormat) {
+			continue
+		}
+		backups = append(backups, name)
+	}
+	sort.Strings(backups)
+
+	for len(backups) > fu.BackupKeep {
+		if err := os.Remove(filepath.Join(fu.BackupDir, backups[0])); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		if fu.Logger != nil {
+			fu.Logger.Infof("🗑️ 删除旧备份: %s", backups[0])
+		}
+		backups = backups[1:]
 	}
+	return nil
+}
 
-	if err := fu.setNestedValue(jsonData, fu.KeyPath, newIP); err != nil {
+// writeValue sets the key to value using the updater's format
+func (fu *FileUpdater) writeValue(value string) error {
+	switch strings.ToLower(fu.Format) {
+	case "json":
+		return fu.updateJSON(value)
+	case "yaml", "yml":
+		return fu.updateYAML(value)
+	case "toml":
+		return fu.updateTOML(value)
+	case "ini":
+		return fu.updateINI(value)
+	case "plist":
+		return fu.updatePlist(value)
+	case "hosts":
+		return fu.updateHosts(value)
+	default:
+		return fmt.Errorf("unsupported file format: %s", fu.Format)
+	}
+}
+
+func (fu *FileUpdater) updateJSON(newIP string) error {
+	// Read and prepare data
+	data, err := fu.readFile()
+	if err != nil {
 		return err
 	}
 
-	updatedData, err := json.MarshalIndent(jsonData, "", "  ")
+	// Only the target value is rewritten; key order, formatting and JSONC
+	// comments are left as they are
+	updatedData, err := setJSONValue(data, fu.KeyPath, fu.KeySeparator, newIP)
 	if err != nil {
 		return err
 	}
@@ -154,7 +305,7 @@ func (fu *FileUpdater) updateJSON(newIP string) error {
 
 func (fu *FileUpdater) updateYAML(newIP string) error {
 	// Read and prepare data
-	data, err := os.ReadFile(fu.FilePath)
+	data, err := fu.readFile()
 	if err != nil {
 		return err
 	}
@@ -179,8 +330,13 @@ func (fu *FileUpdater) updateYAML(newIP string) error {
 
 func (fu *FileUpdater) updateTOML(newIP string) error {
 	// Read and prepare data
+	data, err := fu.readFile()
+	if err != nil {
+		return err
+	}
+
 	var tomlData map[string]interface{}
-	if _, err := toml.DecodeFile(fu.FilePath, &tomlData); err != nil {
+	if _, err := toml.Decode(string(data), &tomlData); err != nil {
 		return err
 	}
 
@@ -200,19 +356,19 @@ func (fu *FileUpdater) updateTOML(newIP string) error {
 
 func (fu *FileUpdater) updateINI(newIP string) error {
 	// Read and prepare data
+	if err := fu.checkFileSize(); err != nil {
+		return err
+	}
 	cfg, err := ini.Load(fu.FilePath)
 	if err != nil {
 		return err
 	}
 
-	parts := strings.Split(fu.KeyPath, "/")
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid key path for INI format: %s (expected: section/key)", fu.KeyPath)
+	sectionName, keyName, ok := iniKeyPath(fu.KeyPath, fu.KeySeparator)
+	if !ok {
+		return fmt.Errorf("invalid key path for INI format: %s (expected: section/key, or key for the default section)", fu.KeyPath)
 	}
 
-	sectionName := parts[0]
-	keyName := parts[1]
-
 	section, err := cfg.GetSection(sectionName)
 	if err != nil {
 		// Create section if it doesn't exist
@@ -235,7 +391,7 @@ func (fu *FileUpdater) updateINI(newIP string) error {
 }
 
 func (fu *FileUpdater) setNestedValue(data map[string]interface{}, keyPath string, value interface{}) error {
-	keys := strings.Split(keyPath, "/")
+	keys := splitKeyPath(keyPath, fu.KeySeparator)
 
 	current := data
 	for i, key := range keys[:len(keys)-1] {
@@ -266,19 +422,23 @@ func (fu *FileUpdater) GetCurrentValue() (string, error) {
 		return fu.getCurrentValueTOML()
 	case "ini":
 		return fu.getCurrentValueINI()
+	case "plist":
+		return fu.getCurrentValuePlist()
+	case "hosts":
+		return fu.getCurrentValueHosts()
 	default:
 		return "", fmt.Errorf("unsupported file format: %s", fu.Format)
 	}
 }
 
 func (fu *FileUpdater) getCurrentValueJSON() (string, error) {
-	data, err := os.ReadFile(fu.FilePath)
+	data, err := fu.readFile()
 	if err != nil {
 		return "", err
 	}
 
 	var jsonData map[string]interface{}
-	if err := json.Unmarshal(data, &jsonData); err != nil {
+	if err := unmarshalJSONC(data, &jsonData); err != nil {
 		return "", err
 	}
 
@@ -294,13 +454,14 @@ func (fu *FileUpdater) getCurrentValueJSON() (string, error) {
 }
 
 func (fu *FileUpdater) getCurrentValueYAML() (string, error) {
-	data, err := os.ReadFile(fu.FilePath)
+	file, err := fu.openFile()
 	if err != nil {
 		return "", err
 	}
+	defer file.Close()
 
 	var yamlData map[string]interface{}
-	if err := yaml.Unmarshal(data, &yamlData); err != nil {
+	if err := yaml.NewDecoder(file).Decode(&yamlData); err != nil {
 		return "", err
 	}
 
@@ -316,8 +477,14 @@ func (fu *FileUpdater) getCurrentValueYAML() (string, error) {
 }
 
 func (fu *FileUpdater) getCurrentValueTOML() (string, error) {
+	file, err := fu.openFile()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
 	var tomlData map[string]interface{}
-	if _, err := toml.DecodeFile(fu.FilePath, &tomlData); err != nil {
+	if _, err := toml.NewDecoder(file).Decode(&tomlData); err != nil {
 		return "", err
 	}
 
@@ -333,19 +500,19 @@ func (fu *FileUpdater) getCurrentValueTOML() (string, error) {
 }
 
 func (fu *FileUpdater) getCurrentValueINI() (string, error) {
+	if err := fu.checkFileSize(); err != nil {
+		return "", err
+	}
 	cfg, err := ini.Load(fu.FilePath)
 	if err != nil {
 		return "", err
 	}
 
-	parts := strings.Split(fu.KeyPath, "/")
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid key path for INI format: %s (expected: section/key)", fu.KeyPath)
+	sectionName, keyName, ok := iniKeyPath(fu.KeyPath, fu.KeySeparator)
+	if !ok {
+		return "", fmt.Errorf("invalid key path for INI format: %s (expected: section/key, or key for the default section)", fu.KeyPath)
 	}
 
-	sectionName := parts[0]
-	keyName := parts[1]
-
 	section, err := cfg.GetSection(sectionName)
 	if err != nil {
 		return "", err
@@ -356,6 +523,11 @@ func (fu *FileUpdater) getCurrentValueINI() (string, error) {
 }
 
 func (fu *FileUpdater) atomicWrite(filePath string, data []byte) error {
+	if fu.dryRun != nil {
+		*fu.dryRun = data
+		return nil
+	}
+
 	// Create a temporary file in the same directory as the target file
 	// This ensures it's on the same filesystem for atomic rename
 	dir := filepath.Dir(filePath)
@@ -374,6 +546,13 @@ func (fu *FileUpdater) atomicWrite(filePath string, data []byte) error {
 		}
 	}()
 
+	// Keep the permissions of the file being replaced (CreateTemp uses 0600)
+	if info, err := os.Stat(filePath); err == nil {
+		if err := tempFile.Chmod(info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to set temp file mode: %w", err)
+		}
+	}
+
 	// Write data to temp file
 	if _, err := tempFile.Write(data); err != nil {
 		return fmt.Errorf("failed to write to temp file: %w", err)
@@ -444,7 +623,7 @@ func (fu *FileUpdater) processIPWithMask(currentValue, newIP string) string {
 }
 
 func (fu *FileUpdater) getNestedValue(data map[string]interface{}, keyPath string) (interface{}, error) {
-	keys := strings.Split(keyPath, "/")
+	keys := splitKeyPath(keyPath, fu.KeySeparator)
 
 	current := data
 	for i, key := range keys[:len(keys)-1] {
@@ -465,6 +644,10 @@ func (fu *FileUpdater) getNestedValue(data map[string]interface{}, keyPath strin
 }
 
 func (fu *FileUpdater) ValidateFile() error {
+	if strings.ToLower(fu.Format) == "template" {
+		return fu.validateTemplate()
+	}
+
 	// Check if file exists
 	if _, err := os.Stat(fu.FilePath); os.IsNotExist(err) {
 		return fmt.Errorf("file does not exist: %s", fu.FilePath)
@@ -480,23 +663,27 @@ func (fu *FileUpdater) ValidateFile() error {
 		return fu.validateTOML()
 	case "ini":
 		return fu.validateINI()
+	case "plist":
+		return fu.validatePlist()
+	case "hosts":
+		return fu.validateHosts()
 	default:
 		return fmt.Errorf("unsupported file format: %s", fu.Format)
 	}
 }
 
 func (fu *FileUpdater) validateJSON() error {
-	data, err := os.ReadFile(fu.FilePath)
+	data, err := fu.readFile()
 	if err != nil {
 		return err
 	}
 
 	var jsonData map[string]interface{}
-	return json.Unmarshal(data, &jsonData)
+	return unmarshalJSONC(data, &jsonData)
 }
 
 func (fu *FileUpdater) validateYAML() error {
-	data, err := os.ReadFile(fu.FilePath)
+	data, err := fu.readFile()
 	if err != nil {
 		return err
 	}
@@ -506,12 +693,20 @@ func (fu *FileUpdater) validateYAML() error {
 }
 
 func (fu *FileUpdater) validateTOML() error {
+	data, err := fu.readFile()
+	if err != nil {
+		return err
+	}
+
 	var tomlData map[string]interface{}
-	_, err := toml.DecodeFile(fu.FilePath, &tomlData)
+	_, err = toml.Decode(string(data), &tomlData)
 	return err
 }
 
 func (fu *FileUpdater) validateINI() error {
+	if err := fu.checkFileSize(); err != nil {
+		return err
+	}
 	_, err := ini.Load(fu.FilePath)
 	return err
 }
\ No newline at end of file
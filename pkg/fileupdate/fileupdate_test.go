@@ -0,0 +1,170 @@
+package fileupdate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"ip-updater/internal/netutil"
+)
+
+// writeTarget writes content to name in a temporary directory and returns
+// its path
+func writeTarget(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readTarget(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBackupNextToFile(t *testing.T) {
+	path := writeTarget(t, "app.json", `{"ip": "192.0.2.1"}`)
+
+	if err := New(path, "json", "ip", true).UpdateIP("192.0.2.2"); err != nil {
+		t.Fatal(err)
+	}
+	if backup := readTarget(t, path+".backup"); backup != `{"ip": "192.0.2.1"}` {
+		t.Fatalf("backup = %q, want the original content", backup)
+	}
+}
+
+func TestBackupDir(t *testing.T) {
+	path := writeTarget(t, "app.json", `{"ip": "192.0.2.1"}`)
+	backupDir := filepath.Join(t.TempDir(), "backups", "nested")
+
+	fu := New(path, "json", "ip", true)
+	fu.BackupDir = backupDir
+	if err := fu.UpdateIP("192.0.2.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if backup := readTarget(t, filepath.Join(backupDir, fu.backupPrefix()+".backup")); backup != `{"ip": "192.0.2.1"}` {
+		t.Fatalf("backup = %q, want the original content", backup)
+	}
+	if _, err := os.Stat(path + ".backup"); !os.IsNotExist(err) {
+		t.Fatalf("a backup was also written next to the file (err = %v)", err)
+	}
+}
+
+func TestBackupDirTimestamp(t *testing.T) {
+	path := writeTarget(t, "app.json", `{"ip": "192.0.2.1"}`)
+	backupDir := t.TempDir()
+
+	fu := New(path, "json", "ip", true)
+	fu.BackupDir = backupDir
+	fu.BackupTimestamp = true
+	if err := fu.UpdateIP("192.0.2.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("backup dir has %d entries, want 1", len(entries))
+	}
+	name := entries[0].Name()
+	if !strings.HasPrefix(name, fu.backupPrefix()+".") || !strings.HasSuffix(name, ".backup") || name == fu.backupPrefix()+".backup" {
+		t.Fatalf("backup name = %q, want app.json.<hash>.<timestamp>.backup", name)
+	}
+}
+
+func TestBackupDirSameFileName(t *testing.T) {
+	first := writeTarget(t, "app.json", `{"ip": "192.0.2.1"}`)
+	second := writeTarget(t, "app.json", `{"ip": "198.51.100.1"}`)
+	backupDir := t.TempDir()
+
+	for _, path := range []string{first, second} {
+		fu := New(path, "json", "ip", true)
+		fu.BackupDir = backupDir
+		if err := fu.UpdateIP("192.0.2.2"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, want := range map[string]string{first: `{"ip": "192.0.2.1"}`, second: `{"ip": "198.51.100.1"}`} {
+		fu := New(path, "json", "ip", true)
+		fu.BackupDir = backupDir
+		if backup := readTarget(t, fu.backupPath()); backup != want {
+			t.Errorf("backup of %s = %q, want %q", path, backup, want)
+		}
+	}
+}
+
+func TestBackupKeep(t *testing.T) {
+	path := writeTarget(t, "app.json", `{"ip": "192.0.2.1"}`)
+	backupDir := t.TempDir()
+
+	fu := New(path, "json", "ip", true)
+	fu.BackupDir = backupDir
+	fu.BackupTimestamp = true
+	fu.BackupKeep = 2
+
+	// Older backups of this file, and one of another file that isn't counted
+	old := []string{"20200101-000000", "20200102-000000", "20200103-000000"}
+	for _, stamp := range old {
+		writeBackup(t, filepath.Join(backupDir, fu.backupPrefix()+"."+stamp+".backup"))
+	}
+	other := filepath.Join(backupDir, "other.json.00000000.20200101-000000.backup")
+	writeBackup(t, other)
+
+	if err := fu.UpdateIP("192.0.2.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	want := []string{
+		"other.json.00000000.20200101-000000.backup",
+		fu.backupPrefix() + ".20200103-000000.backup",
+		filepath.Base(fu.backupPath()),
+	}
+	sort.Strings(names)
+	sort.Strings(want)
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("backups = %q, want %q", names, want)
+	}
+}
+
+func writeBackup(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateIPRefusesUnusableAddress(t *testing.T) {
+	path := writeTarget(t, "app.json", `{"ip": "192.0.2.1"}`)
+	fu := New(path, "json", "ip", false)
+
+	for _, ip := range []string{"", "0.0.0.0", "::"} {
+		if err := fu.UpdateIP(ip); !errors.Is(err, netutil.ErrUnusableAddress) {
+			t.Errorf("UpdateIP(%q) = %v, want ErrUnusableAddress", ip, err)
+		}
+	}
+	if got := readTarget(t, path); got != `{"ip": "192.0.2.1"}` {
+		t.Fatalf("file was rewritten: %s", got)
+	}
+}
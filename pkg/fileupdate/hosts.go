@@ -0,0 +1,111 @@
+package fileupdate
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// hosts(5) files: key_path is the hostname. Only the address of the first
+// IPv4 line naming the host is rewritten; comments, other lines, aliases on
+// the same line and IPv6 lines are left as they are. A missing entry is
+// appended.
+
+// hostsEntry is a line of a hosts file that names the host
+type hostsEntry struct {
+	line      int
+	addrStart int
+	addrEnd   int
+	address   string
+}
+
+// findHostsEntry returns the first IPv4 entry for host
+func findHostsEntry(lines [][]byte, host string) (hostsEntry, bool) {
+	for i, line := range lines {
+		content := line
+		if hash := bytes.IndexByte(content, '#'); hash >= 0 {
+			content = content[:hash]
+		}
+
+		fields := strings.Fields(string(content))
+		if len(fields) < 2 || strings.Contains(fields[0], ":") {
+			continue
+		}
+		for _, name := range fields[1:] {
+			if !strings.EqualFold(name, host) {
+				continue
+			}
+			start := bytes.Index(line, []byte(fields[0]))
+			return hostsEntry{
+				line:      i,
+				addrStart: start,
+				addrEnd:   start + len(fields[0]),
+				address:   fields[0],
+			}, true
+		}
+	}
+	return hostsEntry{}, false
+}
+
+func (fu *FileUpdater) hostsLines() ([][]byte, error) {
+	if strings.TrimSpace(fu.KeyPath) == "" || strings.ContainsAny(fu.KeyPath, " \t#") {
+		return nil, fmt.Errorf("invalid hostname for hosts format: %q", fu.KeyPath)
+	}
+
+	data, err := fu.readFile()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Split(data, []byte("\n")), nil
+}
+
+func (fu *FileUpdater) updateHosts(newIP string) error {
+	lines, err := fu.hostsLines()
+	if err != nil {
+		return err
+	}
+
+	if entry, ok := findHostsEntry(lines, fu.KeyPath); ok {
+		line := lines[entry.line]
+		updated := make([]byte, 0, len(line)+len(newIP))
+		updated = append(updated, line[:entry.addrStart]...)
+		updated = append(updated, newIP...)
+		updated = append(updated, line[entry.addrEnd:]...)
+		lines[entry.line] = updated
+	} else {
+		// Append, keeping the file's line endings and final newline
+		eol := ""
+		if len(lines) > 1 && bytes.HasSuffix(lines[0], []byte("\r")) {
+			eol = "\r"
+		}
+		newLine := []byte(newIP + "\t" + fu.KeyPath + eol)
+		if last := len(lines) - 1; len(bytes.TrimSpace(lines[last])) == 0 {
+			lines = append(lines[:last], newLine, []byte{})
+		} else {
+			lines = append(lines, newLine)
+		}
+		if fu.Logger != nil {
+			fu.Logger.Infof("➕ hosts文件中没有 %s 的条目，已追加: %s", fu.KeyPath, fu.FilePath)
+		}
+	}
+
+	return fu.atomicWrite(fu.FilePath, bytes.Join(lines, []byte("\n")))
+}
+
+func (fu *FileUpdater) getCurrentValueHosts() (string, error) {
+	lines, err := fu.hostsLines()
+	if err != nil {
+		return "", err
+	}
+
+	entry, ok := findHostsEntry(lines, fu.KeyPath)
+	if !ok {
+		return "", fmt.Errorf("no hosts entry for %s", fu.KeyPath)
+	}
+	return entry.address, nil
+}
+
+func (fu *FileUpdater) validateHosts() error {
+	_, err := fu.hostsLines()
+	return err
+}
@@ -0,0 +1,250 @@
+package fileupdate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON files are edited in place: only the bytes of the target value change,
+// so key order, indentation and JSONC comments elsewhere in the file are
+// kept as they were.
+
+// stripJSONComments blanks out // and /* */ comments and trailing commas
+// (JSONC) with spaces. Offsets stay the same, so positions found in the
+// result apply to the original data.
+func stripJSONComments(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	inString := false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(out)
+			} else {
+				end += i + 4
+			}
+			for ; i < end; i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			i--
+		case c == ',':
+			// A trailing comma is followed only by blanks and comments
+			// (already blanked as they're reached) before } or ]
+			if next := nextNonBlank(out, i+1); next < len(out) && (out[next] == '}' || out[next] == ']') {
+				out[i] = ' '
+			}
+		}
+	}
+	return out
+}
+
+// nextNonBlank returns the index of the next byte that isn't whitespace or
+// part of a comment
+func nextNonBlank(data []byte, i int) int {
+	for i < len(data) {
+		switch {
+		case data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r':
+			i++
+		case data[i] == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case data[i] == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return len(data)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// unmarshalJSONC decodes JSON that may contain comments and trailing commas
+func unmarshalJSONC(data []byte, v interface{}) error {
+	return json.Unmarshal(stripJSONComments(data), v)
+}
+
+// jsonLocation is where a key path ends in a JSON document: the value's
+// byte range when found, otherwise the closing brace of the deepest object
+// on the path and the keys missing below it
+type jsonLocation struct {
+	found      bool
+	start, end int
+
+	objectEnd int
+	missing   []string
+}
+
+// locateJSONValue walks the key path through the (comment-free) document
+func locateJSONValue(data []byte, keys []string) (jsonLocation, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return jsonLocation{}, err
+	} else if tok != json.Delim('{') {
+		return jsonLocation{}, fmt.Errorf("top level is not a JSON object")
+	}
+
+	for i := 0; i < len(keys); {
+		tok, err := dec.Token()
+		if err != nil {
+			return jsonLocation{}, err
+		}
+		if tok == json.Delim('}') {
+			return jsonLocation{objectEnd: int(dec.InputOffset()) - 1, missing: keys[i:]}, nil
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			return jsonLocation{}, fmt.Errorf("unexpected token %v", tok)
+		}
+		if key != keys[i] {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return jsonLocation{}, err
+			}
+			continue
+		}
+
+		if i == len(keys)-1 {
+			start := nextNonBlank(data, int(dec.InputOffset()))
+			if start < len(data) && data[start] == ':' {
+				start = nextNonBlank(data, start+1)
+			}
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return jsonLocation{}, err
+			}
+			return jsonLocation{found: true, start: start, end: int(dec.InputOffset())}, nil
+		}
+
+		tok, err = dec.Token()
+		if err != nil {
+			return jsonLocation{}, err
+		}
+		if tok != json.Delim('{') {
+			return jsonLocation{}, fmt.Errorf("invalid path at key %s (step %d)", key, i+1)
+		}
+		i++
+	}
+	return jsonLocation{}, fmt.Errorf("empty key path")
+}
+
+// setJSONValue returns data with the string at keyPath set to value. Missing
+// keys are added at the end of the deepest existing object.
+func setJSONValue(data []byte, keyPath, sep, value string) ([]byte, error) {
+	keys := splitKeyPath(keyPath, sep)
+	stripped := stripJSONComments(data)
+
+	loc, err := locateJSONValue(stripped, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if loc.found {
+		out.Write(data[:loc.start])
+		out.Write(encoded)
+		out.Write(data[loc.end:])
+		return out.Bytes(), nil
+	}
+
+	// Build "k1": {"k2": "value"} for the missing part of the path
+	member := encoded
+	for i := len(loc.missing) - 1; i >= 0; i-- {
+		key, _ := json.Marshal(loc.missing[i])
+		if i == 0 {
+			member = append(append(key, ": "...), member...)
+		} else {
+			member = append(append(append([]byte("{"), key...), ": "...), append(member, '}')...)
+		}
+	}
+
+	// The new member goes after the last one (or after { in an empty
+	// object); a JSONC trailing comma already separates it
+	last := lastNonBlank(stripped, loc.objectEnd-1)
+	empty := stripped[last] == '{'
+	needComma := !empty
+	if next := nextNonBlank(data, last+1); !empty && data[next] == ',' {
+		needComma = false
+		last = next
+	}
+
+	commaAt, insertAt := last+1, last+1
+	text := append([]byte(" "), member...)
+	if indent, ok := memberIndent(data, last, loc.objectEnd, empty); ok {
+		// One member per line: start a new line after any comment that
+		// ends the current one
+		if eol := bytes.IndexByte(data[last:loc.objectEnd], '\n'); eol >= 0 {
+			insertAt = last + eol
+			if data[insertAt-1] == '\r' {
+				insertAt--
+			}
+		}
+		text = append(append([]byte("\n"), indent...), member...)
+	}
+
+	out.Write(data[:commaAt])
+	if needComma {
+		out.WriteByte(',')
+	}
+	out.Write(data[commaAt:insertAt])
+	out.Write(text)
+	out.Write(data[insertAt:])
+	return out.Bytes(), nil
+}
+
+// lastNonBlank returns the index of the last non-whitespace byte at or
+// before i
+func lastNonBlank(data []byte, i int) int {
+	for i > 0 && (data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r') {
+		i--
+	}
+	return i
+}
+
+// memberIndent returns the indentation for a member added after data[last],
+// when the object is laid out one member per line
+func memberIndent(data []byte, last, objectEnd int, empty bool) ([]byte, bool) {
+	lineStart := bytes.LastIndexByte(data[:last+1], '\n') + 1
+	line := data[lineStart : last+1]
+	indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+
+	if empty {
+		// Indent one level deeper than the line holding the brace
+		if bytes.IndexByte(data[last:objectEnd], '\n') < 0 {
+			return nil, false
+		}
+		return append(append([]byte{}, indent...), "  "...), true
+	}
+	if lineStart == 0 {
+		return nil, false
+	}
+	return indent, true
+}
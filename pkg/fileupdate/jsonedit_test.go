@@ -0,0 +1,73 @@
+package fileupdate
+
+import "testing"
+
+const jsoncConfig = `{
+  // managed by ip_updater
+  "zeta": 1,
+  "server": {
+    "port": 8080, /* inline */
+    "public_ip": "192.0.2.1"
+  },
+  "alpha": [1, 2, 3],
+}
+`
+
+func TestJSONUpdateKeepsOrderAndComments(t *testing.T) {
+	path := writeTarget(t, "app.jsonc", jsoncConfig)
+
+	fu := New(path, "json", "server/public_ip", false)
+	if value, err := fu.GetCurrentValue(); err != nil || value != "192.0.2.1" {
+		t.Fatalf("GetCurrentValue = %q, %v; want 192.0.2.1", value, err)
+	}
+	if err := fu.UpdateIP("198.51.100.7"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  // managed by ip_updater
+  "zeta": 1,
+  "server": {
+    "port": 8080, /* inline */
+    "public_ip": "198.51.100.7"
+  },
+  "alpha": [1, 2, 3],
+}
+`
+	if got := readTarget(t, path); got != want {
+		t.Fatalf("file =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONUpdateSkipsCommentedKey(t *testing.T) {
+	path := writeTarget(t, "app.jsonc", `{
+  // "ip": "10.0.0.1",
+  "ip": "192.0.2.1"
+}`)
+
+	if err := New(path, "json", "ip", false).UpdateIP("198.51.100.7"); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  // "ip": "10.0.0.1",
+  "ip": "198.51.100.7"
+}`
+	if got := readTarget(t, path); got != want {
+		t.Fatalf("file =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONUpdateAddsMissingKey(t *testing.T) {
+	path := writeTarget(t, "app.json", `{"name": "app", "server": {"port": 8080}}`)
+
+	fu := New(path, "json", "server/public_ip", false)
+	if err := fu.UpdateIP("198.51.100.7"); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := fu.GetCurrentValue(); err != nil || value != "198.51.100.7" {
+		t.Fatalf("GetCurrentValue = %q, %v; want the added value", value, err)
+	}
+	if got, want := readTarget(t, path), `{"name": "app", "server": {"port": 8080, "public_ip": "198.51.100.7"}}`; got != want {
+		t.Fatalf("file = %s, want %s", got, want)
+	}
+}
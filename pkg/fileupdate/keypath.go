@@ -0,0 +1,50 @@
+package fileupdate
+
+import "strings"
+
+// DefaultKeySeparator separates the keys of a nested key path
+const DefaultKeySeparator = "/"
+
+// splitKeyPath splits a key path on sep ("/" when empty). A key containing
+// the separator is written with it escaped by a backslash, and a literal
+// backslash as "\\", so "routes/10.0.0.0\/8" is the key "10.0.0.0/8"
+// under "routes".
+func splitKeyPath(keyPath, sep string) []string {
+	if sep == "" {
+		sep = DefaultKeySeparator
+	}
+
+	var keys []string
+	var key strings.Builder
+	for i := 0; i < len(keyPath); i++ {
+		rest := keyPath[i:]
+		switch {
+		case strings.HasPrefix(rest, "\\\\"):
+			key.WriteByte('\\')
+			i++
+		case strings.HasPrefix(rest, "\\"+sep):
+			key.WriteString(sep)
+			i += len(sep)
+		case strings.HasPrefix(rest, sep):
+			keys = append(keys, key.String())
+			key.Reset()
+			i += len(sep) - 1
+		default:
+			key.WriteByte(keyPath[i])
+		}
+	}
+	return append(keys, key.String())
+}
+
+// iniKeyPath splits an INI key path into section and key. A path without a
+// section ("key" or "/key") addresses the keys before the first section.
+func iniKeyPath(keyPath, sep string) (section, key string, ok bool) {
+	parts := splitKeyPath(keyPath, sep)
+	switch len(parts) {
+	case 1:
+		return "", parts[0], parts[0] != ""
+	case 2:
+		return parts[0], parts[1], parts[1] != ""
+	}
+	return "", "", false
+}
@@ -0,0 +1,88 @@
+package fileupdate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitKeyPathEscapes(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"server/public_ip", []string{"server", "public_ip"}},
+		{`routes/10.0.0.0\/8`, []string{"routes", "10.0.0.0/8"}},
+		{`a\\/b`, []string{`a\`, "b"}},
+		{"public_ip", []string{"public_ip"}},
+		{"/key", []string{"", "key"}},
+	}
+	for _, tt := range tests {
+		if got := splitKeyPath(tt.path, ""); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitKeyPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEscapedSlashKey(t *testing.T) {
+	path := writeTarget(t, "routes.json", `{"routes": {"10.0.0.0/8": "192.0.2.1", "10.0.0.0": "keep"}}`)
+
+	fu := New(path, "json", `routes/10.0.0.0\/8`, false)
+	if err := fu.UpdateIP("198.51.100.7"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readTarget(t, path), `{"routes": {"10.0.0.0/8": "198.51.100.7", "10.0.0.0": "keep"}}`; got != want {
+		t.Fatalf("file = %s, want %s", got, want)
+	}
+}
+
+func TestINIKeyWithoutSection(t *testing.T) {
+	path := writeTarget(t, "app.ini", "public_ip = 192.0.2.1\n\n[server]\npublic_ip = 192.0.2.9\n")
+
+	for _, keyPath := range []string{"public_ip", "/public_ip"} {
+		fu := New(path, "ini", keyPath, false)
+		if value, err := fu.GetCurrentValue(); err != nil || value != "192.0.2.1" {
+			t.Fatalf("%s: GetCurrentValue = %q, %v; want the value before the first section", keyPath, value, err)
+		}
+	}
+
+	if err := New(path, "ini", "public_ip", false).UpdateIP("198.51.100.7"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := New(path, "ini", "server/public_ip", false).GetCurrentValue(); value != "192.0.2.9" {
+		t.Fatalf("server/public_ip = %q, the section key was changed", value)
+	}
+	if value, _ := New(path, "ini", "public_ip", false).GetCurrentValue(); value != "198.51.100.7" {
+		t.Fatalf("public_ip = %q, want 198.51.100.7", value)
+	}
+}
+
+func TestKeySeparators(t *testing.T) {
+	documents := map[string]string{
+		"json": `{"server": {"public_ip": "192.0.2.1", "a.b": "keep"}}`,
+		"yaml": "server:\n  public_ip: 192.0.2.1\n  a.b: keep\n",
+		"toml": "[server]\npublic_ip = \"192.0.2.1\"\n\"a.b\" = \"keep\"\n",
+	}
+
+	for format, content := range documents {
+		for _, sep := range []string{"/", ".", "::"} {
+			path := writeTarget(t, "app."+format, content)
+			fu := New(path, format, "server"+sep+"public_ip", false)
+			fu.KeySeparator = sep
+
+			if err := fu.UpdateIP("198.51.100.7"); err != nil {
+				t.Fatalf("%s with %q: %v", format, sep, err)
+			}
+			if value, err := New(path, format, "server/public_ip", false).GetCurrentValue(); err != nil || value != "198.51.100.7" {
+				t.Fatalf("%s with %q: server/public_ip = %q, %v; want 198.51.100.7", format, sep, value, err)
+			}
+		}
+
+		// A key containing the separator is escaped
+		path := writeTarget(t, "app."+format, content)
+		fu := New(path, format, `server.a\.b`, false)
+		fu.KeySeparator = "."
+		if value, err := fu.GetCurrentValue(); err != nil || value != "keep" {
+			t.Fatalf(`%s: server.a\.b = %q, %v; want keep`, format, value, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package fileupdate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// updateList maintains one entry of a delimiter-separated list, such as an
+// allowlist "203.0.113.5, 198.51.100.7". The entry written last time
+// (ManagedValue) is replaced with the new IP; the other entries are left
+// exactly as they are. Without a managed entry in the list the new IP is
+// appended, unless it is already listed.
+func (fu *FileUpdater) updateList(newIP string) error {
+	if fu.Logger != nil {
+		fu.Logger.Infof("📁 文件更新开始 - 文件: %s, 格式: %s, 键路径: %s (列表，分隔符 '%s')", fu.FilePath, fu.Format, fu.KeyPath, fu.ListDelimiter)
+	}
+
+	currentValue, err := fu.GetCurrentValue()
+	if err != nil {
+		if fu.Logger != nil {
+			fu.Logger.Warnf("⚠️ 无法获取当前文件键值 %s:%s: %v，按空列表处理", fu.FilePath, fu.KeyPath, err)
+		}
+		currentValue = ""
+	}
+
+	newValue, managed := fu.mergeListEntry(currentValue, newIP)
+	if newValue == currentValue {
+		if fu.Logger != nil {
+			fu.Logger.Infof("✔️ 列表已包含当前IP，跳过更新: %s:%s = '%s'", fu.FilePath, fu.KeyPath, currentValue)
+		}
+		fu.ManagedValue = managed
+		return nil
+	}
+
+	if fu.Logger != nil {
+		fu.Logger.Infof("📝 文件键值需要更新: %s:%s 从 '%s' 更新为 '%s'", fu.FilePath, fu.KeyPath, currentValue, newValue)
+	}
+
+	if fu.Backup {
+		if err := fu.createBackup(); err != nil {
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
+	}
+
+	if err := fu.writeValue(newValue); err != nil {
+		if fu.Logger != nil {
+			fu.Logger.Warnf("❌ 文件更新失败: %s:%s: %v", fu.FilePath, fu.KeyPath, err)
+		}
+		return err
+	}
+
+	if fu.VerifyWrite {
+		if err := fu.verifyValue(newValue); err != nil {
+			return err
+		}
+	}
+
+	fu.ManagedValue = managed
+	if fu.Logger != nil {
+		fu.Logger.Infof("✅ 文件更新成功: %s:%s = '%s'", fu.FilePath, fu.KeyPath, newValue)
+	}
+
+	return nil
+}
+
+// mergeListEntry returns the updated list and the entry now managed by the
+// updater. Whitespace around entries is preserved, as is a CIDR suffix on the
+// managed entry.
+func (fu *FileUpdater) mergeListEntry(list, newIP string) (string, string) {
+	if strings.TrimSpace(list) == "" {
+		return newIP, newIP
+	}
+
+	entries := strings.Split(list, fu.ListDelimiter)
+
+	// Replace the entry written last time
+	if fu.ManagedValue != "" {
+		for i, entry := range entries {
+			if strings.TrimSpace(entry) != fu.ManagedValue {
+				continue
+			}
+			value := fu.processIPWithMask(fu.ManagedValue, newIP)
+			entries[i] = strings.Replace(entry, fu.ManagedValue, value, 1)
+			return strings.Join(entries, fu.ListDelimiter), value
+		}
+	}
+
+	// Already listed, e.g. on the first run: take it over as the managed entry
+	for _, entry := range entries {
+		value := strings.TrimSpace(entry)
+		if value == newIP || strings.HasPrefix(value, newIP+"/") {
+			return list, value
+		}
+	}
+
+	// Append, reusing the spacing of the last entry ("a, b" -> "a, b, c").
+	// A trailing delimiter stays at the end.
+	last := len(entries) - 1
+	trailing := strings.TrimSpace(entries[last]) == ""
+	if trailing && last > 0 {
+		last--
+	}
+	padding := entries[last][:len(entries[last])-len(strings.TrimLeft(entries[last], " \t"))]
+
+	if trailing {
+		entries = append(entries[:len(entries)-1], padding+newIP, entries[len(entries)-1])
+	} else {
+		entries = append(entries, padding+newIP)
+	}
+	return strings.Join(entries, fu.ListDelimiter), newIP
+}
@@ -0,0 +1,74 @@
+package fileupdate
+
+import (
+	"fmt"
+
+	"howett.net/plist"
+)
+
+// Apple property lists. XML, binary and OpenStep files are read; the file
+// is written back in the format it was read in.
+
+func (fu *FileUpdater) readPlist() (map[string]interface{}, int, error) {
+	data, err := fu.readFile()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var plistData map[string]interface{}
+	format, err := plist.Unmarshal(data, &plistData)
+	if err != nil {
+		return nil, 0, err
+	}
+	if plistData == nil {
+		return nil, 0, fmt.Errorf("plist root is not a dictionary")
+	}
+
+	return plistData, format, nil
+}
+
+func (fu *FileUpdater) updatePlist(newIP string) error {
+	plistData, format, err := fu.readPlist()
+	if err != nil {
+		return err
+	}
+
+	if err := fu.setNestedValue(plistData, fu.KeyPath, newIP); err != nil {
+		return err
+	}
+
+	// Binary plists have no indentation
+	var updatedData []byte
+	if format == plist.BinaryFormat {
+		updatedData, err = plist.Marshal(plistData, format)
+	} else {
+		updatedData, err = plist.MarshalIndent(plistData, format, "\t")
+	}
+	if err != nil {
+		return err
+	}
+
+	return fu.atomicWrite(fu.FilePath, updatedData)
+}
+
+func (fu *FileUpdater) getCurrentValuePlist() (string, error) {
+	plistData, _, err := fu.readPlist()
+	if err != nil {
+		return "", err
+	}
+
+	value, err := fu.getNestedValue(plistData, fu.KeyPath)
+	if err != nil {
+		return "", err
+	}
+
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	return "", fmt.Errorf("value is not a string")
+}
+
+func (fu *FileUpdater) validatePlist() error {
+	_, _, err := fu.readPlist()
+	return err
+}
@@ -0,0 +1,74 @@
+package fileupdate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DefaultMaxFileSize is the size above which a target file is reported when
+// MaxFileSize is not set. Every update parses the whole file in memory, so a
+// key_path pointed at a huge file by mistake should not go unnoticed.
+const DefaultMaxFileSize = 10 << 20
+
+// ErrFileTooLarge is returned for files over MaxFileSize when RefuseOversize
+// is set
+var ErrFileTooLarge = errors.New("file too large")
+
+// checkFileSize reports a target file larger than the limit: a warning
+// (once per FileUpdater), or ErrFileTooLarge with RefuseOversize. A negative
+// MaxFileSize disables the check.
+func (fu *FileUpdater) checkFileSize() error {
+	limit := fu.MaxFileSize
+	if limit == 0 {
+		limit = DefaultMaxFileSize
+	}
+	if limit < 0 {
+		return nil
+	}
+
+	info, err := os.Stat(fu.FilePath)
+	if err != nil || info.Size() <= limit {
+		// A missing file is reported by the read itself
+		return nil
+	}
+
+	if fu.RefuseOversize {
+		return fmt.Errorf("%w: %s is %s, limit is %s", ErrFileTooLarge, fu.FilePath, formatSize(info.Size()), formatSize(limit))
+	}
+	if !fu.sizeWarned && fu.Logger != nil {
+		fu.Logger.Warnf("⚠️ 目标文件过大: %s (%s，限制 %s)，每次更新都会完整读入内存，请确认file_path是否正确", fu.FilePath, formatSize(info.Size()), formatSize(limit))
+	}
+	fu.sizeWarned = true
+	return nil
+}
+
+// readFile reads the target file after the size check
+func (fu *FileUpdater) readFile() ([]byte, error) {
+	if err := fu.checkFileSize(); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(fu.FilePath)
+}
+
+// openFile opens the target file for streaming decoders, after the size
+// check. Reading a value this way avoids holding the raw bytes and the
+// decoded document in memory at the same time.
+func (fu *FileUpdater) openFile() (io.ReadCloser, error) {
+	if err := fu.checkFileSize(); err != nil {
+		return nil, err
+	}
+	return os.Open(fu.FilePath)
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
@@ -0,0 +1,97 @@
+package fileupdate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingLogger keeps the warnings logged by the updater
+type recordingLogger struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {}
+
+func (l *recordingLogger) Warnf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+// largeJSON is a valid JSON file of roughly size bytes with "ip" at the top
+func largeJSON(size int) string {
+	return `{"ip": "192.0.2.1", "padding": "` + strings.Repeat("x", size) + `"}`
+}
+
+func TestOversizeFileWarnsOnce(t *testing.T) {
+	path := writeTarget(t, "big.json", largeJSON(64<<10))
+	log := &recordingLogger{}
+
+	fu := New(path, "json", "ip", false)
+	fu.MaxFileSize = 32 << 10
+	fu.SetLogger(log)
+
+	if err := fu.UpdateIP("192.0.2.2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fu.UpdateIP("192.0.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := fu.GetCurrentValue(); value != "192.0.2.3" {
+		t.Fatalf("value = %q, the oversize file was not updated", value)
+	}
+
+	var sizeWarnings int
+	for _, warning := range log.warnings {
+		if strings.Contains(warning, "目标文件过大") {
+			sizeWarnings++
+		}
+	}
+	if sizeWarnings != 1 {
+		t.Fatalf("size warnings = %d, want 1: %q", sizeWarnings, log.warnings)
+	}
+}
+
+func TestOversizeFileRefused(t *testing.T) {
+	content := largeJSON(64 << 10)
+	path := writeTarget(t, "big.json", content)
+
+	fu := New(path, "json", "ip", false)
+	fu.MaxFileSize = 32 << 10
+	fu.RefuseOversize = true
+
+	err := fu.UpdateIP("192.0.2.2")
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("err = %v, want ErrFileTooLarge", err)
+	}
+	if readTarget(t, path) != content {
+		t.Fatal("the refused file was modified")
+	}
+}
+
+func TestSizeLimitDisabled(t *testing.T) {
+	path := writeTarget(t, "big.json", largeJSON(64<<10))
+
+	fu := New(path, "json", "ip", false)
+	fu.MaxFileSize = -1
+	fu.RefuseOversize = true
+	if err := fu.UpdateIP("192.0.2.2"); err != nil {
+		t.Fatalf("err = %v, want no limit with a negative max_file_size", err)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for size, want := range map[int64]string{
+		512:                "512 B",
+		1536:               "1.5 KB",
+		DefaultMaxFileSize: "10.0 MB",
+	} {
+		if got := formatSize(size); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
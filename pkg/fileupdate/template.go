@@ -0,0 +1,125 @@
+package fileupdate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is the data available to template-format files
+type TemplateData struct {
+	IP        string // the detected address
+	IP6       string // the detected address when it is IPv6, otherwise empty
+	Timestamp string // render time in RFC 3339
+}
+
+// ParseTemplate loads a text/template source file used by the template format.
+// The template is also rendered once with sample data so that references to
+// unknown fields are reported here rather than on the first IP change.
+func ParseTemplate(templatePath string) (*template.Template, error) {
+	if templatePath == "" {
+		return nil, fmt.Errorf("template format requires key_path to point to a template file")
+	}
+
+	tmpl, err := template.New(filepath.Base(templatePath)).Option("missingkey=error").ParseFiles(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", templatePath, err)
+	}
+
+	sample := TemplateData{IP: "192.0.2.1", IP6: "2001:db8::1", Timestamp: time.Now().Format(time.RFC3339)}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", templatePath, err)
+	}
+	return tmpl, nil
+}
+
+// updateTemplate renders the template in KeyPath and atomically replaces the
+// output file in FilePath. The file is left untouched when the rendered
+// content is identical to what is already there.
+func (fu *FileUpdater) updateTemplate(newIP string) error {
+	if fu.Logger != nil {
+		fu.Logger.Infof("📁 文件更新开始 - 文件: %s, 格式: template, 模板: %s", fu.FilePath, fu.KeyPath)
+	}
+
+	tmpl, err := ParseTemplate(fu.KeyPath)
+	if err != nil {
+		return err
+	}
+
+	data := TemplateData{
+		IP:        newIP,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if strings.Contains(newIP, ":") {
+		data.IP6 = newIP
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to render template %s: %w", fu.KeyPath, err)
+	}
+
+	current, err := fu.readFile()
+	if err == nil && bytes.Equal(current, buf.Bytes()) {
+		if fu.Logger != nil {
+			fu.Logger.Infof("✔️ 渲染结果未变化，跳过更新: %s", fu.FilePath)
+		}
+		return nil
+	}
+
+	// Only back up an existing output; the first render has nothing to keep
+	if fu.Backup && err == nil {
+		if err := fu.createBackup(); err != nil {
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
+	}
+
+	if err := fu.atomicWrite(fu.FilePath, buf.Bytes()); err != nil {
+		if fu.Logger != nil {
+			fu.Logger.Warnf("❌ 文件更新失败: %s: %v", fu.FilePath, err)
+		}
+		return err
+	}
+
+	if fu.VerifyWrite {
+		written, err := fu.readFile()
+		if err != nil {
+			if fu.Logger != nil {
+				fu.Logger.Warnf("❌ 写入校验失败，无法读回文件 %s: %v", fu.FilePath, err)
+			}
+			return fmt.Errorf("write verification failed: %w", err)
+		}
+		if !bytes.Equal(written, buf.Bytes()) {
+			if fu.Logger != nil {
+				fu.Logger.Warnf("❌ 写入校验失败: %s 的内容与渲染结果不一致 (读回 %d 字节，期望 %d 字节)", fu.FilePath, len(written), buf.Len())
+			}
+			return fmt.Errorf("write verification failed: %s does not match the rendered template", fu.FilePath)
+		}
+	}
+
+	if fu.Logger != nil {
+		fu.Logger.Infof("✅ 模板渲染成功: %s -> %s (IP: %s)", fu.KeyPath, fu.FilePath, newIP)
+	}
+
+	return nil
+}
+
+// validateTemplate checks that the template parses and the output directory
+// exists; the output file itself is created on the first render
+func (fu *FileUpdater) validateTemplate() error {
+	if _, err := ParseTemplate(fu.KeyPath); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(fu.FilePath)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("output directory does not exist: %s", dir)
+	}
+
+	return nil
+}